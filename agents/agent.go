@@ -2,6 +2,10 @@ package agents
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/template"
 	"time"
 
 	"github.com/MrLeeang/langchain-go/llms"
@@ -31,6 +35,48 @@ type Agent struct {
 	StartTime           time.Time
 	EndTime             time.Time
 	debug               bool
+	tokenCounting       TokenCounting
+	tokenCounter        *TokenCounter
+	reasoningCapture    bool
+	cleanMemory         bool
+	chatOptions         func(iteration int) []llms.ChatOption
+	stopWords           []string
+	deterministic       bool
+	grammar             string
+	jsonMode            bool
+	streamRetries       int
+	streamIdleTimeout   time.Duration
+	contextGuard        bool
+	contextLimit        int
+	truncation          TruncationStrategy
+	contextSummary      *contextSummary
+	trimmedMessages     int
+	usageCollector      llms.UsageCollector
+	startupCheck        bool
+	startupErr          error
+	lastReasoning       string
+	serializedTurns     bool
+	messageTimestamps   bool
+	legacyStreamFormat  bool
+	iterationTimings    []IterationTiming
+	toolDataLength      int
+	untruncatedTools    []string
+	truncatedTools      []ToolOutput
+	callbacks           []Callbacks
+	logger              *slog.Logger
+	toolRetryAttempts   int
+	toolRetryBackoff    time.Duration
+	allowedTools        []string
+	deniedTools         []string
+	steps               []Step
+	promptBuilder       SystemPromptBuilder
+	promptTemplate      *template.Template
+	promptVars          map[string]any
+	examples            []Example
+	// runPrompt is the prompt rendered with the RunOpts variables of the current turn
+	runPrompt string
+	// runOpts scopes the current RunWithOptions or StreamWithOptions turn
+	runOpts *RunOpts
 	// registeredSkills lists skill metadata (name, description, path) injected into the system prompt so the model can read the full .md via tools such as read_file.
 	registeredSkills []skills.Skill
 }
@@ -59,6 +105,7 @@ func CreateReactAgent(ctx context.Context, llm llms.LLM, opts ...AgentOption) *A
 		maxIter:         10,
 		mem:             memory.NewBufferMemory(), // Default memory implementation
 		maxWindowTokens: 32000,
+		cleanMemory:     true,
 	}
 
 	// Apply options
@@ -66,9 +113,25 @@ func CreateReactAgent(ctx context.Context, llm llms.LLM, opts ...AgentOption) *A
 		opt(agent)
 	}
 
+	if agent.startupCheck {
+		if p, ok := llm.(llms.Pinger); ok {
+			if err := p.Ping(ctx); err != nil {
+				agent.startupErr = errors.Join(agent.startupErr, fmt.Errorf("startup check failed: %w", err))
+			}
+		}
+	}
+
 	return agent
 }
 
+// log returns the WithLogger logger, or slog.Default().
+func (a *Agent) log() *slog.Logger {
+	if a.logger != nil {
+		return a.logger
+	}
+	return slog.Default()
+}
+
 func (a *Agent) SetMessages(messages []llms.ChatCompletionMessage) {
 	a.messages = append(a.messages, messages...)
 }
@@ -77,6 +140,13 @@ func (a *Agent) GetMessages() []llms.ChatCompletionMessage {
 	return a.messages
 }
 
+// StartupError returns what prevents the agent from running, or nil: an invalid
+// WithPromptTemplate template and the failure of the WithStartupCheck ping, joined when both
+// occurred. Every run returns it instead of running.
+func (a *Agent) StartupError() error {
+	return a.startupErr
+}
+
 // GetLLM returns the underlying LLM instance
 func (a *Agent) GetLLM() llms.LLM {
 	if a == nil {
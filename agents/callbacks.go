@@ -0,0 +1,122 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// Callbacks receives agent lifecycle events, e.g. for tracing, progress UIs or audit logs.
+// Register implementations with WithCallbacks; embed [NoopCallbacks] to implement only some
+// methods. Callbacks run synchronously on the agent's goroutine, so they should return
+// quickly; a panicking callback is recovered and doesn't affect the run.
+type Callbacks interface {
+	// OnRunStart is called when Run, RunWithContext, RunWithImages or Stream starts a turn.
+	OnRunStart(ctx context.Context, conversationID, input string)
+	// OnLLMStart is called before each LLM request with the messages sent. It is followed by
+	// OnLLMEnd, or by OnError when the request fails or the turn is stopped.
+	OnLLMStart(ctx context.Context, messages []llms.ChatCompletionMessage)
+	// OnLLMEnd is called with the assistant message of each LLM response and its usage
+	// (zero when the provider reports none).
+	OnLLMEnd(ctx context.Context, response llms.ChatCompletionMessage, usage llms.ChatUsage)
+	// OnToolStart is called before a tool runs.
+	OnToolStart(ctx context.Context, name string, args map[string]interface{})
+	// OnToolEnd is called after a tool ran, with its error if it failed.
+	OnToolEnd(ctx context.Context, name, result string, err error)
+	// OnFinalAnswer is called with the final answer of a turn.
+	OnFinalAnswer(ctx context.Context, answer string)
+	// OnError is called when a turn fails, with context.Canceled when it was stopped. A
+	// streamed LLM request that fails and is retried (see WithStreamRetry) is reported too.
+	OnError(ctx context.Context, err error)
+}
+
+// NoopCallbacks implements [Callbacks] with methods that do nothing.
+type NoopCallbacks struct{}
+
+func (NoopCallbacks) OnRunStart(ctx context.Context, conversationID, input string) {}
+
+func (NoopCallbacks) OnLLMStart(ctx context.Context, messages []llms.ChatCompletionMessage) {}
+
+func (NoopCallbacks) OnLLMEnd(ctx context.Context, response llms.ChatCompletionMessage, usage llms.ChatUsage) {
+}
+
+func (NoopCallbacks) OnToolStart(ctx context.Context, name string, args map[string]interface{}) {}
+
+func (NoopCallbacks) OnToolEnd(ctx context.Context, name, result string, err error) {}
+
+func (NoopCallbacks) OnFinalAnswer(ctx context.Context, answer string) {}
+
+func (NoopCallbacks) OnError(ctx context.Context, err error) {}
+
+// LogCallbacks implements [Callbacks] by logging each event to a slog.Logger: runs and final
+// answers at info level, LLM and tool calls at debug level and failures at error level.
+//
+// Example:
+//
+//	agent := agents.CreateReactAgent(ctx, llm,
+//	    agents.WithCallbacks(agents.NewLogCallbacks(slog.Default())),
+//	)
+type LogCallbacks struct {
+	logger *slog.Logger
+}
+
+// NewLogCallbacks returns LogCallbacks writing to logger. A nil logger uses slog.Default().
+func NewLogCallbacks(logger *slog.Logger) *LogCallbacks {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LogCallbacks{logger: logger}
+}
+
+func (c *LogCallbacks) OnRunStart(ctx context.Context, conversationID, input string) {
+	c.logger.InfoContext(ctx, "agent run started", "conversation_id", conversationID, "input", input)
+}
+
+func (c *LogCallbacks) OnLLMStart(ctx context.Context, messages []llms.ChatCompletionMessage) {
+	c.logger.DebugContext(ctx, "llm request", "messages", len(messages))
+}
+
+func (c *LogCallbacks) OnLLMEnd(ctx context.Context, response llms.ChatCompletionMessage, usage llms.ChatUsage) {
+	c.logger.DebugContext(ctx, "llm response",
+		"content", response.Content,
+		"tool_calls", len(response.ToolCalls),
+		"prompt_tokens", usage.PromptTokens,
+		"completion_tokens", usage.CompletionTokens,
+	)
+}
+
+func (c *LogCallbacks) OnToolStart(ctx context.Context, name string, args map[string]interface{}) {
+	c.logger.DebugContext(ctx, "tool call", "tool", name, "args", args)
+}
+
+func (c *LogCallbacks) OnToolEnd(ctx context.Context, name, result string, err error) {
+	if err != nil {
+		c.logger.DebugContext(ctx, "tool failed", "tool", name, "error", err)
+		return
+	}
+	c.logger.DebugContext(ctx, "tool result", "tool", name, "result_length", len(result))
+}
+
+func (c *LogCallbacks) OnFinalAnswer(ctx context.Context, answer string) {
+	c.logger.InfoContext(ctx, "agent answered", "answer", answer)
+}
+
+func (c *LogCallbacks) OnError(ctx context.Context, err error) {
+	c.logger.ErrorContext(ctx, "agent run failed", "error", err)
+}
+
+// notify calls fn for each registered callback, recovering panics.
+func (a *Agent) notify(fn func(Callbacks)) {
+	for _, cb := range a.callbacks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					a.log().Warn("agent callback panicked", "callback", fmt.Sprintf("%T", cb), "panic", r)
+				}
+			}()
+			fn(cb)
+		}()
+	}
+}
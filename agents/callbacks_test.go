@@ -0,0 +1,195 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/mcp"
+)
+
+// recordingCallbacks records the name of each event, with the tool name or the answer.
+type recordingCallbacks struct {
+	mu     sync.Mutex
+	events []string
+	errCtx context.Context
+}
+
+func (c *recordingCallbacks) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+func (c *recordingCallbacks) Events() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.events)
+}
+
+func (c *recordingCallbacks) OnRunStart(ctx context.Context, conversationID, input string) {
+	c.record("run_start")
+}
+
+func (c *recordingCallbacks) OnLLMStart(ctx context.Context, messages []llms.ChatCompletionMessage) {
+	c.record("llm_start")
+}
+
+func (c *recordingCallbacks) OnLLMEnd(ctx context.Context, response llms.ChatCompletionMessage, usage llms.ChatUsage) {
+	c.record("llm_end")
+}
+
+func (c *recordingCallbacks) OnToolStart(ctx context.Context, name string, args map[string]interface{}) {
+	c.record("tool_start:" + name)
+}
+
+func (c *recordingCallbacks) OnToolEnd(ctx context.Context, name, result string, err error) {
+	c.record("tool_end:" + name)
+}
+
+func (c *recordingCallbacks) OnFinalAnswer(ctx context.Context, answer string) {
+	c.record("final_answer:" + answer)
+}
+
+func (c *recordingCallbacks) OnError(ctx context.Context, err error) {
+	c.mu.Lock()
+	c.errCtx = ctx
+	c.mu.Unlock()
+	c.record("error")
+}
+
+var toolRunEvents = []string{
+	"run_start",
+	"llm_start", "llm_end",
+	"tool_start:weather", "tool_end:weather",
+	"llm_start", "llm_end",
+	"final_answer:sunny in Paris",
+}
+
+func newToolRunAgent(cb Callbacks, opts ...AgentOption) *Agent {
+	llm := llms.NewFakeModelWithMessages([]llms.ChatCompletionMessage{
+		toolCallReply("call_1", "weather", `{"city":"Paris"}`),
+		{Content: "sunny in Paris"},
+	})
+	opts = append([]AgentOption{
+		WithTools([]mcp.Tool{&fakeTool{name: "weather", result: "sunny"}}),
+		WithCallbacks(cb),
+	}, opts...)
+	return CreateReactAgent(context.Background(), llm, opts...)
+}
+
+func TestCallbacksRunOrder(t *testing.T) {
+	cb := &recordingCallbacks{}
+	if _, err := newToolRunAgent(cb).Run("weather in Paris?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := cb.Events(); !slices.Equal(got, toolRunEvents) {
+		t.Errorf("events = %v, want %v", got, toolRunEvents)
+	}
+}
+
+func TestCallbacksStreamOrder(t *testing.T) {
+	cb := &recordingCallbacks{}
+	_, responses := collectStream(t, newToolRunAgent(cb).Stream("weather in Paris?"), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+	if got := cb.Events(); !slices.Equal(got, toolRunEvents) {
+		t.Errorf("events = %v, want %v", got, toolRunEvents)
+	}
+}
+
+// A failed LLM request is followed by OnError, not OnLLMEnd.
+func TestCallbacksLLMFailure(t *testing.T) {
+	want := []string{"run_start", "llm_start", "error"}
+
+	cb := &recordingCallbacks{}
+	llm := llms.NewFakeModel([]string{"unused"}).FailOnCall(1, errors.New("boom"))
+	if _, err := CreateReactAgent(context.Background(), llm, WithCallbacks(cb)).Run("hi"); err == nil {
+		t.Fatal("Run succeeded, want the LLM error")
+	}
+	if got := cb.Events(); !slices.Equal(got, want) {
+		t.Errorf("Run events = %v, want %v", got, want)
+	}
+
+	cb = &recordingCallbacks{}
+	llm = llms.NewFakeModel([]string{"unused"}).FailOnCall(1, errors.New("boom"))
+	_, responses := collectStream(t, CreateReactAgent(context.Background(), llm, WithCallbacks(cb)).Stream("hi"), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error == nil {
+		t.Fatal("stream succeeded, want the LLM error")
+	}
+	if got := cb.Events(); !slices.Equal(got, want) {
+		t.Errorf("Stream events = %v, want %v", got, want)
+	}
+}
+
+type ctxKey struct{}
+
+// OnError gets the context of the run, not the one the agent was created with.
+func TestCallbacksStreamErrorRunContext(t *testing.T) {
+	cb := &recordingCallbacks{}
+	llm := llms.NewFakeModel(nil)
+	agent := CreateReactAgent(context.Background(), llm, WithCallbacks(cb))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "run")
+	_, responses := collectStream(t, agent.StreamWithContext(ctx, "hi"), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error == nil {
+		t.Fatal("stream succeeded, want an error")
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.errCtx == nil || cb.errCtx.Value(ctxKey{}) != "run" {
+		t.Error("OnError did not get the run context")
+	}
+}
+
+type panickingCallbacks struct{ NoopCallbacks }
+
+func (panickingCallbacks) OnLLMStart(ctx context.Context, messages []llms.ChatCompletionMessage) {
+	panic("callback bug")
+}
+
+func (panickingCallbacks) OnFinalAnswer(ctx context.Context, answer string) {
+	panic("callback bug")
+}
+
+func TestCallbacksPanicRecovered(t *testing.T) {
+	logs := &captureHandler{}
+	cb := &recordingCallbacks{}
+	agent := newToolRunAgent(panickingCallbacks{}, WithCallbacks(cb), WithLogger(slog.New(logs)))
+
+	answer, err := agent.Run("weather in Paris?")
+	if err != nil || answer != "sunny in Paris" {
+		t.Fatalf("Run = %q, %v; want the answer despite the panics", answer, err)
+	}
+	// callbacks registered after the panicking one still get every event
+	if got := cb.Events(); !slices.Equal(got, toolRunEvents) {
+		t.Errorf("events = %v, want %v", got, toolRunEvents)
+	}
+	if n := logs.count(slog.LevelWarn, "agent callback panicked"); n != 3 {
+		t.Errorf("logged %d panics, want 3", n)
+	}
+}
+
+func TestCallbacksStreamStopped(t *testing.T) {
+	cb := &recordingCallbacks{}
+	llm := &scriptedStreamLLM{turns: [][]scriptedChunk{textTurn(time.Second, "never")}}
+	agent := CreateReactAgent(context.Background(), llm, WithCallbacks(cb))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := agent.StreamWithContext(ctx, "hi")
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, responses := collectStream(t, ch, 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stopped stream reported %v, want a plain Done", last.Error)
+	}
+	want := []string{"run_start", "llm_start", "error"}
+	if got := cb.Events(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
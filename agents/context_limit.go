@@ -0,0 +1,202 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// TruncationStrategy selects how WithContextLimit shrinks a prompt that does not fit.
+type TruncationStrategy int
+
+const (
+	// TruncateOldest drops the oldest non-system messages.
+	TruncateOldest TruncationStrategy = iota
+	// SummarizeOldest replaces the oldest non-system messages with an LLM-generated summary.
+	// If summarization fails the messages are dropped as with TruncateOldest.
+	SummarizeOldest
+)
+
+// maxCompletionReserve caps the tokens kept free for the reply under the context limit.
+const maxCompletionReserve = 4096
+
+// contextSummary caches the summary of the first count trimmable messages, so a run does not
+// re-summarize the same prefix on every iteration.
+type contextSummary struct {
+	count   int
+	message llms.ChatCompletionMessage
+}
+
+// completionReserve returns the tokens left free for the completion: a quarter of the limit, at most maxCompletionReserve.
+func completionReserve(limit int) int {
+	return min(limit/4, maxCompletionReserve)
+}
+
+// contextWindow returns the token limit set by WithContextLimit, or the model's registered
+// context window when the limit was given as 0. It returns 0 when no limit applies.
+func (a *Agent) contextWindow() int {
+	if !a.contextGuard {
+		return 0
+	}
+	if a.contextLimit > 0 {
+		return a.contextLimit
+	}
+	info, _ := llms.LookupModel(a.modelName())
+	return info.ContextWindow
+}
+
+// ErrContextLimitExceeded is returned by a run when the prompt doesn't fit under the
+// WithContextLimit limit even after trimming the history and cutting the tool results of the
+// current turn, e.g. because the system prompt and the user message alone are too long.
+var ErrContextLimitExceeded = errors.New("prompt exceeds the context limit")
+
+// summaryMaxTokens caps the summary SummarizeOldest generates.
+const summaryMaxTokens = 1000
+
+// promptMessages returns the messages to send for the next LLM call: the trimmed history with
+// the WithFewShotExamples examples after the system prompt.
+func (a *Agent) promptMessages(ctx context.Context) ([]llms.ChatCompletionMessage, error) {
+	msgs, err := a.trimHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.insertExamples(msgs), nil
+}
+
+// trimHistory returns a.messages without a context limit; otherwise the oldest non-system
+// messages before the latest user message are trimmed until the prompt fits under the limit
+// minus the completion reserve, leaving room for the summary with SummarizeOldest. If the
+// current turn still doesn't fit, its tool results are cut, oldest first. a.messages itself is
+// never modified, so the full turn is still saved to memory.
+func (a *Agent) trimHistory(ctx context.Context) ([]llms.ChatCompletionMessage, error) {
+	limit := a.contextWindow()
+	if limit <= 0 {
+		return a.messages, nil
+	}
+	budget := limit - completionReserve(limit)
+	counter := a.getTokenCounter()
+
+	total := 0
+	for _, msg := range a.messages {
+		total += counter.countMessage(msg)
+	}
+	if total <= budget {
+		return a.messages, nil
+	}
+
+	// Leading system messages and everything from the latest user message on are kept.
+	start := 0
+	for start < len(a.messages) && a.messages[start].Role == llms.ChatMessageRoleSystem {
+		start++
+	}
+	end := len(a.messages)
+	for i := len(a.messages) - 1; i >= start; i-- {
+		if a.messages[i].Role == llms.ChatMessageRoleUser {
+			end = i
+			break
+		}
+	}
+
+	target := budget
+	if a.truncation == SummarizeOldest {
+		target -= min(summaryMaxTokens, budget/4)
+	}
+	trimmed := start
+	for trimmed < end && total > target {
+		total -= counter.countMessage(a.messages[trimmed])
+		trimmed++
+		// never leave tool results without the assistant message that requested them
+		for trimmed < end && a.messages[trimmed].Role == llms.ChatMessageRoleTool {
+			total -= counter.countMessage(a.messages[trimmed])
+			trimmed++
+		}
+	}
+
+	out := make([]llms.ChatCompletionMessage, 0, len(a.messages)-(trimmed-start)+1)
+	out = append(out, a.messages[:start]...)
+	if trimmed > start && a.truncation == SummarizeOldest {
+		if summary, ok := a.summarizeTrimmed(ctx, a.messages[start:trimmed]); ok {
+			if n := counter.countMessage(summary); total+n <= budget {
+				out = append(out, summary)
+				total += n
+			}
+		}
+	}
+	turnStart := len(out) + end - trimmed
+	out = append(out, a.messages[trimmed:]...)
+
+	if trimmed > start {
+		a.trimmedMessages = max(a.trimmedMessages, trimmed-start)
+		if a.debug {
+			fmt.Printf("Context limit: trimmed %d messages\n", trimmed-start)
+		}
+	}
+	if total > budget {
+		total = cutToolResults(out[turnStart:], total, budget, counter)
+	}
+	if total > budget {
+		return nil, fmt.Errorf("%w: %d tokens after trimming, %d available", ErrContextLimitExceeded, total, budget)
+	}
+	return out, nil
+}
+
+// cutToolResults shortens the tool results of msgs, oldest first, until total fits budget, and
+// returns the new total. Each result keeps as much of its beginning as fits, so the tool
+// message stays paired with the call that requested it.
+func cutToolResults(msgs []llms.ChatCompletionMessage, total, budget int, counter *TokenCounter) int {
+	for i := range msgs {
+		if total <= budget {
+			break
+		}
+		if msgs[i].Role != llms.ChatMessageRoleTool {
+			continue
+		}
+		before := counter.countMessage(msgs[i])
+		runes := []rune(msgs[i].Content)
+		keep := 0
+		if over := total - budget; over < before {
+			keep = len(runes) * (before - over) / before
+		}
+		for {
+			msgs[i].Content = fmt.Sprintf("%s...[truncated to fit the context window]", string(runes[:keep]))
+			after := counter.countMessage(msgs[i])
+			if total-before+after <= budget || keep == 0 {
+				total += after - before
+				break
+			}
+			keep = keep * 3 / 4
+		}
+	}
+	return total
+}
+
+// resetContextLimit clears the per-run trimming state.
+func (a *Agent) resetContextLimit() {
+	a.trimmedMessages = 0
+	a.contextSummary = nil
+}
+
+// summarizeTrimmed returns an assistant message summarizing msgs, reusing the cached summary
+// when the same prefix was summarized earlier in the run.
+func (a *Agent) summarizeTrimmed(ctx context.Context, msgs []llms.ChatCompletionMessage) (llms.ChatCompletionMessage, bool) {
+	if a.contextSummary != nil && a.contextSummary.count == len(msgs) {
+		return a.contextSummary.message, true
+	}
+	summarizer := NewSummarizer(SummarizerConfig{
+		LLM:       a.GetLLM(),
+		MaxTokens: summaryMaxTokens,
+	})
+	summary, err := summarizer.GenerateSummaryWithContext(ctx, msgs)
+	if err != nil {
+		a.log().Warn("failed to summarize trimmed messages", "conversation_id", a.conversationID, "error", err)
+		return llms.ChatCompletionMessage{}, false
+	}
+	msg := llms.ChatCompletionMessage{
+		Role:    llms.ChatMessageRoleAssistant,
+		Content: fmt.Sprintf("[System Note: Summary of earlier messages trimmed to fit the context window]\n\n%s", summary),
+	}
+	a.contextSummary = &contextSummary{count: len(msgs), message: msg}
+	return msg, true
+}
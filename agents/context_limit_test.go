@@ -0,0 +1,157 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/mcp"
+	"github.com/MrLeeang/langchain-go/memory"
+)
+
+// contextLimit leaves a budget of 450 tokens (bytes with byteTokenCounter) for the prompt.
+const (
+	contextLimit  = 600
+	contextBudget = 450
+)
+
+// newLimitedAgent returns an agent under contextLimit whose conversation holds pairs
+// user/assistant exchanges of about 100 bytes.
+func newLimitedAgent(t *testing.T, llm llms.LLM, pairs int, opts ...AgentOption) *Agent {
+	t.Helper()
+	mem := memory.NewBufferMemory()
+	var history []llms.ChatCompletionMessage
+	for i := range pairs {
+		history = append(history,
+			llms.ChatCompletionMessage{Role: llms.ChatMessageRoleUser, Content: fmt.Sprintf("question %d %s", i, strings.Repeat("q", 40))},
+			llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: fmt.Sprintf("answer %d %s", i, strings.Repeat("a", 40))},
+		)
+	}
+	if err := mem.SaveMessages(context.Background(), "c1", history); err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]AgentOption{
+		WithMemory(mem),
+		WithConversationID("c1"),
+		WithContextLimit(contextLimit, TruncateOldest),
+		WithUseToolDataLength(0),
+	}, opts...)
+	agent := CreateReactAgent(context.Background(), llm, opts...)
+	agent.tokenCounter = byteTokenCounter(t)
+	return agent
+}
+
+func promptTokens(agent *Agent, msgs []llms.ChatCompletionMessage) int {
+	total := 0
+	for _, msg := range msgs {
+		total += agent.getTokenCounter().countMessage(msg)
+	}
+	return total
+}
+
+func TestContextLimitTrimsOldestHistory(t *testing.T) {
+	llm := llms.NewFakeModel([]string{"ok"})
+	agent := newLimitedAgent(t, llm, 10)
+
+	if _, err := agent.Run("final question"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	sent := llm.Calls()[0]
+	if sent[0].Role != llms.ChatMessageRoleSystem {
+		t.Errorf("first message = %+v, want the system prompt", sent[0])
+	}
+	if last := sent[len(sent)-1]; last.Role != llms.ChatMessageRoleUser || last.Content != "final question" {
+		t.Errorf("last message = %+v, want the user message", last)
+	}
+	if n := promptTokens(agent, sent); n > contextBudget {
+		t.Errorf("prompt has %d tokens, budget is %d", n, contextBudget)
+	}
+	if got := agent.GetMetadata().TrimmedMessages; got == 0 || len(sent) >= 22 {
+		t.Errorf("trimmed %d messages, sent %d; want history trimmed", got, len(sent))
+	}
+}
+
+// Tool results of the current turn are cut when trimming the history isn't enough, and the
+// full output is still kept in the turn.
+func TestContextLimitCutsCurrentTurnToolResults(t *testing.T) {
+	output := strings.Repeat("result line\n", 100)
+	llm := llms.NewFakeModelWithMessages([]llms.ChatCompletionMessage{
+		toolCallReply("call_1", "dump", `{}`),
+		{Content: "summarized the dump"},
+	})
+	agent := newLimitedAgent(t, llm, 0, WithTools([]mcp.Tool{&fakeTool{name: "dump", result: output}}))
+
+	if _, err := agent.Run("dump it"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	sent := llm.Calls()[1]
+	if n := promptTokens(agent, sent); n > contextBudget {
+		t.Errorf("prompt has %d tokens, budget is %d", n, contextBudget)
+	}
+	tool := sent[len(sent)-1]
+	if tool.Role != llms.ChatMessageRoleTool || tool.ToolCallID != "call_1" {
+		t.Fatalf("last message = %+v, want the tool result", tool)
+	}
+	if !strings.HasPrefix(tool.Content, "result line") || !strings.HasSuffix(tool.Content, "[truncated to fit the context window]") {
+		t.Errorf("tool result = %q, want its beginning and a truncation note", tool.Content)
+	}
+	var kept bool
+	for _, msg := range agent.GetMessages() {
+		kept = kept || (msg.Role == llms.ChatMessageRoleTool && msg.Content == output)
+	}
+	if !kept {
+		t.Error("full tool output not kept in the turn")
+	}
+}
+
+func TestContextLimitCountsSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		summary     string
+		wantSummary bool
+	}{
+		{"short summary kept", "they asked ten questions", true},
+		{"summary too long dropped", strings.Repeat("long summary ", 50), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := llms.NewFakeModel([]string{tt.summary, "ok"})
+			agent := newLimitedAgent(t, llm, 10, WithContextLimit(contextLimit, SummarizeOldest))
+
+			if _, err := agent.Run("final question"); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			calls := llm.Calls()
+			if len(calls) != 2 {
+				t.Fatalf("LLM called %d times, want the summary then the answer", len(calls))
+			}
+			sent := calls[1]
+			if n := promptTokens(agent, sent); n > contextBudget {
+				t.Errorf("prompt has %d tokens, budget is %d", n, contextBudget)
+			}
+			var hasSummary bool
+			for _, msg := range sent {
+				hasSummary = hasSummary || strings.Contains(msg.Content, tt.summary)
+			}
+			if hasSummary != tt.wantSummary {
+				t.Errorf("summary sent = %v, want %v", hasSummary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestContextLimitExceeded(t *testing.T) {
+	llm := llms.NewFakeModel([]string{"unused"})
+	agent := newLimitedAgent(t, llm, 2)
+
+	_, err := agent.Run(strings.Repeat("too long ", 100))
+	if !errors.Is(err, ErrContextLimitExceeded) {
+		t.Fatalf("error = %v, want ErrContextLimitExceeded", err)
+	}
+	if llm.CallCount() != 0 {
+		t.Errorf("LLM called %d times, want 0", llm.CallCount())
+	}
+}
@@ -1,6 +1,10 @@
 package agents
 
-import "time"
+import (
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
 
 // GetDuration returns the total execution duration of the agent's last run.
 func (a *Agent) GetDuration() time.Duration {
@@ -22,4 +26,36 @@ func (a *Agent) ResetDuration() {
 	a.Duration = 0
 	a.StartTime = time.Time{}
 	a.EndTime = time.Time{}
+	a.iterationTimings = nil
+	a.truncatedTools = nil
+}
+
+// IterationTiming is where one iteration of a run spent its time: the LLM call, then the
+// tools it requested.
+type IterationTiming struct {
+	Iteration int `json:"iteration"`
+	// Duration covers the LLM call and the tool calls.
+	Duration    time.Duration `json:"duration"`
+	LLMDuration time.Duration `json:"llm_duration"`
+	// Tools names the tools called in this iteration, in order.
+	Tools []string `json:"tools,omitempty"`
+}
+
+// GetIterationTimings returns the timing of each iteration of the last run.
+func (a *Agent) GetIterationTimings() []IterationTiming {
+	return append([]IterationTiming(nil), a.iterationTimings...)
+}
+
+// recordIteration records an iteration that started at start, whose LLM call took
+// llmDuration and which requested calls.
+func (a *Agent) recordIteration(iteration int, start time.Time, llmDuration time.Duration, calls []llms.ChatToolCall) {
+	timing := IterationTiming{
+		Iteration:   iteration,
+		Duration:    time.Since(start),
+		LLMDuration: llmDuration,
+	}
+	for _, tc := range calls {
+		timing.Tools = append(timing.Tools, tc.Name)
+	}
+	a.iterationTimings = append(a.iterationTimings, timing)
 }
@@ -0,0 +1,85 @@
+package agents
+
+import (
+	"fmt"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// Example is a worked exchange shown to the model before the conversation (see
+// WithFewShotExamples): the user input, the tools the assistant ideally calls and its answer.
+type Example struct {
+	Input string
+	// ToolCalls are the tool calls of the ideal reply, with the results the tools return.
+	ToolCalls []ExampleToolCall
+	// Output is the final answer.
+	Output string
+}
+
+// ExampleToolCall is one tool call of an [Example].
+type ExampleToolCall struct {
+	Name string
+	// Arguments is the JSON arguments object.
+	Arguments string
+	Result    string
+}
+
+// messages returns the example as chat messages; n numbers the tool call IDs.
+func (e Example) messages(n int) []llms.ChatCompletionMessage {
+	msgs := []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: e.Input}}
+	if len(e.ToolCalls) > 0 {
+		call := llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant}
+		var results []llms.ChatCompletionMessage
+		for i, tc := range e.ToolCalls {
+			id := fmt.Sprintf("example_%d_%d", n, i)
+			call.ToolCalls = append(call.ToolCalls, llms.ChatToolCall{ID: id, Name: tc.Name, Arguments: tc.Arguments})
+			results = append(results, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleTool, ToolCallID: id, Content: tc.Result})
+		}
+		msgs = append(append(msgs, call), results...)
+	}
+	return append(msgs, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: e.Output})
+}
+
+// insertExamples inserts the few-shot examples after the leading system messages of msgs.
+// Under a context limit, examples are dropped from the last one until the prompt fits: the
+// history was already trimmed without them, so they go last.
+func (a *Agent) insertExamples(msgs []llms.ChatCompletionMessage) []llms.ChatCompletionMessage {
+	if len(a.examples) == 0 {
+		return msgs
+	}
+	examples := make([][]llms.ChatCompletionMessage, len(a.examples))
+	for i, e := range a.examples {
+		examples[i] = e.messages(i + 1)
+	}
+
+	if limit := a.contextWindow(); limit > 0 {
+		counter := a.getTokenCounter()
+		total := 0
+		for _, msg := range msgs {
+			total += counter.countMessage(msg)
+		}
+		for _, ex := range examples {
+			for _, msg := range ex {
+				total += counter.countMessage(msg)
+			}
+		}
+		budget := limit - completionReserve(limit)
+		for len(examples) > 0 && total > budget {
+			for _, msg := range examples[len(examples)-1] {
+				total -= counter.countMessage(msg)
+			}
+			examples = examples[:len(examples)-1]
+		}
+	}
+
+	start := 0
+	for start < len(msgs) && msgs[start].Role == llms.ChatMessageRoleSystem {
+		start++
+	}
+	out := make([]llms.ChatCompletionMessage, 0, len(msgs)+len(examples)*3)
+	out = append(out, msgs[:start]...)
+	for _, ex := range examples {
+		out = append(out, ex...)
+	}
+	return append(out, msgs[start:]...)
+}
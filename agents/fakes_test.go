@@ -0,0 +1,294 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/openai/openai-go/v3"
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// scriptedChunk is one chunk of a scriptedStreamLLM turn, sent after delay.
+type scriptedChunk struct {
+	delay   time.Duration
+	content string
+	finish  string
+}
+
+// scriptedStreamLLM streams one scripted turn per ChatStream call. With ignoreCtx its Recv
+// keeps returning chunks after the context is cancelled, like a connection with buffered data.
+type scriptedStreamLLM struct {
+	mu        sync.Mutex
+	turns     [][]scriptedChunk
+	calls     int
+	ignoreCtx bool
+}
+
+func (m *scriptedStreamLLM) Chat(ctx context.Context, messages []llms.ChatCompletionMessage, opts ...llms.ChatOption) (llms.ChatCompletionResponse, error) {
+	return llms.ChatCompletionResponse{}, errors.New("scripted stream LLM: Chat not supported")
+}
+
+func (m *scriptedStreamLLM) ChatStream(ctx context.Context, messages []llms.ChatCompletionMessage, opts ...llms.ChatOption) (llms.ChatStream, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.calls >= len(m.turns) {
+		return nil, fmt.Errorf("scripted stream LLM: no turn for call %d", m.calls+1)
+	}
+	turn := m.turns[m.calls]
+	m.calls++
+	recv := func() (llms.ChatCompletionStreamResponse, error) {
+		if len(turn) == 0 {
+			return llms.ChatCompletionStreamResponse{}, io.EOF
+		}
+		c := turn[0]
+		turn = turn[1:]
+		if m.ignoreCtx {
+			time.Sleep(c.delay)
+		} else {
+			select {
+			case <-time.After(c.delay):
+			case <-ctx.Done():
+				return llms.ChatCompletionStreamResponse{}, ctx.Err()
+			}
+		}
+		return llms.ChatCompletionStreamResponse{
+			Choices: []llms.ChatCompletionStreamChoice{{
+				Delta:        llms.ChatCompletionStreamDelta{Content: c.content},
+				FinishReason: c.finish,
+			}},
+		}, nil
+	}
+	return llms.NewChatCompletionStream(recv, nil), nil
+}
+
+// textTurn scripts a turn streaming words, each after delay.
+func textTurn(delay time.Duration, words ...string) []scriptedChunk {
+	turn := make([]scriptedChunk, 0, len(words)+1)
+	for _, w := range words {
+		turn = append(turn, scriptedChunk{delay: delay, content: w})
+	}
+	return append(turn, scriptedChunk{finish: "stop"})
+}
+
+// chatOnlyLLM hides every method of the wrapped LLM but Chat.
+type chatOnlyLLM struct {
+	llm llms.LLM
+}
+
+func (m chatOnlyLLM) Chat(ctx context.Context, messages []llms.ChatCompletionMessage, opts ...llms.ChatOption) (llms.ChatCompletionResponse, error) {
+	return m.llm.Chat(ctx, messages, opts...)
+}
+
+// fakeTool is an mcp.Tool recording its calls; it fails the first failures calls.
+type fakeTool struct {
+	name     string
+	result   string
+	failures int
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (t *fakeTool) Name() string         { return t.name }
+func (t *fakeTool) Description() string  { return "fake tool " + t.name }
+func (t *fakeTool) ArgumentsSchema() any { return nil }
+
+func (t *fakeTool) Call(ctx context.Context, input interface{}) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls++
+	if t.calls <= t.failures {
+		return "", fmt.Errorf("%s failed (call %d)", t.name, t.calls)
+	}
+	return t.result, nil
+}
+
+func (t *fakeTool) callCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls
+}
+
+// toolCallReply is an assistant message calling tool with args.
+func toolCallReply(id, tool, args string) llms.ChatCompletionMessage {
+	return llms.ChatCompletionMessage{
+		Role:      llms.ChatMessageRoleAssistant,
+		ToolCalls: []llms.ChatToolCall{{ID: id, Name: tool, Arguments: args}},
+	}
+}
+
+// collectStream receives from ch until it closes and returns the streamed text and the
+// responses received, failing the test if that takes longer than timeout.
+func collectStream(t *testing.T, ch <-chan StreamResponse, timeout time.Duration) (string, []StreamResponse) {
+	t.Helper()
+	var text strings.Builder
+	var responses []StreamResponse
+	deadline := time.After(timeout)
+	for {
+		select {
+		case resp, ok := <-ch:
+			if !ok {
+				return text.String(), responses
+			}
+			text.WriteString(resp.Content)
+			responses = append(responses, resp)
+		case <-deadline:
+			t.Fatalf("stream did not close within %v", timeout)
+		}
+	}
+}
+
+// lastResponse returns the last response, failing the test when it isn't the Done one.
+func lastResponse(t *testing.T, responses []StreamResponse) StreamResponse {
+	t.Helper()
+	if len(responses) == 0 {
+		t.Fatal("stream closed without responses")
+	}
+	last := responses[len(responses)-1]
+	if !last.Done {
+		t.Fatalf("last response is not Done: %+v", last)
+	}
+	return last
+}
+
+// waitFor polls cond until it holds, failing the test after timeout.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %v", timeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// slowChatLLM delays every Chat call of the wrapped LLM.
+type slowChatLLM struct {
+	llm   llms.LLM
+	delay time.Duration
+}
+
+func (m slowChatLLM) Chat(ctx context.Context, messages []llms.ChatCompletionMessage, opts ...llms.ChatOption) (llms.ChatCompletionResponse, error) {
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return llms.ChatCompletionResponse{}, ctx.Err()
+	}
+	return m.llm.Chat(ctx, messages, opts...)
+}
+
+// toolCallerLLM makes a FakeModel an llms.ToolCaller, recording the tool names offered per call.
+type toolCallerLLM struct {
+	*llms.FakeModel
+
+	mu      sync.Mutex
+	offered [][]string
+}
+
+func (m *toolCallerLLM) record(tools []openai.ChatCompletionToolUnionParam) {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		if fn := t.GetFunction(); fn != nil {
+			names = append(names, fn.Name)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offered = append(m.offered, names)
+}
+
+// Offered returns the tool names offered to each call, in order.
+func (m *toolCallerLLM) Offered() [][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([][]string(nil), m.offered...)
+}
+
+func (m *toolCallerLLM) ChatWithTools(ctx context.Context, messages []llms.ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...llms.ChatOption) (llms.ChatCompletionResponse, error) {
+	m.record(tools)
+	return m.Chat(ctx, messages, opts...)
+}
+
+func (m *toolCallerLLM) ChatStreamWithTools(ctx context.Context, messages []llms.ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...llms.ChatOption) (llms.ChatStream, error) {
+	m.record(tools)
+	return m.ChatStream(ctx, messages, opts...)
+}
+
+// byteTokenCounter returns a TokenCounter counting one token per byte, which needs no
+// downloaded encoding.
+func byteTokenCounter(t *testing.T) *TokenCounter {
+	t.Helper()
+	ranks := make(map[string]int, 256)
+	for i := range 256 {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	bpe, err := tiktoken.NewCoreBPE(ranks, map[string]int{"<|endoftext|>": 256}, `\S+|\s+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := tiktoken.NewTiktoken(bpe, &tiktoken.Encoding{Name: "bytes", MergeableRanks: ranks}, map[string]any{})
+	return &TokenCounter{enc: enc}
+}
+
+// captureHandler is a slog.Handler keeping the records it handles.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *captureHandler) WithGroup(string) slog.Handler { return h }
+
+// count returns how many records at level have a message containing msg.
+func (h *captureHandler) count(level slog.Level, msg string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, r := range h.records {
+		if r.Level == level && strings.Contains(r.Message, msg) {
+			n++
+		}
+	}
+	return n
+}
+
+// attr returns the value of the attribute key of the first record at level whose message
+// contains msg, or "" when there is none.
+func (h *captureHandler) attr(level slog.Level, msg, key string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Level != level || !strings.Contains(r.Message, msg) {
+			continue
+		}
+		var value string
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == key {
+				value = a.Value.String()
+				return false
+			}
+			return true
+		})
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package agents
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// ImageInput is an image attached to a user message, given either as a URL or as raw bytes.
+type ImageInput struct {
+	// URL is an http(s) or data URL. It takes precedence over Data.
+	URL string
+	// Data is the raw image, sent base64-encoded as a data URL.
+	Data []byte
+	// MimeType describes Data, e.g. "image/png". Defaults to "image/png".
+	MimeType string
+}
+
+// RunWithImages is like Run but attaches images to the user message, for vision-capable models.
+func (a *Agent) RunWithImages(message string, images []ImageInput) (string, error) {
+	userMsg, err := userMessageWithImages(message, images)
+	if err != nil {
+		return "", err
+	}
+	// Cancel any previous run/stream still active first: with WithSerializedTurns its turn
+	// must end before this one starts.
+	a.Stop()
+
+	defer a.lockTurn()()
+
+	a.ResetTokenUsage()
+	a.ResetDuration()
+
+	a.LoadMessages(message)
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancel = cancel
+	defer func() {
+		if a.cancel != nil {
+			a.cancel()
+			a.cancel = nil
+		}
+	}()
+
+	return a.runUserMessage(ctx, userMsg)
+}
+
+// userMessageWithImages builds a multimodal user message: the text part first, then the images.
+func userMessageWithImages(message string, images []ImageInput) (llms.ChatCompletionMessage, error) {
+	parts := []llms.ChatMessagePart{{Type: llms.ChatMessagePartTypeText, Text: message}}
+	for i, img := range images {
+		url := img.URL
+		if url == "" {
+			if len(img.Data) == 0 {
+				return llms.ChatCompletionMessage{}, fmt.Errorf("image %d has neither URL nor Data", i)
+			}
+			mimeType := img.MimeType
+			if mimeType == "" {
+				mimeType = "image/png"
+			}
+			url = "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+		}
+		parts = append(parts, llms.ChatMessagePart{Type: llms.ChatMessagePartTypeImageURL, ImageURL: url})
+	}
+	return llms.ChatCompletionMessage{
+		Role:         llms.ChatMessageRoleUser,
+		Content:      message,
+		MultiContent: parts,
+	}, nil
+}
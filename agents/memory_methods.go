@@ -2,6 +2,8 @@ package agents
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/MrLeeang/langchain-go/llms"
 	"github.com/MrLeeang/langchain-go/memory"
@@ -20,19 +22,81 @@ func (a *Agent) ClearHistory() error {
 	return nil
 }
 
+// ForkConversation copies the agent's conversation to newID with memory.Fork and returns a
+// new Agent bound to newID that shares the LLM, tools, memory and options of a; run state such
+// as token counts starts fresh. The two agents' conversations then diverge, e.g. to A/B test
+// prompts (set Prompt on the fork). Since the memory is shared, Close only one of the agents.
+func (a *Agent) ForkConversation(newID string) (*Agent, error) {
+	if a.mem == nil {
+		return nil, fmt.Errorf("agent has no memory to fork")
+	}
+	if err := memory.Fork(a.ctx, a.mem, a.conversationID, newID); err != nil {
+		return nil, err
+	}
+
+	fork := *a
+	fork.cancel = nil
+	fork.conversationID = newID
+	fork.messages = []llms.ChatCompletionMessage{}
+	fork.historyMessageIndex = 0
+	fork.TotalTokens, fork.PromptTokens, fork.CompletionTokens = 0, 0, 0
+	fork.Duration = 0
+	fork.StartTime, fork.EndTime = time.Time{}, time.Time{}
+	fork.iterationTimings = nil
+	fork.truncatedTools = nil
+	fork.steps = nil
+	fork.contextSummary = nil
+	fork.trimmedMessages = 0
+	fork.lastReasoning = ""
+	return &fork, nil
+}
+
+// recallLimit is how many Q&A pairs RecallAcrossConversations retrieves.
+const recallLimit = 5
+
+// RecallAcrossConversations searches every conversation of the agent's memory for exchanges
+// relevant to query and formats them as a context block, each headed by its conversation ID
+// and time, e.g. to prepend to the next message. It returns "" when nothing is found and an
+// error when the memory can't search across conversations (see memory.CrossConversationSearcher).
+func (a *Agent) RecallAcrossConversations(query string) (string, error) {
+	searcher, ok := a.mem.(memory.CrossConversationSearcher)
+	if !ok {
+		return "", fmt.Errorf("memory %T does not support cross-conversation search", a.mem)
+	}
+	hits, err := searcher.SearchAllConversations(a.ctx, query, recallLimit)
+	if err != nil {
+		return "", err
+	}
+	if len(hits) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("# Related past conversations\n")
+	for _, hit := range hits {
+		fmt.Fprintf(&b, "\n[conversation %s, %s]\n", hit.ConversationID, hit.Timestamp.Format("2006-01-02 15:04"))
+		for _, msg := range hit.Messages {
+			fmt.Fprintf(&b, "%s: %s\n", msg.Role, msg.Content)
+		}
+	}
+	return b.String(), nil
+}
+
 func (a *Agent) LoadMessages(latestUserInput string) {
 
 	// build system prompt
-	systemPrompt := buildSystemPrompt(a.registeredSkills)
+	systemPrompt := a.systemPrompt()
 	a.messages = []llms.ChatCompletionMessage{
 		{
 			Role:    llms.ChatMessageRoleSystem,
 			Content: systemPrompt,
+			// the system prompt and skills block are identical across iterations
+			CacheHint: true,
 		},
 	}
 
-	if a.Prompt != "" {
-		a.messages[0].Content += "\n\n# User Instructions\n" + a.Prompt
+	if instructions := a.instructions(); instructions != "" {
+		a.messages[0].Content += "\n\n# User Instructions\n" + instructions
 	}
 
 	if a.debug {
@@ -41,22 +105,26 @@ func (a *Agent) LoadMessages(latestUserInput string) {
 
 	if a.mem != nil && a.conversationID != "" {
 
-		// Check if this is MilvusMemory with query-based loading enabled
-		// If so, skip loading here - it will be loaded when we have the user query
-		if milvusMem, ok := a.mem.(*memory.MilvusMemory); ok {
+		// Query-aware memories (Milvus, pgvector) load history relevant to the user input,
+		// so they are not compressed here
+		if queryMem, ok := a.mem.(memory.MilvusMemoryInterface); ok {
 
-			milvusMem.SetQuery(latestUserInput)
+			queryMem.SetQuery(latestUserInput)
 
-			if history, err := a.mem.LoadMessages(a.ctx, a.conversationID); err == nil && len(history) > 0 {
-				a.messages = append(a.messages, history...)
+			if history, sentAt, err := a.loadHistory(); err == nil && len(history) > 0 {
+				a.messages = append(a.messages, a.mergeHistorySystem(annotateSentTimes(history, sentAt, time.Now()))...)
 			}
 		} else {
-			if history, err := a.mem.LoadMessages(a.ctx, a.conversationID); err == nil && len(history) > 0 {
+			if history, sentAt, err := a.loadHistory(); err == nil && len(history) > 0 {
+				// the annotated copy is only sent to the model; compressed history is saved
+				// back to memory, so it is built from the plain messages
+				annotated := a.mergeHistorySystem(annotateSentTimes(history, sentAt, time.Now()))
+				history = withoutSystem(history)
 
 				historyIndex := a.findBestCompressionIndex(history, a.maxWindowTokens)
 
 				if historyIndex == 0 {
-					historyMessages := a.formatHistory(history)
+					historyMessages := a.formatHistory(annotated)
 					a.messages = append(a.messages, historyMessages...)
 				} else {
 
@@ -66,12 +134,12 @@ func (a *Agent) LoadMessages(latestUserInput string) {
 
 					// clear memory and save the new messages with summary
 					if err := a.mem.ClearMessages(a.ctx, a.conversationID); err != nil {
-						fmt.Println("Error clearing memory:", err)
+						a.log().Warn("failed to clear memory for compressed history", "conversation_id", a.conversationID, "error", err)
 					} else {
 						if len(a.messages) > 1 {
 							// saveMessages expects messages without system prompt, so we skip the first message
 							if err := a.mem.SaveMessages(a.ctx, a.conversationID, historyMessages); err != nil {
-								fmt.Println("Error saving messages to memory:", err)
+								a.log().Warn("failed to save compressed history to memory", "conversation_id", a.conversationID, "error", err)
 							}
 						}
 					}
@@ -83,13 +151,140 @@ func (a *Agent) LoadMessages(latestUserInput string) {
 	a.historyMessageIndex = len(a.messages)
 }
 
+// loadHistory loads the conversation history. With WithMessageTimestamps and a memory
+// implementing memory.MemoryV2, it also returns when each message was saved.
+func (a *Agent) loadHistory() ([]llms.ChatCompletionMessage, []time.Time, error) {
+	v2, ok := a.mem.(memory.MemoryV2)
+	if !a.messageTimestamps || !ok {
+		history, err := a.mem.LoadMessages(a.ctx, a.conversationID)
+		return history, nil, err
+	}
+	messages, err := v2.LoadMessagesV2(a.ctx, a.conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	history := make([]llms.ChatCompletionMessage, len(messages))
+	sentAt := make([]time.Time, len(messages))
+	for i, msg := range messages {
+		history[i] = msg.LLM()
+		sentAt[i] = msg.CreatedAt
+	}
+	return history, sentAt, nil
+}
+
+// annotateSentTimes returns a copy of history whose user messages end with when they were
+// sent, e.g. "(sent 2 days ago)", so the model can tell old requests from recent ones.
+// history is returned as is when sentAt is nil.
+func annotateSentTimes(history []llms.ChatCompletionMessage, sentAt []time.Time, now time.Time) []llms.ChatCompletionMessage {
+	if sentAt == nil {
+		return history
+	}
+	annotated := make([]llms.ChatCompletionMessage, len(history))
+	copy(annotated, history)
+	for i := range annotated {
+		if annotated[i].Role != llms.ChatMessageRoleUser || annotated[i].Content == "" || sentAt[i].IsZero() {
+			continue
+		}
+		annotated[i].Content += " (sent " + sentAgo(sentAt[i], now) + ")"
+	}
+	return annotated
+}
+
+// sentAgo describes t relative to now, e.g. "3 hours ago"; dates older than a month are
+// written out.
+func sentAgo(t, now time.Time) string {
+	d := now.Sub(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit + " ago"
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	default:
+		return "on " + t.Format("2006-01-02")
+	}
+}
+
+// withoutSystem returns history without its system messages.
+func withoutSystem(history []llms.ChatCompletionMessage) []llms.ChatCompletionMessage {
+	rest := make([]llms.ChatCompletionMessage, 0, len(history))
+	for _, msg := range history {
+		if msg.Role != llms.ChatMessageRoleSystem {
+			rest = append(rest, msg)
+		}
+	}
+	return rest
+}
+
+// mergeHistorySystem appends the content of system messages loaded from memory (such as a
+// memory.SummaryMemory summary) to the system prompt, so the model still sees a single system
+// message, and returns the remaining history.
+// turnMessages returns the messages of the current turn as saved to memory: without reasoning
+// and, with WithCleanMemory, cleaned by cleanMessages.
+func (a *Agent) turnMessages() []llms.ChatCompletionMessage {
+	messages := withoutReasoning(a.messages[a.historyMessageIndex:])
+	if !a.cleanMemory {
+		return messages
+	}
+	return cleanMessages(messages)
+}
+
+// cleanMessages replaces each assistant message calling tools with a note naming its calls,
+// drops the tool results and keeps only the text of final answers.
+func cleanMessages(messages []llms.ChatCompletionMessage) []llms.ChatCompletionMessage {
+	out := make([]llms.ChatCompletionMessage, 0, len(messages))
+	for _, msg := range messages {
+		switch {
+		case msg.Role == llms.ChatMessageRoleTool:
+		case msg.Role == llms.ChatMessageRoleAssistant && len(msg.ToolCalls) > 0:
+			notes := make([]string, len(msg.ToolCalls))
+			for i, tc := range msg.ToolCalls {
+				args := tc.Arguments
+				if args == "" {
+					args = "{}"
+				}
+				notes[i] = fmt.Sprintf("called tool %s with args %s", tc.Name, args)
+			}
+			out = append(out, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: strings.Join(notes, "\n")})
+		case msg.Role == llms.ChatMessageRoleAssistant:
+			out = append(out, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: msg.Content})
+		default:
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func (a *Agent) mergeHistorySystem(history []llms.ChatCompletionMessage) []llms.ChatCompletionMessage {
+	rest := make([]llms.ChatCompletionMessage, 0, len(history))
+	for _, msg := range history {
+		if msg.Role == llms.ChatMessageRoleSystem {
+			if msg.Content != "" {
+				a.messages[0].Content += "\n\n" + msg.Content
+			}
+			continue
+		}
+		rest = append(rest, msg)
+	}
+	return rest
+}
+
 func (a *Agent) findBestCompressionIndex(history []llms.ChatCompletionMessage, maxWindowTokens int) int {
 	// 触发压缩
 	tokenCount := 0
 	historyIndex := 0
 
 	for index := len(history) - 1; index >= 0; index-- {
-		tokenCount += CountTokens(history[index].Content)
+		tokenCount += a.getTokenCounter().Count(history[index].Content)
 		if tokenCount > maxWindowTokens {
 			if index == 0 {
 				historyIndex = 1
@@ -141,7 +336,7 @@ func (a *Agent) compressHistory(history []llms.ChatCompletionMessage) []llms.Cha
 
 	if err != nil {
 		// if summary generation fails, we can choose to either skip saving the summary or save an error message as a system note
-		fmt.Println("Error generating summary:", err)
+		a.log().Warn("failed to summarize history", "conversation_id", a.conversationID, "error", err)
 	} else {
 		// save the summary as an Assistant message in the conversation history, so it can be used in future interactions
 		summaryMsg := llms.ChatCompletionMessage{
@@ -178,6 +373,8 @@ func (a *Agent) formatHistory(history []llms.ChatCompletionMessage) []llms.ChatC
 			continue
 		}
 
+		// reasoning from earlier turns is never resent to the model
+		msg.ReasoningContent = ""
 		messages = append(messages, msg)
 	}
 
@@ -1,28 +1,50 @@
 package agents
 
-import "time"
+import (
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
 
 // AgentMetadata contains metadata about the agent's execution, including
 // conversation ID, token usage, and timing information.
 type AgentMetadata struct {
 	ConversationID   string        `json:"conversation_id"`
+	Model            string        `json:"model,omitempty"`
+	Provider         string        `json:"provider,omitempty"`
 	TotalTokens      int           `json:"total_tokens"`
 	PromptTokens     int           `json:"prompt_tokens"`
 	CompletionTokens int           `json:"completion_tokens"`
 	Duration         time.Duration `json:"duration"`
 	StartTime        time.Time     `json:"start_time"`
 	EndTime          time.Time     `json:"end_time"`
+	// TrimmedMessages is the most messages WithContextLimit removed from a single request in the last run.
+	TrimmedMessages int `json:"trimmed_messages"`
+	// EstimatedCost is the USD price of the token usage according to the llms model registry.
+	EstimatedCost float64 `json:"estimated_cost"`
+	// Iterations holds the timing of each iteration of the last run.
+	Iterations []IterationTiming `json:"iterations,omitempty"`
+	// TruncatedToolOutputs holds the full outputs of the tool calls of the last run that were
+	// truncated for the model (see WithUseToolDataLength).
+	TruncatedToolOutputs []ToolOutput `json:"truncated_tool_outputs,omitempty"`
 }
 
-// GetMetadata returns the metadata containing conversation ID, token usage, and timing information.
+// GetMetadata returns the metadata containing conversation ID, model, token usage, and timing information.
 func (a *Agent) GetMetadata() AgentMetadata {
+	model, provider := llms.DescribeModel(a.llm)
 	return AgentMetadata{
-		ConversationID:   a.conversationID,
-		TotalTokens:      a.TotalTokens,
-		PromptTokens:     a.PromptTokens,
-		CompletionTokens: a.CompletionTokens,
-		Duration:         a.Duration,
-		StartTime:        a.StartTime,
-		EndTime:          a.EndTime,
+		ConversationID:       a.conversationID,
+		Model:                model,
+		Provider:             provider,
+		TotalTokens:          a.TotalTokens,
+		PromptTokens:         a.PromptTokens,
+		CompletionTokens:     a.CompletionTokens,
+		Duration:             a.Duration,
+		StartTime:            a.StartTime,
+		EndTime:              a.EndTime,
+		TrimmedMessages:      a.trimmedMessages,
+		EstimatedCost:        a.EstimatedCost(),
+		Iterations:           a.GetIterationTimings(),
+		TruncatedToolOutputs: append([]ToolOutput(nil), a.truncatedTools...),
 	}
 }
@@ -1,6 +1,12 @@
 package agents
 
 import (
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
 	"github.com/MrLeeang/langchain-go/mcp"
 	"github.com/MrLeeang/langchain-go/memory"
 	"github.com/MrLeeang/langchain-go/skills"
@@ -59,6 +65,136 @@ func WithConversationID(conversationID string) AgentOption {
 	}
 }
 
+// WithSerializedTurns makes Run, RunWithImages and Stream wait until no other serialized turn
+// of any agent runs on the same conversation ID, from loading the history to saving the turn.
+// Use it when concurrent requests create agents sharing a memory and conversation ID, so their
+// saves don't interleave (which also breaks the Q&A pairing of MilvusMemory). Default is false.
+func WithSerializedTurns(serialize bool) AgentOption {
+	return func(a *Agent) {
+		a.serializedTurns = serialize
+	}
+}
+
+// WithMessageTimestamps appends when each user message of the history was sent, e.g.
+// "(sent 2 days ago)", to the messages sent to the model. It needs a memory implementing
+// memory.MemoryV2 (such as RedisMemory, or one wrapped with memory.FromV2); otherwise history
+// is loaded as usual. Default is false.
+func WithMessageTimestamps(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.messageTimestamps = enabled
+	}
+}
+
+// WithLegacyStreamFormat also writes tool calls and results into StreamResponse.Content as
+// JSON, as Stream did in debug mode before typed events (StreamResponse.Event). It will be
+// removed in the next release; render EventToolCallStarted and EventToolResult instead.
+func WithLegacyStreamFormat(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.legacyStreamFormat = enabled
+	}
+}
+
+// WithToolRetry retries a failing tool call up to maxAttempts calls in total, waiting backoff
+// before the second call and doubling the wait after each further failure up to 10s (or
+// backoff when longer), e.g. for network blips to an MCP server. When every attempt fails,
+// the error is sent back to the model as the tool result so it can try another approach.
+// Unknown tools and invalid arguments are never retried; they are reported to the model the same way. Default is a single attempt.
+func WithToolRetry(maxAttempts int, backoff time.Duration) AgentOption {
+	return func(a *Agent) {
+		a.toolRetryAttempts = maxAttempts
+		a.toolRetryBackoff = backoff
+	}
+}
+
+// WithAllowedTools limits the tools the model is offered and may call to those named; calls to
+// other tools are answered with a note instead of being run. See also RunWithOptions.
+func WithAllowedTools(names []string) AgentOption {
+	return func(a *Agent) {
+		a.allowedTools = names
+	}
+}
+
+// WithDeniedTools hides the named tools from the model; calls to them are answered with a
+// note instead of being run.
+func WithDeniedTools(names []string) AgentOption {
+	return func(a *Agent) {
+		a.deniedTools = names
+	}
+}
+
+// WithSystemPromptBuilder replaces the base system prompt with the one build returns; the
+// skills section and WithPrompt instructions are still appended. See SystemPromptBuilder.
+func WithSystemPromptBuilder(build SystemPromptBuilder) AgentOption {
+	return func(a *Agent) {
+		a.promptBuilder = build
+	}
+}
+
+// WithPromptLanguage selects a shipped system prompt: "en" (EnglishSystemPrompt, the default)
+// or "zh" (ChineseSystemPrompt). Other values keep English.
+func WithPromptLanguage(lang string) AgentOption {
+	return func(a *Agent) {
+		switch lang {
+		case "zh":
+			a.promptBuilder = ChineseSystemPrompt
+		default:
+			a.promptBuilder = EnglishSystemPrompt
+		}
+	}
+}
+
+// WithPromptTemplate sets Prompt to tmpl rendered with text/template and vars, e.g.
+// "You are an assistant for {{.company}} operating in {{.timezone}}". A variable missing from
+// vars is an error. Parse and render errors are reported by StartupError and returned by
+// every run instead of running. RunOpts.PromptVars overrides variables for one run.
+func WithPromptTemplate(tmpl string, vars map[string]any) AgentOption {
+	return func(a *Agent) {
+		t, err := template.New("prompt").Option("missingkey=error").Parse(tmpl)
+		if err != nil {
+			a.startupErr = fmt.Errorf("invalid prompt template: %w", err)
+			return
+		}
+		a.promptTemplate = t
+		a.promptVars = vars
+		prompt, err := a.renderPrompt(nil)
+		if err != nil {
+			a.startupErr = err
+			return
+		}
+		a.Prompt = prompt
+	}
+}
+
+// WithFewShotExamples shows examples to the model as user/assistant exchanges right after
+// the system prompt on every LLM call, which helps small models use tools correctly. They are
+// not part of the history: never saved to memory, compressed or summarized. Under
+// WithContextLimit the history is trimmed first and examples are dropped, last one first,
+// only when they still don't fit.
+func WithFewShotExamples(examples []Example) AgentOption {
+	return func(a *Agent) {
+		a.examples = examples
+	}
+}
+
+// WithCallbacks registers cb to receive the agent's lifecycle events; it can be passed several
+// times and callbacks run in registration order. See [Callbacks].
+func WithCallbacks(cb Callbacks) AgentOption {
+	return func(a *Agent) {
+		if cb != nil {
+			a.callbacks = append(a.callbacks, cb)
+		}
+	}
+}
+
+// WithLogger sets the logger for errors the agent recovers from, such as failing to save
+// messages to memory; they are logged at warn level with the conversation ID. Default is
+// slog.Default(); pass a logger with a discarding handler to silence them.
+func WithLogger(logger *slog.Logger) AgentOption {
+	return func(a *Agent) {
+		a.logger = logger
+	}
+}
+
 // WithDebug sets the debug mode for the agent.
 // Default is false.
 func WithDebug(debug bool) AgentOption {
@@ -74,3 +210,151 @@ func WithMaxWindowTokens(maxWindowTokens int) AgentOption {
 		a.maxWindowTokens = maxWindowTokens
 	}
 }
+
+// WithUseToolDataLength sets how many characters of a tool output are sent back to the model;
+// longer outputs are cut and end with a marker such as "...[truncated, 187KB total]". The full
+// outputs stay available in GetMetadata().TruncatedToolOutputs and in stream events. Tools
+// named with WithUntruncatedTools are never truncated. Zero or less disables truncation.
+// Default is 0.
+func WithUseToolDataLength(length int) AgentOption {
+	return func(a *Agent) {
+		a.toolDataLength = length
+	}
+}
+
+// WithUntruncatedTools exempts the named tools from WithUseToolDataLength, e.g. the file tool
+// reading skill documents, which the model needs whole.
+func WithUntruncatedTools(names []string) AgentOption {
+	return func(a *Agent) {
+		a.untruncatedTools = names
+	}
+}
+
+// TokenCounting selects how the agent accounts token usage.
+type TokenCounting int
+
+const (
+	// TokenCountingAuto records provider-reported usage and falls back to a tiktoken
+	// estimate for responses that carry no usage. This is the default.
+	TokenCountingAuto TokenCounting = iota
+	// TokenCountingProvider records only provider-reported usage.
+	TokenCountingProvider
+)
+
+// WithTokenCounting sets how token usage is accounted.
+// Default is TokenCountingAuto.
+func WithTokenCounting(mode TokenCounting) AgentOption {
+	return func(a *Agent) {
+		a.tokenCounting = mode
+	}
+}
+
+// WithReasoningCapture stores the reasoning trace of responses (e.g. DeepSeek reasoning_content)
+// for retrieval via GetLastReasoning. Reasoning is never saved to memory.
+// Default is false.
+func WithReasoningCapture(capture bool) AgentOption {
+	return func(a *Agent) {
+		a.reasoningCapture = capture
+	}
+}
+
+// WithCleanMemory sets whether turns are saved to memory in a cleaned form: each assistant
+// message calling tools is saved as a short note such as "called tool weather with args
+// {"city":"Paris"}" without the tool results, and final answers as their text only. Turned off,
+// tool calls and results are saved as sent to the model.
+// Default is true.
+func WithCleanMemory(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.cleanMemory = enabled
+	}
+}
+
+// WithChatOptions sets a hook returning per-call LLM options for each iteration (starting at 1),
+// e.g. a low temperature while selecting tools and a higher one afterwards.
+func WithChatOptions(fn func(iteration int) []llms.ChatOption) AgentOption {
+	return func(a *Agent) {
+		a.chatOptions = fn
+	}
+}
+
+// WithStartupCheck makes CreateReactAgent ping the LLM when it implements [llms.Pinger].
+// A failure is reported by StartupError and returned by every Run or Stream call.
+// Default is false.
+func WithStartupCheck(check bool) AgentOption {
+	return func(a *Agent) {
+		a.startupCheck = check
+	}
+}
+
+// WithStopWords sets stop sequences sent with every LLM request, e.g. []string{"\nObservation:"}.
+// They replace any Stop configured on the LLM.
+func WithStopWords(stop []string) AgentOption {
+	return func(a *Agent) {
+		a.stopWords = stop
+	}
+}
+
+// DeterministicSeed is the seed sent on every request when WithDeterministic is enabled.
+const DeterministicSeed = 42
+
+// WithDeterministic forces temperature 0 and DeterministicSeed on every iteration, for
+// reproducible regression runs. Providers treat the seed as best effort; compare
+// SystemFingerprint across runs to detect backend changes.
+// Default is false.
+func WithDeterministic(deterministic bool) AgentOption {
+	return func(a *Agent) {
+		a.deterministic = deterministic
+	}
+}
+
+// WithContextLimit keeps each request under tokens (minus a reserve for the completion) by
+// trimming the oldest non-system messages, or summarizing them with SummarizeOldest (the
+// summary counts against the limit). The system prompt and the latest user message are never
+// dropped; when the current turn alone is too long, its tool results are cut, oldest first,
+// and a prompt that still doesn't fit fails the run with ErrContextLimitExceeded. With tokens
+// 0 the model's ContextWindow from the llms model registry is used. Default is no limit.
+func WithContextLimit(tokens int, strategy TruncationStrategy) AgentOption {
+	return func(a *Agent) {
+		a.contextGuard = true
+		a.contextLimit = tokens
+		a.truncation = strategy
+	}
+}
+
+// WithGrammar constrains every LLM call of a run with a GBNF grammar, e.g. to force JSON
+// output from small local models served by llama.cpp (see [llms.NewLlamaCppModel]).
+// llama.cpp rejects a grammar combined with native tools, so it is meant for agents without
+// WithTools. Default is no grammar.
+func WithGrammar(grammar string) AgentOption {
+	return func(a *Agent) {
+		a.grammar = grammar
+	}
+}
+
+// WithStreamRetry re-issues a streamed LLM turn up to n times when it fails with a transient
+// error (network error, 408/429/5xx, or the WithStreamIdleTimeout deadline). The partial turn
+// is discarded and a StreamResponse with Reconnect set is sent before retrying. Default is 0.
+func WithStreamRetry(n int) AgentOption {
+	return func(a *Agent) {
+		a.streamRetries = n
+	}
+}
+
+// WithStreamIdleTimeout aborts a streamed LLM turn when no chunk arrives for d, e.g. when a
+// proxy silently drops a connection while a reasoning model thinks. Combine with
+// WithStreamRetry to reconnect. Default is 0 (no deadline). LLMs that don't stream (see
+// Stream) are not subject to it.
+func WithStreamIdleTimeout(d time.Duration) AgentOption {
+	return func(a *Agent) {
+		a.streamIdleTimeout = d
+	}
+}
+
+// WithUsageCollector reports the token usage of every Run or Stream to c, tagged with the
+// conversation ID when c implements llms.RunUsageRecorder (plain Record with the model name
+// otherwise). Provider-level usage is collected separately via llms.Config.UsageCollector.
+func WithUsageCollector(c llms.UsageCollector) AgentOption {
+	return func(a *Agent) {
+		a.usageCollector = c
+	}
+}
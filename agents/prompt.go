@@ -2,16 +2,43 @@ package agents
 
 import (
 	"fmt"
+	"maps"
 	"strings"
 
+	"github.com/MrLeeang/langchain-go/mcp"
 	"github.com/MrLeeang/langchain-go/skills"
 )
 
-// buildSystemPrompt is used when the agent has MCP tools registered (OpenAI `tools` API).
-// registered lists skills to advertise by name, description, and path; the model loads full content via read_file (or equivalent).
-func buildSystemPrompt(skills []skills.Skill) string {
+// SystemPromptBuilder returns the base system prompt of an agent from its tools and skills.
+// The agent appends the skills section itself, so a builder can't drop it; tools are sent
+// through the native tools API and need no description here.
+type SystemPromptBuilder func(tools []mcp.Tool, skills []skills.Skill) string
+
+// EnglishSystemPrompt is the default [SystemPromptBuilder].
+func EnglishSystemPrompt(tools []mcp.Tool, skills []skills.Skill) string {
+	return `You are an AI assistant. Use the provided function tools when they help answer the user.`
+}
+
+// ChineseSystemPrompt is a [SystemPromptBuilder] instructing the model in Chinese, selected
+// with WithPromptLanguage("zh").
+func ChineseSystemPrompt(tools []mcp.Tool, skills []skills.Skill) string {
+	return `你是一个 AI 助手。在有助于回答用户问题时，使用提供的函数工具。请使用中文回答。`
+}
+
+// systemPrompt builds the agent's system prompt: the base prompt of its builder followed by
+// the skills section.
+func (a *Agent) systemPrompt() string {
+	build := a.promptBuilder
+	if build == nil {
+		build = EnglishSystemPrompt
+	}
+	return build(a.activeTools(), a.registeredSkills) + skillsSection(a.registeredSkills)
+}
+
+// skillsSection advertises skills by name, description, and path; the model loads the full
+// content via read_file (or equivalent). It is empty without skills.
+func skillsSection(skills []skills.Skill) string {
 	var b strings.Builder
-	b.WriteString(`You are an AI assistant. Use the provided function tools when they help answer the user.`)
 	if len(skills) > 0 {
 
 		b.WriteString(`
@@ -40,8 +67,31 @@ When a skill file references a relative path, resolve it against the skill <path
 	return b.String()
 }
 
-// WithPrompt adds a custom system prompt to the agent.
-// This can be used to customize the agent's behavior or add additional instructions.
+// renderPrompt renders the WithPromptTemplate template with its variables overridden by
+// overrides.
+func (a *Agent) renderPrompt(overrides map[string]any) (string, error) {
+	vars := make(map[string]any, len(a.promptVars)+len(overrides))
+	maps.Copy(vars, a.promptVars)
+	maps.Copy(vars, overrides)
+	var b strings.Builder
+	if err := a.promptTemplate.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// instructions returns the custom instructions of the current run: the prompt rendered with
+// the RunOpts variables, or Prompt.
+func (a *Agent) instructions() string {
+	if a.runPrompt != "" {
+		return a.runPrompt
+	}
+	return a.Prompt
+}
+
+// WithPrompt sets the custom instructions of the agent, replacing those of an earlier call.
+// They are appended to the system prompt of every turn, so the model always sees a single
+// system message.
 func (a *Agent) WithPrompt(prompt string) *Agent {
 	a.Prompt = prompt
 
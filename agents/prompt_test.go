@@ -0,0 +1,176 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/memory"
+)
+
+// systemMessage returns the system message of the n-th LLM call (0-based).
+func systemMessage(t *testing.T, llm *llms.FakeModel, n int) string {
+	t.Helper()
+	calls := llm.Calls()
+	if len(calls) <= n || len(calls[n]) == 0 || calls[n][0].Role != llms.ChatMessageRoleSystem {
+		t.Fatalf("call %d has no system message", n)
+	}
+	return calls[n][0].Content
+}
+
+// summaryMemory loads a stored summary as a system message ahead of the history.
+type summaryMemory struct{ memory.Memory }
+
+func (m summaryMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	history, err := m.Memory.LoadMessages(ctx, conversationID)
+	if err != nil || len(history) == 0 {
+		return history, err
+	}
+	summary := llms.ChatCompletionMessage{Role: llms.ChatMessageRoleSystem, Content: "Summary: earlier chat"}
+	return append([]llms.ChatCompletionMessage{summary}, history...), nil
+}
+
+// WithPrompt replaces the custom instructions, and every call gets a single system message
+// holding the tool protocol, the latest prompt and any system messages loaded from memory.
+func TestWithPromptSingleSystemMessage(t *testing.T) {
+	llm := llms.NewFakeModel([]string{"one", "two", "three", "four"})
+	agent := CreateReactAgent(context.Background(), llm,
+		WithMemory(summaryMemory{memory.NewBufferMemory()}),
+		WithConversationID("c1"),
+	)
+	agent.WithPrompt("Answer in French").WithPrompt("Answer in German")
+
+	for range 2 {
+		if _, err := agent.Run("hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, responses := collectStream(t, agent.Stream("hi"), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+	agent.WithPrompt("Answer in Italian")
+	if _, err := agent.Run("hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	wantPrompts := []string{"Answer in German", "Answer in German", "Answer in German", "Answer in Italian"}
+	for i, call := range llm.Calls() {
+		var system int
+		for _, msg := range call {
+			if msg.Role == llms.ChatMessageRoleSystem {
+				system++
+			}
+		}
+		if system != 1 {
+			t.Errorf("call %d has %d system messages, want 1", i, system)
+		}
+		got := systemMessage(t, llm, i)
+		if !strings.Contains(got, wantPrompts[i]) || strings.Count(got, "# User Instructions") != 1 || strings.Contains(got, "French") {
+			t.Errorf("call %d system message = %q, want only the prompt %q", i, got, wantPrompts[i])
+		}
+		if i > 0 && !strings.Contains(got, "Summary: earlier chat") {
+			t.Errorf("call %d system message = %q, want the stored summary merged in", i, got)
+		}
+	}
+}
+
+func TestPromptTemplateMissingKey(t *testing.T) {
+	llm := llms.NewFakeModel([]string{"unused"})
+	agent := CreateReactAgent(context.Background(), llm,
+		WithPromptTemplate("You work for {{.company}} in {{.timezone}}", map[string]any{"company": "Acme"}),
+	)
+	err := agent.StartupError()
+	if err == nil || !strings.Contains(err.Error(), "timezone") {
+		t.Fatalf("StartupError = %v, want the missing variable", err)
+	}
+	if _, runErr := agent.Run("hi"); !errors.Is(runErr, err) {
+		t.Errorf("Run error = %v, want the startup error", runErr)
+	}
+	if llm.CallCount() != 0 {
+		t.Errorf("LLM called %d times, want 0", llm.CallCount())
+	}
+}
+
+func TestPromptTemplatePerRunOverride(t *testing.T) {
+	llm := llms.NewFakeModel([]string{"one", "two", "three"})
+	agent := CreateReactAgent(context.Background(), llm,
+		WithPromptTemplate("You work for {{.company}}", map[string]any{"company": "Acme"}),
+	)
+
+	if _, err := agent.RunWithOptions("hi", RunOpts{PromptVars: map[string]any{"company": "Globex"}}); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+	if _, err := agent.Run("hi"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	_, responses := collectStream(t, agent.StreamWithOptions("hi", RunOpts{PromptVars: map[string]any{"company": "Initech"}}), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+
+	for i, want := range []string{"You work for Globex", "You work for Acme", "You work for Initech"} {
+		if got := systemMessage(t, llm, i); !strings.Contains(got, want) {
+			t.Errorf("call %d system message = %q, want it to contain %q", i, got, want)
+		}
+	}
+}
+
+func TestPromptVarsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []AgentOption
+		vars map[string]any
+		want string
+	}{
+		{"without template", nil, map[string]any{"company": "Acme"}, "requires a prompt template"},
+		{"render error", []AgentOption{WithPromptTemplate("{{.company.name}}", map[string]any{"company": map[string]any{"name": "Acme"}})}, map[string]any{"company": 42}, "failed to render prompt template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := llms.NewFakeModel([]string{"unused"})
+			agent := CreateReactAgent(context.Background(), llm, tt.opts...)
+			if err := agent.StartupError(); err != nil {
+				t.Fatalf("StartupError: %v", err)
+			}
+
+			_, err := agent.RunWithOptions("hi", RunOpts{PromptVars: tt.vars})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("RunWithOptions error = %v, want %q", err, tt.want)
+			}
+			_, responses := collectStream(t, agent.StreamWithOptions("hi", RunOpts{PromptVars: tt.vars}), time.Second)
+			if last := lastResponse(t, responses); last.Error == nil || !strings.Contains(last.Error.Error(), tt.want) {
+				t.Errorf("StreamWithOptions error = %v, want %q", last.Error, tt.want)
+			}
+			if llm.CallCount() != 0 {
+				t.Errorf("LLM called %d times, want 0", llm.CallCount())
+			}
+		})
+	}
+}
+
+type failingPinger struct {
+	*llms.FakeModel
+}
+
+func (failingPinger) Ping(ctx context.Context) error { return errors.New("unreachable") }
+
+// A failed ping doesn't hide a template error found before it.
+func TestStartupErrorJoinsTemplateAndPing(t *testing.T) {
+	agent := CreateReactAgent(context.Background(), failingPinger{llms.NewFakeModel(nil)},
+		WithPromptTemplate("{{.missing}}", nil),
+		WithStartupCheck(true),
+	)
+	err := agent.StartupError()
+	if err == nil {
+		t.Fatal("StartupError = nil")
+	}
+	for _, want := range []string{"missing", "startup check failed: unreachable"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("StartupError = %q, want it to contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package agents
+
+import "github.com/MrLeeang/langchain-go/llms"
+
+// GetLastReasoning returns the reasoning trace of the last LLM response that carried one
+// during the agent's last run. It is empty unless WithReasoningCapture(true) is set.
+func (a *Agent) GetLastReasoning() string {
+	return a.lastReasoning
+}
+
+// captureReasoning keeps reasoning for GetLastReasoning when capture is enabled.
+func (a *Agent) captureReasoning(reasoning string) {
+	if a.reasoningCapture && reasoning != "" {
+		a.lastReasoning = reasoning
+	}
+}
+
+// withoutReasoning returns a copy of messages with reasoning content removed. Reasoning is
+// resent only within the tool-calling loop of a single run, never persisted to memory, so it
+// does not reach the model on later turns.
+func withoutReasoning(messages []llms.ChatCompletionMessage) []llms.ChatCompletionMessage {
+	out := make([]llms.ChatCompletionMessage, len(messages))
+	for i, msg := range messages {
+		msg.ReasoningContent = ""
+		out[i] = msg
+	}
+	return out
+}
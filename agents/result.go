@@ -0,0 +1,84 @@
+package agents
+
+import (
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// RunResult is the outcome of RunDetailed: the answer and the steps that led to it.
+type RunResult struct {
+	FinalAnswer string
+	// Steps holds one entry per iteration of the run, including the one that failed or was
+	// stopped.
+	Steps []Step
+	// Iterations is how many iterations the run started.
+	Iterations int
+	Metadata   AgentMetadata
+}
+
+// Step is one iteration of a run: what the model replied and the tools it called.
+type Step struct {
+	Iteration int
+	// LLMOutput is the text of the model's reply; often empty when it calls tools.
+	LLMOutput string
+	ToolCalls []ToolStep
+	// Duration covers the LLM call and the tool calls.
+	Duration time.Duration
+	// Usage is the token usage of the LLM call, estimated when the provider reports none.
+	Usage llms.ChatUsage
+	// Error is why the run ended at this step: the LLM call failed, the run was stopped (the
+	// context error) or a tool call couldn't be made. Nil otherwise.
+	Error error
+}
+
+// ToolStep is one tool call of a [Step].
+type ToolStep struct {
+	Name string
+	// Args is the JSON arguments as sent by the model.
+	Args string
+	// Result is the tool output, or the failure or refusal message sent to the model instead.
+	Result string
+}
+
+// RunDetailed is like Run and also returns the steps of the run. On failure (including
+// exceeding the maximum iterations) the result is still returned with the steps completed so
+// far, alongside the error.
+func (a *Agent) RunDetailed(message string) (*RunResult, error) {
+	answer, err := a.run(message, nil)
+	result := &RunResult{
+		FinalAnswer: answer,
+		Steps:       append([]Step(nil), a.steps...),
+		Iterations:  len(a.steps),
+		Metadata:    a.GetMetadata(),
+	}
+	return result, err
+}
+
+// recordStep records the iteration that started at start, whose reply is reply and whose tool
+// messages start at a.messages[toolStart]. err is the failure ending the run, if any.
+func (a *Agent) recordStep(iteration int, start time.Time, reply llms.ChatCompletionMessage, usage llms.ChatUsage, toolStart int, err error) {
+	step := Step{
+		Iteration: iteration,
+		LLMOutput: reply.Content,
+		Duration:  time.Since(start),
+		Usage:     usage,
+		Error:     err,
+	}
+	results := make(map[string]string)
+	for _, msg := range a.messages[min(toolStart, len(a.messages)):] {
+		if msg.Role == llms.ChatMessageRoleTool {
+			results[msg.ToolCallID] = msg.Content
+		}
+	}
+	// report full outputs rather than the truncated ones sent to the model
+	for _, out := range a.truncatedTools {
+		if _, ok := results[out.ToolCallID]; ok {
+			results[out.ToolCallID] = out.Output
+		}
+	}
+	for _, tc := range reply.ToolCalls {
+		step.ToolCalls = append(step.ToolCalls, ToolStep{Name: tc.Name, Args: tc.Arguments, Result: results[tc.ID]})
+	}
+	a.steps = append(a.steps, step)
+}
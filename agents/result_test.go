@@ -0,0 +1,138 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/mcp"
+)
+
+func TestRunDetailedSteps(t *testing.T) {
+	llm := llms.NewFakeModelWithMessages([]llms.ChatCompletionMessage{
+		toolCallReply("call_1", "search", `{"q":"go"}`),
+		{Content: "Go is a language"},
+	})
+	agent := CreateReactAgent(context.Background(), llm,
+		WithTools([]mcp.Tool{&fakeTool{name: "search", result: "found"}}),
+	)
+
+	result, err := agent.RunDetailed("what is go?")
+	if err != nil {
+		t.Fatalf("RunDetailed: %v", err)
+	}
+	if result.FinalAnswer != "Go is a language" || result.Iterations != 2 || len(result.Steps) != 2 {
+		t.Fatalf("result = %+v, want the answer after 2 steps", result)
+	}
+	want := ToolStep{Name: "search", Args: `{"q":"go"}`, Result: "found"}
+	if calls := result.Steps[0].ToolCalls; len(calls) != 1 || calls[0] != want {
+		t.Errorf("first step tool calls = %+v, want %+v", calls, want)
+	}
+	if last := result.Steps[1]; last.LLMOutput != "Go is a language" || last.Error != nil {
+		t.Errorf("last step = %+v", last)
+	}
+}
+
+func TestRunDetailedMaxIterations(t *testing.T) {
+	llm := llms.NewFakeModelWithMessages([]llms.ChatCompletionMessage{
+		toolCallReply("call_1", "search", `{}`),
+		toolCallReply("call_2", "search", `{}`),
+		toolCallReply("call_3", "search", `{}`),
+	})
+	agent := CreateReactAgent(context.Background(), llm,
+		WithTools([]mcp.Tool{&fakeTool{name: "search", result: "found"}}),
+		WithMaxIterations(3),
+	)
+
+	result, err := agent.RunDetailed("loop")
+	if err == nil || !strings.Contains(err.Error(), "max iterations (3) exceeded") {
+		t.Fatalf("error = %v, want max iterations exceeded", err)
+	}
+	if result.Iterations != 3 || len(result.Steps) != 3 {
+		t.Fatalf("got %d steps, want 3", len(result.Steps))
+	}
+	for i, step := range result.Steps {
+		if step.Iteration != i+1 || len(step.ToolCalls) != 1 || step.ToolCalls[0].Result != "found" || step.Error != nil {
+			t.Errorf("step %d = %+v", i, step)
+		}
+	}
+}
+
+func TestRunDetailedFailedStep(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name    string
+		replies []llms.ChatCompletionMessage
+		fail    int
+		want    error
+		wantMsg string
+	}{
+		{"LLM failure", []llms.ChatCompletionMessage{toolCallReply("call_1", "search", `{}`)}, 2, boom, "failed to get LLM response"},
+		{"empty tool name", []llms.ChatCompletionMessage{toolCallReply("call_1", "search", `{}`), toolCallReply("call_2", "", `{}`)}, 0, nil, "tool call has empty function name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := llms.NewFakeModelWithMessages(tt.replies)
+			if tt.fail > 0 {
+				llm.FailOnCall(tt.fail, boom)
+			}
+			agent := CreateReactAgent(context.Background(), llm,
+				WithTools([]mcp.Tool{&fakeTool{name: "search", result: "found"}}),
+			)
+
+			result, err := agent.RunDetailed("go")
+			if err == nil {
+				t.Fatal("RunDetailed succeeded")
+			}
+			if len(result.Steps) != 2 {
+				t.Fatalf("got %d steps, want 2", len(result.Steps))
+			}
+			first, last := result.Steps[0], result.Steps[1]
+			if first.Error != nil || len(first.ToolCalls) != 1 {
+				t.Errorf("first step = %+v, want a successful tool call", first)
+			}
+			if last.Error == nil || last.Error.Error() != err.Error() || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("last step error = %v, run error = %v, want %q", last.Error, err, tt.wantMsg)
+			}
+			if tt.want != nil && !errors.Is(last.Error, tt.want) {
+				t.Errorf("last step error = %v, want %v", last.Error, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDetailedCancelled(t *testing.T) {
+	t.Run("during the LLM call", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		llm := slowChatLLM{llm: llms.NewFakeModel([]string{"late"}), delay: time.Second}
+		agent := CreateReactAgent(ctx, llm)
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		result, err := agent.RunDetailed("hi")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("error = %v, want context.Canceled", err)
+		}
+		if len(result.Steps) != 1 || !errors.Is(result.Steps[0].Error, context.Canceled) {
+			t.Errorf("steps = %+v, want one step with the cancellation", result.Steps)
+		}
+	})
+	t.Run("before the LLM call", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		llm := llms.NewFakeModel([]string{"unused"})
+
+		result, err := CreateReactAgent(ctx, llm).RunDetailed("hi")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("error = %v, want context.Canceled", err)
+		}
+		if result.Iterations != 1 || !errors.Is(result.Steps[0].Error, context.Canceled) {
+			t.Errorf("steps = %+v, want one step with the cancellation", result.Steps)
+		}
+		if llm.CallCount() != 0 {
+			t.Errorf("LLM called %d times, want 0", llm.CallCount())
+		}
+	})
+}
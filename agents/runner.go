@@ -11,17 +11,28 @@ import (
 // Run processes a user message and returns the agent's response.
 // It handles tool calling iteratively until a final answer is reached or max iterations are exceeded.
 func (a *Agent) Run(message string) (string, error) {
+	result, err := a.RunDetailed(message)
+	return result.FinalAnswer, err
+}
+
+// run prepares the turn like Run, scoped by opts when non-nil, and runs it.
+func (a *Agent) run(message string, opts *RunOpts) (string, error) {
+	// Cancel any previous run/stream still active first: with WithSerializedTurns its turn
+	// must end before this one starts.
+	a.Stop()
+
+	defer a.lockTurn()()
+
+	if err := a.scope(opts); err != nil {
+		return "", err
+	}
+	defer a.scope(nil)
+
 	a.ResetTokenUsage()
 	a.ResetDuration()
 
 	a.LoadMessages(message)
 
-	// Cancel any previous run/stream if still active
-	if a.cancel != nil {
-		a.cancel()
-		a.cancel = nil
-	}
-
 	// Create a cancellable context so that Stop() can interrupt this run.
 	ctx, cancel := context.WithCancel(a.ctx)
 	a.cancel = cancel
@@ -37,16 +48,39 @@ func (a *Agent) Run(message string) (string, error) {
 
 // RunWithContext processes a user message with a custom context and returns the agent's response.
 func (a *Agent) RunWithContext(ctx context.Context, message string) (string, error) {
+	return a.runUserMessage(ctx, llms.ChatCompletionMessage{
+		Role:    llms.ChatMessageRoleUser,
+		Content: message,
+	})
+}
+
+// runUserMessage appends userMsg and runs the tool-calling loop until a final answer.
+func (a *Agent) runUserMessage(ctx context.Context, userMsg llms.ChatCompletionMessage) (answer string, err error) {
+	a.notify(func(cb Callbacks) { cb.OnRunStart(ctx, a.conversationID, userMsg.Content) })
+	defer func() {
+		if err != nil {
+			a.notify(func(cb Callbacks) { cb.OnError(ctx, err) })
+		} else {
+			a.notify(func(cb Callbacks) { cb.OnFinalAnswer(ctx, answer) })
+		}
+	}()
+
+	if a.startupErr != nil {
+		return "", a.startupErr
+	}
+
 	a.StartTime = time.Now()
+	a.iterationTimings = nil
+	a.truncatedTools = nil
+	a.steps = nil
+	a.lastReasoning = ""
+	a.resetContextLimit()
 	defer func() {
 		a.EndTime = time.Now()
 		a.Duration = a.EndTime.Sub(a.StartTime)
+		a.reportUsage()
 	}()
 
-	userMsg := llms.ChatCompletionMessage{
-		Role:    llms.ChatMessageRoleUser,
-		Content: message,
-	}
 	a.messages = append(a.messages, userMsg)
 
 	defer func() {
@@ -82,8 +116,8 @@ func (a *Agent) RunWithContext(ctx context.Context, message string) (string, err
 
 		if a.mem != nil && a.conversationID != "" {
 			// user message already saved to memory in handleStreamResponse
-			if err := a.mem.SaveMessages(a.ctx, a.conversationID, a.messages[a.historyMessageIndex:]); err != nil {
-				fmt.Println("Error saving messages to memory:", err)
+			if err := a.mem.SaveMessages(a.ctx, a.conversationID, a.turnMessages()); err != nil {
+				a.log().Warn("failed to save messages to memory", "conversation_id", a.conversationID, "error", err)
 			}
 		}
 	}()
@@ -95,40 +129,86 @@ func (a *Agent) RunWithContext(ctx context.Context, message string) (string, err
 		// If the context has been cancelled (via Stop or parent ctx),
 		// abort early.
 		if err := ctx.Err(); err != nil {
+			a.recordStep(iterations, time.Now(), llms.ChatCompletionMessage{}, llms.ChatUsage{}, len(a.messages), err)
 			return "", err
 		}
 
-		resp, err := a.completeLLMTurn(ctx)
+		iterationStart := time.Now()
+		resp, err := a.completeLLMTurn(ctx, iterations)
+		llmDuration := time.Since(iterationStart)
 		if err != nil {
-			return "", fmt.Errorf("failed to get LLM response: %w", err)
+			err = fmt.Errorf("failed to get LLM response: %w", err)
+			a.recordIteration(iterations, iterationStart, llmDuration, nil)
+			a.recordStep(iterations, iterationStart, llms.ChatCompletionMessage{}, llms.ChatUsage{}, len(a.messages), err)
+			return "", err
 		}
 
 		if len(resp.Choices) == 0 {
-			return "", fmt.Errorf("no response from LLM")
+			err := fmt.Errorf("no response from LLM")
+			a.recordIteration(iterations, iterationStart, llmDuration, nil)
+			a.recordStep(iterations, iterationStart, llms.ChatCompletionMessage{}, resp.Usage, len(a.messages), err)
+			return "", err
 		}
 
 		assistantMsg := resp.Choices[0].Message
+		a.notify(func(cb Callbacks) { cb.OnLLMEnd(ctx, assistantMsg, resp.Usage) })
+		usage := a.recordUsage(resp.Usage, a.messages, assistantMsg)
+		a.captureReasoning(assistantMsg.ReasoningContent)
 		a.messages = append(a.messages, assistantMsg)
 
-		a.CalculateCompletionTokenUsage(resp.Usage)
-
 		if len(assistantMsg.ToolCalls) > 0 {
-			if err := a.executeNativeToolCalls(ctx, nil, assistantMsg.ToolCalls); err != nil {
+			toolStart := len(a.messages)
+			err := a.executeNativeToolCalls(ctx, nil, assistantMsg.ToolCalls)
+			a.recordIteration(iterations, iterationStart, llmDuration, assistantMsg.ToolCalls)
+			a.recordStep(iterations, iterationStart, assistantMsg, usage, toolStart, err)
+			if err != nil {
 				return "", err
 			}
 			continue
 		}
 
+		a.recordIteration(iterations, iterationStart, llmDuration, nil)
+		a.recordStep(iterations, iterationStart, assistantMsg, usage, len(a.messages), nil)
 		return assistantMsg.Content, nil
 	}
 
 	return "", fmt.Errorf("max iterations (%d) exceeded", a.maxIter)
 }
 
-// completeLLMTurn uses OpenAI native tools when the LLM is [*llms.OpenAIModel] and MCP tools are configured.
-func (a *Agent) completeLLMTurn(ctx context.Context) (llms.ChatCompletionResponse, error) {
-	if om, ok := a.llm.(*llms.OpenAIModel); ok && len(a.tools) > 0 {
-		return om.ChatWithTools(ctx, a.messages, OpenAICompletionTools(a.tools))
+// completeLLMTurn uses native tools when the LLM implements [llms.ToolCaller] and MCP tools are configured.
+func (a *Agent) completeLLMTurn(ctx context.Context, iteration int) (llms.ChatCompletionResponse, error) {
+	opts := a.callOptions(iteration)
+	messages, err := a.promptMessages(ctx)
+	if err != nil {
+		return llms.ChatCompletionResponse{}, err
+	}
+	a.notify(func(cb Callbacks) { cb.OnLLMStart(ctx, messages) })
+	if tools := a.activeTools(); len(tools) > 0 {
+		if tc, ok := a.llm.(llms.ToolCaller); ok {
+			return tc.ChatWithTools(ctx, messages, OpenAICompletionTools(tools), opts...)
+		}
+	}
+	return a.llm.Chat(ctx, messages, opts...)
+}
+
+// callOptions returns the per-call options for iteration: WithDeterministic sampling, the
+// WithStopWords stop sequences, the WithGrammar grammar, then anything returned by the WithChatOptions hook, which may override them.
+func (a *Agent) callOptions(iteration int) []llms.ChatOption {
+	var opts []llms.ChatOption
+	if a.deterministic {
+		opts = append(opts, llms.WithCallTemperature(0), llms.WithCallSeed(DeterministicSeed))
+	}
+	if len(a.stopWords) > 0 {
+		opts = append(opts, llms.WithCallStop(a.stopWords))
+	}
+	if a.grammar != "" {
+		opts = append(opts, llms.WithCallGrammar(a.grammar))
+	}
+	if a.jsonMode {
+		opts = append(opts, llms.WithCallJSONMode())
+	}
+	if a.chatOptions != nil {
+		opts = append(opts, a.chatOptions(iteration)...)
 	}
-	return a.llm.Chat(ctx, a.messages)
+	return opts
 }
@@ -0,0 +1,118 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/mcp"
+	"github.com/MrLeeang/langchain-go/memory"
+)
+
+// failingSaveMemory is a BufferMemory whose saves fail.
+type failingSaveMemory struct {
+	*memory.BufferMemory
+}
+
+func (m failingSaveMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	return errors.New("disk full")
+}
+
+// A turn whose messages can't be saved still answers and logs a warning with the conversation.
+func TestRunLogsFailedSave(t *testing.T) {
+	logs := &captureHandler{}
+	agent := CreateReactAgent(context.Background(), llms.NewFakeModel([]string{"hello"}),
+		WithMemory(failingSaveMemory{memory.NewBufferMemory()}),
+		WithConversationID("c1"),
+		WithLogger(slog.New(logs)),
+	)
+
+	answer, err := agent.Run("hi")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if answer != "hello" {
+		t.Errorf("answer = %q, want %q", answer, "hello")
+	}
+	const warning = "failed to save messages to memory"
+	if n := logs.count(slog.LevelWarn, warning); n == 0 {
+		t.Fatal("no warning logged for the failed save")
+	}
+	if id := logs.attr(slog.LevelWarn, warning, "conversation_id"); id != "c1" {
+		t.Errorf("warning conversation_id = %q, want c1", id)
+	}
+}
+
+// WithStopWords reaches every LLM call of a run, in Run and Stream.
+func TestStopWordsCallOptions(t *testing.T) {
+	stop := []string{"\nObservation:"}
+	newAgent := func(opts ...AgentOption) (*Agent, *llms.FakeModel) {
+		llm := llms.NewFakeModelWithMessages([]llms.ChatCompletionMessage{
+			toolCallReply("call_1", "search", `{}`),
+			{Content: "done"},
+		})
+		opts = append(opts, WithTools([]mcp.Tool{&fakeTool{name: "search", result: "found"}}))
+		return CreateReactAgent(context.Background(), llm, opts...), llm
+	}
+
+	agent, llm := newAgent(WithStopWords(stop))
+	if _, err := agent.Run("go"); err != nil {
+		t.Fatal(err)
+	}
+	agent, streamed := newAgent(WithStopWords(stop))
+	if _, responses := collectStream(t, agent.Stream("go"), 2*time.Second); lastResponse(t, responses).Error != nil {
+		t.Fatal(lastResponse(t, responses).Error)
+	}
+	for _, fake := range []*llms.FakeModel{llm, streamed} {
+		opts := fake.Options()
+		if len(opts) != 2 {
+			t.Fatalf("LLM called %d times, want 2", len(opts))
+		}
+		for i, o := range opts {
+			if !slices.Equal(o.Stop, stop) {
+				t.Errorf("call %d Stop = %q, want %q", i, o.Stop, stop)
+			}
+		}
+	}
+
+	agent, llm = newAgent()
+	if _, err := agent.Run("go"); err != nil {
+		t.Fatal(err)
+	}
+	if o := llm.Options()[0]; o.Stop != nil {
+		t.Errorf("Stop = %q without WithStopWords, want none", o.Stop)
+	}
+}
+
+// The stop words replace the Stop configured on an OpenAI model in the request body.
+func TestStopWordsRequestBody(t *testing.T) {
+	var stops []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		stops = append(stops, body["stop"])
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+	llm := llms.NewOpenAIModel(llms.Config{BaseURL: srv.URL, Model: "gpt-test", Stop: []string{"END"}})
+
+	for _, opts := range [][]AgentOption{nil, {WithStopWords([]string{"\nObservation:", "STOP"})}} {
+		if _, err := CreateReactAgent(context.Background(), llm, opts...).Run("hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fmt.Sprint(stops); got != "[[END] [\nObservation: STOP]]" {
+		t.Errorf("request stop fields = %q, want the configured one, then the stop words", got)
+	}
+}
@@ -0,0 +1,94 @@
+package agents
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/MrLeeang/langchain-go/mcp"
+)
+
+// RunOpts scopes a single RunWithOptions or StreamWithOptions call.
+type RunOpts struct {
+	// AllowedTools limits the run to these tools, within those the agent allows (see
+	// WithAllowedTools). Empty means no further limit.
+	AllowedTools []string
+
+	// DeniedTools hides these tools for the run, in addition to WithDeniedTools.
+	DeniedTools []string
+
+	// PromptVars overrides variables of the WithPromptTemplate template for the run. A
+	// rendering error, or PromptVars without a template, is returned without running.
+	PromptVars map[string]any
+}
+
+// RunWithOptions is like Run with the tools scoped by opts for this run only, e.g. to let an
+// agent configured with many MCP tools use just a few of them for a request.
+//
+// Example:
+//
+//	answer, err := agent.RunWithOptions("Summarize the open issues", agents.RunOpts{
+//	    AllowedTools: []string{"github_list_issues", "github_get_issue"},
+//	})
+func (a *Agent) RunWithOptions(message string, opts RunOpts) (string, error) {
+	return a.run(message, &opts)
+}
+
+// StreamWithOptions is like Stream with the tools scoped by opts for this stream only (see
+// RunWithOptions). A prompt rendering error is sent as the only response.
+func (a *Agent) StreamWithOptions(message string, opts RunOpts) <-chan StreamResponse {
+	return a.startStream(message, &opts)
+}
+
+// scope applies opts to the turn about to start; scope(nil) clears them once it ends. Both
+// are called while holding the turn lock.
+func (a *Agent) scope(opts *RunOpts) error {
+	a.runOpts, a.runPrompt = nil, ""
+	if opts == nil {
+		return nil
+	}
+	if len(opts.PromptVars) > 0 {
+		if a.promptTemplate == nil {
+			return errors.New("RunOpts.PromptVars requires a prompt template (see WithPromptTemplate)")
+		}
+		prompt, err := a.renderPrompt(opts.PromptVars)
+		if err != nil {
+			return err
+		}
+		a.runPrompt = prompt
+	}
+	a.runOpts = opts
+	return nil
+}
+
+// activeTools returns the tools the model may call in the current run.
+func (a *Agent) activeTools() []mcp.Tool {
+	if len(a.allowedTools) == 0 && len(a.deniedTools) == 0 && a.runOpts == nil {
+		return a.tools
+	}
+	tools := make([]mcp.Tool, 0, len(a.tools))
+	for _, t := range a.tools {
+		if a.toolAllowed(t.Name()) {
+			tools = append(tools, t)
+		}
+	}
+	return tools
+}
+
+// toolAllowed reports whether the tool may be called in the current run.
+func (a *Agent) toolAllowed(name string) bool {
+	if len(a.allowedTools) > 0 && !slices.Contains(a.allowedTools, name) {
+		return false
+	}
+	if slices.Contains(a.deniedTools, name) {
+		return false
+	}
+	if a.runOpts != nil {
+		if len(a.runOpts.AllowedTools) > 0 && !slices.Contains(a.runOpts.AllowedTools, name) {
+			return false
+		}
+		if slices.Contains(a.runOpts.DeniedTools, name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package agents
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/mcp"
+)
+
+func scopeTools() (search, deploy *fakeTool, tools []mcp.Tool) {
+	search = &fakeTool{name: "search", result: "found"}
+	deploy = &fakeTool{name: "deploy", result: "deployed"}
+	return search, deploy, []mcp.Tool{search, deploy}
+}
+
+func TestRunWithOptionsExcludesTools(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RunOpts
+		want []string
+	}{
+		{"allowed", RunOpts{AllowedTools: []string{"search"}}, []string{"search"}},
+		{"denied", RunOpts{DeniedTools: []string{"deploy"}}, []string{"search"}},
+		{"none", RunOpts{}, []string{"search", "deploy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, tools := scopeTools()
+			llm := &toolCallerLLM{FakeModel: llms.NewFakeModel([]string{"scoped", "unscoped"})}
+			agent := CreateReactAgent(context.Background(), llm, WithTools(tools))
+
+			if _, err := agent.RunWithOptions("hi", tt.opts); err != nil {
+				t.Fatalf("RunWithOptions: %v", err)
+			}
+			if _, err := agent.Run("again"); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			offered := llm.Offered()
+			if !slices.Equal(offered[0], tt.want) {
+				t.Errorf("scoped run offered %v, want %v", offered[0], tt.want)
+			}
+			// the scope ends with the run
+			if !slices.Equal(offered[1], []string{"search", "deploy"}) {
+				t.Errorf("next run offered %v, want every tool", offered[1])
+			}
+		})
+	}
+}
+
+func TestStreamWithOptionsExcludesTools(t *testing.T) {
+	_, _, tools := scopeTools()
+	llm := &toolCallerLLM{FakeModel: llms.NewFakeModel([]string{"scoped"})}
+	agent := CreateReactAgent(context.Background(), llm, WithTools(tools), WithDeniedTools([]string{"search"}))
+
+	_, responses := collectStream(t, agent.StreamWithOptions("hi", RunOpts{AllowedTools: []string{"search", "deploy"}}), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+	// WithDeniedTools still applies within the scope
+	if got := llm.Offered()[0]; !slices.Equal(got, []string{"deploy"}) {
+		t.Errorf("offered %v, want [deploy]", got)
+	}
+	if agent.runOpts != nil {
+		t.Error("scope still set after the stream ended")
+	}
+}
+
+// A call to a tool outside the scope is refused with a tool message instead of running it.
+func TestRunWithOptionsRefusesExcludedTool(t *testing.T) {
+	replies := func() []llms.ChatCompletionMessage {
+		return []llms.ChatCompletionMessage{
+			toolCallReply("call_1", "deploy", `{}`),
+			{Content: "I can't deploy"},
+		}
+	}
+	opts := RunOpts{AllowedTools: []string{"search"}}
+
+	check := func(t *testing.T, llm *toolCallerLLM, deploy *fakeTool) {
+		t.Helper()
+		if deploy.callCount() != 0 {
+			t.Errorf("excluded tool called %d times", deploy.callCount())
+		}
+		got := toolMessage(t, llm.FakeModel)
+		if !strings.Contains(got, "tool deploy is not available") {
+			t.Errorf("tool message = %q, want a refusal", got)
+		}
+	}
+
+	t.Run("Run", func(t *testing.T) {
+		_, deploy, tools := scopeTools()
+		llm := &toolCallerLLM{FakeModel: llms.NewFakeModelWithMessages(replies())}
+		agent := CreateReactAgent(context.Background(), llm, WithTools(tools))
+		answer, err := agent.RunWithOptions("deploy it", opts)
+		if err != nil || answer != "I can't deploy" {
+			t.Fatalf("RunWithOptions = %q, %v", answer, err)
+		}
+		check(t, llm, deploy)
+	})
+	t.Run("Stream", func(t *testing.T) {
+		_, deploy, tools := scopeTools()
+		llm := &toolCallerLLM{FakeModel: llms.NewFakeModelWithMessages(replies())}
+		agent := CreateReactAgent(context.Background(), llm, WithTools(tools))
+		text, responses := collectStream(t, agent.StreamWithOptions("deploy it", opts), 2*time.Second)
+		if last := lastResponse(t, responses); last.Error != nil || text != "I can't deploy" {
+			t.Fatalf("stream = %q, %v", text, last.Error)
+		}
+		check(t, llm, deploy)
+	})
+}
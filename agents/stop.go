@@ -1,5 +1,7 @@
 package agents
 
+import "github.com/MrLeeang/langchain-go/memory"
+
 // Stop cancels the current running task (Run or Stream) if any.
 // It is safe to call multiple times; subsequent calls are no-ops.
 // This is intended to be called from another goroutine while
@@ -13,3 +15,17 @@ func (a *Agent) Stop() {
 		a.cancel = nil
 	}
 }
+
+// Close stops the current task and releases the agent's resources: the memory is closed when
+// it implements [memory.Closer] (MilvusMemory, RedisMemory, SQLiteMemory, ...). MCP tools open
+// a connection per call, so there are none to close. The agent must not be used afterwards.
+func (a *Agent) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.Stop()
+	if c, ok := a.mem.(memory.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
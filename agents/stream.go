@@ -7,6 +7,7 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/MrLeeang/langchain-go/llms"
@@ -17,9 +18,36 @@ type streamToolCallBuffer struct {
 	id, typ, name, args string
 }
 
+// StreamEvent identifies what a [StreamResponse] reports, so consumers can render tool
+// activity without parsing Content.
+type StreamEvent string
+
+const (
+	// EventToken carries a chunk of assistant text in Content.
+	EventToken StreamEvent = "token"
+	// EventReasoning carries a chunk of reasoning in ReasoningContent.
+	EventReasoning StreamEvent = "reasoning"
+	// EventToolCallStarted reports a tool about to run, with ToolName and ToolArgs.
+	EventToolCallStarted StreamEvent = "tool_call_started"
+	// EventToolResult reports the output of a tool in ToolResult.
+	EventToolResult StreamEvent = "tool_result"
+	// EventFinalAnswer carries the whole final answer in Answer; its text was already
+	// streamed as EventToken chunks.
+	EventFinalAnswer StreamEvent = "final_answer"
+	// EventReconnect is sent with Reconnect (see WithStreamRetry).
+	EventReconnect StreamEvent = "reconnect"
+	// EventDone ends a successful stream.
+	EventDone StreamEvent = "done"
+	// EventError ends a failed stream; Error is set.
+	EventError StreamEvent = "error"
+)
+
 // StreamResponse represents a single chunk of streamed content from the agent.
 type StreamResponse struct {
 
+	// Event tells which of the fields below are set.
+	Event StreamEvent
+
 	// ReasoningContent is the reasoning content in this chunk.
 	ReasoningContent string
 
@@ -32,9 +60,28 @@ type StreamResponse struct {
 	// ToolCallResult is the result of the tool call in this chunk.
 	ToolCallResult *callToolResult
 
+	// ToolName and ToolArgs are set on EventToolCallStarted and EventToolResult.
+	ToolName string
+	ToolArgs map[string]interface{}
+
+	// ToolResult is the tool output on EventToolResult, or the failure message when the tool
+	// failed.
+	ToolResult string
+
+	// Answer is the final answer on EventFinalAnswer.
+	Answer string
+
 	// Done indicates whether the stream is complete.
 	Done bool
 
+	// Usage is the accumulated token usage of the run, set on the final (Done) response.
+	Usage *llms.ChatUsage
+
+	// Reconnect reports that the current LLM turn failed mid-stream and is being re-issued
+	// (see WithStreamRetry). Content and reasoning received since the turn started should be
+	// discarded; the retried turn streams them again.
+	Reconnect bool
+
 	// Error contains any error that occurred during streaming.
 	Error error
 }
@@ -44,57 +91,88 @@ type StreamResponse struct {
 // arguments concatenated across chunks); when finish_reason is tool_calls, the stream round
 // ends early, tools execute, then the outer loop continues for the model's next reply.
 //
+// The last response has Done set (with Error on failure) and the channel is closed once the
+// turn is saved to memory, so consumers simply range until it closes. To stop early, call Stop
+// (or cancel the context given to StreamWithContext); the consumer may then stop receiving:
+// pending sends are dropped and the turn is still saved and the channel closed. A consumer
+// that stops receiving without cancelling blocks the stream.
+//
 // Example:
 //
-//	ch := agent.Stream("What's the weather like?")
-//	for resp := range ch {
+//	for resp := range agent.Stream("What's the weather like?") {
 //	    if resp.Error != nil {
 //	        log.Printf("Error: %v", resp.Error)
-//	        break
 //	    }
 //	    fmt.Print(resp.Content)
-//	    if resp.Done {
-//	        break
-//	    }
 //	}
 func (a *Agent) Stream(message string) <-chan StreamResponse {
+	return a.startStream(message, nil)
+}
+
+// startStream prepares the turn like Stream, scoped by opts when non-nil, and streams it.
+func (a *Agent) startStream(message string, opts *RunOpts) <-chan StreamResponse {
+	// Cancel any previous run/stream still active first: with WithSerializedTurns its turn
+	// must end before this one starts.
+	a.Stop()
+
+	unlock := a.lockTurn()
+	if err := a.scope(opts); err != nil {
+		unlock()
+		return a.failedStream(a.ctx, message, err)
+	}
+	endTurn := func() {
+		a.scope(nil)
+		unlock()
+	}
+
 	a.ResetTokenUsage()
 	a.ResetDuration()
 
 	a.LoadMessages(message)
 
-	// Cancel any previous run/stream if still active
-	if a.cancel != nil {
-		a.cancel()
-		a.cancel = nil
-	}
-
 	// Create a cancellable context so that Stop() can interrupt this stream.
 	ctx, cancel := context.WithCancel(a.ctx)
 	a.cancel = cancel
 
-	return a.StreamWithContext(ctx, message)
+	return a.stream(ctx, message, endTurn)
 }
 
 // StreamWithContext processes a user message with a custom context and returns a channel that streams the response.
 func (a *Agent) StreamWithContext(ctx context.Context, message string) <-chan StreamResponse {
+	return a.stream(ctx, message, func() {})
+}
+
+// stream runs the turn in a goroutine and calls endTurn once its messages are saved.
+func (a *Agent) stream(ctx context.Context, message string, endTurn func()) <-chan StreamResponse {
+	if a.startupErr != nil {
+		endTurn()
+		return a.failedStream(ctx, message, a.startupErr)
+	}
+
 	ch := make(chan StreamResponse, 10)
 
 	go func() {
 		a.StartTime = time.Now()
+		a.EndTime = time.Time{}
+		a.iterationTimings = nil
+		a.truncatedTools = nil
+		a.lastReasoning = ""
+		a.resetContextLimit()
 
 		defer func() {
-			a.EndTime = time.Now()
-			a.Duration = a.EndTime.Sub(a.StartTime)
-
-			time.Sleep(1 * time.Second)
+			if a.EndTime.IsZero() {
+				a.EndTime = time.Now()
+				a.Duration = a.EndTime.Sub(a.StartTime)
+			}
+			a.reportUsage()
 
 			if a.mem != nil && a.conversationID != "" {
 				// user message already saved to memory in handleStreamResponse
-				if err := a.mem.SaveMessages(a.ctx, a.conversationID, a.messages[a.historyMessageIndex:]); err != nil {
-					fmt.Println("Error saving messages to memory:", err)
+				if err := a.mem.SaveMessages(a.ctx, a.conversationID, a.turnMessages()); err != nil {
+					a.log().Warn("failed to save messages to memory", "conversation_id", a.conversationID, "error", err)
 				}
 			}
+			endTurn()
 
 			close(ch)
 
@@ -105,127 +183,58 @@ func (a *Agent) StreamWithContext(ctx context.Context, message string) <-chan St
 			Content: message,
 		}
 		a.messages = append(a.messages, userMsg)
+		a.notify(func(cb Callbacks) { cb.OnRunStart(ctx, a.conversationID, message) })
 
 		iterations := 0
 		for iterations < a.maxIter {
 			iterations++
-			finishReason := ""
 
 			if err := ctx.Err(); err != nil {
 
 				if err == context.Canceled {
-					ch <- StreamResponse{Done: true}
+					send(ctx, ch, a.stoppedResponse(ctx))
 					return
 				}
 
-				ch <- StreamResponse{Error: err, Done: true}
+				send(ctx, ch, a.doneResponse(ctx, err))
 				return
 			}
 
-			stream, err := a.chatStream(ctx)
+			iterationStart := time.Now()
+			turn, err := a.streamTurn(ctx, ch, iterations)
+			llmDuration := time.Since(iterationStart)
 			if err != nil {
-				ch <- StreamResponse{Error: fmt.Errorf("failed to create stream: %w", err), Done: true}
-				return
+				a.recordIteration(iterations, iterationStart, llmDuration, nil)
 			}
-
-			toolCallsBuffer := make(map[int]*streamToolCallBuffer)
-			var fullContent strings.Builder
-			var reasoningContent strings.Builder
-			for {
-				response, err := stream.Recv()
-				if errors.Is(err, io.EOF) {
-					break
-				}
-
-				if err == context.Canceled {
-					stream.Close()
-					ch <- StreamResponse{Done: true}
-					if fullContent.Len() > 0 {
-						assistantMsg := llms.ChatCompletionMessage{
-							Role:             llms.ChatMessageRoleAssistant,
-							Content:          fullContent.String(),
-							ReasoningContent: reasoningContent.String(),
-						}
-						a.messages = append(a.messages, assistantMsg)
+			if errors.Is(err, context.Canceled) {
+				send(ctx, ch, a.stoppedResponse(ctx))
+				if turn.content.Len() > 0 {
+					assistantMsg := llms.ChatCompletionMessage{
+						Role:             llms.ChatMessageRoleAssistant,
+						Content:          turn.content.String(),
+						ReasoningContent: turn.reasoning.String(),
 					}
-					return
-				}
-
-				if err != nil {
-					stream.Close()
-					ch <- StreamResponse{Error: fmt.Errorf("stream error: %w", err), Done: true}
-					return
-				}
-
-				if len(response.Choices) == 0 {
-					continue
-				}
-
-				ch0 := response.Choices[0]
-				delta := ch0.Delta
-				if ch0.FinishReason != "" {
-					finishReason = ch0.FinishReason
-				}
-
-				if delta.ReasoningContent != "" {
-					reasoningContent.WriteString(delta.ReasoningContent)
-					ch <- StreamResponse{ReasoningContent: delta.ReasoningContent}
-				}
-
-				if delta.Content != "" {
-					fullContent.WriteString(delta.Content)
-					ch <- StreamResponse{Content: delta.Content}
-				}
-
-				for _, tc := range delta.ToolCalls {
-					idx := tc.Index
-					buf, exists := toolCallsBuffer[idx]
-					if !exists {
-						buf = &streamToolCallBuffer{}
-						toolCallsBuffer[idx] = buf
-					}
-					if tc.ID != "" {
-						buf.id = tc.ID
-					}
-					if tc.Type != "" {
-						buf.typ = tc.Type
-					}
-					buf.name += tc.NameFragment
-					buf.args += tc.ArgumentsFragment
-
-					if buf.name != "" {
-						if a.debug {
-							ch <- StreamResponse{Content: fmt.Sprintf("\n[工具调用中: %s, 参数: %s]\n", buf.name, buf.args)}
-						}
-					}
-				}
-
-				if response.Usage != nil {
-					a.CalculateCompletionTokenUsage(*response.Usage)
-				}
-
-				if strings.EqualFold(ch0.FinishReason, "tool_calls") {
-					if a.debug {
-						fmt.Println("\n[模型请求调用工具，流结束]")
-					}
-					break
+					a.captureReasoning(assistantMsg.ReasoningContent)
+					a.messages = append(a.messages, assistantMsg)
 				}
+				return
 			}
-
-			stream.Close()
+			if err != nil {
+				send(ctx, ch, a.doneResponse(ctx, err))
+				return
+			}
+			finishReason := turn.finishReason
 
 			assistantMsg := llms.ChatCompletionMessage{
 				Role:             llms.ChatMessageRoleAssistant,
-				Content:          fullContent.String(),
-				ReasoningContent: reasoningContent.String(),
-				ToolCalls:        toolCallsSortedFromBuffer(toolCallsBuffer),
+				Content:          turn.content.String(),
+				ReasoningContent: turn.reasoning.String(),
+				ToolCalls:        toolCallsSortedFromBuffer(turn.toolCalls),
 			}
 
 			if strings.EqualFold(finishReason, "tool_calls") && len(assistantMsg.ToolCalls) == 0 {
-				ch <- StreamResponse{
-					Error: fmt.Errorf("model finished with tool_calls but no function name was accumulated from stream deltas"),
-					Done:  true,
-				}
+				a.recordIteration(iterations, iterationStart, llmDuration, nil)
+				send(ctx, ch, a.doneResponse(ctx, fmt.Errorf("model finished with tool_calls but no function name was accumulated from stream deltas")))
 				return
 			}
 
@@ -235,26 +244,252 @@ func (a *Agent) StreamWithContext(ctx context.Context, message string) <-chan St
 				fmt.Println("=============stream accumulated assistant============")
 			}
 
+			a.notify(func(cb Callbacks) { cb.OnLLMEnd(ctx, assistantMsg, turn.usage) })
+			if !turn.usageReported {
+				a.recordUsage(llms.ChatUsage{}, a.messages, assistantMsg)
+			}
+
+			a.captureReasoning(assistantMsg.ReasoningContent)
 			a.messages = append(a.messages, assistantMsg)
 
 			if len(assistantMsg.ToolCalls) > 0 {
-				if err := a.executeNativeToolCalls(ctx, ch, assistantMsg.ToolCalls); err != nil {
-					ch <- StreamResponse{Error: err, Done: true}
+				err := a.executeNativeToolCalls(ctx, ch, assistantMsg.ToolCalls)
+				a.recordIteration(iterations, iterationStart, llmDuration, assistantMsg.ToolCalls)
+				if errors.Is(err, context.Canceled) {
+					send(ctx, ch, a.stoppedResponse(ctx))
+					return
+				}
+				if err != nil {
+					send(ctx, ch, a.doneResponse(ctx, err))
 					return
 				}
 				continue
 			}
 
-			ch <- StreamResponse{Done: true}
+			a.recordIteration(iterations, iterationStart, llmDuration, nil)
+			a.notify(func(cb Callbacks) { cb.OnFinalAnswer(ctx, assistantMsg.Content) })
+			send(ctx, ch, StreamResponse{Event: EventFinalAnswer, Answer: assistantMsg.Content})
+			send(ctx, ch, a.doneResponse(ctx, nil))
 			return
 		}
 
-		ch <- StreamResponse{Error: fmt.Errorf("max iterations (%d) exceeded", a.maxIter), Done: true}
+		send(ctx, ch, a.doneResponse(ctx, fmt.Errorf("max iterations (%d) exceeded", a.maxIter)))
 	}()
 
 	return ch
 }
 
+// failedStream returns a stream reporting err, for a turn failing before it starts.
+func (a *Agent) failedStream(ctx context.Context, message string, err error) <-chan StreamResponse {
+	ch := make(chan StreamResponse, 1)
+	a.notify(func(cb Callbacks) { cb.OnRunStart(ctx, a.conversationID, message) })
+	ch <- a.doneResponse(ctx, err)
+	close(ch)
+	return ch
+}
+
+// streamTurn holds what one streamed LLM turn produced.
+type streamTurn struct {
+	content       strings.Builder
+	reasoning     strings.Builder
+	toolCalls     map[int]*streamToolCallBuffer
+	finishReason  string
+	usageReported bool
+	usage         llms.ChatUsage
+}
+
+// errStreamIdle is reported when no chunk arrives within the WithStreamIdleTimeout window.
+var errStreamIdle = errors.New("stream idle timeout")
+
+// streamTurn streams one LLM turn to ch. With WithStreamRetry, a transient failure (network
+// error, 408/429/5xx, idle timeout) discards the partial turn, sends a Reconnect event and
+// re-issues the request.
+func (a *Agent) streamTurn(ctx context.Context, ch chan<- StreamResponse, iteration int) (*streamTurn, error) {
+	for attempt := 0; ; attempt++ {
+		turn, err := a.streamOnce(ctx, ch, iteration)
+		if err == nil || attempt >= a.streamRetries || ctx.Err() != nil || !isTransientStreamError(err) {
+			return turn, err
+		}
+		if a.debug {
+			fmt.Printf("\n[stream failed, reconnecting (%d/%d)]: %v\n", attempt+1, a.streamRetries, err)
+		}
+		a.notify(func(cb Callbacks) { cb.OnError(ctx, err) })
+		if !send(ctx, ch, StreamResponse{Event: EventReconnect, Reconnect: true}) {
+			return turn, ctx.Err()
+		}
+	}
+}
+
+func isTransientStreamError(err error) bool {
+	return errors.Is(err, errStreamIdle) || errors.Is(err, io.ErrUnexpectedEOF) || llms.IsRetryable(err)
+}
+
+// streamOnce opens a stream for iteration and forwards its chunks to ch until the turn ends.
+func (a *Agent) streamOnce(ctx context.Context, ch chan<- StreamResponse, iteration int) (*streamTurn, error) {
+	turn := &streamTurn{toolCalls: make(map[int]*streamToolCallBuffer)}
+
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The idle timer only runs while waiting on the LLM: it is stopped while chunks are
+	// forwarded, so a slow consumer is never mistaken for a silent stream. It isn't armed for
+	// the Chat fallback, which receives nothing until the whole reply is ready.
+	var idle *time.Timer
+	var idleOnce sync.Once
+	idleFired := make(chan struct{})
+	if a.streamIdleTimeout > 0 && a.streamsNatively() {
+		idle = time.AfterFunc(a.streamIdleTimeout, func() {
+			idleOnce.Do(func() { close(idleFired) })
+			cancel()
+		})
+		defer idle.Stop()
+	}
+	wrapErr := func(err error) error {
+		select {
+		case <-idleFired:
+			return fmt.Errorf("stream error: %w", errStreamIdle)
+		default:
+			return err
+		}
+	}
+
+	stream, err := a.chatStream(attemptCtx, iteration)
+	if err != nil {
+		return turn, wrapErr(fmt.Errorf("failed to create stream: %w", err))
+	}
+	defer stream.Close()
+
+	for {
+		if idle != nil {
+			idle.Reset(a.streamIdleTimeout)
+		}
+		response, err := stream.Recv()
+		if idle != nil {
+			idle.Stop()
+		}
+		if errors.Is(err, io.EOF) {
+			return turn, nil
+		}
+		if err != nil {
+			if err = wrapErr(err); errors.Is(err, errStreamIdle) {
+				return turn, err
+			}
+			if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+				return turn, context.Canceled
+			}
+			return turn, fmt.Errorf("stream error: %w", err)
+		}
+
+		if response.Usage != nil {
+			turn.usageReported = true
+			turn.usage = *response.Usage
+			a.CalculateCompletionTokenUsage(*response.Usage)
+		}
+
+		if len(response.Choices) == 0 {
+			continue
+		}
+
+		ch0 := response.Choices[0]
+		delta := ch0.Delta
+		if ch0.FinishReason != "" {
+			turn.finishReason = ch0.FinishReason
+		}
+
+		if delta.ReasoningContent != "" {
+			turn.reasoning.WriteString(delta.ReasoningContent)
+			if !send(ctx, ch, StreamResponse{Event: EventReasoning, ReasoningContent: delta.ReasoningContent}) {
+				return turn, ctx.Err()
+			}
+		}
+
+		if delta.Content != "" {
+			turn.content.WriteString(delta.Content)
+			if !send(ctx, ch, StreamResponse{Event: EventToken, Content: delta.Content}) {
+				return turn, ctx.Err()
+			}
+		}
+
+		for _, tc := range delta.ToolCalls {
+			idx := tc.Index
+			buf, exists := turn.toolCalls[idx]
+			if !exists {
+				buf = &streamToolCallBuffer{}
+				turn.toolCalls[idx] = buf
+			}
+			if tc.ID != "" {
+				buf.id = tc.ID
+			}
+			if tc.Type != "" {
+				buf.typ = tc.Type
+			}
+			buf.name += tc.NameFragment
+			buf.args += tc.ArgumentsFragment
+
+			if buf.name != "" && a.legacyStreamFormat {
+				if !send(ctx, ch, StreamResponse{Event: EventToken, Content: fmt.Sprintf("\n[工具调用中: %s, 参数: %s]\n", buf.name, buf.args)}) {
+					return turn, ctx.Err()
+				}
+			}
+		}
+
+		if strings.EqualFold(ch0.FinishReason, "tool_calls") {
+			if a.debug {
+				fmt.Println("\n[模型请求调用工具，流结束]")
+			}
+			return turn, nil
+		}
+	}
+}
+
+// send delivers resp to ch unless ctx ends while the consumer isn't receiving, and reports
+// whether it was delivered. A free buffer slot is used even after ctx ended, so the final
+// response of a stopped stream still reaches a consumer that keeps reading.
+func send(ctx context.Context, ch chan<- StreamResponse, resp StreamResponse) bool {
+	select {
+	case ch <- resp:
+		return true
+	default:
+	}
+	select {
+	case ch <- resp:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// doneResponse builds the final stream response carrying err (if any) and the run's token usage,
+// reporting err to OnError. The run ends here, so EndTime and Duration are set before the
+// consumer receives it.
+func (a *Agent) doneResponse(ctx context.Context, err error) StreamResponse {
+	if !a.StartTime.IsZero() {
+		a.EndTime = time.Now()
+		a.Duration = a.EndTime.Sub(a.StartTime)
+	}
+	event := EventDone
+	if err != nil {
+		event = EventError
+		a.notify(func(cb Callbacks) { cb.OnError(ctx, err) })
+	}
+	return StreamResponse{
+		Event: event,
+		Error: err,
+		Done:  true,
+		Usage: &llms.ChatUsage{
+			PromptTokens:     a.PromptTokens,
+			CompletionTokens: a.CompletionTokens,
+			TotalTokens:      a.TotalTokens,
+		},
+	}
+}
+
+// stoppedResponse ends a stream stopped by Stop or its context: the consumer gets a plain Done,
+// while OnError gets context.Canceled as it does when Run is stopped.
+func (a *Agent) stoppedResponse(ctx context.Context) StreamResponse {
+	a.notify(func(cb Callbacks) { cb.OnError(ctx, context.Canceled) })
+	return a.doneResponse(ctx, nil)
+}
+
 func toolCallsSortedFromBuffer(m map[int]*streamToolCallBuffer) []llms.ChatToolCall {
 	if len(m) == 0 {
 		return nil
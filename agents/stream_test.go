@@ -0,0 +1,236 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/mcp"
+	"github.com/MrLeeang/langchain-go/memory"
+)
+
+func TestStreamIdleTimeoutRetries(t *testing.T) {
+	llm := &scriptedStreamLLM{turns: [][]scriptedChunk{
+		textTurn(time.Second, "never"),
+		textTurn(0, "hello ", "world"),
+	}}
+	agent := CreateReactAgent(context.Background(), llm,
+		WithStreamIdleTimeout(30*time.Millisecond),
+		WithStreamRetry(1),
+	)
+
+	text, responses := collectStream(t, agent.Stream("hi"), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+	if text != "hello world" {
+		t.Errorf("text = %q, want %q", text, "hello world")
+	}
+	reconnects := 0
+	for _, r := range responses {
+		if r.Event == EventReconnect {
+			reconnects++
+		}
+	}
+	if reconnects != 1 {
+		t.Errorf("got %d reconnect events, want 1", reconnects)
+	}
+}
+
+func TestStreamIdleTimeoutWithoutRetryFails(t *testing.T) {
+	llm := &scriptedStreamLLM{turns: [][]scriptedChunk{textTurn(time.Second, "never")}}
+	agent := CreateReactAgent(context.Background(), llm, WithStreamIdleTimeout(30*time.Millisecond))
+
+	_, responses := collectStream(t, agent.Stream("hi"), 2*time.Second)
+	last := lastResponse(t, responses)
+	if !errors.Is(last.Error, errStreamIdle) {
+		t.Fatalf("error = %v, want %v", last.Error, errStreamIdle)
+	}
+}
+
+// Every chunk re-arms the idle window, so a stream slower than the timeout in total but
+// with short gaps completes.
+func TestStreamIdleTimeoutRearmsOnChunks(t *testing.T) {
+	llm := &scriptedStreamLLM{turns: [][]scriptedChunk{
+		textTurn(20*time.Millisecond, "a", "b", "c", "d", "e", "f", "g", "h"),
+	}}
+	agent := CreateReactAgent(context.Background(), llm, WithStreamIdleTimeout(80*time.Millisecond))
+
+	text, responses := collectStream(t, agent.Stream("hi"), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+	if text != "abcdefgh" {
+		t.Errorf("text = %q, want %q", text, "abcdefgh")
+	}
+}
+
+// Time spent blocked on a slow consumer doesn't count as idle, and the timer firing is never
+// followed by a re-arm that would fire it again.
+func TestStreamIdleTimeoutSlowConsumer(t *testing.T) {
+	words := strings.Split(strings.Repeat("w ", 15), " ")[:15]
+	llm := &scriptedStreamLLM{turns: [][]scriptedChunk{textTurn(0, words...)}, ignoreCtx: true}
+	agent := CreateReactAgent(context.Background(), llm, WithStreamIdleTimeout(20*time.Millisecond))
+
+	var text strings.Builder
+	var last StreamResponse
+	for resp := range agent.Stream("hi") {
+		time.Sleep(30 * time.Millisecond)
+		text.WriteString(resp.Content)
+		last = resp
+	}
+	if last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+	if want := strings.Repeat("w", 15); text.String() != want {
+		t.Errorf("text = %q, want %q", text.String(), want)
+	}
+}
+
+func TestStreamShortAnswerClosesPromptly(t *testing.T) {
+	llm := llms.NewFakeStreamingModel([]string{"a short answer"}, 4)
+	agent := CreateReactAgent(context.Background(), llm)
+
+	start := time.Now()
+	text, responses := collectStream(t, agent.Stream("hi"), 2*time.Second)
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("stream took %v, want well under a second", elapsed)
+	}
+	if last := lastResponse(t, responses); last.Error != nil || last.Event != EventDone {
+		t.Fatalf("last response = %+v, want a successful Done", last)
+	}
+	if text != "a short answer" {
+		t.Errorf("text = %q, want %q", text, "a short answer")
+	}
+}
+
+// A consumer that breaks out early and cancels must not leave the stream goroutine blocked:
+// the turn is still saved and the channel closed.
+func TestStreamConsumerBreaksThenCancels(t *testing.T) {
+	llm := llms.NewFakeStreamingModel([]string{strings.Repeat("token ", 200)}, 1)
+	mem := memory.NewBufferMemory()
+	agent := CreateReactAgent(context.Background(), llm, WithMemory(mem), WithConversationID("c1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := agent.StreamWithContext(ctx, "hi")
+	for resp := range ch {
+		if resp.Content != "" {
+			break
+		}
+	}
+	cancel()
+
+	waitFor(t, 2*time.Second, func() bool {
+		msgs, _ := mem.LoadMessages(context.Background(), "c1")
+		return len(msgs) > 0
+	})
+	msgs, _ := mem.LoadMessages(context.Background(), "c1")
+	if msgs[0].Role != llms.ChatMessageRoleUser || msgs[0].Content != "hi" {
+		t.Errorf("first saved message = %+v, want the user message", msgs[0])
+	}
+	// the stream goroutine is gone: draining the buffered responses reaches the close
+	collectStream(t, ch, time.Second)
+}
+
+func TestStreamStopWithAbandonedConsumer(t *testing.T) {
+	llm := llms.NewFakeStreamingModel([]string{strings.Repeat("token ", 200)}, 1)
+	mem := memory.NewBufferMemory()
+	agent := CreateReactAgent(context.Background(), llm, WithMemory(mem), WithConversationID("c1"))
+
+	ch := agent.Stream("hi")
+	<-ch
+	agent.Stop()
+
+	waitFor(t, 2*time.Second, func() bool {
+		msgs, _ := mem.LoadMessages(context.Background(), "c1")
+		return len(msgs) > 0
+	})
+	collectStream(t, ch, time.Second)
+}
+
+// LLMs without ChatStreamer are streamed through one Chat call per turn: the answer arrives in
+// several chunks and tool calls run as in the streaming path.
+func TestStreamChatFallback(t *testing.T) {
+	answer := "the weather in Paris is sunny and warm today"
+	llm := llms.NewFakeModelWithMessages([]llms.ChatCompletionMessage{
+		toolCallReply("call_1", "weather", `{"city":"Paris"}`),
+		{Content: answer},
+	})
+	tool := &fakeTool{name: "weather", result: "sunny"}
+	agent := CreateReactAgent(context.Background(), chatOnlyLLM{llm}, WithTools([]mcp.Tool{tool}))
+
+	text, responses := collectStream(t, agent.Stream("weather in Paris?"), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+	if text != answer {
+		t.Errorf("text = %q, want %q", text, answer)
+	}
+	if tool.callCount() != 1 {
+		t.Errorf("tool called %d times, want 1", tool.callCount())
+	}
+
+	var tokens int
+	var events []StreamEvent
+	for _, r := range responses {
+		if r.Event == EventToken {
+			tokens++
+			continue
+		}
+		events = append(events, r.Event)
+	}
+	if tokens < 2 {
+		t.Errorf("answer streamed in %d chunks, want several", tokens)
+	}
+	want := []StreamEvent{EventToolCallStarted, EventToolResult, EventFinalAnswer, EventDone}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	for _, r := range responses {
+		if r.Event == EventToolResult && (r.ToolName != "weather" || r.ToolResult != "sunny" || r.ToolArgs["city"] != "Paris") {
+			t.Errorf("tool result = %+v", r)
+		}
+	}
+}
+
+// Middleware around a Chat-only model keeps the Chat fallback; around a streamer it keeps streaming.
+func TestStreamThroughMiddleware(t *testing.T) {
+	mws := []llms.Middleware{llms.CacheMiddleware(llms.NewLRUCache(10)), llms.RetryMiddleware(3)}
+	tests := []struct {
+		name string
+		llm  llms.LLM
+	}{
+		{"chat only", chatOnlyLLM{llms.NewFakeModel([]string{"the answer from chat"})}},
+		{"streamer", llms.NewFakeStreamingModel([]string{"the answer from chat"}, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := CreateReactAgent(context.Background(), llms.Chain(tt.llm, mws...))
+			text, responses := collectStream(t, agent.Stream("hi"), 2*time.Second)
+			if last := lastResponse(t, responses); last.Error != nil {
+				t.Fatalf("stream failed: %v", last.Error)
+			}
+			if text != "the answer from chat" {
+				t.Errorf("text = %q", text)
+			}
+		})
+	}
+}
+
+// The idle timeout doesn't apply to a Chat fallback taking longer than it.
+func TestStreamChatFallbackIgnoresIdleTimeout(t *testing.T) {
+	llm := slowChatLLM{llm: llms.NewFakeModel([]string{"done thinking"}), delay: 100 * time.Millisecond}
+	agent := CreateReactAgent(context.Background(), llm, WithStreamIdleTimeout(20*time.Millisecond))
+
+	text, responses := collectStream(t, agent.Stream("hi"), 2*time.Second)
+	if last := lastResponse(t, responses); last.Error != nil {
+		t.Fatalf("stream failed: %v", last.Error)
+	}
+	if text != "done thinking" {
+		t.Errorf("text = %q, want %q", text, "done thinking")
+	}
+}
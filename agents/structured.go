@@ -0,0 +1,76 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RunInto runs message and unmarshals the final answer (a JSON document) into out.
+//
+// Every call of the run carries [llms.WithCallJSONMode], so OpenAI-compatible models without a
+// configured response format answer in JSON mode (response_format=json_object), also when
+// wrapped by middleware; other models ignore it. OpenAI requires the word "JSON" to appear in
+// the conversation in that mode, so mention it in the prompt or message.
+// If the answer cannot be decoded into out, the model is re-prompted once with the
+// decoding error before giving up.
+//
+// Example:
+//
+//	var weather struct {
+//	    City string  `json:"city"`
+//	    Temp float64 `json:"temp"`
+//	}
+//	err := agent.RunInto("Return the weather in Paris as JSON with city and temp.", &weather)
+func (a *Agent) RunInto(message string, out any) error {
+	a.jsonMode = true
+	defer func() { a.jsonMode = false }()
+
+	answer, err := a.Run(message)
+	if err != nil {
+		return err
+	}
+
+	decodeErr := json.Unmarshal([]byte(trimJSONFence(answer)), out)
+	if decodeErr == nil {
+		return nil
+	}
+
+	// The first exchange has already been saved to memory; only persist the retry.
+	a.historyMessageIndex = len(a.messages)
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancel = cancel
+	defer func() {
+		if a.cancel != nil {
+			a.cancel()
+			a.cancel = nil
+		}
+	}()
+
+	retry := fmt.Sprintf("Your previous answer could not be parsed as JSON: %v. Reply again with only the corrected JSON document.", decodeErr)
+	answer, err = a.RunWithContext(ctx, retry)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(trimJSONFence(answer)), out); err != nil {
+		return fmt.Errorf("failed to decode JSON answer: %w", err)
+	}
+	return nil
+}
+
+// trimJSONFence strips surrounding whitespace and a Markdown ```json fence if present.
+func trimJSONFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,68 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+type weather struct {
+	City string  `json:"city"`
+	Temp float64 `json:"temp"`
+}
+
+func TestRunInto(t *testing.T) {
+	llm := llms.NewFakeModel([]string{"```json\n{\"city\":\"Paris\",\"temp\":21.5}\n```", "hello"})
+	agent := CreateReactAgent(context.Background(), llms.Chain(llm, llms.RetryMiddleware(1)))
+
+	var got weather
+	if err := agent.RunInto("weather in Paris as JSON", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (weather{"Paris", 21.5}) {
+		t.Errorf("RunInto decoded %+v", got)
+	}
+	if llm.CallCount() != 1 || !llm.Options()[0].JSONMode {
+		t.Errorf("calls %d with options %+v, want one call in JSON mode", llm.CallCount(), llm.Options())
+	}
+
+	// JSON mode only lasts for the run
+	if _, err := agent.Run("hi"); err != nil {
+		t.Fatal(err)
+	}
+	if llm.Options()[1].JSONMode {
+		t.Error("JSON mode still requested after RunInto")
+	}
+}
+
+// An answer that isn't valid JSON is re-prompted once with the decoding error.
+func TestRunIntoRetriesOnce(t *testing.T) {
+	llm := llms.NewFakeModel([]string{"The weather is nice.", `{"city":"Paris","temp":20}`})
+	agent := CreateReactAgent(context.Background(), llm)
+
+	var got weather
+	if err := agent.RunInto("weather in Paris as JSON", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.City != "Paris" || llm.CallCount() != 2 {
+		t.Fatalf("RunInto decoded %+v after %d calls, want Paris after 2", got, llm.CallCount())
+	}
+	retry := llm.Calls()[1]
+	if last := retry[len(retry)-1]; last.Role != llms.ChatMessageRoleUser || !strings.Contains(last.Content, "could not be parsed as JSON") {
+		t.Errorf("retry message = %+v, want the decoding error", last)
+	}
+	if !llm.Options()[1].JSONMode {
+		t.Error("retry not in JSON mode")
+	}
+
+	llm = llms.NewFakeModel([]string{"not json", "still not json", `{"city":"late"}`})
+	agent = CreateReactAgent(context.Background(), llm)
+	if err := agent.RunInto("weather as JSON", &got); err == nil || !strings.Contains(err.Error(), "failed to decode JSON answer") {
+		t.Errorf("RunInto = %v, want a decoding error after one retry", err)
+	}
+	if llm.CallCount() != 2 {
+		t.Errorf("LLM called %d times, want 2", llm.CallCount())
+	}
+}
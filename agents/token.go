@@ -1,6 +1,8 @@
 package agents
 
 import (
+	"sync"
+
 	"github.com/MrLeeang/langchain-go/llms"
 	"github.com/pkoukk/tiktoken-go"
 )
@@ -44,14 +46,120 @@ func (a *Agent) CalculateCompletionTokenUsage(usage llms.ChatUsage) {
 	a.TotalTokens += usage.TotalTokens
 }
 
-func CountTokens(text string) int {
+// recordUsage adds the usage of one LLM round trip. With [TokenCountingAuto], a response
+// without provider-reported usage is estimated from prompt and reply with tiktoken.
+func (a *Agent) recordUsage(usage llms.ChatUsage, prompt []llms.ChatCompletionMessage, reply llms.ChatCompletionMessage) llms.ChatUsage {
+	if usage == (llms.ChatUsage{}) && a.tokenCounting == TokenCountingAuto {
+		usage = a.estimateUsage(prompt, reply)
+	}
+	a.CalculateCompletionTokenUsage(usage)
+	return usage
+}
+
+// estimateUsage approximates token usage with the agent's tokenizer when the provider reports none.
+func (a *Agent) estimateUsage(prompt []llms.ChatCompletionMessage, reply llms.ChatCompletionMessage) llms.ChatUsage {
+	counter := a.getTokenCounter()
+	promptTokens := 0
+	for _, msg := range prompt {
+		promptTokens += counter.countMessage(msg)
+	}
+	completionTokens := counter.countMessage(reply)
+	return llms.ChatUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// getTokenCounter returns the agent's cached TokenCounter, choosing the encoding from the
+// LLM's model name when it implements [llms.ModelNamer].
+func (a *Agent) getTokenCounter() *TokenCounter {
+	if a.tokenCounter == nil {
+		a.tokenCounter = TokenCounterForModel(a.modelName())
+	}
+	return a.tokenCounter
+}
+
+// modelName returns the LLM's model name, or "" when it does not implement [llms.ModelNamer].
+func (a *Agent) modelName() string {
+	name, _ := llms.DescribeModel(a.llm)
+	return name
+}
+
+// EstimatedCost returns the price of the recorded token usage according to the model registry,
+// or 0 when the model is not registered (see [llms.RegisterModel]).
+func (a *Agent) EstimatedCost() float64 {
+	info, ok := llms.LookupModel(a.modelName())
+	if !ok {
+		return 0
+	}
+	return info.Cost(a.PromptTokens, a.CompletionTokens)
+}
+
+// reportUsage sends the recorded token usage of the run to the WithUsageCollector collector.
+func (a *Agent) reportUsage() {
+	if a.usageCollector == nil || a.TotalTokens == 0 {
+		return
+	}
+	usage := llms.ChatUsage{
+		PromptTokens:     a.PromptTokens,
+		CompletionTokens: a.CompletionTokens,
+		TotalTokens:      a.TotalTokens,
+	}
+	if r, ok := a.usageCollector.(llms.RunUsageRecorder); ok {
+		r.RecordRun(a.conversationID, a.modelName(), usage)
+		return
+	}
+	a.usageCollector.Record(a.modelName(), usage)
+}
 
-	enc, err := tiktoken.GetEncoding("cl100k_base")
-	if err != nil {
+// TokenCounter counts tokens with a fixed tiktoken encoding.
+type TokenCounter struct {
+	enc *tiktoken.Tiktoken
+}
+
+// NewTokenCounter returns a TokenCounter using cl100k_base.
+func NewTokenCounter() *TokenCounter {
+	enc, _ := tiktoken.GetEncoding(tiktoken.MODEL_CL100K_BASE)
+	return &TokenCounter{enc: enc}
+}
+
+// TokenCounterForModel returns a TokenCounter using the encoding from the model registry, or
+// the one tiktoken associates with model (e.g. o200k_base for gpt-4o), falling back to
+// cl100k_base for unknown models.
+func TokenCounterForModel(model string) *TokenCounter {
+	if info, ok := llms.LookupModel(model); ok && info.Encoding != "" {
+		if enc, err := tiktoken.GetEncoding(info.Encoding); err == nil {
+			return &TokenCounter{enc: enc}
+		}
+	}
+	if model != "" {
+		if enc, err := tiktoken.EncodingForModel(model); err == nil {
+			return &TokenCounter{enc: enc}
+		}
+	}
+	return NewTokenCounter()
+}
+
+// Count returns the number of tokens in text.
+func (c *TokenCounter) Count(text string) int {
+	if c == nil || c.enc == nil {
 		return 1000 // if encoding fails, return a large number to be safe
 	}
+	return len(c.enc.Encode(text, nil, nil))
+}
+
+func (c *TokenCounter) countMessage(msg llms.ChatCompletionMessage) int {
+	n := c.Count(msg.Content)
+	for _, tc := range msg.ToolCalls {
+		n += c.Count(tc.Name) + c.Count(tc.Arguments)
+	}
+	return n
+}
 
-	tokens := enc.Encode(text, nil, nil)
+var defaultTokenCounter = sync.OnceValue(NewTokenCounter)
 
-	return len(tokens)
+// CountTokens counts tokens in text with cl100k_base.
+func CountTokens(text string) int {
+	return defaultTokenCounter().Count(text)
 }
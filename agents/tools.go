@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/MrLeeang/langchain-go/llms"
 	"github.com/MrLeeang/langchain-go/mcp"
@@ -12,17 +15,43 @@ import (
 	"github.com/openai/openai-go/v3/shared"
 )
 
-// chatStream starts a chat completion stream with optional native tools when the LLM is [*llms.OpenAIModel].
-func (a *Agent) chatStream(ctx context.Context) (*llms.ChatCompletionStream, error) {
-	om, ok := a.llm.(*llms.OpenAIModel)
+// chatStream starts a chat completion stream with optional native tools when the LLM implements [llms.ToolCaller].
+// Other LLMs are streamed with [llms.ChatStreamer] when they implement it; tools are not sent to
+// them. LLMs implementing neither get a single Chat call whose response is replayed as a
+// stream, so Stream works with any LLM.
+func (a *Agent) chatStream(ctx context.Context, iteration int) (llms.ChatStream, error) {
+	opts := a.callOptions(iteration)
+	messages, err := a.promptMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.notify(func(cb Callbacks) { cb.OnLLMStart(ctx, messages) })
+	tc, ok := a.llm.(llms.ToolCaller)
 	if !ok {
-		return nil, fmt.Errorf("streaming requires *llms.OpenAIModel")
+		if streamer, ok := a.llm.(llms.ChatStreamer); ok {
+			return streamer.ChatStream(ctx, messages, opts...)
+		}
+		resp, err := a.llm.Chat(ctx, messages, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return llms.StreamFromResponse(resp), nil
 	}
 	var toolParams []openai.ChatCompletionToolUnionParam
-	if len(a.tools) > 0 {
-		toolParams = OpenAICompletionTools(a.tools)
+	if tools := a.activeTools(); len(tools) > 0 {
+		toolParams = OpenAICompletionTools(tools)
 	}
-	return om.ChatStreamWithTools(ctx, a.messages, toolParams)
+	return tc.ChatStreamWithTools(ctx, messages, toolParams, opts...)
+}
+
+// streamsNatively reports whether chatStream streams from the LLM rather than replaying a
+// Chat response.
+func (a *Agent) streamsNatively() bool {
+	switch a.llm.(type) {
+	case llms.ToolCaller, llms.ChatStreamer:
+		return true
+	}
+	return false
 }
 
 // OpenAICompletionTools builds OpenAI Chat Completions `tools` from MCP tools (function definitions).
@@ -138,14 +167,22 @@ func (a *Agent) executeNativeToolCalls(ctx context.Context, ch chan<- StreamResp
 		if strings.TrimSpace(tc.Name) == "" {
 			return fmt.Errorf("tool call has empty function name (tool_call_id=%q)", tc.ID)
 		}
+		// Permanent errors are not retried; the model is told about them instead of failing the run.
 		tool := a.findTool(tc.Name)
 		if tool == nil {
-			return fmt.Errorf("tool not found: %s", tc.Name)
+			a.appendToolObservation(tc, "tool not found: "+tc.Name+"; use one of the provided tools or answer directly")
+			continue
+		}
+		if !a.toolAllowed(tc.Name) {
+			// the model wasn't offered the tool
+			a.appendToolObservation(tc, "tool "+tc.Name+" is not available for this request; use one of the provided tools or answer directly")
+			continue
 		}
 		var args map[string]interface{}
 		if tc.Arguments != "" && tc.Arguments != "null" {
 			if err := json.Unmarshal([]byte(tc.Arguments), &args); err != nil {
-				return fmt.Errorf("invalid tool arguments JSON for %s: %w", tc.Name, err)
+				a.appendToolObservation(tc, "invalid tool arguments JSON for "+tc.Name+": "+err.Error()+"; call it again with a JSON object matching its schema")
+				continue
 			}
 		}
 		if args == nil {
@@ -155,48 +192,115 @@ func (a *Agent) executeNativeToolCalls(ctx context.Context, ch chan<- StreamResp
 		if ch != nil {
 			// send json message to channel
 			newCallTool := newCallTool(tc.Name, args)
-			if a.debug {
-				ch <- StreamResponse{Content: "\n"}
-				ch <- StreamResponse{Content: newCallTool.String()}
-				ch <- StreamResponse{Content: "\n"}
+			if a.legacyStreamFormat {
+				if !send(ctx, ch, StreamResponse{Event: EventToken, Content: "\n" + newCallTool.String() + "\n"}) {
+					return ctx.Err()
+				}
 			}
 
-			ch <- StreamResponse{ToolCall: newCallTool}
+			if !send(ctx, ch, StreamResponse{Event: EventToolCallStarted, ToolCall: newCallTool, ToolName: tc.Name, ToolArgs: args}) {
+				return ctx.Err()
+			}
 		}
 
 		callToolResult := newCallToolResult(tc.Name, args)
 
-		result, err := tool.Call(ctx, args)
+		a.notify(func(cb Callbacks) { cb.OnToolStart(ctx, tc.Name, args) })
+		result, err := a.callTool(ctx, tool, args)
+		a.notify(func(cb Callbacks) { cb.OnToolEnd(ctx, tc.Name, result, err) })
 		if err != nil {
 			result = "tool call failed for " + tc.Name + ": " + err.Error()
 			callToolResult.Error = true
 			callToolResult.Message = result
-		} else {
-			// runes := []rune(result)
-
-			// if len(runes) > 1000 && tc.Name != "read_file" {
-			// 	// truncate result to 1000 characters to avoid overwhelming the model with too much tool output, which can lead to context window issues and degraded performance. The full result is still included in the tool_result message sent to the channel and added to the agent's messages, so the model can access it if needed.
-			// 	result = string(runes[:1000]) + "...(truncated)"
-			// }
 		}
 
 		if ch != nil {
 			// send json message to channel
 			callToolResult.Result = result
-			if a.debug {
-				ch <- StreamResponse{Content: "\n"}
-				ch <- StreamResponse{Content: callToolResult.String()}
-				ch <- StreamResponse{Content: "\n"}
+			if a.legacyStreamFormat {
+				if !send(ctx, ch, StreamResponse{Event: EventToken, Content: "\n" + callToolResult.String() + "\n"}) {
+					return ctx.Err()
+				}
 			}
 
-			ch <- StreamResponse{ToolCallResult: callToolResult}
+			if !send(ctx, ch, StreamResponse{Event: EventToolResult, ToolCallResult: callToolResult, ToolName: tc.Name, ToolArgs: args, ToolResult: result}) {
+				return ctx.Err()
+			}
 		}
 
 		a.messages = append(a.messages, llms.ChatCompletionMessage{
 			Role:       llms.ChatMessageRoleTool,
 			ToolCallID: tc.ID,
-			Content:    result,
+			Content:    a.truncateToolOutput(tc, result),
 		})
 	}
 	return nil
 }
+
+// appendToolObservation answers tc with content without calling the tool.
+func (a *Agent) appendToolObservation(tc llms.ChatToolCall, content string) {
+	a.messages = append(a.messages, llms.ChatCompletionMessage{
+		Role:       llms.ChatMessageRoleTool,
+		ToolCallID: tc.ID,
+		Content:    content,
+	})
+}
+
+// maxToolRetryBackoff caps the wait between tool call attempts, unless the WithToolRetry
+// backoff is longer.
+const maxToolRetryBackoff = 10 * time.Second
+
+// callTool calls tool, retrying a failed call as set by WithToolRetry (see toolRetryWait).
+// Unknown tools and invalid arguments are rejected before this and never retried.
+func (a *Agent) callTool(ctx context.Context, tool mcp.Tool, args map[string]interface{}) (string, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := tool.Call(ctx, args)
+		if err == nil || attempt >= a.toolRetryAttempts || ctx.Err() != nil {
+			return result, err
+		}
+		if a.debug {
+			fmt.Printf("\n[tool %s failed, retrying (%d/%d)]: %v\n", tool.Name(), attempt, a.toolRetryAttempts-1, err)
+		}
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(toolRetryWait(a.toolRetryBackoff, attempt)):
+		}
+	}
+}
+
+// toolRetryWait returns the wait after failed attempt n (1-based): backoff doubled after each
+// failure, capped at maxToolRetryBackoff.
+func toolRetryWait(backoff time.Duration, n int) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+	limit := max(backoff, maxToolRetryBackoff)
+	if n > 32 {
+		return limit
+	}
+	wait := backoff << (n - 1)
+	if wait <= 0 || wait > limit {
+		return limit
+	}
+	return wait
+}
+
+// ToolOutput is the full output of a tool call that was truncated before being sent to the
+// model (see WithUseToolDataLength).
+type ToolOutput struct {
+	Tool       string `json:"tool"`
+	ToolCallID string `json:"tool_call_id"`
+	Output     string `json:"output"`
+}
+
+// truncateToolOutput cuts result to the WithUseToolDataLength limit, keeping the full
+// output in the run metadata when it does.
+func (a *Agent) truncateToolOutput(tc llms.ChatToolCall, result string) string {
+	if a.toolDataLength <= 0 || slices.Contains(a.untruncatedTools, tc.Name) || utf8.RuneCountInString(result) <= a.toolDataLength {
+		return result
+	}
+	a.truncatedTools = append(a.truncatedTools, ToolOutput{Tool: tc.Name, ToolCallID: tc.ID, Output: result})
+	runes := []rune(result)
+	return fmt.Sprintf("%s...[truncated, %dKB total]", string(runes[:a.toolDataLength]), (len(result)+1023)/1024)
+}
@@ -0,0 +1,281 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/mcp"
+	"github.com/MrLeeang/langchain-go/memory"
+)
+
+func TestToolRetryWait(t *testing.T) {
+	tests := []struct {
+		backoff time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{0, 1, 0},
+		{100 * time.Millisecond, 1, 100 * time.Millisecond},
+		{100 * time.Millisecond, 2, 200 * time.Millisecond},
+		{100 * time.Millisecond, 4, 800 * time.Millisecond},
+		{100 * time.Millisecond, 8, maxToolRetryBackoff},
+		{100 * time.Millisecond, 100, maxToolRetryBackoff},
+		{time.Minute, 1, time.Minute},
+		{time.Minute, 3, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := toolRetryWait(tt.backoff, tt.attempt); got != tt.want {
+			t.Errorf("toolRetryWait(%v, %d) = %v, want %v", tt.backoff, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// toolReplies scripts a tool call followed by a final answer.
+func toolReplies() []llms.ChatCompletionMessage {
+	return []llms.ChatCompletionMessage{
+		toolCallReply("call_1", "flaky", `{}`),
+		{Content: "done"},
+	}
+}
+
+// toolMessage returns the content of the tool message sent back to the model.
+func toolMessage(t *testing.T, llm *llms.FakeModel) string {
+	t.Helper()
+	calls := llm.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("LLM called %d times, want 2", len(calls))
+	}
+	for _, msg := range calls[1] {
+		if msg.Role == llms.ChatMessageRoleTool {
+			return msg.Content
+		}
+	}
+	t.Fatal("no tool message sent to the model")
+	return ""
+}
+
+func TestToolRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		wantCalls  int
+		wantResult string
+	}{
+		{"succeeds after retries", 2, 3, "ok"},
+		{"fails every attempt", 5, 3, "tool call failed for flaky: flaky failed (call 3)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"/Run", func(t *testing.T) {
+			tool := &fakeTool{name: "flaky", result: "ok", failures: tt.failures}
+			llm := llms.NewFakeModelWithMessages(toolReplies())
+			agent := CreateReactAgent(context.Background(), llm,
+				WithTools([]mcp.Tool{tool}),
+				WithToolRetry(3, time.Millisecond),
+			)
+			if _, err := agent.Run("go"); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if tool.callCount() != tt.wantCalls {
+				t.Errorf("tool called %d times, want %d", tool.callCount(), tt.wantCalls)
+			}
+			if got := toolMessage(t, llm); got != tt.wantResult {
+				t.Errorf("tool message = %q, want %q", got, tt.wantResult)
+			}
+		})
+		t.Run(tt.name+"/Stream", func(t *testing.T) {
+			tool := &fakeTool{name: "flaky", result: "ok", failures: tt.failures}
+			llm := llms.NewFakeModelWithMessages(toolReplies())
+			agent := CreateReactAgent(context.Background(), llm,
+				WithTools([]mcp.Tool{tool}),
+				WithToolRetry(3, time.Millisecond),
+			)
+			_, responses := collectStream(t, agent.Stream("go"), 2*time.Second)
+			if last := lastResponse(t, responses); last.Error != nil {
+				t.Fatalf("stream failed: %v", last.Error)
+			}
+			if tool.callCount() != tt.wantCalls {
+				t.Errorf("tool called %d times, want %d", tool.callCount(), tt.wantCalls)
+			}
+			if got := toolMessage(t, llm); got != tt.wantResult {
+				t.Errorf("tool message = %q, want %q", got, tt.wantResult)
+			}
+			for _, r := range responses {
+				if r.Event == EventToolResult && r.ToolResult != tt.wantResult {
+					t.Errorf("tool result event = %q, want %q", r.ToolResult, tt.wantResult)
+				}
+			}
+		})
+	}
+}
+
+// Without WithToolRetry a failing tool is called once.
+func TestToolRetryDefaultSingleAttempt(t *testing.T) {
+	tool := &fakeTool{name: "flaky", result: "ok", failures: 1}
+	llm := llms.NewFakeModelWithMessages(toolReplies())
+	agent := CreateReactAgent(context.Background(), llm, WithTools([]mcp.Tool{tool}))
+	if _, err := agent.Run("go"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if tool.callCount() != 1 {
+		t.Errorf("tool called %d times, want 1", tool.callCount())
+	}
+	if got := toolMessage(t, llm); !strings.HasPrefix(got, "tool call failed for flaky") {
+		t.Errorf("tool message = %q, want the failure", got)
+	}
+}
+
+// Unknown tools and invalid arguments are permanent errors: they are reported to the model
+// without retrying and without failing the run.
+func TestToolPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name, tool, args, want string
+	}{
+		{"tool not found", "missing", `{}`, "tool not found: missing"},
+		{"invalid arguments", "flaky", `{"city":`, "invalid tool arguments JSON for flaky"},
+	}
+	for _, tt := range tests {
+		replies := func() []llms.ChatCompletionMessage {
+			return []llms.ChatCompletionMessage{toolCallReply("call_1", tt.tool, tt.args), {Content: "done"}}
+		}
+		check := func(t *testing.T, tool *fakeTool, llm *llms.FakeModel) {
+			t.Helper()
+			if tool.callCount() != 0 {
+				t.Errorf("tool called %d times, want 0", tool.callCount())
+			}
+			var msg llms.ChatCompletionMessage
+			for _, m := range llm.Calls()[len(llm.Calls())-1] {
+				if m.Role == llms.ChatMessageRoleTool {
+					msg = m
+				}
+			}
+			if msg.ToolCallID != "call_1" || !strings.HasPrefix(msg.Content, tt.want) {
+				t.Errorf("tool message = %+v, want %q for call_1", msg, tt.want)
+			}
+		}
+		t.Run(tt.name+"/Run", func(t *testing.T) {
+			tool := &fakeTool{name: "flaky", result: "ok"}
+			llm := llms.NewFakeModelWithMessages(replies())
+			agent := CreateReactAgent(context.Background(), llm,
+				WithTools([]mcp.Tool{tool}),
+				WithToolRetry(3, time.Millisecond),
+			)
+			if answer, err := agent.Run("go"); err != nil || answer != "done" {
+				t.Fatalf("Run = %q, %v", answer, err)
+			}
+			check(t, tool, llm)
+		})
+		t.Run(tt.name+"/Stream", func(t *testing.T) {
+			tool := &fakeTool{name: "flaky", result: "ok"}
+			llm := llms.NewFakeModelWithMessages(replies())
+			agent := CreateReactAgent(context.Background(), llm,
+				WithTools([]mcp.Tool{tool}),
+				WithToolRetry(3, time.Millisecond),
+			)
+			text, responses := collectStream(t, agent.Stream("go"), 2*time.Second)
+			if last := lastResponse(t, responses); last.Error != nil || text != "done" {
+				t.Fatalf("stream = %q, %v", text, last.Error)
+			}
+			check(t, tool, llm)
+		})
+	}
+}
+
+// With WithCleanMemory, the default, a tool-calling turn is saved as a note of its calls and
+// the answer text; turned off, the calls and results are saved as sent to the model.
+func TestCleanMemory(t *testing.T) {
+	replies := func() []llms.ChatCompletionMessage {
+		return []llms.ChatCompletionMessage{
+			{Content: "let me look", ToolCalls: []llms.ChatToolCall{{ID: "call_1", Name: "flaky", Arguments: `{"city":"Paris"}`}}},
+			{Content: "sunny", ReasoningContent: "the tool said ok"},
+		}
+	}
+	tests := []struct {
+		name string
+		opts []AgentOption
+		want []llms.ChatCompletionMessage
+	}{
+		{"default", nil, []llms.ChatCompletionMessage{
+			{Role: llms.ChatMessageRoleUser, Content: "weather?"},
+			{Role: llms.ChatMessageRoleAssistant, Content: `called tool flaky with args {"city":"Paris"}`},
+			{Role: llms.ChatMessageRoleAssistant, Content: "sunny"},
+		}},
+		{"disabled", []AgentOption{WithCleanMemory(false)}, []llms.ChatCompletionMessage{
+			{Role: llms.ChatMessageRoleUser, Content: "weather?"},
+			{Content: "let me look", ToolCalls: []llms.ChatToolCall{{ID: "call_1", Name: "flaky", Arguments: `{"city":"Paris"}`}}},
+			{Role: llms.ChatMessageRoleTool, ToolCallID: "call_1", Content: "ok"},
+			{Content: "sunny"},
+		}},
+	}
+	for _, tt := range tests {
+		for _, stream := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/stream=%v", tt.name, stream), func(t *testing.T) {
+				mem := memory.NewBufferMemory()
+				agent := CreateReactAgent(context.Background(), llms.NewFakeModelWithMessages(replies()), append([]AgentOption{
+					WithTools([]mcp.Tool{&fakeTool{name: "flaky", result: "ok"}}),
+					WithMemory(mem),
+					WithConversationID("c1"),
+				}, tt.opts...)...)
+				if stream {
+					_, responses := collectStream(t, agent.Stream("weather?"), 2*time.Second)
+					if last := lastResponse(t, responses); last.Error != nil {
+						t.Fatalf("stream failed: %v", last.Error)
+					}
+				} else if _, err := agent.Run("weather?"); err != nil {
+					t.Fatal(err)
+				}
+
+				stored, err := mem.LoadMessages(context.Background(), "c1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(stored) != len(tt.want) {
+					t.Fatalf("stored %+v, want %+v", stored, tt.want)
+				}
+				for i, msg := range stored {
+					want := tt.want[i]
+					if msg.Content != want.Content || msg.ToolCallID != want.ToolCallID || len(msg.ToolCalls) != len(want.ToolCalls) || msg.ReasoningContent != "" {
+						t.Errorf("stored message %d = %+v, want %+v", i, msg, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestToolOutputTruncation(t *testing.T) {
+	long := strings.Repeat("é", 5000)
+	tests := []struct {
+		name          string
+		tool          string
+		opts          []AgentOption
+		want          string
+		wantTruncated bool
+	}{
+		{"off by default", "search", nil, long, false},
+		{"truncated", "search", []AgentOption{WithUseToolDataLength(10)}, strings.Repeat("é", 10) + "...[truncated, 10KB total]", true},
+		{"exempt tool", "read_file", []AgentOption{WithUseToolDataLength(10), WithUntruncatedTools([]string{"read_file"})}, long, false},
+		{"other tool exempt", "search", []AgentOption{WithUseToolDataLength(10), WithUntruncatedTools([]string{"read_file"})}, strings.Repeat("é", 10) + "...[truncated, 10KB total]", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := llms.NewFakeModelWithMessages([]llms.ChatCompletionMessage{toolCallReply("call_1", tt.tool, `{}`), {Content: "done"}})
+			agent := CreateReactAgent(context.Background(), llm, append([]AgentOption{
+				WithTools([]mcp.Tool{&fakeTool{name: tt.tool, result: long}}),
+			}, tt.opts...)...)
+			if _, err := agent.Run("go"); err != nil {
+				t.Fatal(err)
+			}
+			if got := toolMessage(t, llm); got != tt.want {
+				t.Errorf("tool message has %d runes, want %d", len([]rune(got)), len([]rune(tt.want)))
+			}
+			truncated := agent.GetMetadata().TruncatedToolOutputs
+			if (len(truncated) == 1) != tt.wantTruncated || (tt.wantTruncated && truncated[0].Output != long) {
+				t.Errorf("TruncatedToolOutputs = %d entries, want the full output only when truncated", len(truncated))
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package agents
+
+import "sync"
+
+// turnLocks serializes the turns of agents sharing a conversation ID (see
+// WithSerializedTurns). Entries are dropped when no turn holds or waits for them.
+var turnLocks = struct {
+	sync.Mutex
+	m map[string]*turnLock
+}{m: make(map[string]*turnLock)}
+
+type turnLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockTurn waits until no other serialized turn runs on the agent's conversation and returns
+// the function ending the turn. Without WithSerializedTurns it returns immediately.
+func (a *Agent) lockTurn() func() {
+	if !a.serializedTurns || a.mem == nil || a.conversationID == "" {
+		return func() {}
+	}
+	id := a.conversationID
+	turnLocks.Lock()
+	l, ok := turnLocks.m[id]
+	if !ok {
+		l = &turnLock{}
+		turnLocks.m[id] = l
+	}
+	l.refs++
+	turnLocks.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		turnLocks.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(turnLocks.m, id)
+		}
+		turnLocks.Unlock()
+	}
+}
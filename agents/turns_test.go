@@ -0,0 +1,114 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/MrLeeang/langchain-go/memory"
+)
+
+// A second stream on the same agent cancels the first one, whose consumer went away, instead
+// of waiting forever for its turn to end.
+func TestSerializedTurnsSameAgentAbandonedConsumer(t *testing.T) {
+	llm := llms.NewFakeStreamingModel([]string{strings.Repeat("token ", 200), "second answer"}, 1)
+	agent := CreateReactAgent(context.Background(), llm,
+		WithMemory(memory.NewBufferMemory()),
+		WithConversationID("c1"),
+		WithSerializedTurns(true),
+	)
+
+	first := agent.Stream("first")
+	<-first
+
+	done := make(chan string)
+	go func() {
+		text, _ := collectStream(t, agent.Stream("second"), 2*time.Second)
+		done <- text
+	}()
+	select {
+	case text := <-done:
+		if text != "second answer" {
+			t.Errorf("second stream text = %q, want %q", text, "second answer")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("second stream deadlocked behind the abandoned one")
+	}
+}
+
+// RunWithImages cancels the abandoned stream of the same agent before waiting for its turn.
+func TestSerializedTurnsRunWithImagesAfterAbandonedStream(t *testing.T) {
+	llm := llms.NewFakeStreamingModel([]string{strings.Repeat("token ", 200), "a cat"}, 1)
+	agent := CreateReactAgent(context.Background(), llm,
+		WithMemory(memory.NewBufferMemory()),
+		WithConversationID("c1"),
+		WithSerializedTurns(true),
+	)
+
+	first := agent.Stream("first")
+	<-first
+
+	done := make(chan string)
+	go func() {
+		text, err := agent.RunWithImages("what is this?", []ImageInput{{URL: "https://example.com/cat.png"}})
+		if err != nil {
+			t.Errorf("RunWithImages: %v", err)
+		}
+		done <- text
+	}()
+	select {
+	case text := <-done:
+		if text != "a cat" {
+			t.Errorf("RunWithImages = %q, want %q", text, "a cat")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("RunWithImages deadlocked behind the abandoned stream")
+	}
+}
+
+// Two agents share a conversation: the second waits for the first turn, which ends as soon as
+// it is stopped even though its consumer no longer reads.
+func TestSerializedTurnsSharedConversationStop(t *testing.T) {
+	mem := memory.NewBufferMemory()
+	newAgent := func(reply string) *Agent {
+		return CreateReactAgent(context.Background(), llms.NewFakeStreamingModel([]string{reply}, 1),
+			WithMemory(mem),
+			WithConversationID("shared"),
+			WithSerializedTurns(true),
+		)
+	}
+	a, b := newAgent(strings.Repeat("token ", 200)), newAgent("from b")
+
+	ch := a.Stream("to a")
+	<-ch
+
+	done := make(chan string)
+	go func() {
+		text, _ := collectStream(t, b.Stream("to b"), 2*time.Second)
+		done <- text
+	}()
+	time.Sleep(20 * time.Millisecond)
+	a.Stop()
+
+	select {
+	case text := <-done:
+		if text != "from b" {
+			t.Errorf("b text = %q, want %q", text, "from b")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("b deadlocked behind the stopped stream of a")
+	}
+
+	msgs, _ := mem.LoadMessages(context.Background(), "shared")
+	var users []string
+	for _, m := range msgs {
+		if m.Role == llms.ChatMessageRoleUser {
+			users = append(users, m.Content)
+		}
+	}
+	if strings.Join(users, ",") != "to a,to b" {
+		t.Errorf("saved user messages = %v, want the turn of a before the turn of b", users)
+	}
+}
@@ -58,6 +58,7 @@ func main() {
 		agents.WithConversationID("tools-chat"),
 		agents.WithMaxIterations(5), // Limit tool-calling iterations
 	).WithPrompt("You are a helpful assistant that can use tools to help users.")
+	defer agent.Close()
 
 	fmt.Printf("Agent created with %d tools\n", len(tools))
 	fmt.Println("============================")
@@ -37,6 +37,7 @@ func main() {
 		agents.WithMemory(mem),
 		agents.WithConversationID("user-123"),
 	).WithPrompt("You are a helpful assistant that remembers the conversation.")
+	defer agent.Close()
 
 	// First message
 	fmt.Println("Question 1: What is AI?")
@@ -33,6 +33,7 @@ func main() {
 		agents.WithMemory(mem),
 		agents.WithConversationID("file-memory-demo"),
 	).WithPrompt("You are a helpful assistant that keeps memory.")
+	defer agent.Close()
 
 	fmt.Println("Question 1: My favorite color is blue. Remember this.")
 	resp1, err := agent.Run("My favorite color is blue. Remember this.")
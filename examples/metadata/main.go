@@ -26,6 +26,7 @@ func main() {
 
 	agent := agents.CreateReactAgent(ctx, llm).
 		WithPrompt("You are a concise assistant.")
+	defer agent.Close()
 
 	resp, err := agent.Run("Summarize what an LLM agent is in 2 lines.")
 	if err != nil {
@@ -75,7 +75,6 @@ func main() {
 		fmt.Println("3. Network connectivity to Milvus is available")
 		return
 	}
-	defer milvusMem.Close()
 
 	fmt.Println("MilvusMemory created successfully!")
 	fmt.Println()
@@ -85,6 +84,7 @@ func main() {
 		agents.WithMemory(milvusMem),
 		agents.WithConversationID("example-conversation"),
 	).WithPrompt("You are a helpful assistant that remembers past conversations using semantic search.")
+	defer agent.Close()
 
 	// First interaction - store some information
 	fmt.Println("=== First Interaction ===")
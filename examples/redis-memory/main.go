@@ -48,6 +48,7 @@ func main() {
 		agents.WithMemory(mem),
 		agents.WithConversationID("user-456"),
 	).WithPrompt("You are a helpful assistant.")
+	defer agent.Close()
 
 	// First interaction
 	fmt.Println("First interaction:")
@@ -29,6 +29,7 @@ func main() {
 
 	// Create agent without tools (simplest case)
 	agent := agents.CreateReactAgent(ctx, llm)
+	defer agent.Close()
 
 	// Run the agent and get response
 	response, err := agent.Run("What is the capital of France?")
@@ -69,6 +69,7 @@ func main() {
 		agents.WithConversationID("skills-chat"),
 		agents.WithMaxIterations(20), // Limit tool-calling iterations
 	).WithPrompt("You are a helpful assistant that can use tools to help users.")
+	defer agent.Close()
 
 	fmt.Printf("Agent created with %d tools\n", len(tools))
 	fmt.Println("============================")
@@ -27,6 +27,7 @@ func main() {
 
 	agent := agents.CreateReactAgent(ctx, llm).
 		WithPrompt("You are a helpful assistant.")
+	defer agent.Close()
 
 	go func() {
 		time.Sleep(2 * time.Second)
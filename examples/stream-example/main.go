@@ -30,6 +30,7 @@ func main() {
 	// Create agent with a custom prompt
 	agent := agents.CreateReactAgent(ctx, llm).
 		WithPrompt("You are a helpful assistant. Answer concisely.")
+	defer agent.Close()
 
 	// Use streaming to get real-time responses
 	ch := agent.Stream("Write a short poem about programming")
@@ -37,20 +38,21 @@ func main() {
 	fmt.Println("Streaming response:")
 	fmt.Println("===================")
 
-	// Read from the stream channel
+	// Render each event; the channel closes after the final Done or Error event
 	for resp := range ch {
-		if resp.Error != nil {
+		switch resp.Event {
+		case agents.EventReasoning:
+			fmt.Print(resp.ReasoningContent)
+		case agents.EventToken:
+			fmt.Print(resp.Content)
+		case agents.EventToolCallStarted:
+			fmt.Printf("\n→ [%s] calling with %v\n", resp.ToolName, resp.ToolArgs)
+		case agents.EventToolResult:
+			fmt.Printf("→ [%s] result: %s\n", resp.ToolName, resp.ToolResult)
+		case agents.EventError:
 			fmt.Printf("\nError: %v\n", resp.Error)
-			break
-		}
-
-		// Print content as it arrives
-		fmt.Print(resp.Content)
-
-		// Check if stream is complete
-		if resp.Done {
+		case agents.EventDone:
 			fmt.Println("\n[Stream completed]")
-			break
 		}
 	}
 }
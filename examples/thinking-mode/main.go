@@ -29,6 +29,7 @@ func main() {
 
 	agent := agents.CreateReactAgent(ctx, llm).
 		WithPrompt("You are a helpful assistant. Think carefully before answering.")
+	defer agent.Close()
 
 	ch := agent.Stream("Compare TCP and UDP in a concise way.")
 	fmt.Println("Streaming response:")
@@ -0,0 +1,345 @@
+package llms
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// Cache stores chat completion responses by key.
+type Cache interface {
+	// Get returns the cached response for key, if present and not expired.
+	Get(key string) (ChatCompletionResponse, bool)
+	// Set stores value under key. A ttl of zero means no expiration.
+	Set(key string, value ChatCompletionResponse, ttl time.Duration)
+}
+
+// LRUCache is an in-memory [Cache] with least-recently-used eviction. It is safe for concurrent use.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     ChatCompletionResponse
+	expiresAt time.Time
+}
+
+// NewLRUCache creates an LRUCache holding at most size entries (default 1000 when size <= 0).
+func NewLRUCache(size int) *LRUCache {
+	if size <= 0 {
+		size = 1000
+	}
+	return &LRUCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get implements [Cache].
+func (c *LRUCache) Get(key string) (ChatCompletionResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return ChatCompletionResponse{}, false
+	}
+	e := el.Value.(*lruEntry)
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return ChatCompletionResponse{}, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// Set implements [Cache].
+func (c *LRUCache) Set(key string, value ChatCompletionResponse, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// CacheStats reports cache effectiveness.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// CachedModel wraps an [LLM] and serves repeated identical requests from a [Cache].
+// The key is a hash of the model plus the serialized messages, tools and call options. Streaming hits
+// are replayed as a synthetic stream; streaming misses are recorded once the stream ends.
+//
+// Example:
+//
+//	llm := llms.NewCachedModel(openaiModel, llms.NewLRUCache(500))
+//	llm.TTL = time.Hour
+type CachedModel struct {
+	inner LLM
+	cache Cache
+
+	// TTL is applied to new cache entries. Zero means entries never expire.
+	TTL time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCachedModel wraps inner with cache. A nil cache uses NewLRUCache(0).
+func NewCachedModel(inner LLM, cache Cache) *CachedModel {
+	if cache == nil {
+		cache = NewLRUCache(0)
+	}
+	return &CachedModel{inner: inner, cache: cache}
+}
+
+// Stats returns the hit and miss counters.
+func (m *CachedModel) Stats() CacheStats {
+	return CacheStats{Hits: m.hits.Load(), Misses: m.misses.Load()}
+}
+
+// ModelName implements [ModelNamer] with the wrapped model's name.
+func (m *CachedModel) ModelName() string {
+	if n, ok := m.inner.(ModelNamer); ok {
+		return n.ModelName()
+	}
+	return ""
+}
+
+// Provider implements [ModelDescriber] with the wrapped model's provider.
+func (m *CachedModel) Provider() string {
+	if d, ok := m.inner.(ModelDescriber); ok {
+		return d.Provider()
+	}
+	return ""
+}
+
+// Chat implements [LLM].
+func (m *CachedModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.ChatWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatWithTools implements [ToolCaller]. Tools are dropped when the inner model does not implement it.
+func (m *CachedModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
+	key := m.key(messages, tools, NewCallOptions(opts...))
+	if resp, ok := m.cache.Get(key); ok {
+		m.hits.Add(1)
+		return resp, nil
+	}
+	m.misses.Add(1)
+
+	var resp ChatCompletionResponse
+	var err error
+	if tc, ok := m.inner.(ToolCaller); ok && len(tools) > 0 {
+		resp, err = tc.ChatWithTools(ctx, messages, tools, opts...)
+	} else {
+		resp, err = m.inner.Chat(ctx, messages, opts...)
+	}
+	if err != nil {
+		return resp, err
+	}
+	m.cache.Set(key, resp, m.TTL)
+	return resp, nil
+}
+
+// ChatStream implements [ChatStreamer].
+func (m *CachedModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	return m.ChatStreamWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatStreamWithTools implements [ToolCaller].
+func (m *CachedModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
+	key := m.key(messages, tools, NewCallOptions(opts...))
+	if resp, ok := m.cache.Get(key); ok {
+		m.hits.Add(1)
+		return replayStream(resp), nil
+	}
+	m.misses.Add(1)
+
+	var stream ChatStream
+	var err error
+	if tc, ok := m.inner.(ToolCaller); ok {
+		stream, err = tc.ChatStreamWithTools(ctx, messages, tools, opts...)
+	} else if s, ok := m.inner.(ChatStreamer); ok {
+		stream, err = s.ChatStream(ctx, messages, opts...)
+	} else {
+		return nil, errNotStreamer
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	acc := newStreamAccumulator()
+	recv := func() (ChatCompletionStreamResponse, error) {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			m.cache.Set(key, acc.response(), m.TTL)
+		} else if err == nil {
+			acc.add(chunk)
+		}
+		return chunk, err
+	}
+	return NewChatCompletionStream(recv, stream.Close), nil
+}
+
+// Embeddings implements [Embedder] by calling the wrapped model; embeddings are not cached here
+// (see memory.CachedEmbedder).
+func (m *CachedModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	if e, ok := m.inner.(Embedder); ok {
+		return e.Embeddings(ctx, inputs)
+	}
+	return nil, errNotEmbedder
+}
+
+func (m *CachedModel) key(messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, co CallOptions) string {
+	h := sha256.New()
+	h.Write([]byte(describeModel(m.inner)))
+	h.Write([]byte{0})
+	_ = json.NewEncoder(h).Encode(messages)
+	if len(tools) > 0 {
+		_ = json.NewEncoder(h).Encode(tools)
+	}
+	_ = json.NewEncoder(h).Encode(co)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayStream emits a cached response as a single-chunk stream.
+func replayStream(resp ChatCompletionResponse) *ChatCompletionStream {
+	chunk := ChatCompletionStreamResponse{ID: resp.ID, Model: resp.Model}
+	for _, ch := range resp.Choices {
+		delta := ChatCompletionStreamDelta{
+			Content:          ch.Message.Content,
+			ReasoningContent: ch.Message.ReasoningContent,
+		}
+		for i, tc := range ch.Message.ToolCalls {
+			delta.ToolCalls = append(delta.ToolCalls, ChatCompletionStreamToolCallDelta{
+				Index:             i,
+				ID:                tc.ID,
+				Type:              "function",
+				NameFragment:      tc.Name,
+				ArgumentsFragment: tc.Arguments,
+			})
+		}
+		chunk.Choices = append(chunk.Choices, ChatCompletionStreamChoice{Index: ch.Index, Delta: delta, FinishReason: ch.FinishReason})
+	}
+	usage := resp.Usage
+	chunk.Usage = &usage
+
+	sent := false
+	return NewChatCompletionStream(func() (ChatCompletionStreamResponse, error) {
+		if sent {
+			return ChatCompletionStreamResponse{}, io.EOF
+		}
+		sent = true
+		return chunk, nil
+	}, nil)
+}
+
+// streamAccumulator assembles stream chunks of the first choice into a full response.
+type streamAccumulator struct {
+	resp      ChatCompletionResponse
+	content   strings.Builder
+	reasoning strings.Builder
+	finish    string
+	toolCalls map[int]*ChatToolCall
+}
+
+func newStreamAccumulator() *streamAccumulator {
+	return &streamAccumulator{toolCalls: map[int]*ChatToolCall{}}
+}
+
+func (a *streamAccumulator) add(chunk ChatCompletionStreamResponse) {
+	if chunk.ID != "" {
+		a.resp.ID = chunk.ID
+	}
+	if chunk.Model != "" {
+		a.resp.Model = chunk.Model
+	}
+	if chunk.SystemFingerprint != "" {
+		a.resp.SystemFingerprint = chunk.SystemFingerprint
+	}
+	if chunk.Usage != nil {
+		a.resp.Usage = *chunk.Usage
+	}
+	if len(chunk.Choices) == 0 {
+		return
+	}
+	ch := chunk.Choices[0]
+	a.content.WriteString(ch.Delta.Content)
+	a.reasoning.WriteString(ch.Delta.ReasoningContent)
+	if ch.FinishReason != "" {
+		a.finish = ch.FinishReason
+	}
+	for _, d := range ch.Delta.ToolCalls {
+		tc, ok := a.toolCalls[d.Index]
+		if !ok {
+			tc = &ChatToolCall{}
+			a.toolCalls[d.Index] = tc
+		}
+		if d.ID != "" {
+			tc.ID = d.ID
+		}
+		tc.Name += d.NameFragment
+		tc.Arguments += d.ArgumentsFragment
+	}
+}
+
+func (a *streamAccumulator) response() ChatCompletionResponse {
+	msg := ChatCompletionMessage{
+		Role:             ChatMessageRoleAssistant,
+		Content:          a.content.String(),
+		ReasoningContent: a.reasoning.String(),
+	}
+	keys := make([]int, 0, len(a.toolCalls))
+	for k := range a.toolCalls {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		msg.ToolCalls = append(msg.ToolCalls, *a.toolCalls[k])
+	}
+	resp := a.resp
+	resp.Choices = []ChatCompletionChoice{{Message: msg, FinishReason: a.finish}}
+	return resp
+}
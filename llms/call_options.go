@@ -0,0 +1,84 @@
+package llms
+
+// CallOptions overrides model defaults for a single request. Unset fields keep the values from [Config].
+type CallOptions struct {
+	// Model replaces the configured model name.
+	Model string
+	// Temperature replaces the configured temperature; nil keeps it, so an explicit 0 is honoured.
+	Temperature *float64
+	// MaxTokens replaces the configured completion limit when > 0.
+	MaxTokens int
+	// Stop replaces the configured stop sequences when non-empty.
+	Stop []string
+	// Seed replaces the configured seed; nil keeps it.
+	Seed *int
+	// Grammar replaces the configured GBNF grammar when non-empty (llama.cpp server only).
+	Grammar string
+	// JSONMode asks for a JSON object answer when no response format is configured
+	// (OpenAI-compatible models only).
+	JSONMode bool
+}
+
+// ChatOption sets a per-call override on [CallOptions].
+type ChatOption func(*CallOptions)
+
+// WithCallTemperature overrides the sampling temperature for one request.
+func WithCallTemperature(temperature float64) ChatOption {
+	return func(o *CallOptions) {
+		o.Temperature = &temperature
+	}
+}
+
+// WithCallMaxTokens overrides the completion token limit for one request.
+func WithCallMaxTokens(maxTokens int) ChatOption {
+	return func(o *CallOptions) {
+		o.MaxTokens = maxTokens
+	}
+}
+
+// WithCallStop overrides the stop sequences for one request.
+func WithCallStop(stop []string) ChatOption {
+	return func(o *CallOptions) {
+		o.Stop = stop
+	}
+}
+
+// WithCallSeed sets the sampling seed for one request.
+func WithCallSeed(seed int) ChatOption {
+	return func(o *CallOptions) {
+		o.Seed = &seed
+	}
+}
+
+// WithCallGrammar constrains one request's output with a GBNF grammar (llama.cpp server only).
+func WithCallGrammar(grammar string) ChatOption {
+	return func(o *CallOptions) {
+		o.Grammar = grammar
+	}
+}
+
+// WithCallJSONMode requests JSON mode (response_format=json_object) for one request, unless
+// the model has a configured [ResponseFormat]. Models without JSON mode ignore it.
+func WithCallJSONMode() ChatOption {
+	return func(o *CallOptions) {
+		o.JSONMode = true
+	}
+}
+
+// WithCallModel sends one request to a different model on the same provider.
+func WithCallModel(model string) ChatOption {
+	return func(o *CallOptions) {
+		o.Model = model
+	}
+}
+
+// NewCallOptions applies opts in order. Provider implementations use it to read per-call overrides.
+func NewCallOptions(opts ...ChatOption) CallOptions {
+	var o CallOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
@@ -22,11 +22,32 @@ type ChatToolCall struct {
 	Arguments string // JSON object as produced by the model
 }
 
+// Content part types for [ChatMessagePart].
+const (
+	ChatMessagePartTypeText     = "text"
+	ChatMessagePartTypeImageURL = "image_url"
+)
+
+// ChatMessagePart is one part of a multimodal user message.
+type ChatMessagePart struct {
+	// Type is ChatMessagePartTypeText or ChatMessagePartTypeImageURL.
+	Type string
+	Text string
+	// ImageURL is an http(s) URL or a data URL ("data:image/png;base64,...").
+	ImageURL string
+}
+
 // ChatCompletionMessage is one turn in a chat request or history.
 type ChatCompletionMessage struct {
 	Role             string
 	Content          string
 	ReasoningContent string
+	// CacheHint marks a stable prefix message (e.g. the system prompt) as cacheable by providers
+	// with explicit prompt caching. Providers without it ignore the hint.
+	CacheHint bool
+	// MultiContent holds the parts of a multimodal user message and is sent instead of Content
+	// when set. Content keeps the text for history, token counting and text-only memories.
+	MultiContent []ChatMessagePart
 	// ToolCalls is set on assistant messages when the model requests tool execution.
 	ToolCalls []ChatToolCall
 	// ToolCallID is set on role "tool" messages (required by the API when replying to ToolCalls).
@@ -46,6 +67,8 @@ type ChatCompletionResponse struct {
 	Model   string
 	Choices []ChatCompletionChoice
 	Usage   ChatUsage
+	// SystemFingerprint identifies the backend configuration; a change can alter seeded outputs.
+	SystemFingerprint string
 }
 
 // ChatCompletionStreamDelta is one streamed fragment of an assistant message.
@@ -74,10 +97,11 @@ type ChatCompletionStreamChoice struct {
 
 // ChatCompletionStreamResponse is one SSE chunk from a streaming completion.
 type ChatCompletionStreamResponse struct {
-	ID      string
-	Model   string
-	Choices []ChatCompletionStreamChoice
-	Usage   *ChatUsage
+	ID                string
+	Model             string
+	Choices           []ChatCompletionStreamChoice
+	Usage             *ChatUsage
+	SystemFingerprint string
 }
 
 // --- JSON-oriented aliases (e.g. for APIs or logging) ---
@@ -98,10 +122,11 @@ type ChatChoice struct {
 
 // ChatResponse is a JSON-friendly completion snapshot.
 type ChatResponse struct {
-	ID      string       `json:"id"`
-	Model   string       `json:"model"`
-	Choices []ChatChoice `json:"choices"`
-	Usage   ChatUsage    `json:"usage"`
+	ID                string       `json:"id"`
+	Model             string       `json:"model"`
+	Choices           []ChatChoice `json:"choices"`
+	Usage             ChatUsage    `json:"usage"`
+	SystemFingerprint string       `json:"system_fingerprint,omitempty"`
 }
 
 // ChatStreamDelta is delta content in ChatStreamChunk.
@@ -129,17 +154,19 @@ type ChatStreamChoice struct {
 
 // ChatStreamChunk is a JSON-friendly streaming chunk.
 type ChatStreamChunk struct {
-	ID      string             `json:"id"`
-	Model   string             `json:"model"`
-	Choices []ChatStreamChoice `json:"choices"`
-	Usage   *ChatUsage         `json:"usage,omitempty"`
+	ID                string             `json:"id"`
+	Model             string             `json:"model"`
+	Choices           []ChatStreamChoice `json:"choices"`
+	Usage             *ChatUsage         `json:"usage,omitempty"`
+	SystemFingerprint string             `json:"system_fingerprint,omitempty"`
 }
 
 // ToChatResponse maps ChatCompletionResponse to ChatResponse.
 func ToChatResponse(resp ChatCompletionResponse) ChatResponse {
 	cr := ChatResponse{
-		ID:    resp.ID,
-		Model: resp.Model,
+		ID:                resp.ID,
+		Model:             resp.Model,
+		SystemFingerprint: resp.SystemFingerprint,
 		Usage: ChatUsage{
 			PromptTokens:     resp.Usage.PromptTokens,
 			CompletionTokens: resp.Usage.CompletionTokens,
@@ -163,8 +190,9 @@ func ToChatResponse(resp ChatCompletionResponse) ChatResponse {
 // ToChatStreamChunk maps ChatCompletionStreamResponse to ChatStreamChunk.
 func ToChatStreamChunk(resp ChatCompletionStreamResponse) ChatStreamChunk {
 	chunk := ChatStreamChunk{
-		ID:    resp.ID,
-		Model: resp.Model,
+		ID:                resp.ID,
+		Model:             resp.Model,
+		SystemFingerprint: resp.SystemFingerprint,
 	}
 	if resp.Usage != nil {
 		chunk.Usage = &ChatUsage{
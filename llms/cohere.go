@@ -0,0 +1,386 @@
+package llms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+)
+
+const (
+	defaultCohereBaseURL     = "https://api.cohere.com/v2"
+	defaultCohereRerankModel = "rerank-v3.5"
+)
+
+// CohereModel implements [LLM], [ToolCaller] and [Embedder] against the Cohere v2 REST API
+// (chat and embed), and adds [CohereModel.Rerank].
+//
+// Example:
+//
+//	llm := llms.NewCohereModel(llms.Config{
+//	    APIKey: os.Getenv("COHERE_API_KEY"),
+//	    Model:  "command-r-plus",
+//	})
+type CohereModel struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	sampling   samplingParams
+	dims       int
+	usage      UsageCollector
+
+	// RerankModel is used by Rerank. Default is "rerank-v3.5".
+	RerankModel string
+	// EmbedInputType is sent as input_type by Embeddings. Default is "search_document".
+	EmbedInputType string
+}
+
+// NewCohereModel builds a Cohere client. BaseURL defaults to https://api.cohere.com/v2.
+func NewCohereModel(cfg Config) *CohereModel {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultCohereBaseURL
+	}
+	return &CohereModel{
+		httpClient:     cfg.httpClient(),
+		baseURL:        baseURL,
+		apiKey:         cfg.APIKey,
+		model:          cfg.Model,
+		sampling:       newSamplingParams(cfg),
+		dims:           cfg.Dimensions,
+		usage:          cfg.UsageCollector,
+		RerankModel:    defaultCohereRerankModel,
+		EmbedInputType: "search_document",
+	}
+}
+
+// ModelName implements [ModelNamer].
+func (m *CohereModel) ModelName() string {
+	return m.model
+}
+
+// Provider implements [ModelDescriber].
+func (m *CohereModel) Provider() string {
+	return "cohere"
+}
+
+// Chat calls POST /chat.
+func (m *CohereModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.ChatWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatWithTools implements [ToolCaller]: function tools are sent in the tools field, and the
+// tool calls of the reply are returned with its tool_plan as Content.
+func (m *CohereModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
+	co := NewCallOptions(opts...)
+	req := cohereChatRequest{Model: m.model, Tools: cohereTools(tools)}
+	if co.Model != "" {
+		req.Model = co.Model
+	}
+	for _, msg := range messages {
+		req.Messages = append(req.Messages, cohereMessageFrom(msg))
+	}
+	sp := m.sampling
+	if sp.temperature != 0 {
+		req.Temperature = &sp.temperature
+	}
+	if sp.topP != 0 {
+		req.P = &sp.topP
+	}
+	if sp.maxTokens > 0 {
+		req.MaxTokens = sp.maxTokens
+	}
+	if sp.presencePenalty != 0 {
+		req.PresencePenalty = &sp.presencePenalty
+	}
+	if sp.frequencyPenalty != 0 {
+		req.FrequencyPenalty = &sp.frequencyPenalty
+	}
+	req.StopSequences = sp.stop
+	req.Seed = sp.seed
+	if co.Temperature != nil {
+		req.Temperature = co.Temperature
+	}
+	if co.MaxTokens > 0 {
+		req.MaxTokens = co.MaxTokens
+	}
+	if len(co.Stop) > 0 {
+		req.StopSequences = co.Stop
+	}
+	if co.Seed != nil {
+		req.Seed = co.Seed
+	}
+
+	var resp cohereChatResponse
+	if err := postJSON(ctx, m.httpClient, "cohere", m.baseURL+"/chat", m.header(), req, &resp); err != nil {
+		return ChatCompletionResponse{}, err
+	}
+
+	msg := ChatCompletionMessage{Role: ChatMessageRoleAssistant}
+	var text []string
+	for _, c := range resp.Message.Content {
+		if c.Type == "text" {
+			text = append(text, c.Text)
+		}
+	}
+	msg.Content = strings.Join(text, "")
+	if msg.Content == "" {
+		msg.Content = resp.Message.ToolPlan
+	}
+	for _, tc := range resp.Message.ToolCalls {
+		msg.ToolCalls = append(msg.ToolCalls, ChatToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+
+	tokens := resp.Usage.Tokens
+	if tokens.InputTokens == 0 && tokens.OutputTokens == 0 {
+		tokens = resp.Usage.BilledUnits
+	}
+	out := ChatCompletionResponse{
+		ID:    resp.ID,
+		Model: req.Model,
+		Choices: []ChatCompletionChoice{{
+			Message:      msg,
+			FinishReason: cohereFinishReason(resp.FinishReason),
+		}},
+		Usage: ChatUsage{
+			PromptTokens:     int(tokens.InputTokens),
+			CompletionTokens: int(tokens.OutputTokens),
+			TotalTokens:      int(tokens.InputTokens + tokens.OutputTokens),
+		},
+	}
+	collectUsage(m.usage, m.model, out)
+	return out, nil
+}
+
+// ChatStreamWithTools implements [ToolCaller] without streaming: the reply of
+// [CohereModel.ChatWithTools] is replayed as a stream.
+func (m *CohereModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
+	resp, err := m.ChatWithTools(ctx, messages, tools, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return StreamFromResponse(resp), nil
+}
+
+// cohereTools converts the function tools to Cohere tools; other tool types are skipped.
+func cohereTools(tools []openai.ChatCompletionToolUnionParam) []cohereTool {
+	var out []cohereTool
+	for _, t := range tools {
+		fn := t.GetFunction()
+		if fn == nil {
+			continue
+		}
+		out = append(out, cohereTool{Type: "function", Function: cohereFunction{
+			Name:        fn.Name,
+			Description: fn.Description.Value,
+			Parameters:  fn.Parameters,
+		}})
+	}
+	return out
+}
+
+// cohereMessageFrom converts a chat message. Multimodal user messages become text and
+// image_url content blocks; the text of an assistant message calling tools is its tool_plan.
+func cohereMessageFrom(msg ChatCompletionMessage) cohereMessage {
+	out := cohereMessage{Role: msg.Role, ToolCallID: msg.ToolCallID}
+	switch {
+	case len(msg.ToolCalls) > 0:
+		out.ToolPlan = msg.Content
+		for _, tc := range msg.ToolCalls {
+			args := tc.Arguments
+			if args == "" {
+				args = "{}"
+			}
+			out.ToolCalls = append(out.ToolCalls, cohereToolCall{ID: tc.ID, Type: "function", Function: cohereToolCallFunction{Name: tc.Name, Arguments: args}})
+		}
+	case len(msg.MultiContent) > 0:
+		blocks := make([]cohereContent, 0, len(msg.MultiContent))
+		for _, p := range msg.MultiContent {
+			if p.Type == ChatMessagePartTypeImageURL {
+				blocks = append(blocks, cohereContent{Type: "image_url", ImageURL: &cohereImageURL{URL: p.ImageURL}})
+				continue
+			}
+			blocks = append(blocks, cohereContent{Type: "text", Text: p.Text})
+		}
+		out.Content = blocks
+	default:
+		out.Content = msg.Content
+	}
+	return out
+}
+
+// Embeddings calls POST /embed with float embeddings.
+func (m *CohereModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	req := cohereEmbedRequest{
+		Model:          m.model,
+		Texts:          inputs,
+		InputType:      m.EmbedInputType,
+		EmbeddingTypes: []string{"float"},
+		OutputDim:      m.dims,
+	}
+	var resp struct {
+		Embeddings struct {
+			Float [][]float32 `json:"float"`
+		} `json:"embeddings"`
+	}
+	if err := postJSON(ctx, m.httpClient, "cohere", m.baseURL+"/embed", m.header(), req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings.Float) == 0 {
+		return nil, fmt.Errorf("未返回嵌入数据")
+	}
+	return resp.Embeddings.Float, nil
+}
+
+// Rerank calls POST /rerank and returns documents ordered by relevance to query, at most topN
+// (all documents when topN <= 0).
+func (m *CohereModel) Rerank(ctx context.Context, query string, documents []string, topN int) ([]RerankResult, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	req := cohereRerankRequest{
+		Model:     m.RerankModel,
+		Query:     query,
+		Documents: documents,
+	}
+	if topN > 0 {
+		req.TopN = topN
+	}
+	var resp struct {
+		Results []struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		} `json:"results"`
+	}
+	if err := postJSON(ctx, m.httpClient, "cohere", m.baseURL+"/rerank", m.header(), req, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]RerankResult, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		if r.Index < 0 || r.Index >= len(documents) {
+			return nil, fmt.Errorf("cohere: rerank returned out-of-range index %d", r.Index)
+		}
+		out = append(out, RerankResult{Index: r.Index, Score: r.RelevanceScore})
+	}
+	return out, nil
+}
+
+func (m *CohereModel) header() http.Header {
+	h := http.Header{}
+	if m.apiKey != "" {
+		h.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	return h
+}
+
+// cohereFinishReason maps Cohere finish reasons to the OpenAI values used elsewhere.
+func cohereFinishReason(reason string) string {
+	switch reason {
+	case "":
+		return ""
+	case "COMPLETE", "STOP_SEQUENCE":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "TOOL_CALL":
+		return "tool_calls"
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+// --- wire format ---
+
+type cohereChatRequest struct {
+	Model            string          `json:"model"`
+	Messages         []cohereMessage `json:"messages"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	P                *float64        `json:"p,omitempty"`
+	MaxTokens        int             `json:"max_tokens,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	StopSequences    []string        `json:"stop_sequences,omitempty"`
+	Seed             *int            `json:"seed,omitempty"`
+	Tools            []cohereTool    `json:"tools,omitempty"`
+}
+
+type cohereTool struct {
+	Type     string         `json:"type"`
+	Function cohereFunction `json:"function"`
+}
+
+type cohereFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type cohereMessage struct {
+	Role string `json:"role"`
+	// Content is a string, or []cohereContent for multimodal messages.
+	Content    any              `json:"content,omitempty"`
+	ToolPlan   string           `json:"tool_plan,omitempty"`
+	ToolCalls  []cohereToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type cohereContent struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *cohereImageURL `json:"image_url,omitempty"`
+}
+
+type cohereImageURL struct {
+	URL string `json:"url"`
+}
+
+type cohereToolCall struct {
+	ID       string                 `json:"id"`
+	Type     string                 `json:"type"`
+	Function cohereToolCallFunction `json:"function"`
+}
+
+type cohereToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type cohereChatResponse struct {
+	ID           string `json:"id"`
+	FinishReason string `json:"finish_reason"`
+	Message      struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		ToolPlan  string           `json:"tool_plan"`
+		ToolCalls []cohereToolCall `json:"tool_calls"`
+	} `json:"message"`
+	Usage struct {
+		BilledUnits cohereTokens `json:"billed_units"`
+		Tokens      cohereTokens `json:"tokens"`
+	} `json:"usage"`
+}
+
+type cohereTokens struct {
+	InputTokens  float64 `json:"input_tokens"`
+	OutputTokens float64 `json:"output_tokens"`
+}
+
+type cohereEmbedRequest struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type"`
+	EmbeddingTypes []string `json:"embedding_types"`
+	OutputDim      int      `json:"output_dimension,omitempty"`
+}
+
+type cohereRerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+}
@@ -0,0 +1,133 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// cohereServer answers every chat request with reply and records the decoded request bodies.
+func cohereServer(t *testing.T, reply string) (*CohereModel, *[]map[string]any) {
+	t.Helper()
+	var requests []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		requests = append(requests, body)
+		fmt.Fprint(w, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return NewCohereModel(Config{BaseURL: srv.URL, Model: "command-r-plus"}), &requests
+}
+
+func TestCohereModelChatWithTools(t *testing.T) {
+	m, requests := cohereServer(t, `{"id":"r1","finish_reason":"TOOL_CALL","message":{"role":"assistant",
+		"tool_plan":"I will look up the weather.",
+		"tool_calls":[{"id":"weather_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}}]}}`)
+
+	var _ ToolCaller = m
+	res, err := m.ChatWithTools(context.Background(), []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "weather?"}}, []openai.ChatCompletionToolUnionParam{weatherTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tool := (*requests)[0]["tools"].([]any)[0].(map[string]any)
+	fn := tool["function"].(map[string]any)
+	if tool["type"] != "function" || fn["name"] != "weather" || fn["description"] != "Current weather of a city" || fn["parameters"].(map[string]any)["type"] != "object" {
+		t.Errorf("tool = %v", tool)
+	}
+	choice := res.Choices[0]
+	if choice.FinishReason != "tool_calls" || choice.Message.Content != "I will look up the weather." {
+		t.Errorf("choice = %+v, want tool_calls with the tool plan", choice)
+	}
+	if calls := choice.Message.ToolCalls; len(calls) != 1 || calls[0].ID != "weather_1" || calls[0].Name != "weather" || calls[0].Arguments != `{"city":"Paris"}` {
+		t.Errorf("tool calls = %+v", calls)
+	}
+}
+
+// Assistant tool calls, tool results and multimodal user messages are converted, not dropped.
+func TestCohereModelMessages(t *testing.T) {
+	m, requests := cohereServer(t, `{"finish_reason":"COMPLETE","message":{"content":[{"type":"text","text":"Sunny."}]}}`)
+	history := []ChatCompletionMessage{
+		{Role: ChatMessageRoleSystem, Content: "be brief"},
+		{Role: ChatMessageRoleUser, MultiContent: []ChatMessagePart{
+			{Type: ChatMessagePartTypeText, Text: "weather here?"},
+			{Type: ChatMessagePartTypeImageURL, ImageURL: "data:image/png;base64,AAAA"},
+		}},
+		{Role: ChatMessageRoleAssistant, Content: "Checking.", ToolCalls: []ChatToolCall{{ID: "call_1", Name: "weather", Arguments: `{"city":"Paris"}`}, {ID: "call_2", Name: "now"}}},
+		{Role: ChatMessageRoleTool, ToolCallID: "call_1", Content: "22C"},
+		{Role: ChatMessageRoleTool, ToolCallID: "call_2", Content: "noon"},
+	}
+	res, err := m.Chat(context.Background(), history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Choices[0].Message.Content != "Sunny." || res.Choices[0].FinishReason != "stop" {
+		t.Errorf("Chat = %+v", res)
+	}
+
+	msgs := (*requests)[0]["messages"].([]any)
+	if _, ok := (*requests)[0]["tools"]; ok {
+		t.Error("request without tools has a tools field")
+	}
+	if msgs[0].(map[string]any)["content"] != "be brief" {
+		t.Errorf("system message = %v", msgs[0])
+	}
+	blocks := msgs[1].(map[string]any)["content"].([]any)
+	if blocks[0].(map[string]any)["text"] != "weather here?" || blocks[1].(map[string]any)["image_url"].(map[string]any)["url"] != "data:image/png;base64,AAAA" {
+		t.Errorf("multimodal user message = %v", msgs[1])
+	}
+	assistant := msgs[2].(map[string]any)
+	calls := assistant["tool_calls"].([]any)
+	first := calls[0].(map[string]any)
+	if assistant["tool_plan"] != "Checking." || len(calls) != 2 || first["id"] != "call_1" ||
+		first["function"].(map[string]any)["arguments"] != `{"city":"Paris"}` || calls[1].(map[string]any)["function"].(map[string]any)["arguments"] != "{}" {
+		t.Errorf("assistant message = %v", assistant)
+	}
+	if _, ok := assistant["content"]; ok {
+		t.Errorf("assistant message calling tools has content: %v", assistant)
+	}
+	if tool := msgs[3].(map[string]any); tool["tool_call_id"] != "call_1" || tool["content"] != "22C" {
+		t.Errorf("tool message = %v", tool)
+	}
+}
+
+func TestCohereModelChatStreamWithTools(t *testing.T) {
+	m, _ := cohereServer(t, `{"finish_reason":"TOOL_CALL","message":{"tool_calls":[{"id":"t1","type":"function","function":{"name":"weather","arguments":"{}"}}]}}`)
+	stream, err := m.ChatStreamWithTools(context.Background(), nil, []openai.ChatCompletionToolUnionParam{weatherTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var ids []string
+	var reason string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range chunk.Choices {
+			for _, tc := range c.Delta.ToolCalls {
+				ids = append(ids, tc.ID)
+			}
+			if c.FinishReason != "" {
+				reason = c.FinishReason
+			}
+		}
+	}
+	if fmt.Sprint(ids) != "[t1]" || reason != "tool_calls" {
+		t.Errorf("streamed tool calls %v, finish reason %q", ids, reason)
+	}
+}
@@ -0,0 +1,169 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// EmbedAllOption configures [EmbedAll].
+type EmbedAllOption func(*embedAllOptions)
+
+type embedAllOptions struct {
+	batchSize   int
+	concurrency int
+	progress    func(done, total int)
+}
+
+// WithEmbedBatchSize sets how many inputs are sent per request. Default is 100.
+func WithEmbedBatchSize(n int) EmbedAllOption {
+	return func(o *embedAllOptions) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// WithEmbedConcurrency sets how many requests run in parallel. Default is 4.
+func WithEmbedConcurrency(n int) EmbedAllOption {
+	return func(o *embedAllOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithEmbedProgress registers a callback invoked after each batch with the number of inputs
+// processed so far (including failed ones) and the total. Calls are serialized.
+func WithEmbedProgress(fn func(done, total int)) EmbedAllOption {
+	return func(o *embedAllOptions) {
+		o.progress = fn
+	}
+}
+
+// ErrEmbeddingCountMismatch is wrapped by embedders that got fewer or more embeddings than inputs.
+var ErrEmbeddingCountMismatch = errors.New("embedding count mismatch")
+
+// embedEach embeds inputs one at a time, returning nil vectors and an error for the inputs that fail.
+func embedEach(ctx context.Context, embedder Embedder, inputs []string) ([][]float32, map[int]error) {
+	out := make([][]float32, len(inputs))
+	errs := map[int]error{}
+	for i, in := range inputs {
+		vecs, err := embedder.Embeddings(ctx, []string{in})
+		switch {
+		case err != nil:
+			errs[i] = err
+		case len(vecs) != 1 || len(vecs[0]) == 0:
+			errs[i] = fmt.Errorf("embedder returned no embedding")
+		default:
+			out[i] = vecs[0]
+		}
+	}
+	return out, errs
+}
+
+// EmbedAllError reports the inputs [EmbedAll] could not embed.
+type EmbedAllError struct {
+	// Failed lists the indices of the failed inputs in ascending order.
+	Failed []int
+	// Errs holds one error per failed batch.
+	Errs []error
+}
+
+func (e *EmbedAllError) Error() string {
+	return fmt.Sprintf("embed all: %d inputs failed: %v", len(e.Failed), errors.Join(e.Errs...))
+}
+
+// Unwrap returns the batch errors.
+func (e *EmbedAllError) Unwrap() []error {
+	return e.Errs
+}
+
+// EmbedAll embeds inputs in batches spread over concurrent workers. The result has one entry
+// per input, in input order. When some batches fail, the other embeddings are still returned,
+// the failed entries are nil, and the error is an *[EmbedAllError] listing the failed indices.
+// A batch answered with fewer embeddings than inputs is retried input by input, so only the
+// inputs the provider drops are reported.
+//
+// Example:
+//
+//	vecs, err := llms.EmbedAll(ctx, embedder, texts,
+//	    llms.WithEmbedConcurrency(8),
+//	    llms.WithEmbedProgress(func(done, total int) { log.Printf("%d/%d", done, total) }),
+//	)
+func EmbedAll(ctx context.Context, embedder Embedder, inputs []string, opts ...EmbedAllOption) ([][]float32, error) {
+	o := embedAllOptions{batchSize: 100, concurrency: 4}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	out := make([][]float32, len(inputs))
+	if len(inputs) == 0 {
+		return out, nil
+	}
+
+	type batch struct{ start, end int }
+	batches := make(chan batch)
+	go func() {
+		defer close(batches)
+		for start := 0; start < len(inputs); start += o.batchSize {
+			select {
+			case batches <- batch{start, min(start+o.batchSize, len(inputs))}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		done   int
+		failed = &EmbedAllError{}
+	)
+	for range min(o.concurrency, (len(inputs)+o.batchSize-1)/o.batchSize) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				vecs, err := embedder.Embeddings(ctx, inputs[b.start:b.end])
+				var itemErrs map[int]error
+				if (err == nil && len(vecs) != b.end-b.start) || errors.Is(err, ErrEmbeddingCountMismatch) {
+					err = nil
+					// the provider dropped some inputs; embed one by one to find which
+					vecs, itemErrs = embedEach(ctx, embedder, inputs[b.start:b.end])
+				}
+
+				mu.Lock()
+				if err != nil {
+					failed.Errs = append(failed.Errs, fmt.Errorf("inputs [%d:%d]: %w", b.start, b.end, err))
+					for i := b.start; i < b.end; i++ {
+						failed.Failed = append(failed.Failed, i)
+					}
+				} else {
+					copy(out[b.start:b.end], vecs)
+					for i, itemErr := range itemErrs {
+						failed.Errs = append(failed.Errs, fmt.Errorf("input %d: %w", b.start+i, itemErr))
+						failed.Failed = append(failed.Failed, b.start+i)
+					}
+				}
+				done += b.end - b.start
+				if o.progress != nil {
+					o.progress(done, len(inputs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil && done < len(inputs) {
+		return out, err
+	}
+	if len(failed.Failed) > 0 {
+		sort.Ints(failed.Failed)
+		return out, failed
+	}
+	return out, nil
+}
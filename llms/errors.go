@@ -0,0 +1,62 @@
+package llms
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// Sentinel errors for common provider failures. Errors returned by the models in this package
+// wrap one of these when the failure can be classified, so callers can use errors.Is while
+// errors.As still reaches the provider error (*openai.Error or *APIError).
+var (
+	ErrRateLimited           = errors.New("llms: rate limited")
+	ErrContextLengthExceeded = errors.New("llms: context length exceeded")
+	ErrAuthentication        = errors.New("llms: authentication failed")
+	ErrModelNotFound         = errors.New("llms: model not found")
+)
+
+// classifiedError attaches a sentinel to a provider error without changing its message.
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e *classifiedError) Error() string   { return e.err.Error() }
+func (e *classifiedError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// classifyError wraps an OpenAI SDK error with the matching sentinel. Other errors are returned as is;
+// *APIError classifies itself through Unwrap.
+func classifyError(err error) error {
+	var oe *openai.Error
+	if !errors.As(err, &oe) {
+		return err
+	}
+	kind := classifyStatus(oe.StatusCode, oe.Code, oe.Message)
+	if kind == nil {
+		return err
+	}
+	return &classifiedError{kind: kind, err: err}
+}
+
+// classifyStatus maps an HTTP status plus the provider's error code and message to a sentinel, or nil.
+func classifyStatus(status int, code, message string) error {
+	msg := strings.ToLower(message)
+	switch {
+	case code == "context_length_exceeded" ||
+		status == http.StatusRequestEntityTooLarge ||
+		strings.Contains(msg, "maximum context length") ||
+		strings.Contains(msg, "context length exceeded") ||
+		strings.Contains(msg, "exceeds the maximum number of tokens"):
+		return ErrContextLengthExceeded
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuthentication
+	case code == "model_not_found" || status == http.StatusNotFound:
+		return ErrModelNotFound
+	}
+	return nil
+}
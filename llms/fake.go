@@ -0,0 +1,212 @@
+package llms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// FakeModel is a scripted [LLM] and [ChatStreamer] for testing agents without network access.
+// It returns the scripted replies in order, records the messages of every call, and can be
+// programmed to fail on specific calls.
+//
+// Example:
+//
+//	llm := llms.NewFakeModel([]string{"first answer", "second answer"}).
+//	    FailOnCall(2, errors.New("boom"))
+//	agent := agents.CreateReactAgent(ctx, llm)
+type FakeModel struct {
+	mu        sync.Mutex
+	replies   []ChatCompletionMessage
+	calls     [][]ChatCompletionMessage
+	options   []CallOptions
+	failures  map[int]error
+	chunkSize int
+}
+
+// NewFakeModel returns a FakeModel replying with responses as assistant text, one per call.
+func NewFakeModel(responses []string) *FakeModel {
+	replies := make([]ChatCompletionMessage, 0, len(responses))
+	for _, r := range responses {
+		replies = append(replies, ChatCompletionMessage{Role: ChatMessageRoleAssistant, Content: r})
+	}
+	return NewFakeModelWithMessages(replies)
+}
+
+// NewFakeModelWithMessages returns a FakeModel replying with full assistant messages,
+// which allows scripting tool calls.
+func NewFakeModelWithMessages(replies []ChatCompletionMessage) *FakeModel {
+	return &FakeModel{
+		replies:   replies,
+		failures:  map[int]error{},
+		chunkSize: 4,
+	}
+}
+
+// NewFakeStreamingModel is like [NewFakeModel] but ChatStream splits each reply into chunks of chunkSize runes.
+func NewFakeStreamingModel(responses []string, chunkSize int) *FakeModel {
+	m := NewFakeModel(responses)
+	if chunkSize > 0 {
+		m.chunkSize = chunkSize
+	}
+	return m
+}
+
+// FailOnCall makes the n-th call (1-based, counting Chat and ChatStream) return err.
+// The scripted reply is not consumed by a failing call.
+func (m *FakeModel) FailOnCall(n int, err error) *FakeModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[n] = err
+	return m
+}
+
+// Calls returns a copy of the messages passed to each call, in order.
+func (m *FakeModel) Calls() [][]ChatCompletionMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([][]ChatCompletionMessage, len(m.calls))
+	copy(out, m.calls)
+	return out
+}
+
+// Options returns the per-call options resolved for each call, in order.
+func (m *FakeModel) Options() []CallOptions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]CallOptions, len(m.options))
+	copy(out, m.options)
+	return out
+}
+
+// CallCount returns how many times Chat or ChatStream has been called.
+func (m *FakeModel) CallCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.calls)
+}
+
+// next records the call and returns the next scripted reply. Like a real server, the reply
+// content is cut at the first stop sequence from the call options.
+func (m *FakeModel) next(messages []ChatCompletionMessage, opts []ChatOption) (ChatCompletionMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	recorded := make([]ChatCompletionMessage, len(messages))
+	copy(recorded, messages)
+	m.calls = append(m.calls, recorded)
+	co := NewCallOptions(opts...)
+	m.options = append(m.options, co)
+	n := len(m.calls)
+
+	if err, ok := m.failures[n]; ok {
+		return ChatCompletionMessage{}, err
+	}
+	if len(m.replies) == 0 {
+		return ChatCompletionMessage{}, fmt.Errorf("fake model: no scripted response for call %d", n)
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	if reply.Role == "" {
+		reply.Role = ChatMessageRoleAssistant
+	}
+	for _, stop := range co.Stop {
+		if i := strings.Index(reply.Content, stop); stop != "" && i >= 0 {
+			reply.Content = reply.Content[:i]
+		}
+	}
+	return reply, nil
+}
+
+// Chat returns the next scripted reply.
+func (m *FakeModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	reply, err := m.next(messages, opts)
+	if err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	return ChatCompletionResponse{
+		ID:    "fake",
+		Model: "fake",
+		Choices: []ChatCompletionChoice{{
+			Message:      reply,
+			FinishReason: fakeFinishReason(reply),
+		}},
+	}, nil
+}
+
+// ChatStream streams the next scripted reply in chunks; tool calls are sent in the final chunk.
+func (m *FakeModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	reply, err := m.next(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []ChatCompletionStreamResponse
+	for _, part := range splitRunes(reply.ReasoningContent, m.chunkSize) {
+		chunks = append(chunks, fakeChunk(ChatCompletionStreamDelta{ReasoningContent: part}, ""))
+	}
+	for _, part := range splitRunes(reply.Content, m.chunkSize) {
+		chunks = append(chunks, fakeChunk(ChatCompletionStreamDelta{Content: part}, ""))
+	}
+	final := ChatCompletionStreamDelta{}
+	for i, tc := range reply.ToolCalls {
+		final.ToolCalls = append(final.ToolCalls, ChatCompletionStreamToolCallDelta{
+			Index:             i,
+			ID:                tc.ID,
+			Type:              "function",
+			NameFragment:      tc.Name,
+			ArgumentsFragment: tc.Arguments,
+		})
+	}
+	chunks = append(chunks, fakeChunk(final, fakeFinishReason(reply)))
+
+	recv := func() (ChatCompletionStreamResponse, error) {
+		if err := ctx.Err(); err != nil {
+			return ChatCompletionStreamResponse{}, err
+		}
+		if len(chunks) == 0 {
+			return ChatCompletionStreamResponse{}, io.EOF
+		}
+		c := chunks[0]
+		chunks = chunks[1:]
+		return c, nil
+	}
+	return NewChatCompletionStream(recv, nil), nil
+}
+
+func fakeChunk(delta ChatCompletionStreamDelta, finishReason string) ChatCompletionStreamResponse {
+	return ChatCompletionStreamResponse{
+		ID:      "fake",
+		Model:   "fake",
+		Choices: []ChatCompletionStreamChoice{{Delta: delta, FinishReason: finishReason}},
+	}
+}
+
+func fakeFinishReason(reply ChatCompletionMessage) string {
+	if len(reply.ToolCalls) > 0 {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+func splitRunes(s string, size int) []string {
+	if s == "" {
+		return nil
+	}
+	runes := []rune(s)
+	var out []string
+	for len(runes) > 0 {
+		n := min(size, len(runes))
+		out = append(out, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// FallbackModel tries a chain of LLMs in order. It moves on to the next model only when
+// the error is retryable (network failure, 408, 429, 5xx, see [IsRetryable]); any other
+// error is returned immediately.
+//
+// Example:
+//
+//	llm := llms.NewFallbackModel(deepseek, openaiModel)
+//	agent := agents.CreateReactAgent(ctx, llm)
+type FallbackModel struct {
+	models []LLM
+
+	mu   sync.Mutex
+	last int
+}
+
+// NewFallbackModel returns a model that uses primary and falls back to fallbacks in order.
+func NewFallbackModel(primary LLM, fallbacks ...LLM) *FallbackModel {
+	return &FallbackModel{
+		models: append([]LLM{primary}, fallbacks...),
+		last:   -1,
+	}
+}
+
+// LastProvider describes the model that served the last successful request, or "" if none has.
+func (m *FallbackModel) LastProvider() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last < 0 {
+		return ""
+	}
+	return describeModel(m.models[m.last])
+}
+
+// ModelName implements [ModelNamer] with the primary model's name.
+func (m *FallbackModel) ModelName() string {
+	if n, ok := m.models[0].(ModelNamer); ok {
+		return n.ModelName()
+	}
+	return ""
+}
+
+// Provider implements [ModelDescriber] with the primary model's provider.
+func (m *FallbackModel) Provider() string {
+	if d, ok := m.models[0].(ModelDescriber); ok {
+		return d.Provider()
+	}
+	return ""
+}
+
+// Chat implements [LLM].
+func (m *FallbackModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.ChatWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatWithTools implements [ToolCaller]. Tools are dropped for members that do not implement it.
+func (m *FallbackModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return tryEach(m, func(model LLM) (ChatCompletionResponse, error) {
+		if tc, ok := model.(ToolCaller); ok && len(tools) > 0 {
+			return tc.ChatWithTools(ctx, messages, tools, opts...)
+		}
+		return model.Chat(ctx, messages, opts...)
+	})
+}
+
+// ChatStream implements [ChatStreamer]. Members that cannot stream are skipped.
+// Only errors opening the stream trigger a fallback; errors mid-stream are returned to the reader.
+func (m *FallbackModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	return m.ChatStreamWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatStreamWithTools implements [ToolCaller].
+func (m *FallbackModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
+	return tryEach(m, func(model LLM) (ChatStream, error) {
+		if tc, ok := model.(ToolCaller); ok {
+			return tc.ChatStreamWithTools(ctx, messages, tools, opts...)
+		}
+		if s, ok := model.(ChatStreamer); ok {
+			return s.ChatStream(ctx, messages, opts...)
+		}
+		return nil, errNotStreamer
+	})
+}
+
+var (
+	errNotStreamer = errors.New("model does not implement llms.ChatStreamer")
+	errNotEmbedder = errors.New("model does not implement llms.Embedder")
+)
+
+// Embeddings implements [Embedder] with the members that implement it, in order.
+func (m *FallbackModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	return tryEach(m, func(model LLM) ([][]float32, error) {
+		if e, ok := model.(Embedder); ok {
+			return e.Embeddings(ctx, inputs)
+		}
+		return nil, errNotEmbedder
+	})
+}
+
+func tryEach[T any](m *FallbackModel, call func(LLM) (T, error)) (T, error) {
+	var zero T
+	var errs []error
+	unsupported := errNotStreamer
+	for i, model := range m.models {
+		out, err := call(model)
+		if err == nil {
+			m.mu.Lock()
+			m.last = i
+			m.mu.Unlock()
+			return out, nil
+		}
+		if errors.Is(err, errNotStreamer) || errors.Is(err, errNotEmbedder) {
+			unsupported = err
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", describeModel(model), err))
+		if !IsRetryable(err) {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return zero, unsupported
+	}
+	return zero, errors.Join(errs...)
+}
+
+// describeModel returns a short label for a model, used in errors and LastProvider.
+func describeModel(model LLM) string {
+	if n, ok := model.(ModelNamer); ok && n.ModelName() != "" {
+		return fmt.Sprintf("%T(%s)", model, n.ModelName())
+	}
+	return fmt.Sprintf("%T", model)
+}
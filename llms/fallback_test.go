@@ -0,0 +1,119 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// namedModel gives a FakeModel a model name, so LastProvider tells members apart.
+type namedModel struct {
+	*FakeModel
+	name string
+}
+
+func (m namedModel) ModelName() string { return m.name }
+
+func TestFallbackModel(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []struct {
+		name         string
+		primaryErr   error
+		wantErr      bool
+		wantFallback bool
+	}{
+		{"success", nil, false, false},
+		{"network error", netErr, false, true},
+		{"request timeout", &APIError{StatusCode: 408}, false, true},
+		{"rate limited", &APIError{StatusCode: 429}, false, true},
+		{"server error", &APIError{StatusCode: 503}, false, true},
+		{"bad request", &APIError{StatusCode: 400}, true, false},
+		{"unauthorized", &APIError{StatusCode: 401}, true, false},
+		{"canceled", context.Canceled, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := NewFakeModel([]string{"from primary"})
+			if tt.primaryErr != nil {
+				primary.FailOnCall(1, tt.primaryErr)
+			}
+			backup := NewFakeModel([]string{"from backup"})
+			m := NewFallbackModel(namedModel{primary, "primary"}, namedModel{backup, "backup"})
+
+			res, err := m.Chat(context.Background(), []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}})
+			if tt.wantErr {
+				if !errors.Is(err, tt.primaryErr) {
+					t.Errorf("Chat error = %v, want the primary's error", err)
+				}
+				if backup.CallCount() != 0 || m.LastProvider() != "" {
+					t.Errorf("backup called %d times, LastProvider %q, want no fallback", backup.CallCount(), m.LastProvider())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, provider := "from primary", "llms.namedModel(primary)"
+			if tt.wantFallback {
+				want, provider = "from backup", "llms.namedModel(backup)"
+			}
+			if got := res.Choices[0].Message.Content; got != want {
+				t.Errorf("Chat = %q, want %q", got, want)
+			}
+			if m.LastProvider() != provider {
+				t.Errorf("LastProvider = %q, want %q", m.LastProvider(), provider)
+			}
+			if primary.CallCount() != 1 {
+				t.Errorf("primary called %d times, want 1", primary.CallCount())
+			}
+		})
+	}
+}
+
+func TestFallbackModelAllFail(t *testing.T) {
+	primary := NewFakeModel(nil).FailOnCall(1, &APIError{Provider: "a", StatusCode: 500})
+	backup := NewFakeModel(nil).FailOnCall(1, &APIError{Provider: "b", StatusCode: 429})
+	m := NewFallbackModel(namedModel{primary, "primary"}, namedModel{backup, "backup"})
+
+	_, err := m.Chat(context.Background(), nil)
+	var ae *APIError
+	if !errors.As(err, &ae) || !strings.Contains(err.Error(), "primary") || !strings.Contains(err.Error(), "backup") {
+		t.Errorf("Chat error = %v, want both members' errors", err)
+	}
+	if m.LastProvider() != "" {
+		t.Errorf("LastProvider = %q after failures only", m.LastProvider())
+	}
+
+	// a later success is remembered, and the next call starts again at the primary
+	primary = NewFakeModel([]string{"", "ok"}).FailOnCall(1, &APIError{StatusCode: 502})
+	backup = NewFakeModel([]string{"backup"})
+	m = NewFallbackModel(namedModel{primary, "primary"}, namedModel{backup, "backup"})
+	for _, want := range []string{"llms.namedModel(backup)", "llms.namedModel(primary)"} {
+		if _, err := m.Chat(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+		if m.LastProvider() != want {
+			t.Errorf("LastProvider = %q, want %q", m.LastProvider(), want)
+		}
+	}
+}
+
+// Members that cannot stream are skipped by ChatStream.
+func TestFallbackModelStreamSkipsNonStreamers(t *testing.T) {
+	backup := NewFakeStreamingModel([]string{"streamed"}, 3)
+	m := NewFallbackModel(chatOnly{NewFakeModel([]string{"unused"})}, namedModel{backup, "backup"})
+	stream, err := m.ChatStream(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+	if backup.CallCount() != 1 || m.LastProvider() != "llms.namedModel(backup)" {
+		t.Errorf("backup called %d times, LastProvider %q", backup.CallCount(), m.LastProvider())
+	}
+
+	if _, err := NewFallbackModel(chatOnly{NewFakeModel(nil)}).ChatStream(context.Background(), nil); !errors.Is(err, errNotStreamer) {
+		t.Errorf("ChatStream without streamers = %v, want errNotStreamer", err)
+	}
+}
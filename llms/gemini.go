@@ -0,0 +1,506 @@
+package llms
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+)
+
+const defaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiModel implements [LLM], [ChatStreamer], [ToolCaller] and [Embedder] against the Google
+// Gemini REST API (generateContent, streamGenerateContent and batchEmbedContents).
+//
+// Example:
+//
+//	llm := llms.NewGeminiModel(llms.Config{
+//	    APIKey: os.Getenv("GEMINI_API_KEY"),
+//	    Model:  "gemini-2.0-flash",
+//	})
+type GeminiModel struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	sampling   samplingParams
+	dims       int
+	usage      UsageCollector
+}
+
+// NewGeminiModel builds a Gemini client. BaseURL defaults to https://generativelanguage.googleapis.com/v1beta.
+func NewGeminiModel(cfg Config) *GeminiModel {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultGeminiBaseURL
+	}
+	return &GeminiModel{
+		httpClient: cfg.httpClient(),
+		baseURL:    baseURL,
+		apiKey:     cfg.APIKey,
+		model:      strings.TrimPrefix(cfg.Model, "models/"),
+		sampling:   newSamplingParams(cfg),
+		dims:       cfg.Dimensions,
+		usage:      cfg.UsageCollector,
+	}
+}
+
+// ModelName implements [ModelNamer].
+func (m *GeminiModel) ModelName() string {
+	return m.model
+}
+
+// Provider implements [ModelDescriber].
+func (m *GeminiModel) Provider() string {
+	return "gemini"
+}
+
+// Chat calls models/{model}:generateContent.
+func (m *GeminiModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.ChatWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatWithTools implements [ToolCaller]: function tools are sent as functionDeclarations with
+// their JSON Schema parameters, and the functionCall parts of the reply become ToolCalls.
+func (m *GeminiModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
+	co := NewCallOptions(opts...)
+	var resp geminiResponse
+	if err := postJSON(ctx, m.httpClient, "gemini", m.endpoint(co.Model, "generateContent", nil), m.header(), m.request(messages, tools, co), &resp); err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	if resp.Error != nil {
+		return ChatCompletionResponse{}, fmt.Errorf("gemini: %s", resp.Error.Message)
+	}
+
+	out := ChatCompletionResponse{
+		ID:    resp.ResponseID,
+		Model: m.model,
+		Usage: resp.UsageMetadata.usage(),
+	}
+	for i, cand := range resp.Candidates {
+		msg := cand.Content.message()
+		out.Choices = append(out.Choices, ChatCompletionChoice{
+			Index:        i,
+			Message:      msg,
+			FinishReason: geminiFinishReason(cand.FinishReason, len(msg.ToolCalls) > 0),
+		})
+	}
+	collectUsage(m.usage, m.model, out)
+	return out, nil
+}
+
+// ChatStream calls models/{model}:streamGenerateContent with alt=sse.
+func (m *GeminiModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	return m.ChatStreamWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatStreamWithTools is the streaming form of [GeminiModel.ChatWithTools]. Gemini sends each
+// function call whole, so every tool call arrives in a single delta.
+func (m *GeminiModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
+	co := NewCallOptions(opts...)
+	resp, err := doJSON(ctx, m.httpClient, "gemini", http.MethodPost, m.endpoint(co.Model, "streamGenerateContent", url.Values{"alt": {"sse"}}), m.header(), m.request(messages, tools, co))
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	toolIndex := 0
+
+	recv := func() (ChatCompletionStreamResponse, error) {
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			data, ok := strings.CutPrefix(line, "data:")
+			if !ok {
+				continue
+			}
+			var chunk geminiResponse
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &chunk); err != nil {
+				return ChatCompletionStreamResponse{}, fmt.Errorf("gemini: decode stream chunk: %w", err)
+			}
+			if chunk.Error != nil {
+				return ChatCompletionStreamResponse{}, fmt.Errorf("gemini: %s", chunk.Error.Message)
+			}
+
+			out := ChatCompletionStreamResponse{ID: chunk.ResponseID, Model: m.model}
+			finished := false
+			for i, cand := range chunk.Candidates {
+				msg := cand.Content.message()
+				delta := ChatCompletionStreamDelta{Content: msg.Content}
+				for _, tc := range msg.ToolCalls {
+					delta.ToolCalls = append(delta.ToolCalls, ChatCompletionStreamToolCallDelta{
+						Index:             toolIndex,
+						ID:                tc.ID,
+						Type:              "function",
+						NameFragment:      tc.Name,
+						ArgumentsFragment: tc.Arguments,
+					})
+					toolIndex++
+				}
+				reason := geminiFinishReason(cand.FinishReason, len(msg.ToolCalls) > 0 || toolIndex > 0)
+				if cand.FinishReason != "" {
+					finished = true
+				}
+				out.Choices = append(out.Choices, ChatCompletionStreamChoice{Index: i, Delta: delta, FinishReason: reason})
+			}
+			// usageMetadata is cumulative on every chunk; only report it once, on the final chunk.
+			if finished && chunk.UsageMetadata != nil {
+				u := chunk.UsageMetadata.usage()
+				out.Usage = &u
+			}
+			return out, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return ChatCompletionStreamResponse{}, err
+		}
+		return ChatCompletionStreamResponse{}, io.EOF
+	}
+
+	return collectStreamUsage(m.usage, m.model, NewChatCompletionStream(recv, resp.Body.Close)), nil
+}
+
+// Embeddings calls models/{model}:batchEmbedContents.
+func (m *GeminiModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	req := geminiBatchEmbedRequest{Requests: make([]geminiEmbedRequest, 0, len(inputs))}
+	for _, in := range inputs {
+		req.Requests = append(req.Requests, geminiEmbedRequest{
+			Model:                "models/" + m.model,
+			Content:              geminiContent{Parts: []geminiPart{{Text: in}}},
+			OutputDimensionality: m.dims,
+		})
+	}
+
+	var resp struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	}
+	if err := postJSON(ctx, m.httpClient, "gemini", m.endpoint("", "batchEmbedContents", nil), m.header(), req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) == 0 {
+		return nil, fmt.Errorf("未返回嵌入数据")
+	}
+	out := make([][]float32, len(resp.Embeddings))
+	for i, e := range resp.Embeddings {
+		out[i] = e.Values
+	}
+	return out, nil
+}
+
+// endpoint builds the URL for method on model, or on the configured model when model is empty.
+func (m *GeminiModel) endpoint(model, method string, query url.Values) string {
+	if model == "" {
+		model = m.model
+	}
+	u := fmt.Sprintf("%s/models/%s:%s", m.baseURL, strings.TrimPrefix(model, "models/"), method)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (m *GeminiModel) header() http.Header {
+	h := http.Header{}
+	if m.apiKey != "" {
+		h.Set("x-goog-api-key", m.apiKey)
+	}
+	return h
+}
+
+// geminiParts converts multimodal parts. Data URLs become inlineData; other URLs become fileData.
+func geminiParts(parts []ChatMessagePart) []geminiPart {
+	out := make([]geminiPart, 0, len(parts))
+	for _, p := range parts {
+		if p.Type != ChatMessagePartTypeImageURL {
+			out = append(out, geminiPart{Text: p.Text})
+			continue
+		}
+		if rest, ok := strings.CutPrefix(p.ImageURL, "data:"); ok {
+			if meta, data, ok := strings.Cut(rest, ","); ok {
+				out = append(out, geminiPart{InlineData: &geminiBlob{
+					MimeType: strings.TrimSuffix(meta, ";base64"),
+					Data:     data,
+				}})
+				continue
+			}
+		}
+		out = append(out, geminiPart{FileData: &geminiFileData{FileURI: p.ImageURL}})
+	}
+	return out
+}
+
+// geminiTools converts the function tools to a Gemini tool of functionDeclarations; other
+// tool types are skipped.
+func geminiTools(tools []openai.ChatCompletionToolUnionParam) []geminiTool {
+	var decls []geminiFunctionDeclaration
+	for _, t := range tools {
+		fn := t.GetFunction()
+		if fn == nil {
+			continue
+		}
+		decl := geminiFunctionDeclaration{Name: fn.Name, Description: fn.Description.Value}
+		if len(fn.Parameters) > 0 {
+			decl.ParametersJSONSchema = fn.Parameters
+		}
+		decls = append(decls, decl)
+	}
+	if len(decls) == 0 {
+		return nil
+	}
+	return []geminiTool{{FunctionDeclarations: decls}}
+}
+
+// request converts chat messages to a generateContent body. System messages become
+// systemInstruction; assistant turns use role "model"; tool results become functionResponse parts.
+func (m *GeminiModel) request(messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, co CallOptions) geminiRequest {
+	req := geminiRequest{Tools: geminiTools(tools)}
+	toolNames := map[string]string{}
+	var system []string
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case ChatMessageRoleSystem:
+			system = append(system, msg.Content)
+		case ChatMessageRoleAssistant:
+			c := geminiContent{Role: "model"}
+			if msg.Content != "" {
+				c.Parts = append(c.Parts, geminiPart{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Name
+				args := map[string]any{}
+				if tc.Arguments != "" {
+					_ = json.Unmarshal([]byte(tc.Arguments), &args)
+				}
+				c.Parts = append(c.Parts, geminiPart{FunctionCall: &geminiFunctionCall{Name: tc.Name, Args: args}})
+			}
+			if len(c.Parts) > 0 {
+				req.Contents = append(req.Contents, c)
+			}
+		case ChatMessageRoleTool:
+			req.Contents = append(req.Contents, geminiContent{
+				Role: "user",
+				Parts: []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+					Name:     toolNames[msg.ToolCallID],
+					Response: map[string]any{"content": msg.Content},
+				}}},
+			})
+		default:
+			if len(msg.MultiContent) > 0 {
+				req.Contents = append(req.Contents, geminiContent{Role: "user", Parts: geminiParts(msg.MultiContent)})
+				continue
+			}
+			req.Contents = append(req.Contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: msg.Content}}})
+		}
+	}
+
+	if len(system) > 0 {
+		req.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: strings.Join(system, "\n\n")}}}
+	}
+
+	sp := m.sampling
+	gc := &geminiGenerationConfig{StopSequences: sp.stop, Seed: sp.seed}
+	if sp.temperature != 0 {
+		gc.Temperature = &sp.temperature
+	}
+	if sp.topP != 0 {
+		gc.TopP = &sp.topP
+	}
+	if sp.maxTokens > 0 {
+		gc.MaxOutputTokens = sp.maxTokens
+	}
+	if sp.presencePenalty != 0 {
+		gc.PresencePenalty = &sp.presencePenalty
+	}
+	if sp.frequencyPenalty != 0 {
+		gc.FrequencyPenalty = &sp.frequencyPenalty
+	}
+	if co.Temperature != nil {
+		gc.Temperature = co.Temperature
+	}
+	if co.MaxTokens > 0 {
+		gc.MaxOutputTokens = co.MaxTokens
+	}
+	if len(co.Stop) > 0 {
+		gc.StopSequences = co.Stop
+	}
+	if co.Seed != nil {
+		gc.Seed = co.Seed
+	}
+	req.GenerationConfig = gc
+	return req
+}
+
+// geminiFinishReason maps Gemini finish reasons onto the OpenAI values the agent understands.
+func geminiFinishReason(reason string, hasToolCalls bool) string {
+	if reason == "" {
+		return ""
+	}
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	switch reason {
+	case "STOP":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return "content_filter"
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+// --- wire format ---
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent         `json:"contents"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name                 string         `json:"name"`
+	Description          string         `json:"description,omitempty"`
+	ParametersJSONSchema map[string]any `json:"parametersJsonSchema,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type geminiFunctionCall struct {
+	// ID is set by the API on some models; it is not sent back.
+	ID   string         `json:"id,omitempty"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type geminiResponse struct {
+	ResponseID string `json:"responseId"`
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata *geminiUsage `json:"usageMetadata"`
+	Error         *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type geminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+func (u *geminiUsage) usage() ChatUsage {
+	if u == nil {
+		return ChatUsage{}
+	}
+	return ChatUsage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		TotalTokens:      u.TotalTokenCount,
+	}
+}
+
+// message converts a Gemini content block to an assistant message; thought parts become ReasoningContent.
+func (c geminiContent) message() ChatCompletionMessage {
+	msg := ChatCompletionMessage{Role: ChatMessageRoleAssistant}
+	var text, reasoning strings.Builder
+	for _, p := range c.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			args, _ := json.Marshal(p.FunctionCall.Args)
+			id := p.FunctionCall.ID
+			if id == "" {
+				id = geminiCallID()
+			}
+			msg.ToolCalls = append(msg.ToolCalls, ChatToolCall{
+				ID:        id,
+				Name:      p.FunctionCall.Name,
+				Arguments: string(args),
+			})
+		case p.Thought:
+			reasoning.WriteString(p.Text)
+		default:
+			text.WriteString(p.Text)
+		}
+	}
+	msg.Content = text.String()
+	msg.ReasoningContent = reasoning.String()
+	return msg
+}
+
+// geminiCallID returns a random tool call ID for function calls the API sent without one. IDs
+// must be unique across the conversation, not just the reply: agents and memories match tool
+// results to their calls by ID.
+func geminiCallID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "call_" + hex.EncodeToString(b[:])
+}
+
+type geminiBatchEmbedRequest struct {
+	Requests []geminiEmbedRequest `json:"requests"`
+}
+
+type geminiEmbedRequest struct {
+	Model                string        `json:"model"`
+	Content              geminiContent `json:"content"`
+	OutputDimensionality int           `json:"outputDimensionality,omitempty"`
+}
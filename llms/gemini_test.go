@@ -0,0 +1,162 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// geminiServer answers every request with reply and records the decoded request bodies.
+func geminiServer(t *testing.T, reply string) (*GeminiModel, *[]map[string]any) {
+	t.Helper()
+	var requests []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		requests = append(requests, body)
+		fmt.Fprint(w, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return NewGeminiModel(Config{BaseURL: srv.URL, Model: "gemini-test"}), &requests
+}
+
+var weatherTool = openai.ChatCompletionFunctionTool(shared.FunctionDefinitionParam{
+	Name:        "weather",
+	Description: openai.String("Current weather of a city"),
+	Parameters: shared.FunctionParameters{
+		"type":       "object",
+		"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		"required":   []string{"city"},
+	},
+})
+
+func TestGeminiModelChatWithTools(t *testing.T) {
+	ctx := context.Background()
+	m, requests := geminiServer(t, `{"candidates":[{"content":{"role":"model","parts":[
+		{"functionCall":{"name":"weather","args":{"city":"Paris"}}},
+		{"functionCall":{"name":"weather","args":{"city":"Rome"}}},
+		{"functionCall":{"id":"server-id","name":"weather","args":{"city":"Oslo"}}}
+	]},"finishReason":"STOP"}]}`)
+
+	var _ ToolCaller = m
+	res, err := m.ChatWithTools(ctx, []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "weather?"}}, []openai.ChatCompletionToolUnionParam{weatherTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decl := (*requests)[0]["tools"].([]any)[0].(map[string]any)["functionDeclarations"].([]any)[0].(map[string]any)
+	if decl["name"] != "weather" || decl["description"] != "Current weather of a city" {
+		t.Errorf("function declaration = %v", decl)
+	}
+	if params, _ := decl["parametersJsonSchema"].(map[string]any); params["type"] != "object" || params["required"] == nil {
+		t.Errorf("parametersJsonSchema = %v, want the tool's JSON Schema", decl["parametersJsonSchema"])
+	}
+
+	choice := res.Choices[0]
+	if choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) != 3 {
+		t.Fatalf("choice = %+v, want 3 tool calls", choice)
+	}
+	calls := choice.Message.ToolCalls
+	if calls[0].Arguments != `{"city":"Paris"}` || calls[2].ID != "server-id" {
+		t.Errorf("tool calls = %+v", calls)
+	}
+	if calls[0].ID == calls[1].ID || calls[0].ID == "" {
+		t.Errorf("tool call IDs %q and %q are not unique", calls[0].ID, calls[1].ID)
+	}
+
+	// a later reply calling the same tool gets other IDs
+	again, err := m.ChatWithTools(ctx, nil, []openai.ChatCompletionToolUnionParam{weatherTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := again.Choices[0].Message.ToolCalls[0].ID; id == calls[0].ID || id == calls[1].ID {
+		t.Errorf("tool call ID %q repeats one of the previous reply", id)
+	}
+}
+
+func TestGeminiModelChatWithoutTools(t *testing.T) {
+	m, requests := geminiServer(t, `{"candidates":[{"content":{"parts":[{"text":"hi"}]},"finishReason":"STOP"}]}`)
+	res, err := m.Chat(context.Background(), []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hello"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Choices[0].Message.Content != "hi" || res.Choices[0].FinishReason != "stop" {
+		t.Errorf("Chat = %+v", res)
+	}
+	if _, ok := (*requests)[0]["tools"]; ok {
+		t.Error("request without tools has a tools field")
+	}
+}
+
+// Tool results are sent back as functionResponse parts named after their call.
+func TestGeminiModelToolResults(t *testing.T) {
+	m, requests := geminiServer(t, `{"candidates":[{"content":{"parts":[{"text":"sunny"}]},"finishReason":"STOP"}]}`)
+	history := []ChatCompletionMessage{
+		{Role: ChatMessageRoleUser, Content: "weather in Paris?"},
+		{Role: ChatMessageRoleAssistant, ToolCalls: []ChatToolCall{{ID: "call_a", Name: "weather", Arguments: `{"city":"Paris"}`}}},
+		{Role: ChatMessageRoleTool, ToolCallID: "call_a", Content: "22C"},
+	}
+	if _, err := m.ChatWithTools(context.Background(), history, []openai.ChatCompletionToolUnionParam{weatherTool}); err != nil {
+		t.Fatal(err)
+	}
+	contents := (*requests)[0]["contents"].([]any)
+	call := contents[1].(map[string]any)["parts"].([]any)[0].(map[string]any)["functionCall"].(map[string]any)
+	if call["name"] != "weather" || call["args"].(map[string]any)["city"] != "Paris" {
+		t.Errorf("functionCall = %v", call)
+	}
+	resp := contents[2].(map[string]any)["parts"].([]any)[0].(map[string]any)["functionResponse"].(map[string]any)
+	if resp["name"] != "weather" || resp["response"].(map[string]any)["content"] != "22C" {
+		t.Errorf("functionResponse = %v", resp)
+	}
+}
+
+func TestGeminiModelChatStreamWithTools(t *testing.T) {
+	m, requests := geminiServer(t, strings.Join([]string{
+		`data: {"candidates":[{"content":{"parts":[{"text":"Checking"}]}}]}`,
+		`data: {"candidates":[{"content":{"parts":[{"functionCall":{"name":"weather","args":{"city":"Paris"}}},{"functionCall":{"name":"weather","args":{"city":"Rome"}}}]},"finishReason":"STOP"}]}`,
+		"",
+	}, "\n\n"))
+	stream, err := m.ChatStreamWithTools(context.Background(), nil, []openai.ChatCompletionToolUnionParam{weatherTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var deltas []ChatCompletionStreamToolCallDelta
+	var reason string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range chunk.Choices {
+			deltas = append(deltas, c.Delta.ToolCalls...)
+			if c.FinishReason != "" {
+				reason = c.FinishReason
+			}
+		}
+	}
+	if len((*requests)[0]["tools"].([]any)) != 1 {
+		t.Errorf("stream request tools = %v", (*requests)[0]["tools"])
+	}
+	if len(deltas) != 2 || reason != "tool_calls" {
+		t.Fatalf("tool call deltas = %+v, finish reason %q", deltas, reason)
+	}
+	if deltas[0].Index != 0 || deltas[1].Index != 1 || deltas[0].ID == deltas[1].ID || deltas[1].ArgumentsFragment != `{"city":"Rome"}` {
+		t.Errorf("tool call deltas = %+v, want whole calls with unique IDs", deltas)
+	}
+}
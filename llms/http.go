@@ -0,0 +1,100 @@
+package llms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// APIError is a non-2xx response from a REST provider that is not served through the OpenAI SDK.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: HTTP %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Unwrap returns the sentinel matching the status and body (e.g. [ErrRateLimited]), or nil.
+func (e *APIError) Unwrap() error {
+	return classifyStatus(e.StatusCode, "", e.Body)
+}
+
+// doJSON sends body as JSON and returns the raw response. Non-2xx responses are returned as *APIError
+// (with the body already consumed). The caller must close the returned body.
+func doJSON(ctx context.Context, client *http.Client, provider, method, url string, header http.Header, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: marshal request: %w", provider, err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: build request: %w", provider, err)
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		resp.Body.Close()
+		return nil, &APIError{Provider: provider, StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(data))}
+	}
+	return resp, nil
+}
+
+// postJSON posts body and decodes the JSON response into out.
+func postJSON(ctx context.Context, client *http.Client, provider, url string, header http.Header, body, out any) error {
+	resp, err := doJSON(ctx, client, provider, http.MethodPost, url, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: decode response: %w", provider, err)
+	}
+	return nil
+}
+
+// headerTransport sets fixed headers on every outgoing request.
+type headerTransport struct {
+	base   http.RoundTripper
+	header map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.header {
+		req.Header.Set(k, v)
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -1,19 +1,77 @@
 package llms
 
-import "context"
+import (
+	"context"
+
+	"github.com/openai/openai-go/v3"
+)
 
 // LLM is the interface that all language models must implement.
 // It provides a standard way for agents to interact with different LLM providers.
 type LLM interface {
 	// Chat sends a chat completion request to the LLM and returns the response.
-	// The messages parameter contains the conversation history.
-	Chat(ctx context.Context, messages []ChatCompletionMessage) (ChatCompletionResponse, error)
+	// The messages parameter contains the conversation history; opts override model defaults for this call.
+	Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error)
+}
+
+// ChatStream is a provider-neutral stream of chat completion chunks.
+// Recv returns io.EOF after the last chunk; Close releases the underlying connection.
+type ChatStream interface {
+	Recv() (ChatCompletionStreamResponse, error)
+	Close() error
 }
 
 // ChatStreamer is an optional interface for LLMs that support streaming responses.
 type ChatStreamer interface {
 	// ChatStream sends a chat completion request and returns a stream of responses.
-	ChatStream(ctx context.Context, messages []ChatCompletionMessage) (*ChatCompletionStream, error)
+	ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error)
+}
+
+// ToolCaller is an optional interface for LLMs that accept native OpenAI function tools.
+// The agent sends its MCP tools only to LLMs implementing it.
+type ToolCaller interface {
+	// ChatWithTools is like Chat but registers tools when non-empty.
+	ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error)
+
+	// ChatStreamWithTools is like ChatStream but registers tools when non-empty.
+	ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error)
+}
+
+// ModelNamer is an optional interface for LLMs that expose their configured model name,
+// e.g. "gpt-4o". Agents use it to pick a matching tokenizer.
+type ModelNamer interface {
+	ModelName() string
+}
+
+// ModelDescriber is an optional interface for LLMs that also report which provider serves them,
+// e.g. "openai" or "gemini". Wrapper models report the innermost model.
+type ModelDescriber interface {
+	ModelNamer
+	Provider() string
+}
+
+// DescribeModel returns the model name and provider of model, or empty strings for the parts
+// it does not expose.
+func DescribeModel(model LLM) (name, provider string) {
+	if n, ok := model.(ModelNamer); ok {
+		name = n.ModelName()
+	}
+	if d, ok := model.(ModelDescriber); ok {
+		provider = d.Provider()
+	}
+	return name, provider
+}
+
+// RerankResult is one document in a rerank response, in descending relevance order.
+type RerankResult struct {
+	// Index is the position of the document in the input slice.
+	Index int
+	Score float64
+}
+
+// Reranker is an interface for models that reorder documents by relevance to a query.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, documents []string, topN int) ([]RerankResult, error)
 }
 
 // Embedder is an interface for models that support generating embeddings.
@@ -0,0 +1,245 @@
+package llms
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// LlamaCppModel implements [LLM], [ChatStreamer], [ToolCaller] and [Embedder] against the
+// llama.cpp server. Chat goes to /v1/chat/completions; servers without the OpenAI-compatible
+// route (404) are served through the native /completion endpoint instead. [Config.Grammar] and
+// [WithCallGrammar] constrain the output with a GBNF grammar on both routes.
+//
+// Example:
+//
+//	llm := llms.NewLlamaCppModel(llms.Config{
+//	    BaseURL: "http://localhost:8080",
+//	    Grammar: `root ::= "yes" | "no"`,
+//	})
+type LlamaCppModel struct {
+	// PromptTemplate renders messages into the raw prompt for /completion. Default is ChatML.
+	PromptTemplate func(messages []ChatCompletionMessage) string
+
+	compat     *OpenAIModel
+	native     atomic.Bool
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	sampling   samplingParams
+	usage      UsageCollector
+}
+
+// NewLlamaCppModel builds a llama.cpp server client. BaseURL is the server root (e.g.
+// http://localhost:8080), without /v1. Model is optional; the server answers with its loaded model.
+func NewLlamaCppModel(cfg Config) *LlamaCppModel {
+	baseURL := strings.TrimSuffix(strings.TrimRight(cfg.BaseURL, "/"), "/v1")
+	compatCfg := cfg
+	compatCfg.BaseURL = baseURL + "/v1"
+	return &LlamaCppModel{
+		compat:     NewOpenAIModel(compatCfg),
+		httpClient: cfg.httpClient(),
+		baseURL:    baseURL,
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		sampling:   newSamplingParams(cfg),
+		usage:      cfg.UsageCollector,
+	}
+}
+
+// ModelName implements [ModelNamer].
+func (m *LlamaCppModel) ModelName() string {
+	return m.model
+}
+
+// Provider implements [ModelDescriber].
+func (m *LlamaCppModel) Provider() string {
+	return "llama.cpp"
+}
+
+// Chat uses /v1/chat/completions, or /completion when the server lacks the OpenAI-compatible route.
+func (m *LlamaCppModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	if !m.native.Load() {
+		resp, err := m.compat.Chat(ctx, messages, opts...)
+		if !isNotFound(err) {
+			return resp, err
+		}
+		m.native.Store(true)
+	}
+
+	var resp llamaCppCompletion
+	if err := postJSON(ctx, m.httpClient, "llama.cpp", m.baseURL+"/completion", m.header(), m.request(messages, NewCallOptions(opts...), false), &resp); err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	out := ChatCompletionResponse{
+		Model: m.model,
+		Choices: []ChatCompletionChoice{{
+			Message:      ChatCompletionMessage{Role: ChatMessageRoleAssistant, Content: resp.Content},
+			FinishReason: resp.finishReason(),
+		}},
+		Usage: resp.usage(),
+	}
+	collectUsage(m.usage, m.model, out)
+	return out, nil
+}
+
+// ChatStream uses /v1/chat/completions with stream=true, or /completion with stream=true when
+// the server lacks the OpenAI-compatible route.
+func (m *LlamaCppModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	if !m.native.Load() {
+		stream, err := m.compat.ChatStream(ctx, messages, opts...)
+		if !isNotFound(err) {
+			return stream, err
+		}
+		m.native.Store(true)
+	}
+
+	resp, err := doJSON(ctx, m.httpClient, "llama.cpp", http.MethodPost, m.baseURL+"/completion", m.header(), m.request(messages, NewCallOptions(opts...), true))
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	recv := func() (ChatCompletionStreamResponse, error) {
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "data:")
+			if !ok {
+				continue
+			}
+			var event llamaCppCompletion
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+				return ChatCompletionStreamResponse{}, fmt.Errorf("llama.cpp: decode stream event: %w", err)
+			}
+			if event.Error != nil {
+				return ChatCompletionStreamResponse{}, fmt.Errorf("llama.cpp: %s", event.Error.Message)
+			}
+
+			choice := ChatCompletionStreamChoice{Delta: ChatCompletionStreamDelta{Content: event.Content}}
+			out := ChatCompletionStreamResponse{Model: m.model}
+			if event.Stop {
+				choice.FinishReason = event.finishReason()
+				u := event.usage()
+				out.Usage = &u
+			}
+			out.Choices = []ChatCompletionStreamChoice{choice}
+			return out, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return ChatCompletionStreamResponse{}, err
+		}
+		return ChatCompletionStreamResponse{}, io.EOF
+	}
+
+	return collectStreamUsage(m.usage, m.model, NewChatCompletionStream(recv, resp.Body.Close)), nil
+}
+
+// ChatWithTools implements [ToolCaller] on the OpenAI-compatible route (llama.cpp started with --jinja).
+// llama.cpp rejects requests that combine tools with a custom grammar.
+func (m *LlamaCppModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.compat.ChatWithTools(ctx, messages, tools, opts...)
+}
+
+// ChatStreamWithTools is the streaming form of [LlamaCppModel.ChatWithTools].
+func (m *LlamaCppModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
+	return m.compat.ChatStreamWithTools(ctx, messages, tools, opts...)
+}
+
+// Embeddings calls /v1/embeddings (server started with --embedding).
+func (m *LlamaCppModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	return m.compat.Embeddings(ctx, inputs)
+}
+
+func (m *LlamaCppModel) header() http.Header {
+	h := http.Header{}
+	if m.apiKey != "" {
+		h.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	return h
+}
+
+func (m *LlamaCppModel) request(messages []ChatCompletionMessage, co CallOptions, stream bool) llamaCppRequest {
+	render := m.PromptTemplate
+	if render == nil {
+		render = chatMLPrompt
+	}
+	sp := m.sampling
+	req := llamaCppRequest{
+		Prompt:  render(messages),
+		Stop:    sp.stop,
+		Seed:    sp.seed,
+		Grammar: sp.grammar,
+		Stream:  stream,
+	}
+	if sp.temperature != 0 {
+		req.Temperature = &sp.temperature
+	}
+	if sp.topP != 0 {
+		req.TopP = &sp.topP
+	}
+	if sp.maxTokens > 0 {
+		req.NPredict = sp.maxTokens
+	}
+	if co.Temperature != nil {
+		req.Temperature = co.Temperature
+	}
+	if co.MaxTokens > 0 {
+		req.NPredict = co.MaxTokens
+	}
+	if len(co.Stop) > 0 {
+		req.Stop = co.Stop
+	}
+	if co.Seed != nil {
+		req.Seed = co.Seed
+	}
+	if co.Grammar != "" {
+		req.Grammar = co.Grammar
+	}
+	return req
+}
+
+type llamaCppRequest struct {
+	Prompt      string   `json:"prompt"`
+	NPredict    int      `json:"n_predict,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Grammar     string   `json:"grammar,omitempty"`
+	Stream      bool     `json:"stream,omitempty"`
+}
+
+type llamaCppCompletion struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	StoppedLimit    bool   `json:"stopped_limit"`
+	TokensPredicted int    `json:"tokens_predicted"`
+	TokensEvaluated int    `json:"tokens_evaluated"`
+	Error           *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c llamaCppCompletion) finishReason() string {
+	if c.StoppedLimit {
+		return "length"
+	}
+	return "stop"
+}
+
+func (c llamaCppCompletion) usage() ChatUsage {
+	return ChatUsage{
+		PromptTokens:     c.TokensEvaluated,
+		CompletionTokens: c.TokensPredicted,
+		TotalTokens:      c.TokensEvaluated + c.TokensPredicted,
+	}
+}
@@ -0,0 +1,225 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// LoggingOption configures a [LoggingModel].
+type LoggingOption func(*LoggingModel)
+
+// WithRedactor adds a function applied to every logged message and response content.
+// Redactors run in the order they are added.
+func WithRedactor(redact func(string) string) LoggingOption {
+	return func(m *LoggingModel) {
+		if redact != nil {
+			m.redactors = append(m.redactors, redact)
+		}
+	}
+}
+
+var secretPattern = regexp.MustCompile(`(?i)(sk-[a-z0-9_\-]{8,}|bearer\s+[a-z0-9._\-]{8,}|AIza[0-9a-z_\-]{20,})`)
+
+// RedactSecrets replaces strings that look like API keys or bearer tokens with "[REDACTED]".
+// It is always applied by [LoggingModel] before any user redactors.
+func RedactSecrets(s string) string {
+	return secretPattern.ReplaceAllString(s, "[REDACTED]")
+}
+
+// LoggingModel wraps an [LLM] and logs each request and response at debug level: the
+// messages sent, the reply content, latency and token usage. Streams are logged once,
+// with the assembled output, when they end.
+//
+// Example:
+//
+//	llm := llms.NewLoggingModel(openaiModel, slog.Default(),
+//	    llms.WithRedactor(func(s string) string { return emailRe.ReplaceAllString(s, "[email]") }),
+//	)
+type LoggingModel struct {
+	inner     LLM
+	logger    *slog.Logger
+	redactors []func(string) string
+}
+
+// NewLoggingModel wraps inner. A nil logger uses slog.Default().
+func NewLoggingModel(inner LLM, logger *slog.Logger, opts ...LoggingOption) *LoggingModel {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	m := &LoggingModel{inner: inner, logger: logger, redactors: []func(string) string{RedactSecrets}}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// ModelName implements [ModelNamer] with the wrapped model's name.
+func (m *LoggingModel) ModelName() string {
+	if n, ok := m.inner.(ModelNamer); ok {
+		return n.ModelName()
+	}
+	return ""
+}
+
+// Provider implements [ModelDescriber] with the wrapped model's provider.
+func (m *LoggingModel) Provider() string {
+	if d, ok := m.inner.(ModelDescriber); ok {
+		return d.Provider()
+	}
+	return ""
+}
+
+// Chat implements [LLM].
+func (m *LoggingModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.ChatWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatWithTools implements [ToolCaller]. Tools are dropped when the inner model does not implement it.
+func (m *LoggingModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
+	m.logRequest(ctx, "chat", messages, len(tools))
+	start := time.Now()
+
+	var resp ChatCompletionResponse
+	var err error
+	if tc, ok := m.inner.(ToolCaller); ok && len(tools) > 0 {
+		resp, err = tc.ChatWithTools(ctx, messages, tools, opts...)
+	} else {
+		resp, err = m.inner.Chat(ctx, messages, opts...)
+	}
+	m.logResponse(ctx, "chat", resp, time.Since(start), err)
+	return resp, err
+}
+
+// ChatStream implements [ChatStreamer].
+func (m *LoggingModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	return m.ChatStreamWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatStreamWithTools implements [ToolCaller].
+func (m *LoggingModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
+	m.logRequest(ctx, "stream", messages, len(tools))
+	start := time.Now()
+
+	var stream ChatStream
+	var err error
+	if tc, ok := m.inner.(ToolCaller); ok {
+		stream, err = tc.ChatStreamWithTools(ctx, messages, tools, opts...)
+	} else if s, ok := m.inner.(ChatStreamer); ok {
+		stream, err = s.ChatStream(ctx, messages, opts...)
+	} else {
+		err = errNotStreamer
+	}
+	if err != nil {
+		m.logResponse(ctx, "stream", ChatCompletionResponse{}, time.Since(start), err)
+		return nil, err
+	}
+
+	acc := newStreamAccumulator()
+	logged := false
+	recv := func() (ChatCompletionStreamResponse, error) {
+		chunk, err := stream.Recv()
+		if err == nil {
+			acc.add(chunk)
+			return chunk, nil
+		}
+		if !logged {
+			logged = true
+			if errors.Is(err, io.EOF) {
+				m.logResponse(ctx, "stream", acc.response(), time.Since(start), nil)
+			} else {
+				m.logResponse(ctx, "stream", acc.response(), time.Since(start), err)
+			}
+		}
+		return chunk, err
+	}
+	return NewChatCompletionStream(recv, stream.Close), nil
+}
+
+// Embeddings implements [Embedder] when the wrapped model does, logging the input count and latency.
+func (m *LoggingModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	e, ok := m.inner.(Embedder)
+	if !ok {
+		return nil, errNotEmbedder
+	}
+	start := time.Now()
+	out, err := e.Embeddings(ctx, inputs)
+	attrs := []any{
+		slog.String("op", "embeddings"),
+		slog.String("model", describeModel(m.inner)),
+		slog.Duration("latency", time.Since(start)),
+		slog.Int("inputs", len(inputs)),
+	}
+	if err != nil {
+		m.logger.DebugContext(ctx, "llm error", append(attrs, slog.String("error", m.redact(err.Error())))...)
+	} else {
+		m.logger.DebugContext(ctx, "llm response", attrs...)
+	}
+	return out, err
+}
+
+func (m *LoggingModel) redact(s string) string {
+	for _, r := range m.redactors {
+		s = r(s)
+	}
+	return s
+}
+
+func (m *LoggingModel) logRequest(ctx context.Context, op string, messages []ChatCompletionMessage, tools int) {
+	if !m.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := make([]any, 0, len(messages))
+	for i, msg := range messages {
+		attrs = append(attrs, slog.Group(
+			strconv.Itoa(i),
+			slog.String("role", msg.Role),
+			slog.String("content", m.redact(msg.Content)),
+		))
+	}
+	m.logger.DebugContext(ctx, "llm request",
+		slog.String("op", op),
+		slog.String("model", describeModel(m.inner)),
+		slog.Int("tools", tools),
+		slog.Group("messages", attrs...),
+	)
+}
+
+func (m *LoggingModel) logResponse(ctx context.Context, op string, resp ChatCompletionResponse, latency time.Duration, err error) {
+	if err != nil {
+		m.logger.DebugContext(ctx, "llm error",
+			slog.String("op", op),
+			slog.String("model", describeModel(m.inner)),
+			slog.Duration("latency", latency),
+			slog.String("error", m.redact(err.Error())),
+		)
+		return
+	}
+	if !m.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	content, finish := "", ""
+	toolCalls := 0
+	if len(resp.Choices) > 0 {
+		content = resp.Choices[0].Message.Content
+		finish = resp.Choices[0].FinishReason
+		toolCalls = len(resp.Choices[0].Message.ToolCalls)
+	}
+	m.logger.DebugContext(ctx, "llm response",
+		slog.String("op", op),
+		slog.String("model", describeModel(m.inner)),
+		slog.Duration("latency", latency),
+		slog.String("content", m.redact(content)),
+		slog.String("finish_reason", finish),
+		slog.Int("tool_calls", toolCalls),
+		slog.Int("prompt_tokens", resp.Usage.PromptTokens),
+		slog.Int("completion_tokens", resp.Usage.CompletionTokens),
+		slog.Int("total_tokens", resp.Usage.TotalTokens),
+	)
+}
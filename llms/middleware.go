@@ -0,0 +1,134 @@
+package llms
+
+import "log/slog"
+
+// Middleware wraps an [LLM] with extra behaviour. The middlewares in this package return a model
+// implementing [ChatStreamer], [ToolCaller] and [Embedder] only when the wrapped model does, so
+// callers probing for them (such as agents choosing between streaming and a replayed Chat) see
+// the wrapped model's capabilities. [ModelDescriber] and [Pinger] are always implemented. Use the
+// New*Model constructors directly to reach wrapper-specific methods such as [CachedModel.Stats].
+type Middleware func(LLM) LLM
+
+// Chain wraps model with mws. The first middleware is the outermost, so it sees every call first:
+//
+//	llm := llms.Chain(openaiModel,
+//	    llms.LoggingMiddleware(slog.Default()),      // logs every call, including cache hits
+//	    llms.CacheMiddleware(llms.NewLRUCache(500)), // cache hits skip retry and rate limit
+//	    llms.RetryMiddleware(3),                     // each attempt waits on the limiter
+//	    llms.RateLimitMiddleware(limiter),
+//	)
+func Chain(model LLM, mws ...Middleware) LLM {
+	for i := len(mws) - 1; i >= 0; i-- {
+		model = mws[i](model)
+	}
+	return model
+}
+
+// LoggingMiddleware wraps with [NewLoggingModel].
+func LoggingMiddleware(logger *slog.Logger, opts ...LoggingOption) Middleware {
+	return func(inner LLM) LLM {
+		return narrow(NewLoggingModel(inner, logger, opts...), inner)
+	}
+}
+
+// CacheMiddleware wraps with [NewCachedModel].
+func CacheMiddleware(cache Cache) Middleware {
+	return func(inner LLM) LLM {
+		return narrow(NewCachedModel(inner, cache), inner)
+	}
+}
+
+// RateLimitMiddleware wraps with [NewRateLimitedModelWithLimiter]; every model built with the
+// same limiter shares its budget.
+func RateLimitMiddleware(limiter *RateLimiter) Middleware {
+	return func(inner LLM) LLM {
+		return narrow(NewRateLimitedModelWithLimiter(inner, limiter), inner)
+	}
+}
+
+// RetryMiddleware wraps with [NewRetryModel].
+func RetryMiddleware(maxRetries int) Middleware {
+	return func(inner LLM) LLM {
+		return narrow(NewRetryModel(inner, maxRetries), inner)
+	}
+}
+
+// wrapperModel is the method set shared by the wrappers in this package.
+type wrapperModel interface {
+	LLM
+	ChatStreamer
+	ToolCaller
+	Embedder
+	ModelDescriber
+	Pinger
+}
+
+// describedModel is what every narrowed wrapper exposes.
+type describedModel interface {
+	LLM
+	ModelDescriber
+	Pinger
+}
+
+// The narrowed wrappers, one per combination of the optional capabilities.
+type (
+	plainModel  struct{ describedModel }
+	streamModel struct {
+		describedModel
+		ChatStreamer
+	}
+	toolModel struct {
+		describedModel
+		ToolCaller
+	}
+	streamToolModel struct {
+		describedModel
+		ChatStreamer
+		ToolCaller
+	}
+	embedModel struct {
+		describedModel
+		Embedder
+	}
+	streamEmbedModel struct {
+		describedModel
+		ChatStreamer
+		Embedder
+	}
+	toolEmbedModel struct {
+		describedModel
+		ToolCaller
+		Embedder
+	}
+	streamToolEmbedModel struct {
+		describedModel
+		ChatStreamer
+		ToolCaller
+		Embedder
+	}
+)
+
+// narrow hides the optional interfaces of w that inner does not implement.
+func narrow(w wrapperModel, inner LLM) LLM {
+	_, streams := inner.(ChatStreamer)
+	_, tools := inner.(ToolCaller)
+	_, embeds := inner.(Embedder)
+	switch {
+	case streams && tools && embeds:
+		return streamToolEmbedModel{w, w, w, w}
+	case streams && tools:
+		return streamToolModel{w, w, w}
+	case streams && embeds:
+		return streamEmbedModel{w, w, w}
+	case tools && embeds:
+		return toolEmbedModel{w, w, w}
+	case streams:
+		return streamModel{w, w}
+	case tools:
+		return toolModel{w, w}
+	case embeds:
+		return embedModel{w, w}
+	default:
+		return plainModel{w}
+	}
+}
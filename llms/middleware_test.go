@@ -0,0 +1,85 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// chatOnly hides every method of the wrapped LLM but Chat.
+type chatOnly struct{ LLM }
+
+func allMiddlewares() []Middleware {
+	return []Middleware{
+		LoggingMiddleware(slog.New(slog.DiscardHandler)),
+		CacheMiddleware(NewLRUCache(10)),
+		RetryMiddleware(2),
+		RateLimitMiddleware(NewRateLimiter(1000, 10)),
+	}
+}
+
+func TestChainStreamsWrappedStreamer(t *testing.T) {
+	llm := Chain(NewFakeStreamingModel([]string{"hello streaming world"}, 4), allMiddlewares()...)
+	streamer, ok := llm.(ChatStreamer)
+	if !ok {
+		t.Fatalf("%T does not implement ChatStreamer", llm)
+	}
+	if _, ok := llm.(ToolCaller); ok {
+		t.Errorf("%T implements ToolCaller, the wrapped model does not", llm)
+	}
+	if _, ok := llm.(Embedder); ok {
+		t.Errorf("%T implements Embedder, the wrapped model does not", llm)
+	}
+
+	stream, err := streamer.ChatStream(context.Background(), []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var text strings.Builder
+	chunks := 0
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks++
+		text.WriteString(chunk.Choices[0].Delta.Content)
+	}
+	if text.String() != "hello streaming world" || chunks < 2 {
+		t.Errorf("streamed %q in %d chunks", text.String(), chunks)
+	}
+}
+
+func TestChainChatOnlyModel(t *testing.T) {
+	llm := Chain(chatOnly{NewFakeModel([]string{"ok"})}, allMiddlewares()...)
+	if _, ok := llm.(ChatStreamer); ok {
+		t.Errorf("%T implements ChatStreamer, the wrapped model does not", llm)
+	}
+	if _, ok := llm.(ToolCaller); ok {
+		t.Errorf("%T implements ToolCaller, the wrapped model does not", llm)
+	}
+	if _, ok := llm.(Pinger); !ok {
+		t.Errorf("%T does not implement Pinger", llm)
+	}
+	res, err := llm.Chat(context.Background(), nil)
+	if err != nil || res.Choices[0].Message.Content != "ok" {
+		t.Errorf("Chat = %+v, %v", res, err)
+	}
+}
+
+func TestChainKeepsToolCallerAndModelName(t *testing.T) {
+	llm := Chain(NewGeminiModel(Config{Model: "gemini-test"}), allMiddlewares()...)
+	if _, ok := llm.(ToolCaller); !ok {
+		t.Errorf("%T does not implement ToolCaller", llm)
+	}
+	if name, provider := DescribeModel(llm); name != "gemini-test" || provider != "gemini" {
+		t.Errorf("DescribeModel = %q, %q", name, provider)
+	}
+}
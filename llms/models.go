@@ -0,0 +1,81 @@
+package llms
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelInfo describes the limits and pricing of a model. Prices are in USD.
+type ModelInfo struct {
+	// ContextWindow is the maximum number of prompt plus completion tokens.
+	ContextWindow int
+	// InputPricePer1K is the price of 1,000 prompt tokens.
+	InputPricePer1K float64
+	// OutputPricePer1K is the price of 1,000 completion tokens.
+	OutputPricePer1K float64
+	// Encoding is the tiktoken encoding used to estimate token counts (e.g. "o200k_base").
+	Encoding string
+}
+
+// Cost returns the estimated price of a call with the given token counts.
+func (m ModelInfo) Cost(promptTokens, completionTokens int) float64 {
+	return float64(promptTokens)/1000*m.InputPricePer1K + float64(completionTokens)/1000*m.OutputPricePer1K
+}
+
+var (
+	modelRegistryMu sync.RWMutex
+	modelRegistry   = map[string]ModelInfo{
+		// OpenAI
+		"gpt-4o":        {ContextWindow: 128000, InputPricePer1K: 0.0025, OutputPricePer1K: 0.01, Encoding: "o200k_base"},
+		"gpt-4o-mini":   {ContextWindow: 128000, InputPricePer1K: 0.00015, OutputPricePer1K: 0.0006, Encoding: "o200k_base"},
+		"gpt-4.1":       {ContextWindow: 1047576, InputPricePer1K: 0.002, OutputPricePer1K: 0.008, Encoding: "o200k_base"},
+		"gpt-4.1-mini":  {ContextWindow: 1047576, InputPricePer1K: 0.0004, OutputPricePer1K: 0.0016, Encoding: "o200k_base"},
+		"gpt-4.1-nano":  {ContextWindow: 1047576, InputPricePer1K: 0.0001, OutputPricePer1K: 0.0004, Encoding: "o200k_base"},
+		"gpt-4-turbo":   {ContextWindow: 128000, InputPricePer1K: 0.01, OutputPricePer1K: 0.03, Encoding: "cl100k_base"},
+		"gpt-4":         {ContextWindow: 8192, InputPricePer1K: 0.03, OutputPricePer1K: 0.06, Encoding: "cl100k_base"},
+		"gpt-3.5-turbo": {ContextWindow: 16385, InputPricePer1K: 0.0005, OutputPricePer1K: 0.0015, Encoding: "cl100k_base"},
+		"o1":            {ContextWindow: 200000, InputPricePer1K: 0.015, OutputPricePer1K: 0.06, Encoding: "o200k_base"},
+		"o3-mini":       {ContextWindow: 200000, InputPricePer1K: 0.0011, OutputPricePer1K: 0.0044, Encoding: "o200k_base"},
+		// DeepSeek
+		"deepseek-chat":     {ContextWindow: 65536, InputPricePer1K: 0.00027, OutputPricePer1K: 0.0011, Encoding: "cl100k_base"},
+		"deepseek-reasoner": {ContextWindow: 65536, InputPricePer1K: 0.00055, OutputPricePer1K: 0.00219, Encoding: "cl100k_base"},
+		// Ollama (local, no cost); tags such as "llama3.1:8b" match by prefix
+		"llama3":   {ContextWindow: 8192, Encoding: "cl100k_base"},
+		"llama3.1": {ContextWindow: 131072, Encoding: "cl100k_base"},
+		"llama3.2": {ContextWindow: 131072, Encoding: "cl100k_base"},
+		"qwen2.5":  {ContextWindow: 32768, Encoding: "cl100k_base"},
+		"mistral":  {ContextWindow: 32768, Encoding: "cl100k_base"},
+		"gemma2":   {ContextWindow: 8192, Encoding: "cl100k_base"},
+	}
+)
+
+// RegisterModel adds or replaces the registry entry for name.
+func RegisterModel(name string, info ModelInfo) {
+	modelRegistryMu.Lock()
+	defer modelRegistryMu.Unlock()
+	modelRegistry[name] = info
+}
+
+// LookupModel returns the registry entry for name. Without an exact match, the longest
+// registered name that prefixes it is used, so dated or tagged names such as
+// "gpt-4o-2024-08-06" and "llama3.1:8b" resolve to their family.
+func LookupModel(name string) (ModelInfo, bool) {
+	if name == "" {
+		return ModelInfo{}, false
+	}
+	modelRegistryMu.RLock()
+	defer modelRegistryMu.RUnlock()
+	if info, ok := modelRegistry[name]; ok {
+		return info, true
+	}
+	best := ""
+	for key := range modelRegistry {
+		if len(key) > len(best) && strings.HasPrefix(name, key) {
+			best = key
+		}
+	}
+	if best == "" {
+		return ModelInfo{}, false
+	}
+	return modelRegistry[best], true
+}
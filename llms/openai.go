@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/packages/param"
 	"github.com/openai/openai-go/v3/packages/ssestream"
 	"github.com/openai/openai-go/v3/shared"
 	"github.com/tidwall/gjson"
@@ -15,12 +18,86 @@ import (
 
 // Config is options for [NewOpenAIModel] (OpenAI or compatible HTTP API).
 type Config struct {
-	BaseURL string // e.g. https://api.openai.com/v1
+	BaseURL string // e.g. https://api.openai.com/v1; any path prefix is kept (e.g. https://gw.example.com/llm/openai/v1)
 	APIKey  string
 	Model   string
 
+	// OrgID and ProjectID scope OpenAI requests (OpenAI-Organization / OpenAI-Project headers).
+	OrgID     string
+	ProjectID string
+
+	// DefaultHeaders are set on every request, overriding headers of the same name (e.g. X-Org-Id or a gateway auth header).
+	DefaultHeaders map[string]string
+
 	// Thinking enables provider-specific extended thinking where supported (e.g. via chat_template_kwargs).
 	Thinking bool
+
+	// ReasoningModel marks an OpenAI reasoning model (o1, o3, o4-mini, ...): sampling parameters
+	// and stop sequences are dropped, system messages are sent with the developer role, and the
+	// completion limit is sent as max_completion_tokens. Models named o1*, o3* and o4* are
+	// detected automatically.
+	ReasoningModel bool
+
+	// Sampling parameters. Zero values mean "not set" and the provider default is used.
+	Temperature      float64
+	TopP             float64
+	MaxTokens        int
+	PresencePenalty  float64
+	FrequencyPenalty float64
+	Stop             []string
+	// Seed requests best-effort deterministic sampling. Nil leaves it unset.
+	Seed *int
+	// Grammar is a GBNF grammar constraining the output, sent as the `grammar` request field
+	// understood by the llama.cpp server (see [NewLlamaCppModel]).
+	Grammar string
+
+	// HTTPClient overrides the HTTP client used for requests (e.g. to route through a proxy).
+	HTTPClient *http.Client
+	// Timeout bounds each HTTP request. Ignored when HTTPClient is set.
+	Timeout time.Duration
+
+	// MaxRetries is the number of retries on 429, 408, 5xx and network errors (never on other 4xx).
+	// Zero keeps the SDK default (2 retries); a negative value disables retries.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, doubled on each attempt. Default 500ms.
+	// A Retry-After header from the server takes precedence.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries, including one asked by Retry-After. Default 8s.
+	MaxBackoff time.Duration
+
+	// ResponseFormat constrains the output format (JSON mode or JSON schema). Nil leaves it unset.
+	ResponseFormat *ResponseFormat
+
+	// PromptCacheControl emits `cache_control: {"type": "ephemeral"}` on system messages with
+	// CacheHint set, for OpenAI-compatible gateways in front of Anthropic models (e.g. OpenRouter,
+	// LiteLLM). OpenAI and DeepSeek cache prompt prefixes automatically and need no markers.
+	PromptCacheControl bool
+
+	// UsageCollector, when set, receives the token usage of every chat request.
+	UsageCollector UsageCollector
+
+	// Dimensions requests shortened embeddings from models that support it (e.g. text-embedding-3-*).
+	// Zero uses the model's native size. A vector store must be created with the same dimension.
+	Dimensions int
+}
+
+// Response format types accepted by [ResponseFormat].
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat maps to the OpenAI `response_format` request field.
+type ResponseFormat struct {
+	// Type is one of ResponseFormatText, ResponseFormatJSONObject or ResponseFormatJSONSchema.
+	Type string
+
+	// Name, Description, Schema and Strict are used when Type is ResponseFormatJSONSchema.
+	Name        string
+	Description string
+	Schema      map[string]any
+	Strict      bool
 }
 
 // OpenAIModel implements [LLM], [ChatStreamer], and [Embedder] using github.com/openai/openai-go/v3.
@@ -28,6 +105,25 @@ type OpenAIModel struct {
 	client   openai.Client
 	model    string
 	thinking bool
+	sampling samplingParams
+	format   *ResponseFormat
+	dims     int
+	// cacheControl mirrors Config.PromptCacheControl.
+	cacheControl bool
+	reasoning    bool
+	usage        UsageCollector
+}
+
+// samplingParams holds the optional sampling fields copied from [Config].
+type samplingParams struct {
+	temperature      float64
+	topP             float64
+	maxTokens        int
+	presencePenalty  float64
+	frequencyPenalty float64
+	stop             []string
+	seed             *int
+	grammar          string
 }
 
 // NewOpenAIModel builds a client. BaseURL/APIKey/Model come from cfg.
@@ -36,14 +132,75 @@ func NewOpenAIModel(cfg Config) *OpenAIModel {
 	if cfg.BaseURL != "" {
 		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
 	}
+	if cfg.OrgID != "" {
+		opts = append(opts, option.WithOrganization(cfg.OrgID))
+	}
+	if cfg.ProjectID != "" {
+		opts = append(opts, option.WithProject(cfg.ProjectID))
+	}
+	if hc := cfg.httpClient(); hc != nil {
+		opts = append(opts, option.WithHTTPClient(hc))
+	}
+	opts = append(opts, newRetryPolicy(cfg).requestOptions()...)
 
 	return &OpenAIModel{
 		client:   openai.NewClient(opts...),
 		model:    cfg.Model,
 		thinking: cfg.Thinking,
+		sampling: newSamplingParams(cfg),
+		format:   cfg.ResponseFormat,
+		dims:     cfg.Dimensions,
+
+		cacheControl: cfg.PromptCacheControl,
+		reasoning:    cfg.ReasoningModel || isReasoningModel(cfg.Model),
+		usage:        cfg.UsageCollector,
 	}
 }
 
+// isReasoningModel reports whether model belongs to OpenAI's o-series reasoning family.
+func isReasoningModel(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4"} {
+		if model == prefix || strings.HasPrefix(model, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func newSamplingParams(cfg Config) samplingParams {
+	return samplingParams{
+		temperature:      cfg.Temperature,
+		topP:             cfg.TopP,
+		maxTokens:        cfg.MaxTokens,
+		presencePenalty:  cfg.PresencePenalty,
+		frequencyPenalty: cfg.FrequencyPenalty,
+		stop:             cfg.Stop,
+		seed:             cfg.Seed,
+		grammar:          cfg.Grammar,
+	}
+}
+
+// httpClient returns the configured HTTP client, or a new one bounded by Timeout, or nil for the default.
+// With DefaultHeaders the client is copied and its transport wrapped, leaving cfg.HTTPClient untouched.
+func (cfg Config) httpClient() *http.Client {
+	var hc *http.Client
+	if cfg.HTTPClient != nil {
+		hc = cfg.HTTPClient
+	} else if cfg.Timeout > 0 {
+		hc = &http.Client{Timeout: cfg.Timeout}
+	}
+	if len(cfg.DefaultHeaders) == 0 {
+		return hc
+	}
+
+	wrapped := &http.Client{}
+	if hc != nil {
+		*wrapped = *hc
+	}
+	wrapped.Transport = &headerTransport{base: wrapped.Transport, header: cfg.DefaultHeaders}
+	return wrapped
+}
+
 // NewOpenAIModelWithParams is shorthand for three string fields.
 func NewOpenAIModelWithParams(baseURL, apiKey, model string) *OpenAIModel {
 	return NewOpenAIModel(Config{BaseURL: baseURL, APIKey: apiKey, Model: model})
@@ -54,74 +211,238 @@ func NewOpenaiModel(baseURL, apiKey, model string) *OpenAIModel {
 	return NewOpenAIModelWithParams(baseURL, apiKey, model)
 }
 
+// ModelName implements [ModelNamer].
+func (m *OpenAIModel) ModelName() string {
+	return m.model
+}
+
+// Provider implements [ModelDescriber].
+func (m *OpenAIModel) Provider() string {
+	return "openai"
+}
+
+// ResponseFormat returns the configured response format, or nil.
+func (m *OpenAIModel) ResponseFormat() *ResponseFormat {
+	return m.format
+}
+
+// WithResponseFormat returns a copy of m that requests the given response format.
+// The copy shares the underlying HTTP client.
+func (m *OpenAIModel) WithResponseFormat(rf *ResponseFormat) *OpenAIModel {
+	cp := *m
+	cp.format = rf
+	return &cp
+}
+
 // Chat calls POST /chat/completions (non-streaming).
-func (m *OpenAIModel) Chat(ctx context.Context, messages []ChatCompletionMessage) (ChatCompletionResponse, error) {
-	return m.ChatWithTools(ctx, messages, nil)
+func (m *OpenAIModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.ChatWithTools(ctx, messages, nil, opts...)
 }
 
 // ChatWithTools is like [OpenAIModel.Chat] but registers native OpenAI function tools when non-empty.
-func (m *OpenAIModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam) (ChatCompletionResponse, error) {
+func (m *OpenAIModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
 	params := openai.ChatCompletionNewParams{
-		Messages: openaiMessageParams(messages),
+		Messages: openaiMessageParams(messages, m.cacheControl),
 		Model:    shared.ChatModel(m.model),
 	}
 	if len(tools) > 0 {
 		params.Tools = tools
 	}
-	m.applyThinkingParams(&params, false)
+	m.applySamplingParams(&params)
+	co := NewCallOptions(opts...)
+	applyCallOptions(&params, co)
+	m.applyResponseFormat(&params, co)
+	m.applyModelParams(&params, false)
 
 	resp, err := m.client.Chat.Completions.New(ctx, params)
 	if err != nil {
-		return ChatCompletionResponse{}, err
+		return ChatCompletionResponse{}, classifyError(err)
 	}
-	return completionFromSDK(resp), nil
+	out := completionFromSDK(resp)
+	collectUsage(m.usage, m.model, out)
+	return out, nil
 }
 
 // ChatStream calls POST /chat/completions with stream=true.
-func (m *OpenAIModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage) (*ChatCompletionStream, error) {
-	return m.ChatStreamWithTools(ctx, messages, nil)
+func (m *OpenAIModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	return m.ChatStreamWithTools(ctx, messages, nil, opts...)
 }
 
 // ChatStreamWithTools is like [OpenAIModel.ChatStream] with optional tools (tool_calls deltas require client-side assembly).
-func (m *OpenAIModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam) (*ChatCompletionStream, error) {
+func (m *OpenAIModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
 	params := openai.ChatCompletionNewParams{
-		Messages: openaiMessageParams(messages),
+		Messages: openaiMessageParams(messages, m.cacheControl),
 		Model:    shared.ChatModel(m.model),
 	}
 	if len(tools) > 0 {
 		params.Tools = tools
 	}
-	m.applyThinkingParams(&params, m.thinking)
+	m.applySamplingParams(&params)
+	co := NewCallOptions(opts...)
+	applyCallOptions(&params, co)
+	m.applyResponseFormat(&params, co)
+	m.applyModelParams(&params, m.thinking)
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
 
 	stream := m.client.Chat.Completions.NewStreaming(ctx, params)
 	if stream.Err() != nil {
-		return nil, stream.Err()
+		return nil, classifyError(stream.Err())
+	}
+	return collectStreamUsage(m.usage, m.model, newChatCompletionStream(stream)), nil
+}
+
+// applySamplingParams copies the non-zero sampling fields from [Config] into params.
+func (m *OpenAIModel) applySamplingParams(params *openai.ChatCompletionNewParams) {
+	sp := m.sampling
+	if sp.temperature != 0 {
+		params.Temperature = openai.Float(sp.temperature)
+	}
+	if sp.topP != 0 {
+		params.TopP = openai.Float(sp.topP)
+	}
+	if sp.maxTokens > 0 {
+		params.MaxTokens = openai.Int(int64(sp.maxTokens))
+	}
+	if sp.presencePenalty != 0 {
+		params.PresencePenalty = openai.Float(sp.presencePenalty)
+	}
+	if sp.frequencyPenalty != 0 {
+		params.FrequencyPenalty = openai.Float(sp.frequencyPenalty)
+	}
+	if len(sp.stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: sp.stop}
+	}
+	if sp.seed != nil {
+		params.Seed = openai.Int(int64(*sp.seed))
+	}
+	if sp.grammar != "" {
+		setExtraField(params, "grammar", sp.grammar)
+	}
+}
+
+// applyCallOptions overrides model, temperature, max tokens, stop sequences, seed and grammar for one request.
+func applyCallOptions(params *openai.ChatCompletionNewParams, o CallOptions) {
+	if o.Model != "" {
+		params.Model = shared.ChatModel(o.Model)
+	}
+	if o.Temperature != nil {
+		params.Temperature = openai.Float(*o.Temperature)
+	}
+	if o.MaxTokens > 0 {
+		params.MaxTokens = openai.Int(int64(o.MaxTokens))
+	}
+	if len(o.Stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: o.Stop}
+	}
+	if o.Seed != nil {
+		params.Seed = openai.Int(int64(*o.Seed))
+	}
+	if o.Grammar != "" {
+		setExtraField(params, "grammar", o.Grammar)
+	}
+}
+
+// setExtraField adds a non-standard body field, keeping extra fields set earlier.
+func setExtraField(params *openai.ChatCompletionNewParams, key string, value any) {
+	ex := params.ExtraFields()
+	if ex == nil {
+		ex = map[string]any{}
+	}
+	ex[key] = value
+	params.SetExtraFields(ex)
+}
+
+// applyResponseFormat sets params.ResponseFormat from the configured [ResponseFormat], or to
+// JSON mode when none is configured and the call asks for it.
+func (m *OpenAIModel) applyResponseFormat(params *openai.ChatCompletionNewParams, co CallOptions) {
+	rf := m.format
+	if rf == nil && co.JSONMode {
+		rf = &ResponseFormat{Type: ResponseFormatJSONObject}
+	}
+	if rf == nil {
+		return
+	}
+	switch rf.Type {
+	case ResponseFormatText:
+		params.ResponseFormat.OfText = &shared.ResponseFormatTextParam{}
+	case ResponseFormatJSONObject:
+		params.ResponseFormat.OfJSONObject = &shared.ResponseFormatJSONObjectParam{}
+	case ResponseFormatJSONSchema:
+		schema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   rf.Name,
+			Schema: rf.Schema,
+		}
+		if rf.Description != "" {
+			schema.Description = openai.String(rf.Description)
+		}
+		if rf.Strict {
+			schema.Strict = openai.Bool(true)
+		}
+		params.ResponseFormat.OfJSONSchema = &shared.ResponseFormatJSONSchemaParam{JSONSchema: schema}
+	}
+}
+
+// applyModelParams adapts params to the model family: reasoning models get
+// [applyReasoningModelParams], all others the thinking switches.
+func (m *OpenAIModel) applyModelParams(params *openai.ChatCompletionNewParams, enableThinking bool) {
+	if m.reasoning || isReasoningModel(string(params.Model)) {
+		applyReasoningModelParams(params)
+		return
+	}
+	m.applyThinkingParams(params, enableThinking)
+}
+
+// applyReasoningModelParams removes the parameters o-series models reject, moves max_tokens to
+// max_completion_tokens and sends system messages with the developer role.
+func applyReasoningModelParams(params *openai.ChatCompletionNewParams) {
+	params.Temperature = param.Opt[float64]{}
+	params.TopP = param.Opt[float64]{}
+	params.PresencePenalty = param.Opt[float64]{}
+	params.FrequencyPenalty = param.Opt[float64]{}
+	params.Stop = openai.ChatCompletionNewParamsStopUnion{}
+	if params.MaxTokens.Valid() {
+		params.MaxCompletionTokens = params.MaxTokens
+		params.MaxTokens = param.Opt[int64]{}
+	}
+	for i, msg := range params.Messages {
+		if msg.OfSystem == nil {
+			continue
+		}
+		params.Messages[i] = openai.ChatCompletionMessageParamUnion{
+			OfDeveloper: &openai.ChatCompletionDeveloperMessageParam{
+				Content: openai.ChatCompletionDeveloperMessageParamContentUnion{
+					OfString:              msg.OfSystem.Content.OfString,
+					OfArrayOfContentParts: msg.OfSystem.Content.OfArrayOfContentParts,
+				},
+			},
+		}
 	}
-	return newChatCompletionStream(stream), nil
 }
 
 func (m *OpenAIModel) applyThinkingParams(params *openai.ChatCompletionNewParams, enableThinking bool) {
 	if enableThinking {
 		return
 	}
-	ex := map[string]any{"enable_thinking": false}
+	setExtraField(params, "enable_thinking", false)
 	if strings.HasPrefix(m.model, "kimi-") || strings.HasPrefix(m.model, "deepseek-") {
-		ex["thinking"] = map[string]any{"type": "disabled"}
+		setExtraField(params, "thinking", map[string]any{"type": "disabled"})
 	}
 
-	ex["chat_template_kwargs"] = map[string]any{"enable_thinking": false}
-
-	params.SetExtraFields(ex)
+	setExtraField(params, "chat_template_kwargs", map[string]any{"enable_thinking": false})
 }
 
 // Embeddings calls POST /embeddings.
 func (m *OpenAIModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
-	resp, err := m.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+	params := openai.EmbeddingNewParams{
 		Model: openai.EmbeddingModel(m.model),
 		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: inputs},
-	})
+	}
+	if m.dims > 0 {
+		params.Dimensions = openai.Int(int64(m.dims))
+	}
+	resp, err := m.client.Embeddings.New(ctx, params)
 	if err != nil {
-		return nil, err
+		return nil, classifyError(err)
 	}
 	if len(resp.Data) == 0 {
 		return nil, fmt.Errorf("未返回嵌入数据")
@@ -137,17 +458,23 @@ func (m *OpenAIModel) Embeddings(ctx context.Context, inputs []string) ([][]floa
 	return out, nil
 }
 
-func openaiMessageParams(msgs []ChatCompletionMessage) []openai.ChatCompletionMessageParamUnion {
+// openaiMessageParams converts messages to SDK params. With cacheControl, system messages that carry
+// CacheHint are sent as a text part with an ephemeral cache_control marker.
+func openaiMessageParams(msgs []ChatCompletionMessage, cacheControl bool) []openai.ChatCompletionMessageParamUnion {
 	out := make([]openai.ChatCompletionMessageParamUnion, 0, len(msgs))
 	for _, msg := range msgs {
 		switch msg.Role {
 		case ChatMessageRoleSystem:
+			content := openai.ChatCompletionSystemMessageParamContentUnion{}
+			if cacheControl && msg.CacheHint {
+				part := openai.ChatCompletionContentPartTextParam{Text: msg.Content}
+				part.SetExtraFields(map[string]any{"cache_control": map[string]any{"type": "ephemeral"}})
+				content.OfArrayOfContentParts = []openai.ChatCompletionContentPartTextParam{part}
+			} else {
+				content.OfString = openai.String(msg.Content)
+			}
 			out = append(out, openai.ChatCompletionMessageParamUnion{
-				OfSystem: &openai.ChatCompletionSystemMessageParam{
-					Content: openai.ChatCompletionSystemMessageParamContentUnion{
-						OfString: openai.String(msg.Content),
-					},
-				},
+				OfSystem: &openai.ChatCompletionSystemMessageParam{Content: content},
 			})
 		case ChatMessageRoleAssistant:
 			ap := openai.ChatCompletionAssistantMessageParam{}
@@ -179,18 +506,33 @@ func openaiMessageParams(msgs []ChatCompletionMessage) []openai.ChatCompletionMe
 				},
 			})
 		default:
+			content := openai.ChatCompletionUserMessageParamContentUnion{}
+			if len(msg.MultiContent) > 0 {
+				content.OfArrayOfContentParts = openaiContentParts(msg.MultiContent)
+			} else {
+				content.OfString = openai.String(msg.Content)
+			}
 			out = append(out, openai.ChatCompletionMessageParamUnion{
-				OfUser: &openai.ChatCompletionUserMessageParam{
-					Content: openai.ChatCompletionUserMessageParamContentUnion{
-						OfString: openai.String(msg.Content),
-					},
-				},
+				OfUser: &openai.ChatCompletionUserMessageParam{Content: content},
 			})
 		}
 	}
 	return out
 }
 
+func openaiContentParts(parts []ChatMessagePart) []openai.ChatCompletionContentPartUnionParam {
+	out := make([]openai.ChatCompletionContentPartUnionParam, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case ChatMessagePartTypeImageURL:
+			out = append(out, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: p.ImageURL}))
+		default:
+			out = append(out, openai.TextContentPart(p.Text))
+		}
+	}
+	return out
+}
+
 // chatToolCallsFromOpenAIMessageUnions maps SDK assistant tool_calls to [ChatToolCall].
 // OpenAI-compatible providers sometimes omit tool `type` in streamed deltas; then
 // [openai.ChatCompletionMessageToolCallUnion.AsAny] is nil even though function.name was merged.
@@ -268,8 +610,9 @@ func completionFromSDK(resp *openai.ChatCompletion) ChatCompletionResponse {
 		return ChatCompletionResponse{}
 	}
 	out := ChatCompletionResponse{
-		ID:    resp.ID,
-		Model: resp.Model,
+		ID:                resp.ID,
+		Model:             resp.Model,
+		SystemFingerprint: resp.SystemFingerprint,
 		Usage: ChatUsage{
 			PromptTokens:     int(resp.Usage.PromptTokens),
 			CompletionTokens: int(resp.Usage.CompletionTokens),
@@ -295,44 +638,110 @@ func completionFromSDK(resp *openai.ChatCompletion) ChatCompletionResponse {
 	return out
 }
 
-// ChatCompletionStream adapts the SDK SSE stream to Recv/Close used by agents.
+// ChatCompletionStream is a [ChatStream] backed by a recv function; it adapts the OpenAI SDK stream
+// and is available to other providers through [NewChatCompletionStream].
 type ChatCompletionStream struct {
-	s *ssestream.Stream[openai.ChatCompletionChunk]
+	recv  func() (ChatCompletionStreamResponse, error)
+	close func() error
+}
+
+// NewChatCompletionStream builds a stream for providers other than OpenAI.
+// recv must return io.EOF after the last chunk; close may be nil.
+func NewChatCompletionStream(recv func() (ChatCompletionStreamResponse, error), close func() error) *ChatCompletionStream {
+	return &ChatCompletionStream{recv: recv, close: close}
+}
+
+// streamChunkRunes is the chunk size StreamFromResponse splits content into.
+const streamChunkRunes = 16
+
+// StreamFromResponse replays a non-streaming response as a stream, e.g. for LLMs that don't
+// implement [ChatStreamer]: the reasoning and content of the first choice are split into short
+// chunks and its tool calls, finish reason and usage are sent in the final chunk, as OpenAI
+// streams do.
+func StreamFromResponse(resp ChatCompletionResponse) *ChatCompletionStream {
+	var chunks []ChatCompletionStreamResponse
+	chunk := func(delta ChatCompletionStreamDelta, finishReason string) ChatCompletionStreamResponse {
+		return ChatCompletionStreamResponse{
+			ID:                resp.ID,
+			Model:             resp.Model,
+			Choices:           []ChatCompletionStreamChoice{{Delta: delta, FinishReason: finishReason}},
+			SystemFingerprint: resp.SystemFingerprint,
+		}
+	}
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		for _, part := range splitRunes(choice.Message.ReasoningContent, streamChunkRunes) {
+			chunks = append(chunks, chunk(ChatCompletionStreamDelta{ReasoningContent: part}, ""))
+		}
+		for _, part := range splitRunes(choice.Message.Content, streamChunkRunes) {
+			chunks = append(chunks, chunk(ChatCompletionStreamDelta{Content: part}, ""))
+		}
+		final := ChatCompletionStreamDelta{}
+		for i, tc := range choice.Message.ToolCalls {
+			final.ToolCalls = append(final.ToolCalls, ChatCompletionStreamToolCallDelta{
+				Index:             i,
+				ID:                tc.ID,
+				Type:              "function",
+				NameFragment:      tc.Name,
+				ArgumentsFragment: tc.Arguments,
+			})
+		}
+		chunks = append(chunks, chunk(final, choice.FinishReason))
+	}
+	if resp.Usage != (ChatUsage{}) {
+		usage := resp.Usage
+		last := chunk(ChatCompletionStreamDelta{}, "")
+		last.Choices = nil
+		last.Usage = &usage
+		chunks = append(chunks, last)
+	}
+
+	return NewChatCompletionStream(func() (ChatCompletionStreamResponse, error) {
+		if len(chunks) == 0 {
+			return ChatCompletionStreamResponse{}, io.EOF
+		}
+		c := chunks[0]
+		chunks = chunks[1:]
+		return c, nil
+	}, nil)
 }
 
 func newChatCompletionStream(s *ssestream.Stream[openai.ChatCompletionChunk]) *ChatCompletionStream {
 	if s == nil {
 		return nil
 	}
-	return &ChatCompletionStream{s: s}
+	return NewChatCompletionStream(func() (ChatCompletionStreamResponse, error) {
+		if !s.Next() {
+			if err := s.Err(); err != nil {
+				return ChatCompletionStreamResponse{}, classifyError(err)
+			}
+			return ChatCompletionStreamResponse{}, io.EOF
+		}
+		return streamChunkFromSDK(s.Current()), nil
+	}, s.Close)
 }
 
 // Recv returns the next chunk, or io.EOF after a normal end.
 func (cs *ChatCompletionStream) Recv() (ChatCompletionStreamResponse, error) {
-	if cs == nil || cs.s == nil {
-		return ChatCompletionStreamResponse{}, io.EOF
-	}
-	if !cs.s.Next() {
-		if err := cs.s.Err(); err != nil {
-			return ChatCompletionStreamResponse{}, err
-		}
+	if cs == nil || cs.recv == nil {
 		return ChatCompletionStreamResponse{}, io.EOF
 	}
-	return streamChunkFromSDK(cs.s.Current()), nil
+	return cs.recv()
 }
 
 // Close releases the response body.
 func (cs *ChatCompletionStream) Close() error {
-	if cs == nil || cs.s == nil {
+	if cs == nil || cs.close == nil {
 		return nil
 	}
-	return cs.s.Close()
+	return cs.close()
 }
 
 func streamChunkFromSDK(chunk openai.ChatCompletionChunk) ChatCompletionStreamResponse {
 	out := ChatCompletionStreamResponse{
-		ID:    chunk.ID,
-		Model: chunk.Model,
+		ID:                chunk.ID,
+		Model:             chunk.Model,
+		SystemFingerprint: chunk.SystemFingerprint,
 	}
 
 	raw := chunk.RawJSON()
@@ -0,0 +1,120 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// openaiServer answers every chat completion with "ok" and records the requests with their
+// decoded bodies.
+func openaiServer(t *testing.T) (*httptest.Server, *[]*http.Request, *[]map[string]any) {
+	t.Helper()
+	var requests []*http.Request
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests, &bodies
+}
+
+func TestOpenAIModelJSONMode(t *testing.T) {
+	ctx := context.Background()
+	srv, _, bodies := openaiServer(t)
+	hello := []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "reply in JSON"}}
+
+	m := NewOpenAIModel(Config{BaseURL: srv.URL, Model: "gpt-test"})
+	for _, opts := range [][]ChatOption{nil, {WithCallJSONMode()}} {
+		if _, err := m.Chat(ctx, hello, opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schema := NewOpenAIModel(Config{BaseURL: srv.URL, Model: "gpt-test", ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONSchema, Name: "answer", Schema: map[string]any{"type": "object"}}})
+	if _, err := schema.Chat(ctx, hello, WithCallJSONMode()); err != nil {
+		t.Fatal(err)
+	}
+
+	formatType := func(body map[string]any) any {
+		rf, _ := body["response_format"].(map[string]any)
+		return rf["type"]
+	}
+	if got := formatType((*bodies)[0]); got != nil {
+		t.Errorf("response_format without JSON mode = %v, want none", got)
+	}
+	if got := formatType((*bodies)[1]); got != "json_object" {
+		t.Errorf("response_format with JSON mode = %v, want json_object", got)
+	}
+	if got := formatType((*bodies)[2]); got != "json_schema" {
+		t.Errorf("response_format with a configured schema = %v, want the schema kept", got)
+	}
+}
+
+// countingTransport counts the requests sent through it and tags them with a header.
+type countingTransport struct {
+	n int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n++
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Transport", "custom")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestOpenAIModelRequestHeaders(t *testing.T) {
+	srv, requests, _ := openaiServer(t)
+	transport := &countingTransport{}
+	client := &http.Client{Transport: transport}
+	m := NewOpenAIModel(Config{
+		BaseURL:        srv.URL,
+		APIKey:         "sk-test",
+		Model:          "gpt-test",
+		OrgID:          "org-123",
+		ProjectID:      "proj-456",
+		DefaultHeaders: map[string]string{"X-Gateway-Key": "gw", "OpenAI-Organization": "org-override"},
+		HTTPClient:     client,
+	})
+	if _, err := m.Chat(context.Background(), []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := (*requests)[0].Header
+	want := map[string]string{
+		"Authorization":       "Bearer sk-test",
+		"OpenAI-Organization": "org-override", // DefaultHeaders win over OrgID
+		"OpenAI-Project":      "proj-456",
+		"X-Gateway-Key":       "gw",
+		"X-Transport":         "custom",
+	}
+	for k, v := range want {
+		if got := h.Get(k); got != v {
+			t.Errorf("header %s = %q, want %q", k, got, v)
+		}
+	}
+	if transport.n != 1 {
+		t.Errorf("custom transport saw %d requests, want 1", transport.n)
+	}
+	if client.Transport != transport {
+		t.Error("Config.HTTPClient was modified")
+	}
+
+	// OrgID alone reaches the OpenAI-Organization header
+	m = NewOpenAIModel(Config{BaseURL: srv.URL, Model: "gpt-test", OrgID: "org-123"})
+	if _, err := m.Chat(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := (*requests)[1].Header.Get("OpenAI-Organization"); got != "org-123" {
+		t.Errorf("OpenAI-Organization = %q, want org-123", got)
+	}
+}
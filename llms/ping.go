@@ -0,0 +1,100 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// Pinger is an optional interface for LLMs that can verify their endpoint and model are reachable
+// without running a full request.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that the endpoint answers and serves the configured model. It lists models first; when
+// the server does not implement /models it falls back to a one-token completion.
+func (m *OpenAIModel) Ping(ctx context.Context) error {
+	page, err := m.client.Models.List(ctx)
+	if err == nil {
+		for _, model := range page.Data {
+			if model.ID == m.model {
+				return nil
+			}
+		}
+		// An empty listing is inconclusive (some gateways return none); fall through to a completion.
+		if len(page.Data) > 0 {
+			return fmt.Errorf("openai: model %q not listed by /models: %w", m.model, ErrModelNotFound)
+		}
+	} else {
+		var oe *openai.Error
+		if !errors.As(err, &oe) || (oe.StatusCode != http.StatusNotFound && oe.StatusCode != http.StatusMethodNotAllowed) {
+			return fmt.Errorf("openai: ping model %q: %w", m.model, classifyError(err))
+		}
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Messages:  openaiMessageParams([]ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "ping"}}, false),
+		Model:     shared.ChatModel(m.model),
+		MaxTokens: openai.Int(1),
+	}
+	if _, err := m.client.Chat.Completions.New(ctx, params); err != nil {
+		return fmt.Errorf("openai: ping model %q: %w", m.model, classifyError(err))
+	}
+	return nil
+}
+
+// Ping fetches models/{model}.
+func (m *GeminiModel) Ping(ctx context.Context) error {
+	resp, err := doJSON(ctx, m.httpClient, "gemini", http.MethodGet, m.baseURL+"/models/"+m.model, m.header(), nil)
+	if err != nil {
+		return fmt.Errorf("gemini: ping model %q: %w", m.model, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Ping delegates to the wrapped model when it implements [Pinger].
+func (m *CachedModel) Ping(ctx context.Context) error {
+	return ping(ctx, m.inner)
+}
+
+// Ping delegates to the wrapped model when it implements [Pinger].
+func (m *LoggingModel) Ping(ctx context.Context) error {
+	return ping(ctx, m.inner)
+}
+
+// Ping delegates to the wrapped model when it implements [Pinger]. It is not rate limited.
+func (m *RateLimitedModel) Ping(ctx context.Context) error {
+	return ping(ctx, m.inner)
+}
+
+// Ping delegates to the wrapped model when it implements [Pinger].
+func (m *RetryModel) Ping(ctx context.Context) error {
+	return ping(ctx, m.inner)
+}
+
+// Ping succeeds when any model in the chain answers.
+func (m *FallbackModel) Ping(ctx context.Context) error {
+	var errs []error
+	for _, model := range m.models {
+		err := ping(ctx, model)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ping calls model.Ping, treating models without [Pinger] as healthy.
+func ping(ctx context.Context, model LLM) error {
+	if p, ok := model.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
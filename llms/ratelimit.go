@@ -0,0 +1,241 @@
+package llms
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// RateLimiter is a token-bucket limiter for LLM requests, with an optional tokens-per-minute
+// budget. It is safe for concurrent use; share one limiter between several [RateLimitedModel]s
+// (e.g. agents using the same API key) to enforce a common limit.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rps    float64
+	burst  float64
+	avail  float64
+	last   time.Time
+	tpm    float64
+	budget float64
+	stats  RateLimitStats
+}
+
+// RateLimitStats reports how long callers have been blocked by a [RateLimiter].
+type RateLimitStats struct {
+	// Requests is the number of requests admitted.
+	Requests int64
+	// Waits is the number of requests that had to wait.
+	Waits int64
+	// TotalWait and MaxWait summarize the time spent waiting.
+	TotalWait time.Duration
+	MaxWait   time.Duration
+	// Waiting is the number of callers blocked right now.
+	Waiting int
+}
+
+// NewRateLimiter allows rps requests per second on average with bursts of up to burst requests.
+// A burst below 1 is treated as 1.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	b := float64(max(burst, 1))
+	return &RateLimiter{rps: rps, burst: b, avail: b, last: time.Now()}
+}
+
+// WithTokensPerMinute adds a budget of tokens (prompt plus completion) per minute. Usage is
+// charged after each response, so a request waits while earlier responses have overdrawn the budget.
+func (l *RateLimiter) WithTokensPerMinute(tpm int) *RateLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tpm = float64(tpm)
+	l.budget = l.tpm
+	return l
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	l.avail--
+	delay := time.Duration(0)
+	if l.avail < 0 && l.rps > 0 {
+		delay = time.Duration(-l.avail / l.rps * float64(time.Second))
+	}
+	if l.budget < 0 && l.tpm > 0 {
+		delay = max(delay, time.Duration(-l.budget/l.tpm*float64(time.Minute)))
+	}
+	if delay == 0 {
+		l.stats.Requests++
+		l.mu.Unlock()
+		return nil
+	}
+	l.stats.Waiting++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.stats.Waiting--
+		l.avail++ // give the reserved slot back
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	l.mu.Lock()
+	l.stats.Waiting--
+	l.stats.Requests++
+	l.stats.Waits++
+	l.stats.TotalWait += delay
+	l.stats.MaxWait = max(l.stats.MaxWait, delay)
+	l.mu.Unlock()
+	return nil
+}
+
+// refill credits requests and tokens accrued since the last update. Callers hold l.mu.
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last)
+	l.last = now
+	if l.rps > 0 {
+		l.avail = min(l.burst, l.avail+elapsed.Seconds()*l.rps)
+	} else {
+		l.avail = l.burst
+	}
+	if l.tpm > 0 {
+		l.budget = min(l.tpm, l.budget+elapsed.Minutes()*l.tpm)
+	}
+}
+
+// charge deducts the tokens used by a response from the tokens-per-minute budget.
+func (l *RateLimiter) charge(usage ChatUsage) {
+	if usage.TotalTokens == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tpm > 0 {
+		l.refill(time.Now())
+		l.budget -= float64(usage.TotalTokens)
+	}
+}
+
+// Stats returns the wait statistics so far.
+func (l *RateLimiter) Stats() RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// RateLimitedModel wraps an [LLM] and waits on a [RateLimiter] before each Chat, ChatStream and
+// Embeddings call. Waiting honours context cancellation.
+//
+// Example:
+//
+//	limiter := llms.NewRateLimiter(5, 10).WithTokensPerMinute(90000)
+//	llmA := llms.NewRateLimitedModelWithLimiter(openaiModel, limiter)
+//	llmB := llms.NewRateLimitedModelWithLimiter(openaiModel, limiter) // shares the same budget
+type RateLimitedModel struct {
+	inner   LLM
+	limiter *RateLimiter
+}
+
+// NewRateLimitedModel wraps inner with its own limiter of rps requests per second and the given burst.
+func NewRateLimitedModel(inner LLM, rps float64, burst int) *RateLimitedModel {
+	return NewRateLimitedModelWithLimiter(inner, NewRateLimiter(rps, burst))
+}
+
+// NewRateLimitedModelWithLimiter wraps inner with a shared limiter.
+func NewRateLimitedModelWithLimiter(inner LLM, limiter *RateLimiter) *RateLimitedModel {
+	return &RateLimitedModel{inner: inner, limiter: limiter}
+}
+
+// Limiter returns the limiter, e.g. to read its [RateLimiter.Stats].
+func (m *RateLimitedModel) Limiter() *RateLimiter {
+	return m.limiter
+}
+
+// ModelName implements [ModelNamer] with the wrapped model's name.
+func (m *RateLimitedModel) ModelName() string {
+	if n, ok := m.inner.(ModelNamer); ok {
+		return n.ModelName()
+	}
+	return ""
+}
+
+// Provider implements [ModelDescriber] with the wrapped model's provider.
+func (m *RateLimitedModel) Provider() string {
+	if d, ok := m.inner.(ModelDescriber); ok {
+		return d.Provider()
+	}
+	return ""
+}
+
+// Chat implements [LLM].
+func (m *RateLimitedModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.ChatWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatWithTools implements [ToolCaller]. Tools are dropped when the inner model does not implement it.
+func (m *RateLimitedModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
+	if err := m.limiter.Wait(ctx); err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	var resp ChatCompletionResponse
+	var err error
+	if tc, ok := m.inner.(ToolCaller); ok && len(tools) > 0 {
+		resp, err = tc.ChatWithTools(ctx, messages, tools, opts...)
+	} else {
+		resp, err = m.inner.Chat(ctx, messages, opts...)
+	}
+	if err == nil {
+		m.limiter.charge(resp.Usage)
+	}
+	return resp, err
+}
+
+// ChatStream implements [ChatStreamer].
+func (m *RateLimitedModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	return m.ChatStreamWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatStreamWithTools implements [ToolCaller]. Usage reported by the stream is charged when it arrives.
+func (m *RateLimitedModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
+	if err := m.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	var stream ChatStream
+	var err error
+	if tc, ok := m.inner.(ToolCaller); ok {
+		stream, err = tc.ChatStreamWithTools(ctx, messages, tools, opts...)
+	} else if s, ok := m.inner.(ChatStreamer); ok {
+		stream, err = s.ChatStream(ctx, messages, opts...)
+	} else {
+		err = errNotStreamer
+	}
+	if err != nil {
+		return nil, err
+	}
+	recv := func() (ChatCompletionStreamResponse, error) {
+		chunk, err := stream.Recv()
+		if err == nil && chunk.Usage != nil {
+			m.limiter.charge(*chunk.Usage)
+		}
+		return chunk, err
+	}
+	return NewChatCompletionStream(recv, stream.Close), nil
+}
+
+// Embeddings implements [Embedder] when the wrapped model does.
+func (m *RateLimitedModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	e, ok := m.inner.(Embedder)
+	if !ok {
+		return nil, errNotEmbedder
+	}
+	if err := m.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.Embeddings(ctx, inputs)
+}
@@ -0,0 +1,260 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 8 * time.Second
+)
+
+// retryPolicy controls how transient HTTP failures (429, 408, 5xx, network errors) are retried.
+type retryPolicy struct {
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+func newRetryPolicy(cfg Config) retryPolicy {
+	p := retryPolicy{
+		maxRetries:     cfg.MaxRetries,
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+	}
+	if p.initialBackoff <= 0 {
+		p.initialBackoff = defaultInitialBackoff
+	}
+	if p.maxBackoff <= 0 {
+		p.maxBackoff = defaultMaxBackoff
+	}
+	if p.maxBackoff < p.initialBackoff {
+		p.maxBackoff = p.initialBackoff
+	}
+	return p
+}
+
+// requestOptions maps the policy onto SDK options.
+// MaxRetries == 0 keeps the SDK default; negative disables retries; positive installs [retryPolicy.middleware].
+func (p retryPolicy) requestOptions() []option.RequestOption {
+	switch {
+	case p.maxRetries < 0:
+		return []option.RequestOption{option.WithMaxRetries(0)}
+	case p.maxRetries > 0:
+		return []option.RequestOption{option.WithMaxRetries(0), option.WithMiddleware(p.middleware)}
+	default:
+		return nil
+	}
+}
+
+// middleware retries a request with exponential backoff, honoring Retry-After (up to
+// maxBackoff) and ctx cancellation.
+func (p retryPolicy) middleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := next(req)
+		if attempt >= p.maxRetries || !shouldRetry(req, res, err) {
+			return res, err
+		}
+
+		delay := p.backoff(attempt, res)
+		if res != nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return nil, berr
+			}
+			req.Body = body
+		}
+	}
+}
+
+// backoff returns the delay before retry number attempt+1. A server's Retry-After is
+// honored up to maxBackoff, so a misbehaving server can't stall a request for hours.
+func (p retryPolicy) backoff(attempt int, res *http.Response) time.Duration {
+	if d, ok := retryAfter(res); ok {
+		return min(d, p.maxBackoff)
+	}
+	delay := p.initialBackoff << attempt
+	if delay <= 0 || delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	// up to 25% jitter so concurrent agents don't retry in lockstep
+	if jitter := int64(delay / 4); jitter > 0 {
+		delay -= time.Duration(rand.Int63n(jitter))
+	}
+	return delay
+}
+
+func shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if res == nil {
+		return true
+	}
+	return retryableStatus(res.StatusCode)
+}
+
+// retryAfter parses Retry-After (seconds or HTTP date) and retry-after-ms.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+	if v := res.Header.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(0, time.Until(t)), true
+	}
+	return 0, false
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying on the same or another
+// provider: network errors and HTTP 408, 429 or 5xx. Context cancellation is never retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var oe *openai.Error
+	if errors.As(err, &oe) {
+		return retryableStatus(oe.StatusCode)
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		return retryableStatus(ae.StatusCode)
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout ||
+		code == http.StatusTooManyRequests ||
+		code >= http.StatusInternalServerError
+}
+
+// RetryModel wraps an [LLM] and retries calls that fail with a retryable error (see
+// [IsRetryable]) with exponential backoff. Unlike [Config.MaxRetries], which retries HTTP
+// requests inside one provider, it works with any LLM. A stream is retried only while it is
+// being opened, never after chunks have been delivered.
+type RetryModel struct {
+	inner  LLM
+	policy retryPolicy
+}
+
+// NewRetryModel wraps inner, retrying up to maxRetries times with the default backoff.
+func NewRetryModel(inner LLM, maxRetries int) *RetryModel {
+	return &RetryModel{inner: inner, policy: newRetryPolicy(Config{MaxRetries: maxRetries})}
+}
+
+// ModelName implements [ModelNamer] with the wrapped model's name.
+func (m *RetryModel) ModelName() string {
+	if n, ok := m.inner.(ModelNamer); ok {
+		return n.ModelName()
+	}
+	return ""
+}
+
+// Provider implements [ModelDescriber] with the wrapped model's provider.
+func (m *RetryModel) Provider() string {
+	if d, ok := m.inner.(ModelDescriber); ok {
+		return d.Provider()
+	}
+	return ""
+}
+
+// Chat implements [LLM].
+func (m *RetryModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return m.ChatWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatWithTools implements [ToolCaller]. Tools are dropped when the inner model does not implement it.
+func (m *RetryModel) ChatWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatCompletionResponse, error) {
+	return withRetry(ctx, m.policy, func() (ChatCompletionResponse, error) {
+		if tc, ok := m.inner.(ToolCaller); ok && len(tools) > 0 {
+			return tc.ChatWithTools(ctx, messages, tools, opts...)
+		}
+		return m.inner.Chat(ctx, messages, opts...)
+	})
+}
+
+// ChatStream implements [ChatStreamer].
+func (m *RetryModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	return m.ChatStreamWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatStreamWithTools implements [ToolCaller].
+func (m *RetryModel) ChatStreamWithTools(ctx context.Context, messages []ChatCompletionMessage, tools []openai.ChatCompletionToolUnionParam, opts ...ChatOption) (ChatStream, error) {
+	return withRetry(ctx, m.policy, func() (ChatStream, error) {
+		if tc, ok := m.inner.(ToolCaller); ok {
+			return tc.ChatStreamWithTools(ctx, messages, tools, opts...)
+		}
+		if s, ok := m.inner.(ChatStreamer); ok {
+			return s.ChatStream(ctx, messages, opts...)
+		}
+		return nil, errNotStreamer
+	})
+}
+
+// Embeddings implements [Embedder] when the wrapped model does.
+func (m *RetryModel) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	e, ok := m.inner.(Embedder)
+	if !ok {
+		return nil, errNotEmbedder
+	}
+	return withRetry(ctx, m.policy, func() ([][]float32, error) {
+		return e.Embeddings(ctx, inputs)
+	})
+}
+
+// withRetry calls fn until it succeeds, fails with a non-retryable error, or retries run out.
+func withRetry[T any](ctx context.Context, p retryPolicy, fn func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		out, err := fn()
+		if err == nil || attempt >= p.maxRetries || !IsRetryable(err) {
+			return out, err
+		}
+		timer := time.NewTimer(p.backoff(attempt, nil))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			var zero T
+			return zero, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := newRetryPolicy(Config{MaxRetries: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	withHeader := func(key, value string) *http.Response {
+		return &http.Response{Header: http.Header{key: []string{value}}}
+	}
+	tests := []struct {
+		name     string
+		attempt  int
+		res      *http.Response
+		min, max time.Duration
+	}{
+		{"first retry", 0, nil, 75 * time.Millisecond, 100 * time.Millisecond},
+		{"doubled", 2, nil, 300 * time.Millisecond, 400 * time.Millisecond},
+		{"capped", 10, nil, 750 * time.Millisecond, time.Second},
+		{"overflow capped", 80, nil, 750 * time.Millisecond, time.Second},
+		{"retry-after seconds", 0, withHeader("Retry-After", "0.5"), 500 * time.Millisecond, 500 * time.Millisecond},
+		{"retry-after-ms", 0, withHeader("Retry-After-Ms", "250"), 250 * time.Millisecond, 250 * time.Millisecond},
+		{"retry-after clamped", 0, withHeader("Retry-After", "3600"), time.Second, time.Second},
+		{"retry-after date clamped", 0, withHeader("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)), time.Second, time.Second},
+		{"retry-after past date", 0, withHeader("Retry-After", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)), 0, 0},
+		{"invalid retry-after", 0, withHeader("Retry-After", "soon"), 75 * time.Millisecond, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 20 {
+				if d := p.backoff(tt.attempt, tt.res); d < tt.min || d > tt.max {
+					t.Fatalf("backoff = %v, want between %v and %v", d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRetryPolicyDefaults(t *testing.T) {
+	p := newRetryPolicy(Config{})
+	if p.initialBackoff != defaultInitialBackoff || p.maxBackoff != defaultMaxBackoff {
+		t.Errorf("defaults = %v, %v", p.initialBackoff, p.maxBackoff)
+	}
+	p = newRetryPolicy(Config{InitialBackoff: time.Minute, MaxBackoff: time.Second})
+	if p.maxBackoff != time.Minute {
+		t.Errorf("maxBackoff = %v, want raised to the initial backoff", p.maxBackoff)
+	}
+	if len(newRetryPolicy(Config{}).requestOptions()) != 0 || len(newRetryPolicy(Config{MaxRetries: -1}).requestOptions()) != 1 ||
+		len(newRetryPolicy(Config{MaxRetries: 2}).requestOptions()) != 2 {
+		t.Error("requestOptions don't match MaxRetries")
+	}
+}
+
+// statusServer answers with the given statuses in order, then 200, and counts requests and
+// the bodies it received.
+func statusServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32, *[]string) {
+	t.Helper()
+	var calls atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		n := int(calls.Add(1))
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &bodies
+}
+
+func TestRetryPolicyMiddleware(t *testing.T) {
+	p := retryPolicy{maxRetries: 2, initialBackoff: time.Millisecond, maxBackoff: 5 * time.Millisecond}
+	tests := []struct {
+		name       string
+		header     http.Header
+		statuses   []int
+		wantStatus int
+		wantCalls  int32
+	}{
+		{"success", nil, nil, http.StatusOK, 1},
+		{"transient then success", nil, []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, http.StatusOK, 3},
+		{"retries exhausted", nil, []int{500, 502, 503}, http.StatusServiceUnavailable, 3},
+		{"request timeout", nil, []int{http.StatusRequestTimeout}, http.StatusOK, 2},
+		{"client error not retried", nil, []int{http.StatusBadRequest}, http.StatusBadRequest, 1},
+		{"long retry-after clamped", http.Header{"Retry-After": {"3600"}}, []int{http.StatusTooManyRequests}, http.StatusOK, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls, bodies := statusServer(t, tt.header, tt.statuses...)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader(`{"q":1}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := p.middleware(req, http.DefaultClient.Do)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tt.wantStatus || calls.Load() != tt.wantCalls {
+				t.Errorf("got %d after %d calls, want %d after %d", res.StatusCode, calls.Load(), tt.wantStatus, tt.wantCalls)
+			}
+			for _, body := range *bodies {
+				if body != `{"q":1}` {
+					t.Errorf("retried request body = %q, want it resent", body)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyMiddlewareCanceled(t *testing.T) {
+	p := retryPolicy{maxRetries: 3, initialBackoff: time.Hour, maxBackoff: time.Hour}
+	srv, calls, _ := statusServer(t, nil, 503, 503, 503)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.middleware(req, http.DefaultClient.Do); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("middleware = %v, want the context error", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want no retry after cancellation", calls.Load())
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{&APIError{StatusCode: 429}, true},
+		{&APIError{StatusCode: 408}, true},
+		{&APIError{StatusCode: 503}, true},
+		{&APIError{StatusCode: 400}, false},
+		{&APIError{StatusCode: 401}, false},
+		{errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryModel(t *testing.T) {
+	ctx := context.Background()
+	fast := retryPolicy{maxRetries: 2, initialBackoff: time.Millisecond, maxBackoff: time.Millisecond}
+
+	fake := NewFakeModel([]string{"ok"}).FailOnCall(1, &APIError{StatusCode: 503}).FailOnCall(2, &APIError{StatusCode: 429})
+	res, err := (&RetryModel{inner: fake, policy: fast}).Chat(ctx, nil)
+	if err != nil || res.Choices[0].Message.Content != "ok" || fake.CallCount() != 3 {
+		t.Errorf("Chat = %+v, %v after %d calls, want ok after 3", res, err, fake.CallCount())
+	}
+
+	fake = NewFakeModel([]string{"ok"}).FailOnCall(1, &APIError{StatusCode: 400})
+	if _, err := (&RetryModel{inner: fake, policy: fast}).Chat(ctx, nil); err == nil || fake.CallCount() != 1 {
+		t.Errorf("Chat = %v after %d calls, want the 400 without retry", err, fake.CallCount())
+	}
+
+	fake = NewFakeModel(nil)
+	for n := 1; n <= 3; n++ {
+		fake.FailOnCall(n, &APIError{StatusCode: 500})
+	}
+	if _, err := (&RetryModel{inner: fake, policy: fast}).Chat(ctx, nil); err == nil || fake.CallCount() != 3 {
+		t.Errorf("Chat = %v after %d calls, want the error after 3", err, fake.CallCount())
+	}
+}
@@ -0,0 +1,283 @@
+package llms
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// TGIModel implements [LLM] and [ChatStreamer] against HuggingFace text-generation-inference.
+// Requests go to the OpenAI-compatible /v1/chat/completions route; when the server does not
+// expose it (404), the model switches to the native /generate and /generate_stream endpoints
+// for the rest of its lifetime.
+//
+// Example:
+//
+//	llm := llms.NewTGIModel(llms.Config{
+//	    BaseURL: "http://tgi:8080",
+//	    Model:   "tgi",
+//	})
+type TGIModel struct {
+	// BestOf asks the native endpoint to sample this many sequences and return the best one.
+	// It is ignored by the OpenAI-compatible route and by streaming.
+	BestOf int
+	// PromptTemplate renders messages into the raw prompt for the native endpoints.
+	// Default is ChatML (<|im_start|>role ... <|im_end|>).
+	PromptTemplate func(messages []ChatCompletionMessage) string
+
+	compat     *OpenAIModel
+	native     atomic.Bool
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	sampling   samplingParams
+	usage      UsageCollector
+}
+
+// NewTGIModel builds a TGI client. BaseURL is the server root (e.g. http://tgi:8080), without /v1.
+// Model defaults to "tgi", the name TGI accepts for its single loaded model.
+func NewTGIModel(cfg Config) *TGIModel {
+	baseURL := strings.TrimSuffix(strings.TrimRight(cfg.BaseURL, "/"), "/v1")
+	if cfg.Model == "" {
+		cfg.Model = "tgi"
+	}
+	compatCfg := cfg
+	compatCfg.BaseURL = baseURL + "/v1"
+	return &TGIModel{
+		compat:     NewOpenAIModel(compatCfg),
+		httpClient: cfg.httpClient(),
+		baseURL:    baseURL,
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		sampling:   newSamplingParams(cfg),
+		usage:      cfg.UsageCollector,
+	}
+}
+
+// ModelName implements [ModelNamer].
+func (m *TGIModel) ModelName() string {
+	return m.model
+}
+
+// Provider implements [ModelDescriber].
+func (m *TGIModel) Provider() string {
+	return "tgi"
+}
+
+// Chat uses /v1/chat/completions, or /generate when the server lacks the OpenAI-compatible route.
+func (m *TGIModel) Chat(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatCompletionResponse, error) {
+	if !m.native.Load() {
+		resp, err := m.compat.Chat(ctx, messages, opts...)
+		if !isNotFound(err) {
+			return resp, err
+		}
+		m.native.Store(true)
+	}
+
+	req := m.request(messages, NewCallOptions(opts...), false)
+	var resp tgiGenerateResponse
+	if err := postJSON(ctx, m.httpClient, "tgi", m.baseURL+"/generate", m.header(), req, &resp); err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	out := ChatCompletionResponse{
+		Model: m.model,
+		Choices: []ChatCompletionChoice{{
+			Message:      ChatCompletionMessage{Role: ChatMessageRoleAssistant, Content: resp.GeneratedText},
+			FinishReason: tgiFinishReason(resp.Details.FinishReason),
+		}},
+		Usage: resp.Details.usage(),
+	}
+	collectUsage(m.usage, m.model, out)
+	return out, nil
+}
+
+// ChatStream uses /v1/chat/completions with stream=true, or /generate_stream when the server
+// lacks the OpenAI-compatible route. Native streams report completion tokens only.
+func (m *TGIModel) ChatStream(ctx context.Context, messages []ChatCompletionMessage, opts ...ChatOption) (ChatStream, error) {
+	if !m.native.Load() {
+		stream, err := m.compat.ChatStream(ctx, messages, opts...)
+		if !isNotFound(err) {
+			return stream, err
+		}
+		m.native.Store(true)
+	}
+
+	req := m.request(messages, NewCallOptions(opts...), true)
+	resp, err := doJSON(ctx, m.httpClient, "tgi", http.MethodPost, m.baseURL+"/generate_stream", m.header(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	recv := func() (ChatCompletionStreamResponse, error) {
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "data:")
+			if !ok {
+				continue
+			}
+			var event tgiStreamEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+				return ChatCompletionStreamResponse{}, fmt.Errorf("tgi: decode stream event: %w", err)
+			}
+			if event.Error != "" {
+				return ChatCompletionStreamResponse{}, fmt.Errorf("tgi: %s", event.Error)
+			}
+
+			choice := ChatCompletionStreamChoice{}
+			if !event.Token.Special {
+				choice.Delta.Content = event.Token.Text
+			}
+			out := ChatCompletionStreamResponse{Model: m.model}
+			if event.Details != nil {
+				choice.FinishReason = tgiFinishReason(event.Details.FinishReason)
+				u := event.Details.usage()
+				out.Usage = &u
+			}
+			out.Choices = []ChatCompletionStreamChoice{choice}
+			return out, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return ChatCompletionStreamResponse{}, err
+		}
+		return ChatCompletionStreamResponse{}, io.EOF
+	}
+
+	return collectStreamUsage(m.usage, m.model, NewChatCompletionStream(recv, resp.Body.Close)), nil
+}
+
+func (m *TGIModel) header() http.Header {
+	h := http.Header{}
+	if m.apiKey != "" {
+		h.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	return h
+}
+
+// request builds a native generate request. decoder_input_details (needed for prompt token
+// counts) is rejected by TGI when streaming, so it is only set for /generate.
+func (m *TGIModel) request(messages []ChatCompletionMessage, co CallOptions, stream bool) tgiGenerateRequest {
+	render := m.PromptTemplate
+	if render == nil {
+		render = chatMLPrompt
+	}
+	sp := m.sampling
+	p := tgiParameters{
+		Details:             true,
+		DecoderInputDetails: !stream,
+		Stop:                sp.stop,
+		Seed:                sp.seed,
+	}
+	if sp.temperature != 0 {
+		p.Temperature = &sp.temperature
+	}
+	if sp.topP != 0 {
+		p.TopP = &sp.topP
+	}
+	if sp.maxTokens > 0 {
+		p.MaxNewTokens = &sp.maxTokens
+	}
+	if !stream && m.BestOf > 1 {
+		p.BestOf = m.BestOf
+		p.DoSample = true
+	}
+	if co.Temperature != nil {
+		p.Temperature = co.Temperature
+	}
+	if co.MaxTokens > 0 {
+		p.MaxNewTokens = &co.MaxTokens
+	}
+	if len(co.Stop) > 0 {
+		p.Stop = co.Stop
+	}
+	if co.Seed != nil {
+		p.Seed = co.Seed
+	}
+	// TGI rejects a temperature of exactly 0; greedy decoding is the default without sampling.
+	if p.Temperature != nil && *p.Temperature <= 0 {
+		p.Temperature = nil
+	}
+	return tgiGenerateRequest{Inputs: render(messages), Parameters: p}
+}
+
+// chatMLPrompt renders messages in the ChatML format and opens an assistant turn.
+func chatMLPrompt(messages []ChatCompletionMessage) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "<|im_start|>%s\n%s<|im_end|>\n", msg.Role, msg.Content)
+	}
+	b.WriteString("<|im_start|>assistant\n")
+	return b.String()
+}
+
+// isNotFound reports whether err is a 404 from the OpenAI-compatible route.
+func isNotFound(err error) bool {
+	var apiErr *openai.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+func tgiFinishReason(reason string) string {
+	switch reason {
+	case "length":
+		return "length"
+	case "":
+		return ""
+	default: // eos_token, stop_sequence
+		return "stop"
+	}
+}
+
+type tgiGenerateRequest struct {
+	Inputs     string        `json:"inputs"`
+	Parameters tgiParameters `json:"parameters"`
+}
+
+type tgiParameters struct {
+	MaxNewTokens        *int     `json:"max_new_tokens,omitempty"`
+	Temperature         *float64 `json:"temperature,omitempty"`
+	TopP                *float64 `json:"top_p,omitempty"`
+	Stop                []string `json:"stop,omitempty"`
+	Seed                *int     `json:"seed,omitempty"`
+	BestOf              int      `json:"best_of,omitempty"`
+	DoSample            bool     `json:"do_sample,omitempty"`
+	Details             bool     `json:"details"`
+	DecoderInputDetails bool     `json:"decoder_input_details,omitempty"`
+}
+
+type tgiGenerateResponse struct {
+	GeneratedText string     `json:"generated_text"`
+	Details       tgiDetails `json:"details"`
+}
+
+type tgiStreamEvent struct {
+	Token struct {
+		Text    string `json:"text"`
+		Special bool   `json:"special"`
+	} `json:"token"`
+	Details *tgiDetails `json:"details"`
+	Error   string      `json:"error"`
+}
+
+type tgiDetails struct {
+	FinishReason    string            `json:"finish_reason"`
+	GeneratedTokens int               `json:"generated_tokens"`
+	Prefill         []json.RawMessage `json:"prefill"`
+}
+
+func (d tgiDetails) usage() ChatUsage {
+	return ChatUsage{
+		PromptTokens:     len(d.Prefill),
+		CompletionTokens: d.GeneratedTokens,
+		TotalTokens:      len(d.Prefill) + d.GeneratedTokens,
+	}
+}
@@ -0,0 +1,124 @@
+package llms
+
+import (
+	"maps"
+	"sync"
+)
+
+// UsageCollector receives the token usage of every request made by a provider configured with
+// [Config.UsageCollector]. Implementations must be safe for concurrent use.
+type UsageCollector interface {
+	Record(model string, usage ChatUsage)
+}
+
+// RunUsageRecorder is an optional interface for collectors that also accept per-run totals
+// from agents, tagged with the conversation ID.
+type RunUsageRecorder interface {
+	RecordRun(conversationID, model string, usage ChatUsage)
+}
+
+// ModelUsage is the aggregated usage of one model or conversation.
+type ModelUsage struct {
+	ChatUsage
+	// Requests is the number of recorded requests (runs, for conversations).
+	Requests int `json:"requests"`
+	// Cost is the estimated USD price from the model registry; 0 for unregistered models.
+	Cost float64 `json:"cost"`
+}
+
+// InMemoryUsageCollector totals usage per model and per conversation in memory. It implements
+// [UsageCollector] and [RunUsageRecorder] and is safe for concurrent use. For periodic billing,
+// call Snapshot followed by Reset on a timer.
+//
+// Example:
+//
+//	collector := llms.NewInMemoryUsageCollector()
+//	llm := llms.NewOpenAIModel(llms.Config{..., UsageCollector: collector})
+//	agent := agents.CreateReactAgent(ctx, llm, agents.WithUsageCollector(collector))
+type InMemoryUsageCollector struct {
+	mu            sync.Mutex
+	models        map[string]ModelUsage
+	conversations map[string]ModelUsage
+}
+
+// NewInMemoryUsageCollector returns an empty collector.
+func NewInMemoryUsageCollector() *InMemoryUsageCollector {
+	return &InMemoryUsageCollector{
+		models:        map[string]ModelUsage{},
+		conversations: map[string]ModelUsage{},
+	}
+}
+
+// Record implements [UsageCollector].
+func (c *InMemoryUsageCollector) Record(model string, usage ChatUsage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.models[model] = addUsage(c.models[model], model, usage)
+}
+
+// RecordRun implements [RunUsageRecorder].
+func (c *InMemoryUsageCollector) RecordRun(conversationID, model string, usage ChatUsage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conversations[conversationID] = addUsage(c.conversations[conversationID], model, usage)
+}
+
+// Snapshot returns a copy of the per-model totals.
+func (c *InMemoryUsageCollector) Snapshot() map[string]ModelUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.models)
+}
+
+// ConversationSnapshot returns a copy of the per-conversation totals reported by agents.
+func (c *InMemoryUsageCollector) ConversationSnapshot() map[string]ModelUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.conversations)
+}
+
+// Reset clears all totals.
+func (c *InMemoryUsageCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.models)
+	clear(c.conversations)
+}
+
+func addUsage(total ModelUsage, model string, usage ChatUsage) ModelUsage {
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+	total.Requests++
+	if info, ok := LookupModel(model); ok {
+		total.Cost += info.Cost(usage.PromptTokens, usage.CompletionTokens)
+	}
+	return total
+}
+
+// collectUsage reports resp's usage to c, attributed to resp.Model or fallback.
+func collectUsage(c UsageCollector, fallback string, resp ChatCompletionResponse) {
+	if c == nil || resp.Usage == (ChatUsage{}) {
+		return
+	}
+	model := resp.Model
+	if model == "" {
+		model = fallback
+	}
+	c.Record(model, resp.Usage)
+}
+
+// collectStreamUsage wraps stream so usage chunks are reported to c.
+func collectStreamUsage(c UsageCollector, fallback string, stream ChatStream) ChatStream {
+	if c == nil {
+		return stream
+	}
+	recv := func() (ChatCompletionStreamResponse, error) {
+		chunk, err := stream.Recv()
+		if err == nil && chunk.Usage != nil {
+			collectUsage(c, fallback, ChatCompletionResponse{Model: chunk.Model, Usage: *chunk.Usage})
+		}
+		return chunk, err
+	}
+	return NewChatCompletionStream(recv, stream.Close)
+}
@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// ArchivingMemory keeps active conversations in a hot [Memory] (e.g. RedisMemory) and moves
+// those inactive for a while to a cold one (e.g. FileMemory or SQLiteMemory) with
+// ArchiveInactive. A conversation is its cold history followed by its hot history, so
+// LoadMessages reads both stores and archived conversations stay readable.
+//
+// Example:
+//
+//	mem := memory.NewArchivingMemory(redisMem, sqliteMem, 30*24*time.Hour,
+//	    memory.WithRehydrateOnAccess(true))
+//	moved, err := mem.ArchiveInactive(ctx) // e.g. from a nightly job
+type ArchivingMemory struct {
+	hot           Memory
+	cold          Memory
+	inactiveAfter time.Duration
+	rehydrate     bool
+	// mu is held for writing while a conversation moves between the stores, so concurrent
+	// saves don't land in the middle of a move
+	mu sync.RWMutex
+}
+
+// ArchivingOption configures [NewArchivingMemory].
+type ArchivingOption func(*ArchivingMemory)
+
+// WithRehydrateOnAccess moves an archived conversation back to the hot memory when it is
+// loaded. The messages keep their time when both memories implement [MemoryV2], so the
+// conversation is archived again unless a new message is saved.
+func WithRehydrateOnAccess(enabled bool) ArchivingOption {
+	return func(m *ArchivingMemory) {
+		m.rehydrate = enabled
+	}
+}
+
+// NewArchivingMemory combines hot and cold; ArchiveInactive moves the conversations of hot
+// without activity for inactiveAfter. Messages are always saved to hot.
+func NewArchivingMemory(hot, cold Memory, inactiveAfter time.Duration, opts ...ArchivingOption) *ArchivingMemory {
+	m := &ArchivingMemory{hot: hot, cold: cold, inactiveAfter: inactiveAfter}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// LoadMessages returns the archived messages of the conversation followed by its hot ones.
+// With WithRehydrateOnAccess, archived messages are moved back to the hot memory.
+func (m *ArchivingMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	m.mu.RLock()
+	archived, messages, err := m.load(ctx, conversationID)
+	m.mu.RUnlock()
+	if err != nil || len(archived) == 0 || !m.rehydrate {
+		return messages, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.rehydrateConversation(ctx, conversationID); err != nil {
+		return nil, err
+	}
+	return m.hot.LoadMessages(ctx, conversationID)
+}
+
+// rehydrateConversation moves the archived messages of the conversation back before its hot
+// ones, keeping their time and metadata when both memories implement [MemoryV2]. Callers hold
+// mu for writing.
+func (m *ArchivingMemory) rehydrateConversation(ctx context.Context, conversationID string) error {
+	// reload: the conversation may have changed since it was read
+	archived, err := ToV2(m.cold).LoadMessagesV2(ctx, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to load archived messages: %w", err)
+	}
+	if len(archived) == 0 {
+		return nil
+	}
+	recent, err := ToV2(m.hot).LoadMessagesV2(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+	// a previous rehydration that failed to clear the cold copy already moved them
+	if !hasPrefix(recent, archived) {
+		if err := m.hot.ClearMessages(ctx, conversationID); err != nil {
+			return fmt.Errorf("failed to rehydrate conversation: %w", err)
+		}
+		if err := ToV2(m.hot).SaveMessagesV2(ctx, conversationID, append(archived, recent...)); err != nil {
+			return fmt.Errorf("failed to rehydrate conversation: %w", err)
+		}
+	}
+	if err := m.cold.ClearMessages(ctx, conversationID); err != nil {
+		return fmt.Errorf("failed to clear archived conversation: %w", err)
+	}
+	return nil
+}
+
+// load returns the archived messages of the conversation and the whole history. Callers hold mu.
+func (m *ArchivingMemory) load(ctx context.Context, conversationID string) (archived, messages []llms.ChatCompletionMessage, err error) {
+	archived, err = m.cold.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load archived messages: %w", err)
+	}
+	recent, err := m.hot.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	messages = make([]llms.ChatCompletionMessage, 0, len(archived)+len(recent))
+	return archived, append(append(messages, archived...), recent...), nil
+}
+
+// SaveMessages saves messages to the hot memory.
+func (m *ArchivingMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.hot.SaveMessages(ctx, conversationID, messages)
+}
+
+// ClearMessages clears the conversation in both memories.
+func (m *ArchivingMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return errors.Join(
+		m.hot.ClearMessages(ctx, conversationID),
+		m.cold.ClearMessages(ctx, conversationID),
+	)
+}
+
+// Close closes both memories when they implement [Closer].
+func (m *ArchivingMemory) Close() error {
+	return errors.Join(closeMemory(m.hot), closeMemory(m.cold))
+}
+
+// ArchiveInactive moves every conversation of the hot memory whose last message is older than
+// inactiveAfter to the cold memory: it is appended there (with the time and metadata of its
+// messages when both implement [MemoryV2]) and cleared from hot. Messages the cold memory
+// already ends with are not appended again, so a run that failed to clear hot can be retried.
+// The hot memory must list conversations (a [ConversationLister], or BufferMemory) and report
+// their last activity with GetLastActivity (RedisMemory) or GetStats (SQLiteMemory);
+// conversations without a known last activity are kept. A failed conversation doesn't stop the others; the failures are returned joined.
+func (m *ArchivingMemory) ArchiveInactive(ctx context.Context) (moved int, err error) {
+	lastActivity, err := lastActivityFunc(m.hot)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := listConversations(ctx, m.hot)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-m.inactiveAfter)
+	var errs []error
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return moved, errors.Join(append(errs, err)...)
+		}
+		last, err := lastActivity(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		if last.IsZero() || last.After(cutoff) {
+			continue
+		}
+		ok, err := m.archive(ctx, id, cutoff, lastActivity)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		if ok {
+			moved++
+		}
+	}
+	return moved, errors.Join(errs...)
+}
+
+// archive moves one conversation to the cold memory and reports whether it did: a
+// conversation that became active since it was listed is kept.
+func (m *ArchivingMemory) archive(ctx context.Context, conversationID string, cutoff time.Time, lastActivity func(context.Context, string) (time.Time, error)) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if last, err := lastActivity(ctx, conversationID); err != nil || last.IsZero() || last.After(cutoff) {
+		return false, err
+	}
+	archived, err := ToV2(m.cold).LoadMessagesV2(ctx, conversationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load archived messages: %w", err)
+	}
+	recent, err := ToV2(m.hot).LoadMessagesV2(ctx, conversationID)
+	if err != nil {
+		return false, err
+	}
+	// a previous run that failed to clear the hot copy already archived them
+	if !hasSuffix(archived, recent) {
+		if err := ToV2(m.cold).SaveMessagesV2(ctx, conversationID, recent); err != nil {
+			return false, fmt.Errorf("failed to archive conversation: %w", err)
+		}
+	}
+	if err := m.hot.ClearMessages(ctx, conversationID); err != nil {
+		return false, fmt.Errorf("failed to clear archived conversation: %w", err)
+	}
+	return true, nil
+}
+
+// hasPrefix reports whether messages starts with prefix.
+func hasPrefix(messages, prefix []Message) bool {
+	return len(prefix) <= len(messages) && sameMessages(messages[:len(prefix)], prefix)
+}
+
+// hasSuffix reports whether messages ends with suffix.
+func hasSuffix(messages, suffix []Message) bool {
+	return len(suffix) <= len(messages) && sameMessages(messages[len(messages)-len(suffix):], suffix)
+}
+
+// sameMessages reports whether a and b hold the same messages: equal content and, for messages
+// timestamped in both, times within a millisecond (backends store times at different precisions).
+func sameMessages(a, b []Message) bool {
+	for i := range a {
+		ta, tb := a[i].CreatedAt, b[i].CreatedAt
+		if !ta.IsZero() && !tb.IsZero() && ta.Sub(tb).Abs() >= time.Millisecond {
+			return false
+		}
+		ja, _ := json.Marshal(messageToStored(a[i].LLM()))
+		jb, _ := json.Marshal(messageToStored(b[i].LLM()))
+		if !bytes.Equal(ja, jb) {
+			return false
+		}
+	}
+	return true
+}
+
+// lastActivityFunc returns how to get the last activity of a conversation of m.
+func lastActivityFunc(m Memory) (func(ctx context.Context, conversationID string) (time.Time, error), error) {
+	switch hot := m.(type) {
+	case interface {
+		GetLastActivity(ctx context.Context, conversationID string) (time.Time, error)
+	}:
+		return hot.GetLastActivity, nil
+	case StatsProvider:
+		return func(ctx context.Context, conversationID string) (time.Time, error) {
+			stats, err := hot.GetStats(ctx, conversationID)
+			return stats.LastMessageAt, err
+		}, nil
+	default:
+		return nil, fmt.Errorf("%T cannot report the last activity of conversations", m)
+	}
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// flakyClearMemory is a RedisMemory whose first failClears ClearMessages calls fail.
+type flakyClearMemory struct {
+	*RedisMemory
+	failClears int
+}
+
+func (m *flakyClearMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	if m.failClears > 0 {
+		m.failClears--
+		return errors.New("clear refused")
+	}
+	return m.RedisMemory.ClearMessages(ctx, conversationID)
+}
+
+// newArchivingStores returns a hot and a cold RedisMemory on one server.
+func newArchivingStores(t *testing.T) (hot, cold *RedisMemory) {
+	t.Helper()
+	_, client := newTestRedis(t)
+	hot, err := NewRedisMemoryWithConfig(RedisConfig{Client: client, KeyPrefix: "hot:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cold, err = NewRedisMemoryWithConfig(RedisConfig{Client: client, KeyPrefix: "cold:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hot, cold
+}
+
+// saveOld saves a turn to m as if it was sent at.
+func saveOld(t *testing.T, m MemoryV2, id string, at time.Time) {
+	t.Helper()
+	var msgs []Message
+	for _, msg := range turn(1, 0) {
+		msg := MessageFromLLM(msg)
+		msg.CreatedAt = at
+		msgs = append(msgs, msg)
+	}
+	if err := m.SaveMessagesV2(context.Background(), id, msgs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveInactiveRetryDoesNotDuplicate(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := newArchivingStores(t)
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	saveOld(t, hot, "c1", old)
+	flaky := &flakyClearMemory{RedisMemory: hot, failClears: 1}
+	m := NewArchivingMemory(flaky, cold, 24*time.Hour)
+
+	if _, err := m.ArchiveInactive(ctx); err == nil {
+		t.Fatal("ArchiveInactive succeeded, want the clear failure")
+	}
+	moved, err := m.ArchiveInactive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 1 {
+		t.Errorf("moved = %d, want 1", moved)
+	}
+
+	archived, _ := cold.LoadMessagesV2(ctx, "c1")
+	if len(archived) != 2 {
+		t.Fatalf("cold holds %d messages, want 2", len(archived))
+	}
+	if !archived[0].CreatedAt.Equal(old) {
+		t.Errorf("archived CreatedAt = %v, want %v", archived[0].CreatedAt, old)
+	}
+	if n, _ := hot.GetMessageCount(ctx, "c1"); n != 0 {
+		t.Errorf("hot holds %d messages, want 0", n)
+	}
+}
+
+func TestArchivingMemoryRehydrateKeepsTimestamps(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := newArchivingStores(t)
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	saveOld(t, cold, "c1", old)
+	if err := hot.SaveMessages(ctx, "c1", []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: "new"}}); err != nil {
+		t.Fatal(err)
+	}
+	m := NewArchivingMemory(hot, cold, 24*time.Hour, WithRehydrateOnAccess(true))
+
+	msgs, err := m.LoadMessages(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 3 || msgs[0].Content != "q1" || msgs[2].Content != "new" {
+		t.Fatalf("loaded %+v, want the archived turn then the new message", msgs)
+	}
+	if n, _ := cold.GetMessageCount(ctx, "c1"); n != 0 {
+		t.Errorf("cold holds %d messages after rehydration, want 0", n)
+	}
+	rehydrated, _ := hot.LoadMessagesV2(ctx, "c1")
+	if len(rehydrated) != 3 || !rehydrated[0].CreatedAt.Equal(old) {
+		t.Errorf("rehydrated %+v, want the archived time %v kept", rehydrated, old)
+	}
+}
+
+// A rehydration that failed to clear the cold copy doesn't duplicate it in hot when retried.
+func TestArchivingMemoryRehydrateRetryDoesNotDuplicate(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := newArchivingStores(t)
+	saveOld(t, cold, "c1", time.Now().Add(-48*time.Hour))
+	flaky := &flakyClearMemory{RedisMemory: cold, failClears: 1}
+	m := NewArchivingMemory(hot, flaky, 24*time.Hour, WithRehydrateOnAccess(true))
+
+	if _, err := m.LoadMessages(ctx, "c1"); err == nil {
+		t.Fatal("LoadMessages succeeded, want the clear failure")
+	}
+	msgs, err := m.LoadMessages(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("loaded %d messages, want 2", len(msgs))
+	}
+}
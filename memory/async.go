@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// ErrMemoryClosed is returned by AsyncMemory after Close.
+var ErrMemoryClosed = errors.New("memory is closed")
+
+// AsyncMemory wraps a [Memory] so SaveMessages returns as soon as the messages are queued; a
+// background worker writes them to the wrapped memory in order. LoadMessages and
+// ClearMessages first wait for the queued writes of their conversation, so a conversation
+// always reads its own writes. Call Close (or Flush) before shutdown so queued writes are not
+// lost.
+//
+// Example:
+//
+//	mem := memory.NewAsyncMemory(sqliteMem, 256,
+//	    memory.WithAsyncErrorHandler(func(id string, err error) { log.Println(id, err) }))
+//	defer mem.Close()
+type AsyncMemory struct {
+	inner   Memory
+	queue   chan asyncSave
+	onError func(conversationID string, err error)
+
+	// sendMu serializes enqueueing and registering in last, so both follow the queue order
+	sendMu sync.Mutex
+	closed bool
+
+	mu sync.Mutex
+	// last holds the done channel of the latest queued save per conversation; lastAll the
+	// latest of any conversation. Saves complete in queue order.
+	last    map[string]chan struct{}
+	lastAll chan struct{}
+
+	workerDone chan struct{}
+}
+
+type asyncSave struct {
+	ctx            context.Context
+	conversationID string
+	messages       []llms.ChatCompletionMessage
+	done           chan struct{}
+}
+
+// AsyncOption configures [NewAsyncMemory].
+type AsyncOption func(*AsyncMemory)
+
+// WithAsyncErrorHandler sets a function called with the errors of background writes. By
+// default they are dropped.
+func WithAsyncErrorHandler(fn func(conversationID string, err error)) AsyncOption {
+	return func(m *AsyncMemory) {
+		m.onError = fn
+	}
+}
+
+// NewAsyncMemory wraps inner with a write queue of queueSize saves (minimum 1). SaveMessages
+// blocks only when the queue is full.
+func NewAsyncMemory(inner Memory, queueSize int, opts ...AsyncOption) *AsyncMemory {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	m := &AsyncMemory{
+		inner:      inner,
+		queue:      make(chan asyncSave, queueSize),
+		last:       make(map[string]chan struct{}),
+		workerDone: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	go m.worker()
+	return m
+}
+
+func (m *AsyncMemory) worker() {
+	defer close(m.workerDone)
+	for job := range m.queue {
+		if err := m.inner.SaveMessages(job.ctx, job.conversationID, job.messages); err != nil && m.onError != nil {
+			m.onError(job.conversationID, err)
+		}
+		m.mu.Lock()
+		if m.last[job.conversationID] == job.done {
+			delete(m.last, job.conversationID)
+		}
+		if m.lastAll == job.done {
+			m.lastAll = nil
+		}
+		// closed under mu, so SaveMessages never registers a finished save
+		close(job.done)
+		m.mu.Unlock()
+	}
+}
+
+// SaveMessages queues messages for the wrapped memory. Write errors are reported to the
+// WithAsyncErrorHandler function, not returned.
+func (m *AsyncMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	job := asyncSave{
+		// the write outlives the call, so it must not be canceled with it
+		ctx:            context.WithoutCancel(ctx),
+		conversationID: conversationID,
+		messages:       append([]llms.ChatCompletionMessage(nil), messages...),
+		done:           make(chan struct{}),
+	}
+
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+	if m.closed {
+		return ErrMemoryClosed
+	}
+	select {
+	case m.queue <- job:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-job.done:
+		// already written
+	default:
+		m.last[conversationID] = job.done
+		m.lastAll = job.done
+	}
+	return nil
+}
+
+// waitDone blocks until done is closed or ctx ends.
+func waitDone(ctx context.Context, done chan struct{}) error {
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flushConversation waits for the queued writes of conversationID.
+func (m *AsyncMemory) flushConversation(ctx context.Context, conversationID string) error {
+	m.mu.Lock()
+	done := m.last[conversationID]
+	m.mu.Unlock()
+	return waitDone(ctx, done)
+}
+
+// LoadMessages waits for the queued writes of the conversation, then loads it.
+func (m *AsyncMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	if err := m.flushConversation(ctx, conversationID); err != nil {
+		return nil, err
+	}
+	return m.inner.LoadMessages(ctx, conversationID)
+}
+
+// ClearMessages waits for the queued writes of the conversation, then clears it.
+func (m *AsyncMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	if err := m.flushConversation(ctx, conversationID); err != nil {
+		return err
+	}
+	return m.inner.ClearMessages(ctx, conversationID)
+}
+
+// Flush waits until every save queued before the call has been written, or ctx ends.
+func (m *AsyncMemory) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	done := m.lastAll
+	m.mu.Unlock()
+	return waitDone(ctx, done)
+}
+
+// Close stops accepting saves, waits for the queued ones to be written and closes the wrapped
+// memory when it has a Close method.
+func (m *AsyncMemory) Close() error {
+	m.sendMu.Lock()
+	if m.closed {
+		m.sendMu.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.queue)
+	m.sendMu.Unlock()
+
+	<-m.workerDone
+	return closeMemory(m.inner)
+}
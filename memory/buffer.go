@@ -3,6 +3,9 @@ package memory
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 
 	"github.com/MrLeeang/langchain-go/llms"
 )
@@ -15,12 +18,45 @@ import (
 type BufferMemory struct {
 	mu            sync.RWMutex
 	conversations map[string][]llms.ChatCompletionMessage
+	opts          BufferOptions
+	// lastUse holds the clock tick of each conversation's latest load or save; the values are
+	// atomic so LoadMessages can update them under the read lock
+	lastUse map[string]*atomic.Int64
+	clock   atomic.Int64
+	// changes counts modifications, so snapshots are only written when something changed
+	changes atomic.Int64
+	// persist is set by NewPersistentBufferMemory
+	persist *bufferPersistence
+}
+
+// BufferOptions limits the size of a BufferMemory. Zero values mean no limit.
+type BufferOptions struct {
+	// MaxMessagesPerConversation keeps about the newest N messages of each conversation. The
+	// kept messages start at a user message, so tool results are never separated from their
+	// tool calls; a turn longer than N is kept whole.
+	MaxMessagesPerConversation int
+	// MaxConversations evicts the least recently used conversations beyond N.
+	MaxConversations int
 }
 
 // NewBufferMemory creates a new BufferMemory instance.
 func NewBufferMemory() *BufferMemory {
+	return NewBufferMemoryWithOptions(BufferOptions{})
+}
+
+// NewBufferMemoryWithOptions creates a BufferMemory bounded by opts, for long-lived processes.
+//
+// Example:
+//
+//	mem := memory.NewBufferMemoryWithOptions(memory.BufferOptions{
+//	    MaxMessagesPerConversation: 100,
+//	    MaxConversations:           1000,
+//	})
+func NewBufferMemoryWithOptions(opts BufferOptions) *BufferMemory {
 	return &BufferMemory{
 		conversations: make(map[string][]llms.ChatCompletionMessage),
+		opts:          opts,
+		lastUse:       make(map[string]*atomic.Int64),
 	}
 }
 
@@ -31,6 +67,7 @@ func (m *BufferMemory) LoadMessages(ctx context.Context, conversationID string)
 
 	id := m.getConversationID(conversationID)
 	messages := m.conversations[id]
+	m.touch(id)
 
 	// Return a copy to prevent external modifications
 	result := make([]llms.ChatCompletionMessage, len(messages))
@@ -51,9 +88,79 @@ func (m *BufferMemory) SaveMessages(ctx context.Context, conversationID string,
 		}
 		m.conversations[id] = append(m.conversations[id], msg)
 	}
+
+	if _, ok := m.conversations[id]; !ok {
+		return nil
+	}
+	m.changes.Add(1)
+	if _, ok := m.lastUse[id]; !ok {
+		m.lastUse[id] = new(atomic.Int64)
+	}
+	m.touch(id)
+	if msgs := m.conversations[id]; m.opts.MaxMessagesPerConversation > 0 {
+		if start := trimStart(len(msgs), m.opts.MaxMessagesPerConversation, func(i int) bool { return msgs[i].Role == llms.ChatMessageRoleUser }); start > 0 {
+			// copy so the evicted messages can be collected
+			m.conversations[id] = append([]llms.ChatCompletionMessage(nil), msgs[start:]...)
+		}
+	}
+	if limit := m.opts.MaxConversations; limit > 0 {
+		for len(m.conversations) > limit {
+			m.evictLRU(id)
+		}
+	}
 	return nil
 }
 
+// trimStart returns the index where the newest limit or so of n messages start: the first user
+// message among the newest limit, or else the last one before them, so trimming never separates
+// tool results from their tool call. It returns 0 when nothing is to be trimmed.
+func trimStart(n, limit int, isUser func(i int) bool) int {
+	start := n - limit
+	if limit <= 0 || start <= 0 {
+		return 0
+	}
+	for i := start; i < n; i++ {
+		if isUser(i) {
+			return i
+		}
+	}
+	for i := start - 1; i > 0; i-- {
+		if isUser(i) {
+			return i
+		}
+	}
+	return 0
+}
+
+// touch marks the conversation as used now. Callers hold m.mu (read or write).
+func (m *BufferMemory) touch(id string) {
+	if t, ok := m.lastUse[id]; ok {
+		t.Store(m.clock.Add(1))
+	}
+}
+
+// evictLRU removes the least recently used conversation other than keep. Callers hold m.mu.
+func (m *BufferMemory) evictLRU(keep string) {
+	oldest, oldestUse := "", int64(0)
+	for id := range m.conversations {
+		if id == keep {
+			continue
+		}
+		var use int64
+		if t, ok := m.lastUse[id]; ok {
+			use = t.Load()
+		}
+		if oldest == "" || use < oldestUse {
+			oldest, oldestUse = id, use
+		}
+	}
+	if oldest == "" {
+		return
+	}
+	delete(m.conversations, oldest)
+	delete(m.lastUse, oldest)
+}
+
 // ClearMessages clears all messages for the given conversation ID.
 func (m *BufferMemory) ClearMessages(ctx context.Context, conversationID string) error {
 	m.mu.Lock()
@@ -61,9 +168,23 @@ func (m *BufferMemory) ClearMessages(ctx context.Context, conversationID string)
 
 	id := m.getConversationID(conversationID)
 	delete(m.conversations, id)
+	delete(m.lastUse, id)
+	m.changes.Add(1)
 	return nil
 }
 
+// Close implements [Closer]. A memory created with NewPersistentBufferMemory stops flushing
+// and writes a final snapshot; otherwise it does nothing.
+func (m *BufferMemory) Close() error {
+	p := m.persist
+	if p == nil {
+		return nil
+	}
+	p.closeOnce.Do(func() { close(p.stop) })
+	<-p.done
+	return m.FlushSnapshot()
+}
+
 // getConversationID returns the conversation ID, using a default if empty.
 func (m *BufferMemory) getConversationID(conversationID string) string {
 	if conversationID == "" {
@@ -93,3 +214,65 @@ func (m *BufferMemory) GetRelevantMessages(conversationID string) []llms.ChatCom
 
 	return messages
 }
+
+// BufferStats describes the contents of a BufferMemory.
+type BufferStats struct {
+	Conversations int
+	Messages      int
+	// ApproxBytes estimates the memory held by the messages (struct sizes plus string data).
+	ApproxBytes int
+	// PerConversation holds the message count and size of each conversation.
+	PerConversation map[string]ConversationStats
+}
+
+// ConversationStats is the size of one conversation in a BufferMemory.
+type ConversationStats struct {
+	Messages    int
+	ApproxBytes int
+}
+
+// Stats returns message counts and approximate memory usage, overall and per conversation.
+func (m *BufferMemory) Stats() BufferStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := BufferStats{
+		Conversations:   len(m.conversations),
+		PerConversation: make(map[string]ConversationStats, len(m.conversations)),
+	}
+	for id, messages := range m.conversations {
+		cs := ConversationStats{Messages: len(messages)}
+		for _, msg := range messages {
+			cs.ApproxBytes += approxMessageSize(msg)
+		}
+		stats.PerConversation[id] = cs
+		stats.Messages += cs.Messages
+		stats.ApproxBytes += cs.ApproxBytes
+	}
+	return stats
+}
+
+// GetStats implements [StatsProvider]. BufferMemory doesn't record when messages were saved,
+// so FirstMessageAt and LastMessageAt are zero.
+func (m *BufferMemory) GetStats(ctx context.Context, conversationID string) (Stats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var stats Stats
+	for _, msg := range m.conversations[m.getConversationID(conversationID)] {
+		stats.add(msg, time.Time{})
+	}
+	return stats, nil
+}
+
+// approxMessageSize estimates the bytes held by msg.
+func approxMessageSize(msg llms.ChatCompletionMessage) int {
+	n := int(unsafe.Sizeof(msg)) + len(msg.Role) + len(msg.Content) + len(msg.ReasoningContent) + len(msg.ToolCallID)
+	for _, p := range msg.MultiContent {
+		n += int(unsafe.Sizeof(p)) + len(p.Type) + len(p.Text) + len(p.ImageURL)
+	}
+	for _, tc := range msg.ToolCalls {
+		n += int(unsafe.Sizeof(tc)) + len(tc.ID) + len(tc.Name) + len(tc.Arguments)
+	}
+	return n
+}
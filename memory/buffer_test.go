@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+func TestTrimStart(t *testing.T) {
+	// u = user, a = assistant, t = tool
+	tests := []struct {
+		roles string
+		limit int
+		want  int
+	}{
+		{"uaua", 0, 0},
+		{"uaua", 4, 0},
+		{"uaua", 2, 2},
+		{"uatauata", 5, 4},
+		{"uatauata", 4, 4},
+		{"uaua" + "uatata", 3, 4},
+		{"uatata", 2, 0},
+		{"ttua", 1, 2},
+	}
+	for _, tt := range tests {
+		got := trimStart(len(tt.roles), tt.limit, func(i int) bool { return tt.roles[i] == 'u' })
+		if got != tt.want {
+			t.Errorf("trimStart(%q, %d) = %d, want %d", tt.roles, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestBufferMemoryMaxMessagesKeepsWholeTurns(t *testing.T) {
+	ctx := context.Background()
+	m := NewBufferMemoryWithOptions(BufferOptions{MaxMessagesPerConversation: 5})
+	for n := 1; n <= 3; n++ {
+		if err := m.SaveMessages(ctx, "c1", turn(n, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, _ := m.LoadMessages(ctx, "c1")
+	if len(got) != 4 || got[0].Role != llms.ChatMessageRoleUser || got[0].Content != "q3" {
+		t.Errorf("kept %+v, want only the last turn", got)
+	}
+	for i, msg := range got {
+		if msg.Role == llms.ChatMessageRoleTool && (i == 0 || len(got[i-1].ToolCalls) == 0) {
+			t.Errorf("tool result %d kept without its tool call", i)
+		}
+	}
+}
+
+func TestBufferMemoryMaxConversationsEvictsLRU(t *testing.T) {
+	ctx := context.Background()
+	m := NewBufferMemoryWithOptions(BufferOptions{MaxConversations: 2})
+	for _, id := range []string{"a", "b"} {
+		if err := m.SaveMessages(ctx, id, turn(1, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.LoadMessages(ctx, "a")
+	if err := m.SaveMessages(ctx, "c", turn(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]int{"a": 2, "b": 0, "c": 2} {
+		if got, _ := m.LoadMessages(ctx, id); len(got) != want {
+			t.Errorf("conversation %s has %d messages, want %d", id, len(got), want)
+		}
+	}
+	if n := m.Stats().Conversations; n != 2 {
+		t.Errorf("Stats().Conversations = %d, want 2", n)
+	}
+}
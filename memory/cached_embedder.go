@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+type bypassEmbeddingCacheKey struct{}
+
+// WithoutEmbeddingCache returns a context that makes [CachedEmbedder] skip cache lookups
+// (fresh results are still stored), e.g. after switching the embedding model.
+func WithoutEmbeddingCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassEmbeddingCacheKey{}, true)
+}
+
+// EmbeddingCacheStats reports CachedEmbedder effectiveness.
+type EmbeddingCacheStats struct {
+	Hits   int64
+	Misses int64
+	Size   int
+}
+
+// CachedEmbedder wraps an EmbedderInterface with an LRU cache keyed by a hash of each input.
+// It is safe for concurrent use and can be used wherever MilvusConfig.Embedder is expected.
+//
+// Example:
+//
+//	embedder := memory.NewCachedEmbedder(memory.NewEmbedderWrapperFromEmbeddings(model), 10000)
+type CachedEmbedder struct {
+	inner EmbedderInterface
+	size  int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type embeddingEntry struct {
+	key    [sha256.Size]byte
+	vector []float32
+}
+
+// NewCachedEmbedder creates a CachedEmbedder holding at most size vectors (default 1000 when size <= 0).
+func NewCachedEmbedder(inner EmbedderInterface, size int) *CachedEmbedder {
+	if size <= 0 {
+		size = 1000
+	}
+	return &CachedEmbedder{
+		inner:   inner,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// Embeddings implements EmbedderInterface. Only inputs missing from the cache are sent to the inner embedder.
+func (c *CachedEmbedder) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	bypass, _ := ctx.Value(bypassEmbeddingCacheKey{}).(bool)
+
+	out := make([][]float32, len(inputs))
+	keys := make([][sha256.Size]byte, len(inputs))
+	var missing []string
+	var missingIdx []int
+	for i, input := range inputs {
+		keys[i] = sha256.Sum256([]byte(input))
+		if !bypass {
+			if vec, ok := c.get(keys[i]); ok {
+				out[i] = vec
+				c.hits.Add(1)
+				continue
+			}
+		}
+		c.misses.Add(1)
+		missing = append(missing, input)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	vectors, err := c.inner.Embeddings(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(missing) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d inputs", len(vectors), len(missing))
+	}
+	for j, i := range missingIdx {
+		out[i] = vectors[j]
+		c.put(keys[i], vectors[j])
+	}
+	return out, nil
+}
+
+// Stats returns hit/miss counters and the current number of cached vectors.
+func (c *CachedEmbedder) Stats() EmbeddingCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+	return EmbeddingCacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Size: size}
+}
+
+func (c *CachedEmbedder) get(key [sha256.Size]byte) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*embeddingEntry).vector, true
+}
+
+func (c *CachedEmbedder) put(key [sha256.Size]byte, vector []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*embeddingEntry).vector = vector
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&embeddingEntry{key: key, vector: vector})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*embeddingEntry).key)
+	}
+}
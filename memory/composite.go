@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// CompositeMemory combines a recent-history [Memory] with a long-term [ConversationMemory]:
+// LoadMessages returns older exchanges relevant to the current query, recalled from the
+// long-term memory, followed by the last few exchanges of the recent memory verbatim.
+// Messages are saved to both.
+//
+// Example:
+//
+//	mem := memory.NewCompositeMemory(memory.NewBufferMemory(), milvusMem, memory.CompositeConfig{
+//	    RecentExchanges: 3,
+//	    MaxRelevantMessages: 6,
+//	})
+type CompositeMemory struct {
+	recent   Memory
+	longTerm ConversationMemory
+	cfg      CompositeConfig
+	query    string
+	mutex    sync.RWMutex
+}
+
+// CompositeConfig holds configuration for CompositeMemory.
+type CompositeConfig struct {
+	// RecentExchanges is how many of the latest exchanges of the recent memory are returned.
+	// Default is 3.
+	RecentExchanges int
+
+	// MaxRelevantMessages is the limit passed to the long-term GetRelevantMessages.
+	// Default is 10.
+	MaxRelevantMessages int
+}
+
+// NewCompositeMemory combines recent and longTerm.
+func NewCompositeMemory(recent Memory, longTerm ConversationMemory, cfg CompositeConfig) *CompositeMemory {
+	if cfg.RecentExchanges <= 0 {
+		cfg.RecentExchanges = 3
+	}
+	if cfg.MaxRelevantMessages <= 0 {
+		cfg.MaxRelevantMessages = 10
+	}
+	return &CompositeMemory{recent: recent, longTerm: longTerm, cfg: cfg}
+}
+
+// SetQuery sets the query used to recall long-term exchanges and forwards it to the long-term
+// memory when it is query-aware. Agents call it with the user input before LoadMessages.
+func (m *CompositeMemory) SetQuery(query string) {
+	m.mutex.Lock()
+	m.query = query
+	m.mutex.Unlock()
+	if q, ok := m.longTerm.(MilvusMemoryInterface); ok {
+		q.SetQuery(query)
+	}
+}
+
+// LoadMessages returns the long-term exchanges relevant to the query that are not already in
+// the recent window, followed by the recent window in chronological order. Without a query
+// only the recent window is returned.
+func (m *CompositeMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	recent, err := m.recent.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	window := lastExchanges(recent, m.cfg.RecentExchanges)
+
+	m.mutex.RLock()
+	query := m.query
+	m.mutex.RUnlock()
+	if query == "" {
+		return window, nil
+	}
+
+	relevant, err := m.longTerm.GetRelevantMessages(ctx, conversationID, query, m.cfg.MaxRelevantMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	inWindow := make(map[string]bool)
+	for _, msg := range window {
+		if msg.Role == llms.ChatMessageRoleUser {
+			inWindow[msg.Content] = true
+		}
+	}
+
+	out := make([]llms.ChatCompletionMessage, 0, len(relevant)+len(window))
+	skip := false
+	for _, msg := range relevant {
+		// an exchange starts at a user message; drop the whole exchange when the window has it
+		if msg.Role == llms.ChatMessageRoleUser {
+			skip = inWindow[msg.Content]
+		}
+		if !skip {
+			out = append(out, msg)
+		}
+	}
+	return append(out, window...), nil
+}
+
+// SaveMessages saves messages to both memories.
+func (m *CompositeMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	return errors.Join(
+		m.recent.SaveMessages(ctx, conversationID, messages),
+		m.longTerm.SaveMessages(ctx, conversationID, messages),
+	)
+}
+
+// ClearMessages clears the conversation in both memories.
+func (m *CompositeMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	return errors.Join(
+		m.recent.ClearMessages(ctx, conversationID),
+		m.longTerm.ClearMessages(ctx, conversationID),
+	)
+}
+
+// Close closes both memories when they implement [Closer].
+func (m *CompositeMemory) Close() error {
+	return errors.Join(closeMemory(m.recent), closeMemory(m.longTerm))
+}
@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// importBatch is how many messages Import passes to one SaveMessages call.
+const importBatch = 500
+
+// exportedMessage is one line of an export: the message and optional metadata.
+type exportedMessage struct {
+	storedMessage
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// metadataSaver is implemented by memories that store metadata with messages
+// (MilvusMemory, RedisMemory).
+type metadataSaver interface {
+	SaveMessagesWithMetadata(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage, metadata map[string]string) error
+}
+
+// Export writes the history of conversationID loaded from m to w as JSON Lines, one message
+// per line with role, content, tool calls and the other message fields.
+//
+// Example:
+//
+//	f, _ := os.Create("conv-123.jsonl")
+//	defer f.Close()
+//	err := memory.Export(ctx, mem, "conv-123", f)
+func Export(ctx context.Context, m Memory, conversationID string, w io.Writer) error {
+	messages, err := m.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to load messages: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	for _, msg := range messages {
+		if err := enc.Encode(exportedMessage{storedMessage: messageToStored(msg)}); err != nil {
+			return fmt.Errorf("failed to write message: %w", err)
+		}
+	}
+	return nil
+}
+
+// Import reads messages written by Export from r and replays them into m with SaveMessages,
+// in order and in batches, so large exports are not held in memory. Lines with metadata are
+// saved with it when m supports metadata (MilvusMemory, RedisMemory); otherwise it is dropped.
+func Import(ctx context.Context, m Memory, conversationID string, r io.Reader) error {
+	saver, withMeta := m.(metadataSaver)
+	save := func(batch []llms.ChatCompletionMessage, metadata map[string]string) error {
+		if len(batch) == 0 {
+			return nil
+		}
+		var err error
+		if withMeta && len(metadata) > 0 {
+			err = saver.SaveMessagesWithMetadata(ctx, conversationID, batch, metadata)
+		} else {
+			err = m.SaveMessages(ctx, conversationID, batch)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save messages: %w", err)
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(r)
+	var batch []llms.ChatCompletionMessage
+	var metadata map[string]string
+	for line := 1; ; line++ {
+		var em exportedMessage
+		if err := dec.Decode(&em); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("failed to read message %d: %w", line, err)
+		}
+		// a batch shares one metadata value
+		if len(batch) == importBatch || (withMeta && !maps.Equal(em.Metadata, metadata)) {
+			if err := save(batch, metadata); err != nil {
+				return err
+			}
+			batch = nil
+		}
+		batch = append(batch, storedToLLM(em.storedMessage))
+		metadata = em.Metadata
+	}
+	return save(batch, metadata)
+}
+
+// MigrationError reports the conversations Migrate could not copy.
+type MigrationError struct {
+	// Errors maps each failed conversation ID to its error.
+	Errors map[string]error
+}
+
+func (e *MigrationError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("%s: %v", id, e.Errors[id])
+	}
+	return fmt.Sprintf("failed to migrate %d conversations: %s", len(ids), strings.Join(parts, "; "))
+}
+
+// Migrate copies conversationIDs from src to dst, one conversation at a time: each is exported
+// from src and imported into dst through a pipe. A failed conversation doesn't stop the others;
+// the failures are returned as a *MigrationError.
+//
+// Example:
+//
+//	err := memory.Migrate(ctx, bufferMem, sqliteMem, []string{"conv-1", "conv-2"})
+//	var migErr *memory.MigrationError
+//	if errors.As(err, &migErr) {
+//	    for id, err := range migErr.Errors { ... }
+//	}
+func Migrate(ctx context.Context, src, dst Memory, conversationIDs []string) error {
+	failed := make(map[string]error)
+	for _, id := range conversationIDs {
+		if err := ctx.Err(); err != nil {
+			failed[id] = err
+			continue
+		}
+		if err := migrateConversation(ctx, src, dst, id); err != nil {
+			failed[id] = err
+		}
+	}
+	if len(failed) > 0 {
+		return &MigrationError{Errors: failed}
+	}
+	return nil
+}
+
+// migrateConversation streams one conversation from src to dst.
+func migrateConversation(ctx context.Context, src, dst Memory, conversationID string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(Export(ctx, src, conversationID, pw))
+	}()
+	err := Import(ctx, dst, conversationID, pr)
+	// unblock the exporter if Import stopped early
+	pr.CloseWithError(err)
+	return err
+}
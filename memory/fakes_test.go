@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// captureHandler is a slog.Handler keeping the records it handles.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *captureHandler) WithGroup(string) slog.Handler { return h }
+
+// count returns how many records at level have a message containing msg.
+func (h *captureHandler) count(level slog.Level, msg string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, r := range h.records {
+		if r.Level == level && strings.Contains(r.Message, msg) {
+			n++
+		}
+	}
+	return n
+}
+
+// attr returns the value of the attribute key of the first record at level whose message
+// contains msg, or "" when there is none.
+func (h *captureHandler) attr(level slog.Level, msg, key string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Level != level || !strings.Contains(r.Message, msg) {
+			continue
+		}
+		var value string
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == key {
+				value = a.Value.String()
+				return false
+			}
+			return true
+		})
+		return value
+	}
+	return ""
+}
+
+// turn returns the messages of a turn: the user message, calls tool calls with their
+// results, and the answer.
+func turn(n, calls int) []llms.ChatCompletionMessage {
+	msgs := []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: fmt.Sprintf("q%d", n)}}
+	for i := range calls {
+		id := fmt.Sprintf("call_%d_%d", n, i)
+		msgs = append(msgs,
+			llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, ToolCalls: []llms.ChatToolCall{{ID: id, Name: "search", Arguments: "{}"}}},
+			llms.ChatCompletionMessage{Role: llms.ChatMessageRoleTool, ToolCallID: id, Content: "result"},
+		)
+	}
+	return append(msgs, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: fmt.Sprintf("a%d", n)})
+}
+
+// conversationIDs formats the IDs of convs, in order, e.g. "[c2 c1]".
+func conversationIDs(convs []ConversationInfo) string {
+	ids := make([]string, len(convs))
+	for i, c := range convs {
+		ids[i] = c.ID
+	}
+	return fmt.Sprint(ids)
+}
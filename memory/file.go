@@ -105,10 +105,17 @@ type storedMessage struct {
 	Role             string           `json:"role"`
 	ReasoningContent string           `json:"reasoning_content"`
 	Content          string           `json:"content"`
+	MultiContent     []storedPart     `json:"multi_content,omitempty"`
 	ToolCalls        []storedToolCall `json:"tool_calls,omitempty"`
 	ToolCallID       string           `json:"tool_call_id,omitempty"`
 }
 
+type storedPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+}
+
 type storedToolCall struct {
 	ID        string `json:"id"`
 	Name      string `json:"name"`
@@ -131,27 +138,35 @@ func llmToStored(msgs []llms.ChatCompletionMessage) []storedMessage {
 			continue
 		}
 
-		sm := storedMessage{
-			Role:             msg.Role,
-			Content:          msg.Content,
-			ReasoningContent: msg.ReasoningContent,
-			ToolCallID:       msg.ToolCallID,
-		}
-		if len(msg.ToolCalls) > 0 {
-			sm.ToolCalls = make([]storedToolCall, 0, len(msg.ToolCalls))
-			for _, tc := range msg.ToolCalls {
-				sm.ToolCalls = append(sm.ToolCalls, storedToolCall{
-					ID:        tc.ID,
-					Name:      tc.Name,
-					Arguments: tc.Arguments,
-				})
-			}
-		}
-		out = append(out, sm)
+		out = append(out, messageToStored(msg))
 	}
 	return out
 }
 
+// messageToStored converts msg, whatever its role, to its stored form.
+func messageToStored(msg llms.ChatCompletionMessage) storedMessage {
+	sm := storedMessage{
+		Role:             msg.Role,
+		Content:          msg.Content,
+		ReasoningContent: msg.ReasoningContent,
+		ToolCallID:       msg.ToolCallID,
+	}
+	for _, p := range msg.MultiContent {
+		sm.MultiContent = append(sm.MultiContent, storedPart{Type: p.Type, Text: p.Text, ImageURL: p.ImageURL})
+	}
+	if len(msg.ToolCalls) > 0 {
+		sm.ToolCalls = make([]storedToolCall, 0, len(msg.ToolCalls))
+		for _, tc := range msg.ToolCalls {
+			sm.ToolCalls = append(sm.ToolCalls, storedToolCall{
+				ID:        tc.ID,
+				Name:      tc.Name,
+				Arguments: tc.Arguments,
+			})
+		}
+	}
+	return sm
+}
+
 func storedToLLM(sm storedMessage) llms.ChatCompletionMessage {
 	msg := llms.ChatCompletionMessage{
 		Role:             sm.Role,
@@ -159,6 +174,9 @@ func storedToLLM(sm storedMessage) llms.ChatCompletionMessage {
 		ReasoningContent: sm.ReasoningContent,
 		ToolCallID:       sm.ToolCallID,
 	}
+	for _, p := range sm.MultiContent {
+		msg.MultiContent = append(msg.MultiContent, llms.ChatMessagePart{Type: p.Type, Text: p.Text, ImageURL: p.ImageURL})
+	}
 	if len(sm.ToolCalls) > 0 {
 		msg.ToolCalls = make([]llms.ChatToolCall, 0, len(sm.ToolCalls))
 		for _, tc := range sm.ToolCalls {
@@ -173,8 +191,18 @@ func storedToLLM(sm storedMessage) llms.ChatCompletionMessage {
 }
 
 func (m *FileMemory) readStoreLocked() (fileStore, error) {
+	return readStoreFile(m.filePath)
+}
+
+func (m *FileMemory) writeStoreLocked(store fileStore) error {
+	return writeStoreFile(m.filePath, store)
+}
+
+// readStoreFile reads a store written by writeStoreFile; a missing or empty file is an
+// empty store.
+func readStoreFile(path string) (fileStore, error) {
 	var store fileStore
-	data, err := os.ReadFile(m.filePath)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return fileStore{Conversations: make(map[string][]storedMessage)}, nil
@@ -185,7 +213,7 @@ func (m *FileMemory) readStoreLocked() (fileStore, error) {
 		return fileStore{Conversations: make(map[string][]storedMessage)}, nil
 	}
 	if err := json.Unmarshal(data, &store); err != nil {
-		return fileStore{}, fmt.Errorf("memory file %s: invalid JSON: %w", m.filePath, err)
+		return fileStore{}, fmt.Errorf("memory file %s: invalid JSON: %w", path, err)
 	}
 	if store.Conversations == nil {
 		store.Conversations = make(map[string][]storedMessage)
@@ -193,8 +221,9 @@ func (m *FileMemory) readStoreLocked() (fileStore, error) {
 	return store, nil
 }
 
-func (m *FileMemory) writeStoreLocked(store fileStore) error {
-	dir := filepath.Dir(m.filePath)
+// writeStoreFile atomically replaces the file at path (temp file + rename) with store.
+func writeStoreFile(path string, store fileStore) error {
+	dir := filepath.Dir(path)
 	if dir != "" && dir != "." {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return fmt.Errorf("create memory dir %s: %w", dir, err)
@@ -222,9 +251,9 @@ func (m *FileMemory) writeStoreLocked(store fileStore) error {
 		return fmt.Errorf("close temp memory file: %w", cerr)
 	}
 
-	if err := os.Rename(tmpPath, m.filePath); err != nil {
+	if err := os.Rename(tmpPath, path); err != nil {
 		_ = os.Remove(tmpPath)
-		return fmt.Errorf("replace memory file %s: %w", m.filePath, err)
+		return fmt.Errorf("replace memory file %s: %w", path, err)
 	}
 	return nil
 }
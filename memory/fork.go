@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrConversationExists is returned by Fork when the destination conversation has messages.
+var ErrConversationExists = errors.New("conversation already exists")
+
+// conversationForker is implemented by memories that copy a conversation natively
+// (RedisMemory, SQLiteMemory).
+type conversationForker interface {
+	ForkConversation(ctx context.Context, srcConversationID, dstConversationID string) error
+}
+
+// Fork copies the history of srcConversationID to the empty conversation dstConversationID,
+// so the two can diverge from a common history (e.g. to A/B test prompts). RedisMemory and
+// SQLiteMemory copy natively, keeping timestamps and metadata; other memories copy what
+// LoadMessages returns with SaveMessages, so a windowed or query-based memory forks only the
+// history it would load. A dstConversationID with messages is an ErrConversationExists error.
+//
+// Example:
+//
+//	if err := memory.Fork(ctx, mem, "conv-1", "conv-1-b"); err != nil {
+//	    log.Fatal(err)
+//	}
+func Fork(ctx context.Context, m Memory, srcConversationID, dstConversationID string) error {
+	if normalizeConversationID(srcConversationID) == normalizeConversationID(dstConversationID) {
+		return fmt.Errorf("cannot fork conversation %q into itself", normalizeConversationID(srcConversationID))
+	}
+	if f, ok := m.(conversationForker); ok {
+		return f.ForkConversation(ctx, srcConversationID, dstConversationID)
+	}
+
+	existing, err := m.LoadMessages(ctx, dstConversationID)
+	if err != nil {
+		return fmt.Errorf("failed to load messages: %w", err)
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("%w: %s", ErrConversationExists, normalizeConversationID(dstConversationID))
+	}
+	messages, err := m.LoadMessages(ctx, srcConversationID)
+	if err != nil {
+		return fmt.Errorf("failed to load messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	if err := m.SaveMessages(ctx, dstConversationID, messages); err != nil {
+		return fmt.Errorf("failed to save messages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// Hooks are functions run around the operations of a [HookedMemory]. Nil hooks are skipped.
+type Hooks struct {
+	// BeforeSave can transform the messages before they are saved (e.g. redact them). An
+	// error aborts the save and is returned by SaveMessages.
+	BeforeSave func(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) ([]llms.ChatCompletionMessage, error)
+
+	// AfterLoad can filter or transform loaded messages, or audit the read. An error is
+	// returned by LoadMessages instead of the messages.
+	AfterLoad func(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) ([]llms.ChatCompletionMessage, error)
+
+	// OnClear runs before a conversation is cleared. An error aborts the clear.
+	OnClear func(ctx context.Context, conversationID string) error
+}
+
+// HookedMemory wraps a [Memory] and runs [Hooks] around its operations, for auditing or
+// scrubbing personal data before it is persisted.
+//
+// Example:
+//
+//	mem := memory.NewHookedMemory(redisMem, memory.PIIScrubber())
+type HookedMemory struct {
+	inner Memory
+	hooks Hooks
+}
+
+// NewHookedMemory wraps inner with hooks.
+func NewHookedMemory(inner Memory, hooks Hooks) *HookedMemory {
+	return &HookedMemory{inner: inner, hooks: hooks}
+}
+
+// LoadMessages loads from the wrapped memory and passes the messages through AfterLoad.
+func (m *HookedMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	messages, err := m.inner.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if m.hooks.AfterLoad != nil {
+		return m.hooks.AfterLoad(ctx, conversationID, messages)
+	}
+	return messages, nil
+}
+
+// SaveMessages passes messages through BeforeSave and saves the result.
+func (m *HookedMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	if m.hooks.BeforeSave != nil {
+		var err error
+		if messages, err = m.hooks.BeforeSave(ctx, conversationID, messages); err != nil {
+			return err
+		}
+	}
+	return m.inner.SaveMessages(ctx, conversationID, messages)
+}
+
+// ClearMessages runs OnClear, then clears the conversation.
+func (m *HookedMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	if m.hooks.OnClear != nil {
+		if err := m.hooks.OnClear(ctx, conversationID); err != nil {
+			return err
+		}
+	}
+	return m.inner.ClearMessages(ctx, conversationID)
+}
+
+// Close closes the wrapped memory when it implements [Closer].
+func (m *HookedMemory) Close() error {
+	return closeMemory(m.inner)
+}
+
+// PIIPattern is a pattern redacted by [PIIScrubber].
+type PIIPattern struct {
+	Name        string
+	Regexp      *regexp.Regexp
+	Replacement string
+	// Valid reports whether a match is redacted, e.g. to check a checksum; nil redacts every
+	// match.
+	Valid func(match string) bool
+}
+
+// DefaultPIIPatterns redact e-mail addresses, payment card numbers (13 to 19 digits, optionally
+// grouped with spaces or dashes, passing the Luhn check) and phone numbers (international,
+// mainland China mobile, and grouped local formats such as 555-123-4567).
+var DefaultPIIPatterns = []PIIPattern{
+	{
+		Name:        "email",
+		Regexp:      regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		Replacement: "[EMAIL]",
+	},
+	{
+		// before phone, whose grouped formats match parts of card numbers
+		Name:        "card",
+		Regexp:      regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Replacement: "[CARD]",
+		Valid:       luhnValid,
+	},
+	{
+		Name:        "phone",
+		Regexp:      regexp.MustCompile(`\+\d{1,3}[\s.-]?\d{1,4}(?:[\s.-]?\d{2,4}){2,4}|\b1[3-9]\d{9}\b|(?:\(\d{2,4}\)\s?|\b\d{3,4}[\s.-])\d{3,4}[\s.-]\d{4}\b`),
+		Replacement: "[PHONE]",
+	},
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum of card numbers.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// ScrubPII replaces the matches of patterns in text.
+func ScrubPII(text string, patterns []PIIPattern) string {
+	for _, p := range patterns {
+		if p.Valid == nil {
+			text = p.Regexp.ReplaceAllString(text, p.Replacement)
+			continue
+		}
+		text = p.Regexp.ReplaceAllStringFunc(text, func(match string) string {
+			if !p.Valid(match) {
+				return match
+			}
+			return p.Regexp.ReplaceAllString(match, p.Replacement)
+		})
+	}
+	return text
+}
+
+// PIIScrubber returns hooks that redact patterns (DefaultPIIPatterns when none are given)
+// from message content, reasoning, text parts and tool call arguments before they are saved.
+func PIIScrubber(patterns ...PIIPattern) Hooks {
+	if len(patterns) == 0 {
+		patterns = DefaultPIIPatterns
+	}
+	return Hooks{
+		BeforeSave: func(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) ([]llms.ChatCompletionMessage, error) {
+			out := make([]llms.ChatCompletionMessage, len(messages))
+			for i, msg := range messages {
+				msg.Content = ScrubPII(msg.Content, patterns)
+				msg.ReasoningContent = ScrubPII(msg.ReasoningContent, patterns)
+				if len(msg.MultiContent) > 0 {
+					parts := make([]llms.ChatMessagePart, len(msg.MultiContent))
+					for j, p := range msg.MultiContent {
+						p.Text = ScrubPII(p.Text, patterns)
+						parts[j] = p
+					}
+					msg.MultiContent = parts
+				}
+				if len(msg.ToolCalls) > 0 {
+					calls := make([]llms.ChatToolCall, len(msg.ToolCalls))
+					for j, tc := range msg.ToolCalls {
+						tc.Arguments = ScrubPII(tc.Arguments, patterns)
+						calls[j] = tc
+					}
+					msg.ToolCalls = calls
+				}
+				out[i] = msg
+			}
+			return out, nil
+		},
+	}
+}
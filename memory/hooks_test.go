@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+func TestScrubPII(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"email", "mail jane.doe+tag@example.co.uk today", "mail [EMAIL] today"},
+		{"two emails", "a@b.io, c_d@e-f.org", "[EMAIL], [EMAIL]"},
+		{"international phone", "call +1 415-555-2671", "call [PHONE]"},
+		{"international phone without separators", "call +8613800138000", "call [PHONE]"},
+		{"mainland mobile", "手机 13800138000 联系", "手机 [PHONE] 联系"},
+		{"grouped local phone", "555-123-4567 or 555.123.4567", "[PHONE] or [PHONE]"},
+		{"phone with area code", "(010) 1234-5678", "[PHONE]"},
+		{"card", "card 4111111111111111 ok", "card [CARD] ok"},
+		{"card with spaces", "4111 1111 1111 1111", "[CARD]"},
+		{"card with dashes", "5500-0000-0000-0004", "[CARD]"},
+		{"amex", "3782 822463 10005", "[CARD]"},
+
+		// false positives
+		{"date", "due 2024-01-15", "due 2024-01-15"},
+		{"time", "at 12:30:45", "at 12:30:45"},
+		{"version", "v1.2.3 and 10.0.19045", "v1.2.3 and 10.0.19045"},
+		{"ip address", "host 192.168.1.100", "host 192.168.1.100"},
+		{"order number", "order 1234567", "order 1234567"},
+		{"long id failing luhn", "id 4111111111111112", "id 4111111111111112"},
+		{"mobile-like id too long", "id 138001380001", "id 138001380001"},
+		{"at without domain", "meet @ noon, user@localhost", "meet @ noon, user@localhost"},
+		{"price", "costs 1,299.00", "costs 1,299.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScrubPII(tt.in, DefaultPIIPatterns); got != tt.want {
+				t.Errorf("ScrubPII(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPIIScrubberCustomPatterns(t *testing.T) {
+	ctx := context.Background()
+	inner := NewBufferMemory()
+	mem := NewHookedMemory(inner, PIIScrubber(PIIPattern{
+		Name:        "ticket",
+		Regexp:      regexp.MustCompile(`TICKET-\d+`),
+		Replacement: "[TICKET]",
+	}))
+	msgs := []llms.ChatCompletionMessage{
+		{Role: llms.ChatMessageRoleUser, MultiContent: []llms.ChatMessagePart{{Type: llms.ChatMessagePartTypeText, Text: "see TICKET-42, a@b.io"}}},
+		{Role: llms.ChatMessageRoleAssistant, ReasoningContent: "TICKET-42", ToolCalls: []llms.ChatToolCall{{ID: "c1", Name: "lookup", Arguments: `{"id":"TICKET-42"}`}}},
+	}
+	if err := mem.SaveMessages(ctx, "c1", msgs); err != nil {
+		t.Fatal(err)
+	}
+	got, err := inner.LoadMessages(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := got[0].MultiContent[0].Text; text != "see [TICKET], a@b.io" {
+		t.Errorf("text part = %q, want only the custom pattern redacted", text)
+	}
+	if got[1].ReasoningContent != "[TICKET]" || got[1].ToolCalls[0].Arguments != `{"id":"[TICKET]"}` {
+		t.Errorf("assistant message = %+v, want reasoning and arguments redacted", got[1])
+	}
+	if msgs[0].MultiContent[0].Text != "see TICKET-42, a@b.io" || msgs[1].ToolCalls[0].Arguments != `{"id":"TICKET-42"}` {
+		t.Error("the caller's messages were modified")
+	}
+}
+
+func TestHookedMemory(t *testing.T) {
+	ctx := context.Background()
+	errDenied := errors.New("denied")
+	inner := NewBufferMemory()
+	var audited []string
+	mem := NewHookedMemory(inner, Hooks{
+		BeforeSave: func(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) ([]llms.ChatCompletionMessage, error) {
+			if conversationID == "readonly" {
+				return nil, errDenied
+			}
+			return messages, nil
+		},
+		AfterLoad: func(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) ([]llms.ChatCompletionMessage, error) {
+			audited = append(audited, conversationID)
+			var kept []llms.ChatCompletionMessage
+			for _, msg := range messages {
+				if msg.Role != llms.ChatMessageRoleTool {
+					kept = append(kept, msg)
+				}
+			}
+			return kept, nil
+		},
+		OnClear: func(ctx context.Context, conversationID string) error {
+			if conversationID == "c1" {
+				return errDenied
+			}
+			return nil
+		},
+	})
+
+	if err := mem.SaveMessages(ctx, "readonly", turn(1, 0)); !errors.Is(err, errDenied) {
+		t.Errorf("SaveMessages = %v, want the BeforeSave error", err)
+	}
+	if got, _ := inner.LoadMessages(ctx, "readonly"); len(got) != 0 {
+		t.Errorf("aborted save stored %+v", got)
+	}
+
+	if err := mem.SaveMessages(ctx, "c1", turn(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := mem.LoadMessages(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || len(audited) != 1 {
+		t.Errorf("LoadMessages = %+v (audited %v), want the tool result filtered out", got, audited)
+	}
+
+	if err := mem.ClearMessages(ctx, "c1"); !errors.Is(err, errDenied) {
+		t.Errorf("ClearMessages = %v, want the OnClear error", err)
+	}
+	if got, _ := inner.LoadMessages(ctx, "c1"); len(got) != 4 {
+		t.Errorf("aborted clear left %d messages, want 4", len(got))
+	}
+}
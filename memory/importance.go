@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ImportanceScorer rates how worth remembering a Q&A pair is, from 0 (not at all) to 1.
+// MilvusMemory skips pairs scoring below MilvusConfig.MinImportance. Implement it with an LLM
+// call for better judgments than [HeuristicScorer].
+type ImportanceScorer interface {
+	Score(ctx context.Context, userInput, llmOutput string) (float64, error)
+}
+
+// HeuristicScorer scores Q&A pairs without a model: long exchanges, questions and exchanges
+// mentioning names or numbers score higher, while acknowledgements such as "ok thanks" score
+// close to 0.
+type HeuristicScorer struct{}
+
+// Score implements [ImportanceScorer]. Length contributes up to 0.5, a question mark in the
+// user input 0.2 and named entities or numbers up to 0.3.
+func (HeuristicScorer) Score(ctx context.Context, userInput, llmOutput string) (float64, error) {
+	length := utf8.RuneCountInString(strings.TrimSpace(userInput)) + utf8.RuneCountInString(strings.TrimSpace(llmOutput))
+	score := 0.5 * math.Min(float64(length)/200, 1)
+	if strings.ContainsAny(userInput, "?？") {
+		score += 0.2
+	}
+	entities := countEntities(userInput) + countEntities(llmOutput)
+	score += 0.3 * math.Min(float64(entities)/5, 1)
+	return score, nil
+}
+
+// countEntities counts the words of text that look like names (capitalized, not starting a
+// sentence) or contain digits.
+func countEntities(text string) int {
+	n := 0
+	sentenceStart := true
+	for _, word := range strings.Fields(text) {
+		first, _ := utf8.DecodeRuneInString(word)
+		switch {
+		case strings.ContainsFunc(word, unicode.IsDigit):
+			n++
+		case unicode.IsUpper(first) && !sentenceStart:
+			n++
+		}
+		sentenceStart = strings.ContainsAny(word[len(word)-1:], ".!?")
+	}
+	return n
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+// mapScorer scores Q&A pairs by their question, 1 when absent; questions in fail fail to score.
+type mapScorer struct {
+	scores map[string]float64
+	fail   map[string]bool
+}
+
+func (s mapScorer) Score(ctx context.Context, userInput, llmOutput string) (float64, error) {
+	if s.fail[userInput] {
+		return 0, errors.New("scorer unavailable")
+	}
+	if score, ok := s.scores[userInput]; ok {
+		return score, nil
+	}
+	return 1, nil
+}
+
+func TestHeuristicScorer(t *testing.T) {
+	ctx := context.Background()
+	score := func(q, a string) float64 {
+		s, err := HeuristicScorer{}.Score(ctx, q, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	thanks := score("ok thanks", "You're welcome!")
+	question := score("How do I deploy the billing service to Kubernetes in region eu-west-1?",
+		"Build the image with Docker, push it to ECR and apply the Helm chart with replicas set to 3 in the eu-west-1 cluster managed by Terraform.")
+	if thanks > 0.2 {
+		t.Errorf("acknowledgement scored %v, want close to 0", thanks)
+	}
+	if question < 0.6 || question > 1 {
+		t.Errorf("detailed question scored %v, want between 0.6 and 1", question)
+	}
+	if got := score("", ""); got != 0 {
+		t.Errorf("empty pair scored %v, want 0", got)
+	}
+}
+
+func TestMilvusMemoryMinImportance(t *testing.T) {
+	ctx := context.Background()
+	m, fake := newTestMilvus(t, MilvusConfig{ImportanceScorer: HeuristicScorer{}, MinImportance: 0.3})
+	if err := m.SaveMessages(ctx, "c1", qa("ok thanks", "You're welcome!")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveMessages(ctx, "c1", qa("Which port does Milvus listen on in Docker?", "Milvus listens on port 19530 and serves metrics on 9091.")); err != nil {
+		t.Fatal(err)
+	}
+	if n := storedPairs(fake); n != 1 {
+		t.Fatalf("stored %d pairs, want only the important one", n)
+	}
+	for _, row := range fake.rows {
+		if imp, _ := row["importance"].(float32); row["content_hash"] != "" && imp < 0.3 {
+			t.Errorf("stored importance = %v, want the score", imp)
+		}
+	}
+}
+
+// A failing scorer doesn't lose the exchange: it is saved with the default score.
+func TestMilvusMemoryImportanceScorerError(t *testing.T) {
+	ctx := context.Background()
+	logs := &captureHandler{}
+	m, fake := newTestMilvus(t, MilvusConfig{
+		ImportanceScorer: mapScorer{fail: map[string]bool{"cat facts": true}},
+		MinImportance:    0.5,
+		Logger:           slog.New(logs),
+	})
+	if err := m.SaveMessages(ctx, "c1", qa("cat facts", "meow")); err != nil {
+		t.Fatalf("SaveMessages = %v, want the scorer error ignored", err)
+	}
+	if n := storedPairs(fake); n != 1 {
+		t.Fatalf("stored %d pairs, want 1", n)
+	}
+	for _, row := range fake.rows {
+		if row["content_hash"] != "" && row["importance"] != float32(1) {
+			t.Errorf("stored importance = %v, want the default 1", row["importance"])
+		}
+	}
+	if logs.count(slog.LevelWarn, "failed to score importance") != 1 {
+		t.Error("scorer failure not logged")
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := m.SaveMessages(canceled, "c1", qa("cat facts", "purr")); !errors.Is(err, context.Canceled) {
+		t.Errorf("SaveMessages with a canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestMilvusMemoryImportanceWeight(t *testing.T) {
+	ctx := context.Background()
+	scorer := mapScorer{scores: map[string]float64{"cat facts": 0.1}}
+	tests := []struct {
+		name   string
+		weight float64
+		limit  int
+		want   string
+	}{
+		{"similarity only", 0, 10, "[cat facts kitten facts car facts]"},
+		{"weighted", 0.5, 10, "[kitten facts cat facts car facts]"},
+		{"weighted best of more candidates", 0.5, 1, "[kitten facts]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestMilvus(t, MilvusConfig{Embedder: topicEmbedder{}, MetricType: entity.COSINE, ImportanceScorer: scorer, ImportanceWeight: tt.weight})
+			saveTopics(t, m)
+			scored, err := m.GetRelevantMessagesWithScores(ctx, "c1", "cat", tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var questions []string
+			for _, s := range scored {
+				questions = append(questions, s.Messages[0].Content)
+			}
+			if got := fmt.Sprint(questions); got != tt.want {
+				t.Errorf("GetRelevantMessagesWithScores = %v, want %v", got, tt.want)
+			}
+			if tt.weight > 0 && tt.limit > 1 && scored[1].Score != 1 {
+				t.Errorf("score of cat facts = %v, want the unweighted similarity 1", scored[1].Score)
+			}
+		})
+	}
+
+	if _, err := NewMilvusMemory(MilvusConfig{MilvusClient: &fakeMilvus{}, Embedder: fakeEmbedder{}, ImportanceWeight: 2}); err == nil {
+		t.Error("NewMilvusMemory accepted ImportanceWeight 2")
+	}
+}
+
+// With L2 the distance grows for unimportant pairs.
+func TestWeighPairsL2(t *testing.T) {
+	m := &MilvusMemory{metricType: entity.L2, importWeight: 1}
+	pairs := []qaPair{{userInput: "near", score: 1, importance: 0}, {userInput: "far", score: 1.5, importance: 1}}
+	m.weighPairs(pairs)
+	if pairs[0].userInput != "far" {
+		t.Errorf("order = %+v, want the important pair first", pairs)
+	}
+}
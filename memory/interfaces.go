@@ -2,6 +2,8 @@ package memory
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/MrLeeang/langchain-go/llms"
 )
@@ -37,3 +39,87 @@ type Memory interface {
 	// This is useful for starting fresh conversations or cleaning up old data.
 	ClearMessages(ctx context.Context, conversationID string) error
 }
+
+// ConversationMemory is a [Memory] that can also retrieve history by semantic relevance and
+// summarize a conversation. It is implemented by the vector-store memories (MilvusMemory,
+// PgVectorMemory, QdrantMemory, RedisVectorMemory).
+type ConversationMemory interface {
+	Memory
+
+	// GetRelevantMessages returns up to limit stored exchanges most relevant to query.
+	GetRelevantMessages(ctx context.Context, conversationID string, query string, limit int) ([]llms.ChatCompletionMessage, error)
+
+	// SummarizeMessages returns a summary of the conversation history.
+	SummarizeMessages(ctx context.Context, conversationID string) (string, error)
+}
+
+// ScoredMessage is one Q&A pair found by SearchAllConversations, with the conversation it
+// belongs to so callers can open the source thread.
+type ScoredMessage struct {
+	ConversationID string
+	// Messages holds the user message and, when stored, the assistant answer.
+	Messages []llms.ChatCompletionMessage
+	// Score is the backend's search score; see the SearchAllConversations method of each memory
+	// for whether higher or lower is closer.
+	Score float32
+	// Timestamp is when the pair was saved.
+	Timestamp time.Time
+}
+
+// CrossConversationSearcher is implemented by memories that can search every stored
+// conversation at once (MilvusMemory, PgVectorMemory, QdrantMemory).
+type CrossConversationSearcher interface {
+	// SearchAllConversations returns up to limit Q&A pairs from any conversation most
+	// relevant to query, most relevant first.
+	SearchAllConversations(ctx context.Context, query string, limit int) ([]ScoredMessage, error)
+}
+
+// ConversationInfo summarizes a stored conversation for listings such as an admin UI.
+type ConversationInfo struct {
+	ID           string
+	MessageCount int64
+	// LastActivity is when the latest message was saved.
+	LastActivity time.Time
+}
+
+// ConversationLister is implemented by memories that can enumerate their conversations
+// (RedisMemory, SQLiteMemory, MilvusMemory, JSONLMemory, and NamespacedMemory over them).
+type ConversationLister interface {
+	// ListConversations returns the stored conversations, most recently active first,
+	// skipping offset and returning at most limit (all when limit <= 0).
+	ListConversations(ctx context.Context, limit, offset int) ([]ConversationInfo, error)
+}
+
+// pageConversations sorts convs most recently active first, by ID on ties, and returns the
+// page of at most limit (all when limit <= 0) starting at offset.
+func pageConversations(convs []ConversationInfo, limit, offset int) []ConversationInfo {
+	sort.Slice(convs, func(i, j int) bool {
+		if !convs[i].LastActivity.Equal(convs[j].LastActivity) {
+			return convs[i].LastActivity.After(convs[j].LastActivity)
+		}
+		return convs[i].ID < convs[j].ID
+	})
+	offset = max(offset, 0)
+	if offset >= len(convs) {
+		return []ConversationInfo{}
+	}
+	convs = convs[offset:]
+	if limit > 0 && limit < len(convs) {
+		convs = convs[:limit]
+	}
+	return convs
+}
+
+// Closer is implemented by memories holding resources such as connections (MilvusMemory,
+// RedisMemory, SQLiteMemory, ...). Wrappers forward Close to the memories they wrap.
+type Closer interface {
+	Close() error
+}
+
+// closeMemory closes m when it implements [Closer].
+func closeMemory(m Memory) error {
+	if c, ok := m.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// JSONLMemory persists each conversation in its own append-only file, <dir>/<conversationID>.jsonl,
+// with one JSON message per line. Unlike [FileMemory], saving never rewrites existing history,
+// and the files are easy to inspect with standard tools.
+//
+// Lines that fail to parse (e.g. a write cut short by a crash) are skipped with a warning.
+type JSONLMemory struct {
+	dir    string
+	logger *slog.Logger
+	mu     sync.Mutex
+	locks  map[string]*sync.Mutex
+}
+
+// JSONLOptions configures a JSONLMemory.
+type JSONLOptions struct {
+	// Logger receives a warning, with the conversation ID, for each corrupted line skipped
+	// when loading. Default is slog.Default().
+	Logger *slog.Logger
+}
+
+// NewJSONLMemory creates a [JSONLMemory] storing files in dir. The directory is created on
+// first save if missing.
+func NewJSONLMemory(dir string) *JSONLMemory {
+	return NewJSONLMemoryWithOptions(dir, JSONLOptions{})
+}
+
+// NewJSONLMemoryWithOptions is like [NewJSONLMemory] with opts.
+func NewJSONLMemoryWithOptions(dir string, opts JSONLOptions) *JSONLMemory {
+	return &JSONLMemory{dir: dir, logger: opts.Logger, locks: make(map[string]*sync.Mutex)}
+}
+
+// log returns the configured logger, or slog.Default().
+func (m *JSONLMemory) log() *slog.Logger {
+	if m.logger != nil {
+		return m.logger
+	}
+	return slog.Default()
+}
+
+// lock returns the mutex guarding the conversation's file.
+func (m *JSONLMemory) lock(id string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.locks[id]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[id] = l
+	}
+	return l
+}
+
+func (m *JSONLMemory) path(id string) string {
+	// keep IDs such as "user/1" inside dir
+	return filepath.Join(m.dir, url.PathEscape(id)+".jsonl")
+}
+
+func (m *JSONLMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	id := normalizeConversationID(conversationID)
+	l := m.lock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	f, err := os.Open(m.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []llms.ChatCompletionMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var sm storedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &sm); err != nil {
+			m.log().WarnContext(ctx, "skipping corrupted memory line", "conversation_id", id, "file", f.Name(), "line", line, "error", err)
+			continue
+		}
+		out = append(out, storedToLLM(sm))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read memory file %s: %w", f.Name(), err)
+	}
+	return out, nil
+}
+
+func (m *JSONLMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stored := llmToStored(messages)
+	if len(stored) == 0 {
+		return nil
+	}
+
+	var buf []byte
+	for _, sm := range stored {
+		line, err := json.Marshal(sm)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	id := normalizeConversationID(conversationID)
+	l := m.lock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("create memory dir %s: %w", m.dir, err)
+	}
+	f, err := os.OpenFile(m.path(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	_, werr := f.Write(buf)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return fmt.Errorf("append memory file %s: %w", f.Name(), werr)
+	}
+	return nil
+}
+
+func (m *JSONLMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	id := normalizeConversationID(conversationID)
+	l := m.lock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	if err := os.Truncate(m.path(id), 0); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ListConversations implements [ConversationLister] for the conversations with a non-empty
+// file in dir. LastActivity is the modification time of the file and MessageCount its number
+// of lines, so each file is read once.
+func (m *JSONLMemory) ListConversations(ctx context.Context, limit, offset int) ([]ConversationInfo, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ConversationInfo{}, nil
+		}
+		return nil, err
+	}
+	var convs []ConversationInfo
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		name, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if e.IsDir() || !ok {
+			continue
+		}
+		id, err := url.PathUnescape(name)
+		if err != nil {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if fi.Size() == 0 {
+			// cleared by ClearMessages
+			continue
+		}
+		n, err := m.countLines(id)
+		if err != nil {
+			return nil, err
+		}
+		convs = append(convs, ConversationInfo{ID: id, MessageCount: n, LastActivity: fi.ModTime()})
+	}
+	return pageConversations(convs, limit, offset), nil
+}
+
+// countLines returns the number of non-empty lines of the conversation's file.
+func (m *JSONLMemory) countLines(id string) (int64, error) {
+	l := m.lock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	data, err := os.ReadFile(m.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var n int64
+	for line := range bytes.SplitSeq(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			n++
+		}
+	}
+	return n, nil
+}
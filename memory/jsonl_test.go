@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+func TestJSONLMemoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	m := NewJSONLMemory(t.TempDir())
+	msgs := []llms.ChatCompletionMessage{
+		{Role: llms.ChatMessageRoleUser, Content: "hi"},
+		{Role: llms.ChatMessageRoleAssistant, Content: "hello"},
+	}
+	if err := m.SaveMessages(ctx, "user/1", msgs[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveMessages(ctx, "user/1", msgs[1:]); err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.LoadMessages(ctx, "user/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Content != "hi" || got[1].Content != "hello" {
+		t.Errorf("loaded %+v, want both messages in order", got)
+	}
+}
+
+func TestJSONLMemoryListConversations(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m := NewJSONLMemory(dir)
+	for _, id := range []string{"old", "user/1", "cleared"} {
+		if err := m.SaveMessages(ctx, id, turn(1, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.ClearMessages(ctx, "cleared"); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "old.jsonl"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	convs, err := m.ListConversations(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conversationIDs(convs); got != "[user/1 old]" {
+		t.Errorf("ListConversations = %v, want [user/1 old]", got)
+	}
+	if convs[1].MessageCount != 4 || !convs[1].LastActivity.Equal(past) {
+		t.Errorf("ListConversations = %+v, want 4 messages and the file time", convs)
+	}
+	if page, _ := m.ListConversations(ctx, 1, 1); conversationIDs(page) != "[old]" {
+		t.Errorf("ListConversations(1, 1) = %v, want [old]", conversationIDs(page))
+	}
+}
+
+func TestJSONLMemorySkipsCorruptedLines(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	logs := &captureHandler{}
+	m := NewJSONLMemoryWithOptions(dir, JSONLOptions{Logger: slog.New(logs)})
+	if err := m.SaveMessages(ctx, "c1", []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: "kept"}}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "c1.jsonl"), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"role":"assistant","cont` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := m.LoadMessages(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Content != "kept" {
+		t.Errorf("loaded %+v, want only the valid message", got)
+	}
+	const warning = "skipping corrupted memory line"
+	if n := logs.count(slog.LevelWarn, warning); n != 1 {
+		t.Fatalf("got %d warnings, want 1", n)
+	}
+	if id := logs.attr(slog.LevelWarn, warning, "conversation_id"); id != "c1" {
+		t.Errorf("warning conversation_id = %q, want c1", id)
+	}
+	if line := logs.attr(slog.LevelWarn, warning, "line"); line != "2" {
+		t.Errorf("warning line = %q, want 2", line)
+	}
+}
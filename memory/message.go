@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// Message is a stored chat message with when it was saved and its metadata, as returned by
+// [MemoryV2] implementations.
+type Message struct {
+	Role    string
+	Content string
+	// MultiContent holds the parts of a multimodal user message.
+	MultiContent []llms.ChatMessagePart
+	ToolCalls    []llms.ChatToolCall
+	ToolCallID   string
+	// CreatedAt is when the message was saved; zero when the backend doesn't record it.
+	CreatedAt time.Time
+	Metadata  map[string]string
+}
+
+// MessageFromLLM converts msg to a Message without time or metadata. Reasoning content is
+// not kept: memories never send it back to the model.
+func MessageFromLLM(msg llms.ChatCompletionMessage) Message {
+	return Message{
+		Role:         msg.Role,
+		Content:      msg.Content,
+		MultiContent: msg.MultiContent,
+		ToolCalls:    msg.ToolCalls,
+		ToolCallID:   msg.ToolCallID,
+	}
+}
+
+// LLM converts m to the message type of the llms package, dropping the time and metadata.
+func (m Message) LLM() llms.ChatCompletionMessage {
+	return llms.ChatCompletionMessage{
+		Role:         m.Role,
+		Content:      m.Content,
+		MultiContent: m.MultiContent,
+		ToolCalls:    m.ToolCalls,
+		ToolCallID:   m.ToolCallID,
+	}
+}
+
+// MemoryV2 is a [Memory] whose messages carry timestamps and metadata. New backends can
+// implement it and be used wherever a Memory is expected through FromV2; RedisMemory
+// implements both. Agents created with agents.WithMessageTimestamps load history through it
+// when available.
+type MemoryV2 interface {
+	// LoadMessagesV2 returns the history of the conversation in chronological order.
+	LoadMessagesV2(ctx context.Context, conversationID string) ([]Message, error)
+
+	// SaveMessagesV2 appends messages; a zero CreatedAt is set to the time of the call.
+	SaveMessagesV2(ctx context.Context, conversationID string, messages []Message) error
+
+	// ClearMessages removes the history of the conversation.
+	ClearMessages(ctx context.Context, conversationID string) error
+}
+
+// ToV2 returns m as a MemoryV2: m itself when it implements it, otherwise an adapter whose
+// messages have no time or metadata.
+func ToV2(m Memory) MemoryV2 {
+	if v2, ok := m.(MemoryV2); ok {
+		return v2
+	}
+	return v1Adapter{m}
+}
+
+// FromV2 returns m as a Memory (for agents.WithMemory): m itself when it implements it,
+// otherwise an adapter that converts messages and still implements MemoryV2.
+func FromV2(m MemoryV2) Memory {
+	if v1, ok := m.(Memory); ok {
+		return v1
+	}
+	return v2Adapter{m}
+}
+
+// v1Adapter implements MemoryV2 on top of a Memory.
+type v1Adapter struct {
+	Memory
+}
+
+func (a v1Adapter) LoadMessagesV2(ctx context.Context, conversationID string) ([]Message, error) {
+	messages, err := a.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Message, len(messages))
+	for i, msg := range messages {
+		out[i] = MessageFromLLM(msg)
+	}
+	return out, nil
+}
+
+func (a v1Adapter) SaveMessagesV2(ctx context.Context, conversationID string, messages []Message) error {
+	converted := make([]llms.ChatCompletionMessage, len(messages))
+	for i, msg := range messages {
+		converted[i] = msg.LLM()
+	}
+	return a.SaveMessages(ctx, conversationID, converted)
+}
+
+// v2Adapter implements Memory on top of a MemoryV2.
+type v2Adapter struct {
+	MemoryV2
+}
+
+func (a v2Adapter) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	messages, err := a.LoadMessagesV2(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]llms.ChatCompletionMessage, len(messages))
+	for i, msg := range messages {
+		out[i] = msg.LLM()
+	}
+	return out, nil
+}
+
+func (a v2Adapter) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	converted := make([]Message, len(messages))
+	for i, msg := range messages {
+		converted[i] = MessageFromLLM(msg)
+	}
+	return a.SaveMessagesV2(ctx, conversationID, converted)
+}
+
+// Close closes the wrapped memory when it implements [Closer].
+func (a v2Adapter) Close() error {
+	if c, ok := a.MemoryV2.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
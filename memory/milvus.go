@@ -2,7 +2,15 @@ package memory
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,6 +20,8 @@ import (
 	"github.com/MrLeeang/langchain-go/llms"
 )
 
+// MilvusMemoryInterface is implemented by memories that load history relevant to the current
+// user input. Agents call SetQuery with the user message before LoadMessages.
 type MilvusMemoryInterface interface {
 	SetQuery(query string)
 }
@@ -24,14 +34,49 @@ type MilvusMemory struct {
 	embedder       EmbedderInterface
 	collectionName string
 	embeddingDim   int
+	reranker       llms.Reranker
+	rerankCands    int
+	strictEmbed    bool
+	scoreThreshold float32
+	metricType     entity.MetricType
+	indexType      entity.IndexType
+	searchParam    entity.SearchParam
+	consistency    entity.ConsistencyLevel
+	summaryLLM     llms.LLM
+	summaryChunk   int
+	summaryCache   map[string]cachedSummary
+	metaFilter     MetadataFilter
+	namespace      string
+	// hasMetadata is false for collections created before the metadata field was added
+	hasMetadata bool
+	// hasRole is true for collections that store every message (role and message fields);
+	// legacy collections store Q&A pairs only
+	hasRole     bool
+	allowLegacy bool
+	// hasHash is false for collections created before the content_hash field was added
+	hasHash     bool
+	dedupWindow time.Duration
+	// hasImportance is false for collections created before the importance field was added
+	hasImportance bool
+	scorer        ImportanceScorer
+	logger        *slog.Logger
+	minImportance float64
+	importWeight  float64
 	// EnableQueryBasedLoading enables query-based loading in LoadMessages.
 	// When enabled, LoadMessages will use the latest user input to retrieve relevant messages.
 	EnableQueryBasedLoading bool
 	// MaxRelevantMessages limits the number of relevant messages to retrieve when using query-based loading.
 	MaxRelevantMessages int
+	// MaxLoadedMessages limits LoadMessages without a query to the most recent N Q&A pairs.
+	// Zero loads the full history.
+	MaxLoadedMessages int
 	// latestUserInput stores the latest user input for automatic query-based loading
 	latestUserInput string
-	mutex           sync.RWMutex
+	// pending holds, per conversation, the user input awaiting its answer
+	pending map[string]string
+	// buffered holds, per conversation, the messages of the exchange not yet answered
+	buffered map[string][]llms.ChatCompletionMessage
+	mutex    sync.RWMutex
 }
 
 // EmbedderInterface defines the interface for generating embeddings.
@@ -60,6 +105,9 @@ type MilvusConfig struct {
 
 	// EmbeddingDim is the dimension of embedding vectors.
 	// Common values: 1536 (text-embedding-ada-002), 768, etc.
+	// If 0, it is inferred by embedding a probe string with Embedder. It must match the
+	// vectors the embedder returns, including a reduced llms.Config.Dimensions, and the
+	// dimension of an existing collection; a mismatch is reported at construction.
 	EmbeddingDim int
 
 	// Embedder is the embedding model interface.
@@ -73,6 +121,117 @@ type MilvusConfig struct {
 	// MaxRelevantMessages limits the number of relevant messages to retrieve
 	// when using query-based loading. Default is 10.
 	MaxRelevantMessages int
+
+	// Reranker optionally reorders vector search hits in GetRelevantMessages
+	// (e.g. llms.CohereModel). When set, RerankCandidates Q&A pairs are fetched
+	// and the best `limit` of them are kept.
+	Reranker llms.Reranker
+
+	// ScoreThreshold drops GetRelevantMessages hits that are not similar enough: with the L2
+	// metric, hits whose distance exceeds it; with IP or COSINE, hits scoring below it.
+	// Zero keeps every hit.
+	ScoreThreshold float32
+
+	// MetricType is the similarity metric of the index and of searches. Default is entity.L2;
+	// use entity.COSINE (or entity.IP) for normalized embeddings such as OpenAI's. It must
+	// match the index of an existing collection.
+	MetricType entity.MetricType
+
+	// IndexType is the index built on a new collection: entity.HNSW (default, M=16,
+	// efConstruction=200), entity.IvfFlat (nlist=1024), entity.Flat or entity.AUTOINDEX.
+	IndexType entity.IndexType
+
+	// SearchParams are the search parameters, e.g. entity.NewIndexHNSWSearchParam(64).
+	// Default is flat search parameters.
+	SearchParams entity.SearchParam
+
+	// ConsistencyLevel of searches and queries. The zero value is entity.ClStrong, so pairs
+	// saved by SaveMessages are visible to the next load; entity.ClBounded or
+	// entity.ClEventually trade that for latency.
+	ConsistencyLevel entity.ConsistencyLevel
+
+	// RerankCandidates is how many Q&A pairs are fetched for reranking.
+	// Default is 3 times the requested limit.
+	RerankCandidates int
+
+	// StrictEmbedding makes SaveMessages store nothing when any Q&A pair fails to embed.
+	// By default the pairs that embedded are stored and an *EmbeddingError lists the rest.
+	StrictEmbedding bool
+
+	// MaxLoadedMessages limits LoadMessages without query-based loading to the most recent
+	// N Q&A pairs (see LoadMessagesWithLimit). Default is 0, the full history.
+	MaxLoadedMessages int
+
+	// SummaryLLM, when set, makes SummarizeMessages summarize the full history with this
+	// model: chunk by chunk, then merged. Results are cached until the conversation changes.
+	SummaryLLM llms.LLM
+
+	// SummaryChunkTokens is the token budget of one chunk sent to SummaryLLM. Default is 3000.
+	SummaryChunkTokens int
+
+	// MetadataFilter restricts GetRelevantMessages (and query-based LoadMessages) to Q&A pairs
+	// saved with matching metadata, e.g. {"user_id": "u-42"}. See SaveMessagesWithMetadata.
+	MetadataFilter MetadataFilter
+
+	// Namespace isolates tenants sharing a collection: it is prepended to every conversation
+	// ID ("namespace:conversation"), so memories with different namespaces never see each
+	// other's conversations. It must not contain ':'.
+	Namespace string
+
+	// AllowLegacySchema opens collections created before messages were stored individually
+	// (without the role field). They keep working in Q&A-pair mode: only user questions and
+	// final answers are stored, tool and system messages are dropped. Without it such a
+	// collection is rejected; use a new CollectionName and ImportConversations to migrate.
+	AllowLegacySchema bool
+
+	// DedupWindow skips saving a Q&A pair identical (same conversation, question and answer)
+	// to one stored within the window, or earlier in the same save, e.g. when an exchange is
+	// saved twice. Pairs are matched by a content hash stored with them; only the recent ones
+	// are checked so the lookup stays cheap. Zero disables deduplication. Collections created
+	// before the content_hash field was added are only deduplicated within a save.
+	DedupWindow time.Duration
+
+	// ImportanceScorer rates each Q&A pair before it is embedded; the score is stored in the
+	// importance field (1 without a scorer, or when scoring fails: the failure is logged and
+	// the pair saved anyway). See HeuristicScorer.
+	ImportanceScorer ImportanceScorer
+
+	// MinImportance drops Q&A pairs (with the other messages of their exchange) scoring
+	// below it, so small talk such as "ok thanks" isn't embedded or stored. It requires
+	// ImportanceScorer. To keep the full recent history anyway, combine this memory with a
+	// cheap one in a CompositeMemory.
+	MinImportance float64
+
+	// ImportanceWeight, between 0 and 1, ranks GetRelevantMessages hits by their similarity
+	// weighted by the stored importance: a hit's similarity (COSINE or IP) is scaled by
+	// 1-w+w*importance, its L2 distance by 1+w*(1-importance). The search then fetches three
+	// times as many candidates, and ScoreThreshold and the returned scores still apply to the
+	// unweighted search score. Zero ranks by similarity only.
+	ImportanceWeight float64
+
+	// Logger receives debug records for Q&A pairs skipped by DedupWindow or MinImportance,
+	// with their conversation ID. Default is slog.Default().
+	Logger *slog.Logger
+}
+
+// MetadataFilter selects Q&A pairs whose metadata has every key set to the given value.
+type MetadataFilter map[string]string
+
+// EmbeddingError reports the Q&A pairs whose embeddings could not be generated, e.g. inputs
+// the provider dropped for exceeding its token limit.
+type EmbeddingError struct {
+	// FailedIndices are the positions of the failed pairs in the order they were saved.
+	FailedIndices []int
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *EmbeddingError) Error() string {
+	return fmt.Sprintf("failed to generate embeddings for %d Q&A pairs %v: %v", len(e.FailedIndices), e.FailedIndices, e.Err)
+}
+
+func (e *EmbeddingError) Unwrap() error {
+	return e.Err
 }
 
 // NewMilvusMemory creates a new MilvusMemory instance.
@@ -114,13 +273,24 @@ func NewMilvusMemory(cfg MilvusConfig) (*MilvusMemory, error) {
 		collectionName = "langchain_memory"
 	}
 
-	if cfg.EmbeddingDim == 0 {
-		return nil, fmt.Errorf("embedding dimension must be specified")
-	}
-
 	if cfg.Embedder == nil {
 		return nil, fmt.Errorf("embedder must be provided")
 	}
+	if err := checkNamespace(cfg.Namespace); err != nil {
+		return nil, err
+	}
+
+	embeddingDim := cfg.EmbeddingDim
+	if embeddingDim == 0 {
+		probe, err := cfg.Embedder.Embeddings(context.Background(), []string{"dimension probe"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to infer embedding dimension: %w", err)
+		}
+		if len(probe) == 0 || len(probe[0]) == 0 {
+			return nil, fmt.Errorf("failed to infer embedding dimension: embedder returned no vector")
+		}
+		embeddingDim = len(probe[0])
+	}
 
 	maxRelevant := cfg.MaxRelevantMessages
 	if maxRelevant <= 0 {
@@ -131,9 +301,51 @@ func NewMilvusMemory(cfg MilvusConfig) (*MilvusMemory, error) {
 		milvusClient:            milvusClient,
 		embedder:                cfg.Embedder,
 		collectionName:          collectionName,
-		embeddingDim:            cfg.EmbeddingDim,
+		embeddingDim:            embeddingDim,
 		EnableQueryBasedLoading: cfg.EnableQueryBasedLoading,
 		MaxRelevantMessages:     maxRelevant,
+		MaxLoadedMessages:       cfg.MaxLoadedMessages,
+		reranker:                cfg.Reranker,
+		rerankCands:             cfg.RerankCandidates,
+		strictEmbed:             cfg.StrictEmbedding,
+		scoreThreshold:          cfg.ScoreThreshold,
+		metricType:              cfg.MetricType,
+		indexType:               cfg.IndexType,
+		searchParam:             cfg.SearchParams,
+		consistency:             cfg.ConsistencyLevel,
+		summaryLLM:              cfg.SummaryLLM,
+		summaryChunk:            cfg.SummaryChunkTokens,
+		summaryCache:            make(map[string]cachedSummary),
+		metaFilter:              cfg.MetadataFilter,
+		namespace:               cfg.Namespace,
+		pending:                 make(map[string]string),
+		buffered:                make(map[string][]llms.ChatCompletionMessage),
+		allowLegacy:             cfg.AllowLegacySchema,
+		dedupWindow:             cfg.DedupWindow,
+		scorer:                  cfg.ImportanceScorer,
+		logger:                  cfg.Logger,
+		minImportance:           cfg.MinImportance,
+		importWeight:            cfg.ImportanceWeight,
+	}
+	if mem.minImportance > 0 && mem.scorer == nil {
+		return nil, fmt.Errorf("MinImportance requires an ImportanceScorer")
+	}
+	if mem.importWeight < 0 || mem.importWeight > 1 {
+		return nil, fmt.Errorf("ImportanceWeight must be between 0 and 1, got %v", mem.importWeight)
+	}
+	if mem.summaryChunk <= 0 {
+		mem.summaryChunk = 3000
+	}
+	if mem.metricType == "" {
+		mem.metricType = entity.L2
+	}
+	if mem.indexType == "" {
+		mem.indexType = entity.HNSW
+	}
+	if mem.searchParam == nil {
+		if mem.searchParam, err = entity.NewIndexFlatSearchParam(); err != nil {
+			return nil, fmt.Errorf("failed to create search param: %w", err)
+		}
 	}
 
 	// Ensure collection exists
@@ -153,7 +365,10 @@ func (m *MilvusMemory) ensureCollection(ctx context.Context) error {
 	}
 
 	if exists {
-		return nil
+		if err := m.checkCollectionSchema(ctx); err != nil {
+			return err
+		}
+		return m.checkIndexMetric(ctx)
 	}
 
 	// Define schema - store as Q&A pairs (user_input, llm_output)
@@ -200,6 +415,32 @@ func (m *MilvusMemory) ensureCollection(ctx context.Context) error {
 				Name:     "timestamp",
 				DataType: entity.FieldTypeInt64,
 			},
+			{
+				Name:     "metadata",
+				DataType: entity.FieldTypeJSON,
+			},
+			{
+				Name:     "role",
+				DataType: entity.FieldTypeVarChar,
+				TypeParams: map[string]string{
+					"max_length": "32",
+				},
+			},
+			{
+				Name:     "message",
+				DataType: entity.FieldTypeJSON,
+			},
+			{
+				Name:     "content_hash",
+				DataType: entity.FieldTypeVarChar,
+				TypeParams: map[string]string{
+					"max_length": "64",
+				},
+			},
+			{
+				Name:     "importance",
+				DataType: entity.FieldTypeFloat,
+			},
 		},
 	}
 
@@ -210,7 +451,7 @@ func (m *MilvusMemory) ensureCollection(ctx context.Context) error {
 	}
 
 	// Create index for embedding field
-	index, err := entity.NewIndexHNSW(entity.L2, 16, 200)
+	index, err := m.newIndex()
 	if err != nil {
 		return fmt.Errorf("failed to create index: %w", err)
 	}
@@ -225,17 +466,127 @@ func (m *MilvusMemory) ensureCollection(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to load collection: %w", err)
 	}
+	m.hasMetadata = true
+	m.hasRole = true
+	m.hasHash = true
+	m.hasImportance = true
+
+	return nil
+}
+
+// newIndex builds the configured index for the embedding field.
+func (m *MilvusMemory) newIndex() (entity.Index, error) {
+	switch m.indexType {
+	case entity.HNSW:
+		return entity.NewIndexHNSW(m.metricType, 16, 200)
+	case entity.IvfFlat:
+		return entity.NewIndexIvfFlat(m.metricType, 1024)
+	case entity.Flat:
+		return entity.NewIndexFlat(m.metricType)
+	case entity.AUTOINDEX:
+		return entity.NewIndexAUTOINDEX(m.metricType)
+	default:
+		return nil, fmt.Errorf("unsupported index type %q", m.indexType)
+	}
+}
 
+// checkIndexMetric verifies that the index of an existing collection uses metricType, since
+// Milvus rejects searches with a different metric.
+func (m *MilvusMemory) checkIndexMetric(ctx context.Context) error {
+	indexes, err := m.milvusClient.DescribeIndex(ctx, m.collectionName, "embedding")
+	if err != nil {
+		// a collection without index yet has nothing to compare
+		return nil
+	}
+	for _, idx := range indexes {
+		if metric := idx.Params()["metric_type"]; metric != "" && !strings.EqualFold(metric, string(m.metricType)) {
+			return fmt.Errorf("collection %s is indexed with metric %s, but memory is configured for %s", m.collectionName, metric, m.metricType)
+		}
+	}
 	return nil
 }
 
-// getConversationID returns the conversation ID, using default if empty.
+// checkCollectionSchema verifies that an existing collection stores vectors of embeddingDim
+// and detects whether it has the metadata, role, content_hash and importance fields (collections created by older
+// versions don't). A collection without the role field is rejected unless AllowLegacySchema.
+func (m *MilvusMemory) checkCollectionSchema(ctx context.Context) error {
+	coll, err := m.milvusClient.DescribeCollection(ctx, m.collectionName)
+	if err != nil {
+		return fmt.Errorf("failed to describe collection: %w", err)
+	}
+	if coll.Schema == nil {
+		return nil
+	}
+	for _, field := range coll.Schema.Fields {
+		if field.Name == "metadata" && field.DataType == entity.FieldTypeJSON {
+			m.hasMetadata = true
+		}
+		if field.Name == "role" && field.DataType == entity.FieldTypeVarChar {
+			m.hasRole = true
+		}
+		if field.Name == "content_hash" && field.DataType == entity.FieldTypeVarChar {
+			m.hasHash = true
+		}
+		if field.Name == "importance" && field.DataType == entity.FieldTypeFloat {
+			m.hasImportance = true
+		}
+		if field.Name != "embedding" {
+			continue
+		}
+		if dim := field.TypeParams["dim"]; dim != "" && dim != fmt.Sprintf("%d", m.embeddingDim) {
+			return fmt.Errorf("collection %s has embedding dimension %s, but memory is configured for %d", m.collectionName, dim, m.embeddingDim)
+		}
+	}
+	if !m.hasRole && !m.allowLegacy {
+		return fmt.Errorf("collection %s was created by an older version and only stores Q&A pairs; set MilvusConfig.AllowLegacySchema to use it, or use a new CollectionName", m.collectionName)
+	}
+	return nil
+}
+
+// getConversationID returns the stored conversation ID: the ID, or "default" if empty,
+// prefixed with the namespace when one is configured.
 func (m *MilvusMemory) getConversationID(conversationID string) string {
-	if conversationID != "" {
-		return conversationID
+	if conversationID == "" {
+		conversationID = "default"
+	}
+	return namespaced(m.namespace, conversationID)
+}
+
+// conversationExpr returns the boolean expression selecting the rows of the stored
+// conversation ID convID (see getConversationID). The ID is escaped as a string literal, so
+// quotes in it can't change the expression.
+func (m *MilvusMemory) conversationExpr(convID string) string {
+	return "conversation_id == " + milvusString(convID)
+}
+
+// answerExpr selects, in collections storing every message, the row of each exchange that
+// holds its Q&A pair (the final answer); searches and pair counts are limited to these rows.
+const answerExpr = `llm_output != ""`
+
+// metadataExpr returns the expression matching filter, or "" when filter is empty. Keys are
+// sorted so the same filter always yields the same expression.
+func metadataExpr(filter MetadataFilter) string {
+	keys := make([]string, 0, len(filter))
+	for k := range filter {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("metadata[%s] == %s", milvusString(k), milvusString(filter[k]))
+	}
+	return strings.Join(parts, " && ")
+}
 
-	return "default"
+// milvusString quotes s as a Milvus expression string literal.
+func milvusString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// likePrefix returns the like pattern, as a string literal, matching the strings starting with
+// prefix. The wildcards % and _ and the escape character in prefix match themselves.
+func likePrefix(prefix string) string {
+	return milvusString(strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%")
 }
 
 // LoadMessages loads conversation history for the given conversation ID.
@@ -256,8 +607,8 @@ func (m *MilvusMemory) LoadMessages(ctx context.Context, conversationID string)
 		// If no user input has been saved yet, fall back to loading all messages
 	}
 
-	// Default behavior: load all messages
-	return m.loadAllMessages(ctx, conversationID)
+	// Default behavior: load all messages, or the most recent MaxLoadedMessages pairs
+	return m.LoadMessagesWithLimit(ctx, conversationID, m.MaxLoadedMessages)
 }
 
 // SetQuery manually sets a query for context-aware message loading.
@@ -276,10 +627,82 @@ func (m *MilvusMemory) SetQuery(query string) {
 
 // loadAllMessages loads all messages for the conversation ID in chronological order.
 func (m *MilvusMemory) loadAllMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
-	convID := m.getConversationID(conversationID)
+	return m.queryMessages(ctx, m.conversationExpr(m.getConversationID(conversationID)))
+}
 
-	// Query by conversation_id, ordered by timestamp
-	expr := fmt.Sprintf("conversation_id == \"%s\"", convID)
+// LoadMessagesWithLimit returns the most recent limit Q&A pairs (all when limit <= 0) in
+// chronological order. Only the timestamps of the conversation are fetched to find the cutoff,
+// so long conversations are not loaded into memory in full.
+func (m *MilvusMemory) LoadMessagesWithLimit(ctx context.Context, conversationID string, limit int) ([]llms.ChatCompletionMessage, error) {
+	if limit <= 0 {
+		return m.loadAllMessages(ctx, conversationID)
+	}
+	expr := m.conversationExpr(m.getConversationID(conversationID))
+	if m.hasRole {
+		return m.loadExchanges(ctx, expr, limit)
+	}
+
+	results, err := m.milvusClient.Query(ctx, m.collectionName, []string{}, expr, []string{"timestamp"},
+		client.WithSearchQueryConsistencyLevel(m.consistency))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Milvus: %w", err)
+	}
+	var timestamps []int64
+	for _, col := range results {
+		if ts, ok := col.(*entity.ColumnInt64); ok && col.Name() == "timestamp" {
+			timestamps = ts.Data()
+		}
+	}
+	if len(timestamps) > limit {
+		sorted := append([]int64(nil), timestamps...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+		expr += fmt.Sprintf(" && timestamp >= %d", sorted[limit-1])
+	}
+
+	messages, err := m.queryMessages(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+	// pairs sharing the cutoff timestamp may exceed the limit
+	if len(messages) > 2*limit {
+		messages = messages[len(messages)-2*limit:]
+	}
+	return messages, nil
+}
+
+// loadExchanges returns the messages of the most recent limit exchanges matching expr. The
+// cutoff is the answer of the exchange before them: every later row belongs to the last limit.
+func (m *MilvusMemory) loadExchanges(ctx context.Context, expr string, limit int) ([]llms.ChatCompletionMessage, error) {
+	results, err := m.milvusClient.Query(ctx, m.collectionName, []string{}, expr+" && "+answerExpr, []string{"timestamp"},
+		client.WithSearchQueryConsistencyLevel(m.consistency))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Milvus: %w", err)
+	}
+	var timestamps []int64
+	for _, col := range results {
+		if ts, ok := col.(*entity.ColumnInt64); ok && col.Name() == "timestamp" {
+			timestamps = ts.Data()
+		}
+	}
+	if len(timestamps) > limit {
+		sorted := append([]int64(nil), timestamps...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+		expr += fmt.Sprintf(" && timestamp > %d", sorted[limit])
+	}
+	return m.queryMessages(ctx, expr)
+}
+
+// queryMessages queries the messages (Q&A pairs in legacy collections) matching expr and
+// assembles them in chronological order.
+func (m *MilvusMemory) queryMessages(ctx context.Context, expr string) ([]llms.ChatCompletionMessage, error) {
+	if m.hasRole {
+		results, err := m.milvusClient.Query(ctx, m.collectionName, []string{}, expr, []string{"message", "timestamp"},
+			client.WithSearchQueryConsistencyLevel(m.consistency))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query Milvus: %w", err)
+		}
+		return assembleStoredMessages(results)
+	}
 
 	results, err := m.milvusClient.Query(
 		ctx,
@@ -287,6 +710,7 @@ func (m *MilvusMemory) loadAllMessages(ctx context.Context, conversationID strin
 		[]string{},
 		expr,
 		[]string{"user_input", "llm_output", "timestamp"},
+		client.WithSearchQueryConsistencyLevel(m.consistency),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query Milvus: %w", err)
@@ -295,11 +719,13 @@ func (m *MilvusMemory) loadAllMessages(ctx context.Context, conversationID strin
 	return m.assembleMessagesFromColumns(results)
 }
 
-// assembleMessagesFromColumns converts Milvus query results to messages.
+// assembleMessagesFromColumns converts Milvus query results to messages, ordered by the
+// timestamp column when present (Query returns rows in segment order).
 func (m *MilvusMemory) assembleMessagesFromColumns(results []entity.Column) ([]llms.ChatCompletionMessage, error) {
 	messages := make([]llms.ChatCompletionMessage, 0)
 
 	var userInputCol, llmOutputCol *entity.ColumnVarChar
+	var timestampCol *entity.ColumnInt64
 
 	// Extract columns
 	for _, col := range results {
@@ -308,6 +734,8 @@ func (m *MilvusMemory) assembleMessagesFromColumns(results []entity.Column) ([]l
 			userInputCol = col.(*entity.ColumnVarChar)
 		case "llm_output":
 			llmOutputCol = col.(*entity.ColumnVarChar)
+		case "timestamp":
+			timestampCol, _ = col.(*entity.ColumnInt64)
 		}
 	}
 
@@ -315,8 +743,17 @@ func (m *MilvusMemory) assembleMessagesFromColumns(results []entity.Column) ([]l
 		return messages, nil
 	}
 
+	order := make([]int, userInputCol.Len())
+	for i := range order {
+		order[i] = i
+	}
+	if timestampCol != nil && timestampCol.Len() == len(order) {
+		ts := timestampCol.Data()
+		sort.SliceStable(order, func(a, b int) bool { return ts[order[a]] < ts[order[b]] })
+	}
+
 	// Assemble messages from Q&A pairs
-	for i := 0; i < userInputCol.Len(); i++ {
+	for _, i := range order {
 		userInputVal, _ := userInputCol.Get(i)
 		llmOutputVal, _ := llmOutputCol.Get(i)
 
@@ -343,149 +780,820 @@ func (m *MilvusMemory) assembleMessagesFromColumns(results []entity.Column) ([]l
 	return messages, nil
 }
 
-// use conversationID to store the latest user input
-var latestUserInput map[string]string
+// assembleStoredMessages decodes the message column of query results in timestamp order.
+// Rows whose message can't be decoded are skipped.
+func assembleStoredMessages(results []entity.Column) ([]llms.ChatCompletionMessage, error) {
+	var raw [][]byte
+	var timestamps []int64
+	for _, col := range results {
+		switch c := col.(type) {
+		case *entity.ColumnJSONBytes:
+			if c.Name() == "message" {
+				raw = c.Data()
+			}
+		case *entity.ColumnInt64:
+			if c.Name() == "timestamp" {
+				timestamps = c.Data()
+			}
+		}
+	}
+
+	order := make([]int, len(raw))
+	for i := range order {
+		order[i] = i
+	}
+	if len(timestamps) == len(raw) {
+		sort.SliceStable(order, func(a, b int) bool { return timestamps[order[a]] < timestamps[order[b]] })
+	}
 
-func init() {
-	latestUserInput = make(map[string]string)
+	messages := make([]llms.ChatCompletionMessage, 0, len(raw))
+	for _, i := range order {
+		var sm storedMessage
+		if err := json.Unmarshal(raw[i], &sm); err != nil {
+			continue
+		}
+		messages = append(messages, storedToLLM(sm))
+	}
+	return messages, nil
 }
 
+// SetLatestUserInput records userInput as the question awaiting an answer in conversationID;
+// the next assistant message saved for that conversation is paired with it.
 func (m *MilvusMemory) SetLatestUserInput(conversationID string, userInput string) {
-	latestUserInput[conversationID] = userInput
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.pending[m.getConversationID(conversationID)] = userInput
 }
 
 // SaveMessages saves messages to the conversation history.
-// It pairs user messages with assistant messages and stores them as Q&A pairs in Milvus.
+// Every message, including tool calls, tool results and system messages, is stored in order;
+// each exchange (the messages up to a final assistant answer) is embedded as its Q&A pair, so
+// messages are written once the answer arrives and kept per conversation until then. Legacy
+// collections (see MilvusConfig.AllowLegacySchema) store only the Q&A pairs.
 func (m *MilvusMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	return m.SaveMessagesWithMetadata(ctx, conversationID, messages, nil)
+}
+
+// SaveMessagesWithMetadata is like SaveMessages and tags the stored Q&A pairs with metadata
+// (e.g. user_id, channel, source) that MetadataFilter can select on. Collections created
+// before metadata support have no metadata field; saving metadata to them is an error.
+func (m *MilvusMemory) SaveMessagesWithMetadata(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage, metadata map[string]string) error {
 	if len(messages) == 0 {
 		return nil
 	}
+	if len(metadata) > 0 && !m.hasMetadata {
+		return fmt.Errorf("collection %s has no metadata field; recreate it to store metadata", m.collectionName)
+	}
 
-	convID := m.getConversationID(conversationID)
+	m.mutex.Lock()
+	for _, msg := range messages {
+		if msg.Role == llms.ChatMessageRoleUser {
+			// Save the latest user input for query-based loading
+			m.latestUserInput = msg.Content
+		}
+	}
+	if m.hasRole {
+		groups := groupExchanges(m.pending, m.buffered, m.getConversationID(conversationID), messages)
+		m.mutex.Unlock()
+		for i := range groups {
+			groups[i].record.metadata = metadata
+		}
+		return m.insertExchanges(ctx, groups)
+	}
+	records := pairQA(m.pending, m.getConversationID(conversationID), messages)
+	m.mutex.Unlock()
+
+	if len(records) == 0 {
+		return nil
+	}
+	for i := range records {
+		records[i].metadata = metadata
+	}
+	return m.insertRecords(ctx, records)
+}
 
-	// Pair user and assistant messages
-	// We need to match each user message with its corresponding assistant message
-	// For simplicity, we'll pair them in order: user -> assistant
+// qaRecord is one Q&A row to insert.
+type qaRecord struct {
+	conversationID string
+	userInput      string
+	llmOutput      string
+	timestamp      int64
+	metadata       map[string]string
+	// importance is the ImportanceScorer score of the pair (MilvusMemory only)
+	importance float32
+}
 
-	// First, collect pairs from the incoming messages
-	type QAPair struct {
-		userInput string
-		llmOutput string
-		timestamp int64
+// pairQA pairs each user message with the final assistant answer of the conversation: the
+// last assistant text before the next user message of the call, so tool call notes saved
+// ahead of the answer (see agents.WithCleanMemory) are skipped. pending holds, per
+// conversation, a user input still waiting for its answer across calls.
+func pairQA(pending map[string]string, convID string, messages []llms.ChatCompletionMessage) []qaRecord {
+	var records []qaRecord
+	now := time.Now().UnixNano()
+	answered := false // the last record's question has no later user message yet
+	for _, msg := range messages {
+		switch {
+		case msg.Role == llms.ChatMessageRoleUser:
+			pending[convID] = msg.Content
+			answered = false
+		case !isAnswer(msg):
+		case pending[convID] != "":
+			// keep the original order within a call
+			records = append(records, qaRecord{conversationID: convID, userInput: pending[convID], llmOutput: msg.Content, timestamp: now + int64(len(records))})
+			delete(pending, convID)
+			answered = true
+		case answered:
+			records[len(records)-1].llmOutput = msg.Content
+		}
 	}
+	return records
+}
 
-	var pairs []QAPair
+// isAnswer reports whether msg is assistant text rather than a tool call.
+func isAnswer(msg llms.ChatCompletionMessage) bool {
+	return msg.Role == llms.ChatMessageRoleAssistant && msg.ToolCalls == nil && msg.Content != ""
+}
+
+// exchange is the messages of one exchange, from the message after the previous final answer
+// through the next one, and the Q&A pair they are embedded and searched by.
+type exchange struct {
+	record   qaRecord
+	messages []llms.ChatCompletionMessage
+}
 
+// groupExchanges appends messages to the conversation's buffered messages and returns the
+// exchanges completed by a final assistant answer. As in pairQA, pending holds the question
+// of the open exchange, and a later answer before the next user message of the call replaces
+// the previous one; a question recorded with SetLatestUserInput is stored as its user message.
+func groupExchanges(pending map[string]string, buffered map[string][]llms.ChatCompletionMessage, convID string, messages []llms.ChatCompletionMessage) []exchange {
+	var groups []exchange
+	buf := buffered[convID]
+	answered := false
 	for _, msg := range messages {
-		switch msg.Role {
-		case llms.ChatMessageRoleUser:
-			// Save the latest user input for query-based loading
-			m.mutex.Lock()
-			m.latestUserInput = msg.Content
-			m.mutex.Unlock()
-
-			// If we have a previous user input without output, we'll store it with empty output
-			// Otherwise, start a new pair
-			m.SetLatestUserInput(conversationID, msg.Content)
-		case llms.ChatMessageRoleAssistant:
-			// if calling tool, skip
-			if msg.ToolCalls != nil {
-				continue
+		buf = append(buf, msg)
+		switch {
+		case msg.Role == llms.ChatMessageRoleUser:
+			pending[convID] = msg.Content
+			answered = false
+		case !isAnswer(msg):
+		case answered:
+			last := &groups[len(groups)-1]
+			last.messages = append(last.messages, buf...)
+			last.record.llmOutput = msg.Content
+			buf = nil
+		default:
+			question := pending[convID]
+			if question != "" && !slices.ContainsFunc(buf, func(m llms.ChatCompletionMessage) bool { return m.Role == llms.ChatMessageRoleUser }) {
+				buf = append([]llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: question}}, buf...)
 			}
+			groups = append(groups, exchange{
+				record:   qaRecord{conversationID: convID, userInput: question, llmOutput: msg.Content},
+				messages: buf,
+			})
+			buf = nil
+			delete(pending, convID)
+			answered = true
+		}
+	}
+	if len(buf) > 0 {
+		buffered[convID] = buf
+	} else {
+		delete(buffered, convID)
+	}
+	return groups
+}
 
-			// If we have a user input, pair it with this assistant response
-			if latestUserInput[conversationID] != "" && msg.Content != "" {
-				pairs = append(pairs, QAPair{
-					userInput: latestUserInput[conversationID],
-					llmOutput: msg.Content,
-					timestamp: time.Now().UnixNano(),
-				})
-				m.SetLatestUserInput(conversationID, "") // Reset after pairing
+// embedRecords embeds the Q&A text of records with [llms.EmbedAll]. Embeddings that failed or
+// don't have dim dimensions are nil and listed in the returned *EmbeddingError; err is set
+// when nothing should be stored (a complete failure, or any failure when strict).
+func embedRecords(ctx context.Context, embedder EmbedderInterface, dim int, strict bool, records []qaRecord, opts ...llms.EmbedAllOption) ([][]float32, *EmbeddingError, error) {
+	// Combine user input and LLM output for better semantic representation
+	texts := make([]string, len(records))
+	for i, r := range records {
+		texts[i] = fmt.Sprintf("Q: %s\nA: %s", r.userInput, r.llmOutput)
+	}
+
+	embeddings, embedErr := llms.EmbedAll(ctx, embedder, texts, opts...)
+	var partial *llms.EmbedAllError
+	if embedErr != nil && !errors.As(embedErr, &partial) {
+		return nil, nil, fmt.Errorf("failed to generate embeddings: %w", embedErr)
+	}
+	var failedErr *EmbeddingError
+	if partial != nil {
+		failedErr = &EmbeddingError{FailedIndices: partial.Failed, Err: partial}
+	}
+	for i, vec := range embeddings {
+		if vec != nil && len(vec) != dim {
+			embeddings[i] = nil
+			if failedErr == nil {
+				failedErr = &EmbeddingError{Err: fmt.Errorf("embedding dimension mismatch: expected %d", dim)}
 			}
-		case llms.ChatMessageRoleTool:
-			// Tool messages are handled separately, skip for now
-			continue
-		case llms.ChatMessageRoleSystem:
-			// System messages are handled separately, skip for now
-			continue
+			failedErr.FailedIndices = append(failedErr.FailedIndices, i)
+		}
+	}
+	if failedErr != nil {
+		sort.Ints(failedErr.FailedIndices)
+		if strict {
+			return nil, failedErr, failedErr
 		}
 	}
+	return embeddings, failedErr, nil
+}
+
+// milvusInsertBatch caps the rows sent in one Insert call.
+const milvusInsertBatch = 1000
+
+// milvusRow is one row to insert: a Q&A pair, or in collections storing every message, one
+// message of an exchange (only the answer row carries the pair's text).
+type milvusRow struct {
+	record  qaRecord
+	vector  []float32
+	message *llms.ChatCompletionMessage
+	// hash is the pairHash of the Q&A pair on the row that carries it
+	hash string
+}
 
-	if len(pairs) == 0 {
+// insertRecords embeds the Q&A text of records with [llms.EmbedAll] and inserts them.
+// Records whose embedding failed or has the wrong dimension are skipped and reported in an
+// *EmbeddingError after the others are stored; with StrictEmbedding nothing is stored.
+func (m *MilvusMemory) insertRecords(ctx context.Context, records []qaRecord, opts ...llms.EmbedAllOption) error {
+	keep, err := m.dedupRecords(ctx, records)
+	if err != nil {
+		return err
+	}
+	if keep, err = m.scoreRecords(ctx, records, keep); err != nil {
+		return err
+	}
+	kept := make([]qaRecord, len(keep))
+	for i, k := range keep {
+		kept[i] = records[k]
+	}
+	if len(kept) == 0 {
 		return nil
 	}
+	embeddings, failedErr, err := embedRecords(ctx, m.embedder, m.embeddingDim, m.strictEmbed, kept, opts...)
+	remapFailed(failedErr, keep)
+	if err != nil {
+		return err
+	}
 
-	// Prepare data for insertion
-	conversationIDs := make([]string, len(pairs))
-	userInputs := make([]string, len(pairs))
-	llmOutputs := make([]string, len(pairs))
-	timestamps := make([]int64, len(pairs))
-
-	for i, pair := range pairs {
-		conversationIDs[i] = convID
-		userInputs[i] = pair.userInput
-		llmOutputs[i] = pair.llmOutput
-		timestamps[i] = pair.timestamp
+	rows := make([]milvusRow, 0, len(kept))
+	for i, r := range kept {
+		if embeddings[i] != nil {
+			rows = append(rows, milvusRow{record: r, vector: embeddings[i], hash: pairHash(r)})
+		}
+	}
+	if err := m.insertRows(ctx, rows, milvusInsertBatch); err != nil {
+		return err
 	}
 
-	// Generate embeddings for the Q&A pairs
-	// Combine user input and LLM output for better semantic representation
-	texts := make([]string, len(pairs))
-	for i, pair := range pairs {
-		// Full Q&A pair
-		texts[i] = fmt.Sprintf("Q: %s\nA: %s", pair.userInput, pair.llmOutput)
+	if failedErr != nil {
+		return failedErr
 	}
+	return nil
+}
 
-	embeddings, err := m.embedder.Embeddings(ctx, texts)
+// insertExchanges stores every message of groups, each with the embedding of its exchange's
+// Q&A pair. Failed embeddings are handled as in insertRecords; indices refer to exchanges.
+func (m *MilvusMemory) insertExchanges(ctx context.Context, groups []exchange, opts ...llms.EmbedAllOption) error {
+	records := make([]qaRecord, len(groups))
+	for i, g := range groups {
+		records[i] = g.record
+	}
+	keep, err := m.dedupRecords(ctx, records)
 	if err != nil {
-		return fmt.Errorf("failed to generate embeddings: %w", err)
+		return err
+	}
+	if keep, err = m.scoreRecords(ctx, records, keep); err != nil {
+		return err
 	}
+	if len(keep) == 0 {
+		return nil
+	}
+	kept := make([]qaRecord, len(keep))
+	for i, k := range keep {
+		kept[i] = records[k]
+	}
+	embeddings, failedErr, err := embedRecords(ctx, m.embedder, m.embeddingDim, m.strictEmbed, kept, opts...)
+	remapFailed(failedErr, keep)
+	if err != nil {
+		return err
+	}
+
+	// one timestamp per message keeps the original order within and across exchanges
+	ts := time.Now().UnixNano()
+	var rows []milvusRow
+	for i, k := range keep {
+		if embeddings[i] == nil {
+			continue
+		}
+		g := groups[k]
+		for j := range g.messages {
+			row := milvusRow{
+				record:  qaRecord{conversationID: g.record.conversationID, timestamp: ts, metadata: g.record.metadata, importance: records[k].importance},
+				vector:  embeddings[i],
+				message: &g.messages[j],
+			}
+			if j == len(g.messages)-1 {
+				row.record.userInput = g.record.userInput
+				row.record.llmOutput = g.record.llmOutput
+				row.hash = pairHash(g.record)
+			}
+			rows = append(rows, row)
+			ts++
+		}
+	}
+	if err := m.insertRows(ctx, rows, milvusInsertBatch); err != nil {
+		return err
+	}
+
+	if failedErr != nil {
+		return failedErr
+	}
+	return nil
+}
+
+// pairHash identifies a Q&A pair of a conversation for DedupWindow.
+func pairHash(r qaRecord) string {
+	sum := sha256.Sum256([]byte(r.conversationID + "\x00" + r.userInput + "\x00" + r.llmOutput))
+	return hex.EncodeToString(sum[:])
+}
 
-	if len(embeddings) != len(pairs) {
-		return fmt.Errorf("embedding count mismatch: expected %d, got %d", len(pairs), len(embeddings))
+// remapFailed rewrites the indices of failedErr, positions in the deduplicated records, to
+// the positions keep gives them in the records passed to the insert.
+func remapFailed(failedErr *EmbeddingError, keep []int) {
+	if failedErr == nil {
+		return
+	}
+	for i, idx := range failedErr.FailedIndices {
+		failedErr.FailedIndices[i] = keep[idx]
 	}
+}
 
-	// Convert embeddings to [][]float32 for insertion
-	embeddingVectors := make([][]float32, len(embeddings))
-	for i, emb := range embeddings {
-		embeddingVectors[i] = emb
+// dedupRecords returns the indices of the records to store. With DedupWindow set, records
+// repeating an earlier one of records, or a pair stored within the window, are left out.
+func (m *MilvusMemory) dedupRecords(ctx context.Context, records []qaRecord) ([]int, error) {
+	keep := make([]int, 0, len(records))
+	if m.dedupWindow <= 0 {
+		for i := range records {
+			keep = append(keep, i)
+		}
+		return keep, nil
 	}
 
-	// Create entity columns for insertion
-	insertData := []entity.Column{
-		entity.NewColumnVarChar("conversation_id", conversationIDs),
-		entity.NewColumnVarChar("user_input", userInputs),
-		entity.NewColumnVarChar("llm_output", llmOutputs),
-		entity.NewColumnFloatVector("embedding", m.embeddingDim, embeddingVectors),
-		entity.NewColumnInt64("timestamp", timestamps),
+	seen := make(map[string]bool, len(records))
+	hashes := make([]string, 0, len(records))
+	for i, r := range records {
+		h := pairHash(r)
+		if seen[h] {
+			m.log().DebugContext(ctx, "skipped duplicate Q&A pair", "conversation_id", r.conversationID)
+			continue
+		}
+		seen[h] = true
+		keep = append(keep, i)
+		hashes = append(hashes, h)
+	}
+	if !m.hasHash {
+		return keep, nil
 	}
 
-	_, err = m.milvusClient.Insert(ctx, m.collectionName, "", insertData...)
+	stored, err := m.storedHashes(ctx, hashes, time.Now().Add(-m.dedupWindow).UnixNano())
 	if err != nil {
-		return fmt.Errorf("failed to insert into Milvus: %w", err)
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return keep, nil
+	}
+	fresh := keep[:0]
+	for i, k := range keep {
+		if !stored[hashes[i]] {
+			fresh = append(fresh, k)
+		} else {
+			m.log().DebugContext(ctx, "skipped Q&A pair stored within the dedup window", "conversation_id", records[k].conversationID)
+		}
 	}
+	return fresh, nil
+}
 
+// scoreRecords sets the importance of records[keep] and returns the indices of those scoring
+// at least MinImportance. Without an ImportanceScorer every record scores 1, as do records
+// the scorer fails on: scoring is a hint, not worth losing the exchange for.
+func (m *MilvusMemory) scoreRecords(ctx context.Context, records []qaRecord, keep []int) ([]int, error) {
+	if m.scorer == nil {
+		for _, k := range keep {
+			records[k].importance = 1
+		}
+		return keep, nil
+	}
+	important := keep[:0]
+	for _, k := range keep {
+		score, err := m.scorer.Score(ctx, records[k].userInput, records[k].llmOutput)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			m.log().WarnContext(ctx, "failed to score importance, saving with the default score", "conversation_id", records[k].conversationID, "error", err)
+			score = 1
+		}
+		records[k].importance = float32(score)
+		if score >= m.minImportance {
+			important = append(important, k)
+		} else {
+			m.log().DebugContext(ctx, "skipped unimportant Q&A pair", "conversation_id", records[k].conversationID, "importance", score)
+		}
+	}
+	return important, nil
+}
+
+// log returns the configured logger, or slog.Default().
+func (m *MilvusMemory) log() *slog.Logger {
+	if m.logger != nil {
+		return m.logger
+	}
+	return slog.Default()
+}
+
+// storedHashes returns which of hashes belong to pairs stored at or after since.
+func (m *MilvusMemory) storedHashes(ctx context.Context, hashes []string, since int64) (map[string]bool, error) {
+	stored := make(map[string]bool)
+	for start := 0; start < len(hashes); start += milvusInsertBatch {
+		chunk := hashes[start:min(start+milvusInsertBatch, len(hashes))]
+		quoted := make([]string, len(chunk))
+		for i, h := range chunk {
+			quoted[i] = milvusString(h)
+		}
+		expr := fmt.Sprintf("content_hash in [%s] && timestamp >= %d", strings.Join(quoted, ", "), since)
+		results, err := m.milvusClient.Query(ctx, m.collectionName, []string{}, expr, []string{"content_hash"},
+			client.WithSearchQueryConsistencyLevel(m.consistency))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query Milvus: %w", err)
+		}
+		for _, col := range results {
+			if c, ok := col.(*entity.ColumnVarChar); ok && c.Name() == "content_hash" {
+				for _, h := range c.Data() {
+					stored[h] = true
+				}
+			}
+		}
+	}
+	return stored, nil
+}
+
+// insertRows inserts rows in batches of batchSize.
+func (m *MilvusMemory) insertRows(ctx context.Context, rows []milvusRow, batchSize int) error {
+	for start := 0; start < len(rows); start += batchSize {
+		end := min(start+batchSize, len(rows))
+		var (
+			conversationIDs []string
+			userInputs      []string
+			llmOutputs      []string
+			timestamps      []int64
+			vectors         [][]float32
+			metadata        [][]byte
+			roles           []string
+			messages        [][]byte
+			hashes          []string
+			importance      []float32
+		)
+		for _, row := range rows[start:end] {
+			conversationIDs = append(conversationIDs, row.record.conversationID)
+			userInputs = append(userInputs, row.record.userInput)
+			llmOutputs = append(llmOutputs, row.record.llmOutput)
+			timestamps = append(timestamps, row.record.timestamp)
+			vectors = append(vectors, row.vector)
+			if m.hasMetadata {
+				meta := row.record.metadata
+				if meta == nil {
+					meta = map[string]string{}
+				}
+				raw, err := json.Marshal(meta)
+				if err != nil {
+					return fmt.Errorf("failed to encode metadata: %w", err)
+				}
+				metadata = append(metadata, raw)
+			}
+			if m.hasRole {
+				msg := llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: row.record.llmOutput}
+				if row.message != nil {
+					msg = *row.message
+				}
+				raw, err := json.Marshal(messageToStored(msg))
+				if err != nil {
+					return fmt.Errorf("failed to encode message: %w", err)
+				}
+				roles = append(roles, msg.Role)
+				messages = append(messages, raw)
+			}
+			if m.hasHash {
+				hashes = append(hashes, row.hash)
+			}
+			if m.hasImportance {
+				importance = append(importance, row.record.importance)
+			}
+		}
+
+		insertData := []entity.Column{
+			entity.NewColumnVarChar("conversation_id", conversationIDs),
+			entity.NewColumnVarChar("user_input", userInputs),
+			entity.NewColumnVarChar("llm_output", llmOutputs),
+			entity.NewColumnFloatVector("embedding", m.embeddingDim, vectors),
+			entity.NewColumnInt64("timestamp", timestamps),
+		}
+		if m.hasMetadata {
+			insertData = append(insertData, entity.NewColumnJSONBytes("metadata", metadata))
+		}
+		if m.hasRole {
+			insertData = append(insertData,
+				entity.NewColumnVarChar("role", roles),
+				entity.NewColumnJSONBytes("message", messages),
+			)
+		}
+		if m.hasHash {
+			insertData = append(insertData, entity.NewColumnVarChar("content_hash", hashes))
+		}
+		if m.hasImportance {
+			insertData = append(insertData, entity.NewColumnFloat("importance", importance))
+		}
+		if _, err := m.milvusClient.Insert(ctx, m.collectionName, "", insertData...); err != nil {
+			return fmt.Errorf("failed to insert into Milvus: %w", err)
+		}
+	}
 	return nil
 }
 
+// ImportConversations bulk-loads historical conversations, e.g. for a backfill. Messages of
+// each conversation are grouped into exchanges as in SaveMessages (messages after the last
+// answer are not stored); embeddings are generated concurrently with [llms.EmbedAll] (pass
+// options to tune batch size, workers and progress). When some embeddings fail, the other
+// exchanges are still stored (unless StrictEmbedding) and the error is an *EmbeddingError whose
+// indices refer to the exchanges in conversation-ID order.
+func (m *MilvusMemory) ImportConversations(ctx context.Context, conversations map[string][]llms.ChatCompletionMessage, opts ...llms.EmbedAllOption) error {
+	ids := make([]string, 0, len(conversations))
+	for id := range conversations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if m.hasRole {
+		var groups []exchange
+		for _, id := range ids {
+			groups = append(groups, groupExchanges(map[string]string{}, map[string][]llms.ChatCompletionMessage{}, m.getConversationID(id), conversations[id])...)
+		}
+		return m.insertExchanges(ctx, groups, opts...)
+	}
+
+	var records []qaRecord
+	now := time.Now().UnixNano()
+	for _, id := range ids {
+		convID := m.getConversationID(id)
+		pending, answered := "", false
+		for _, msg := range conversations[id] {
+			switch {
+			case msg.Role == llms.ChatMessageRoleUser:
+				pending, answered = msg.Content, false
+			case !isAnswer(msg):
+			case pending != "":
+				// keep the original order within a conversation
+				records = append(records, qaRecord{conversationID: convID, userInput: pending, llmOutput: msg.Content, timestamp: now + int64(len(records))})
+				pending, answered = "", true
+			case answered:
+				records[len(records)-1].llmOutput = msg.Content
+			}
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return m.insertRecords(ctx, records, opts...)
+}
+
+// QAPair is a question and its answer for [MilvusMemory.BulkImport].
+type QAPair struct {
+	UserInput string
+	LLMOutput string
+	// Timestamp is when the pair happened; zero uses the import time, keeping the input order.
+	Timestamp time.Time
+	// Metadata tags the pair as in SaveMessagesWithMetadata.
+	Metadata map[string]string
+}
+
+// BulkOptions configures [MilvusMemory.BulkImport]. Zero values use the defaults.
+type BulkOptions struct {
+	// EmbedBatchSize is how many pairs are embedded per request. Default is 100.
+	EmbedBatchSize int
+	// EmbedConcurrency is how many embedding requests run in parallel. Default is 4.
+	EmbedConcurrency int
+	// InsertBatchSize is how many pairs are embedded and inserted per round, and the maximum
+	// rows of one Insert call. Default is 1000.
+	InsertBatchSize int
+	// Progress is called after each round with the number of pairs processed so far and the
+	// total.
+	Progress func(done, total int)
+}
+
+// BulkResult reports the outcome of [MilvusMemory.BulkImport].
+type BulkResult struct {
+	Inserted int
+	// Failed counts the pairs whose embedding failed; FailedIndices are their positions.
+	Failed        int
+	FailedIndices []int
+	// Skipped counts the pairs left out by DedupWindow or MinImportance.
+	Skipped int
+}
+
+// BulkImport loads historical Q&A pairs into conversationID much faster than SaveMessages:
+// pairs are processed in rounds of InsertBatchSize, each embedded concurrently with
+// [llms.EmbedAll] and inserted in one go, and the collection is flushed at the end. DedupWindow
+// and ImportanceScorer apply as in SaveMessages. Pairs that fail to embed are counted in the
+// result instead of failing the import (StrictEmbedding is ignored); the error reports what
+// stopped the import, with the result of the rounds done so far.
+//
+// Example:
+//
+//	res, err := mem.BulkImport(ctx, "tickets", pairs, memory.BulkOptions{
+//	    EmbedConcurrency: 8,
+//	    Progress:         func(done, total int) { log.Printf("%d/%d", done, total) },
+//	})
+func (m *MilvusMemory) BulkImport(ctx context.Context, conversationID string, pairs []QAPair, opts BulkOptions) (BulkResult, error) {
+	batchSize := opts.InsertBatchSize
+	if batchSize <= 0 {
+		batchSize = milvusInsertBatch
+	}
+	var embedOpts []llms.EmbedAllOption
+	if opts.EmbedBatchSize > 0 {
+		embedOpts = append(embedOpts, llms.WithEmbedBatchSize(opts.EmbedBatchSize))
+	}
+	if opts.EmbedConcurrency > 0 {
+		embedOpts = append(embedOpts, llms.WithEmbedConcurrency(opts.EmbedConcurrency))
+	}
+
+	convID := m.getConversationID(conversationID)
+	now := time.Now().UnixNano()
+	var res BulkResult
+	for start := 0; start < len(pairs); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		end := min(start+batchSize, len(pairs))
+		records := make([]qaRecord, end-start)
+		for i, p := range pairs[start:end] {
+			// two nanoseconds per pair: the question and answer rows
+			ts := now + 2*int64(start+i)
+			if !p.Timestamp.IsZero() {
+				ts = p.Timestamp.UnixNano()
+			}
+			records[i] = qaRecord{conversationID: convID, userInput: p.UserInput, llmOutput: p.LLMOutput, timestamp: ts, metadata: p.Metadata}
+		}
+
+		keep, err := m.dedupRecords(ctx, records)
+		if err != nil {
+			return res, err
+		}
+		if keep, err = m.scoreRecords(ctx, records, keep); err != nil {
+			return res, err
+		}
+		res.Skipped += len(records) - len(keep)
+		kept := make([]qaRecord, len(keep))
+		for i, k := range keep {
+			kept[i] = records[k]
+		}
+		embeddings, _, err := embedRecords(ctx, m.embedder, m.embeddingDim, false, kept, embedOpts...)
+		if err != nil {
+			return res, err
+		}
+
+		var rows []milvusRow
+		inserted := 0
+		for i, r := range kept {
+			if embeddings[i] == nil {
+				res.Failed++
+				res.FailedIndices = append(res.FailedIndices, start+keep[i])
+				continue
+			}
+			inserted++
+			if !m.hasRole {
+				rows = append(rows, milvusRow{record: r, vector: embeddings[i], hash: pairHash(r)})
+				continue
+			}
+			if r.userInput != "" {
+				question := llms.ChatCompletionMessage{Role: llms.ChatMessageRoleUser, Content: r.userInput}
+				rows = append(rows, milvusRow{
+					record:  qaRecord{conversationID: convID, timestamp: r.timestamp, metadata: r.metadata, importance: r.importance},
+					vector:  embeddings[i],
+					message: &question,
+				})
+			}
+			answer := llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: r.llmOutput}
+			row := milvusRow{record: r, vector: embeddings[i], message: &answer, hash: pairHash(r)}
+			row.record.timestamp++
+			rows = append(rows, row)
+		}
+		if err := m.insertRows(ctx, rows, batchSize); err != nil {
+			return res, err
+		}
+		res.Inserted += inserted
+		if opts.Progress != nil {
+			opts.Progress(end, len(pairs))
+		}
+	}
+
+	if res.Inserted > 0 {
+		// imported pairs may predate the latest one, which the summary cache is keyed by
+		m.mutex.Lock()
+		delete(m.summaryCache, convID)
+		m.mutex.Unlock()
+		if err := m.milvusClient.Flush(ctx, m.collectionName, false); err != nil {
+			return res, fmt.Errorf("failed to flush collection: %w", err)
+		}
+	}
+	return res, nil
+}
+
 // ClearMessages clears all messages for the given conversation ID.
 func (m *MilvusMemory) ClearMessages(ctx context.Context, conversationID string) error {
 	convID := m.getConversationID(conversationID)
 
-	expr := fmt.Sprintf("conversation_id == \"%s\"", convID)
+	expr := m.conversationExpr(convID)
 
 	err := m.milvusClient.Delete(ctx, m.collectionName, "", expr)
 	if err != nil {
 		return fmt.Errorf("failed to delete from Milvus: %w", err)
 	}
 
+	m.mutex.Lock()
+	delete(m.pending, convID)
+	delete(m.buffered, convID)
+	m.mutex.Unlock()
+
 	return nil
 }
 
 // GetRelevantMessages retrieves relevant messages from history based on a query.
 // It uses vector similarity search to find the most relevant Q&A pairs and assembles them.
+// Hits beyond MilvusConfig.ScoreThreshold, or not matching MilvusConfig.MetadataFilter, are dropped.
 func (m *MilvusMemory) GetRelevantMessages(ctx context.Context, conversationID string, query string, limit int) ([]llms.ChatCompletionMessage, error) {
+	return m.GetRelevantMessagesWithFilter(ctx, conversationID, query, limit, m.metaFilter)
+}
+
+// GetRelevantMessagesWithFilter is like GetRelevantMessages but only searches Q&A pairs whose
+// metadata matches filter instead of MilvusConfig.MetadataFilter.
+//
+// Example:
+//
+//	msgs, err := mem.GetRelevantMessagesWithFilter(ctx, "conv-1", "refund policy", 5,
+//	    memory.MetadataFilter{"user_id": "u-42"})
+func (m *MilvusMemory) GetRelevantMessagesWithFilter(ctx context.Context, conversationID string, query string, limit int, filter MetadataFilter) ([]llms.ChatCompletionMessage, error) {
+	pairs, err := m.searchPairs(ctx, conversationID, query, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert Q&A pairs to messages
+	messages := make([]llms.ChatCompletionMessage, 0, len(pairs)*2)
+	for _, pair := range pairs {
+		messages = append(messages, pair.messages()...)
+	}
+
+	return messages, nil
+}
+
+// ScoredMessages is one Q&A pair returned by GetRelevantMessagesWithScores.
+type ScoredMessages struct {
+	// Messages holds the user message and, when stored, the assistant answer.
+	Messages []llms.ChatCompletionMessage
+	// Score is the Milvus search score: a distance for L2 (lower is closer), a similarity for
+	// IP and COSINE (higher is closer).
+	Score float32
+}
+
+// GetRelevantMessagesWithScores is like GetRelevantMessages but keeps each Q&A pair separate,
+// with its search score.
+func (m *MilvusMemory) GetRelevantMessagesWithScores(ctx context.Context, conversationID string, query string, limit int) ([]ScoredMessages, error) {
+	pairs, err := m.searchPairs(ctx, conversationID, query, limit, m.metaFilter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ScoredMessages, len(pairs))
+	for i, pair := range pairs {
+		out[i] = ScoredMessages{Messages: pair.messages(), Score: pair.score}
+	}
+	return out, nil
+}
+
+// searchPairs returns up to limit Q&A pairs matching filter nearest to query, without
+// duplicates or hits beyond the score threshold, reranked when a Reranker is configured.
+func (m *MilvusMemory) searchPairs(ctx context.Context, conversationID string, query string, limit int, filter MetadataFilter) ([]qaPair, error) {
 	convID := m.getConversationID(conversationID)
+	expr := m.conversationExpr(convID)
+	if m.hasRole {
+		expr += " && " + answerExpr
+	}
+	if len(filter) > 0 {
+		if !m.hasMetadata {
+			return nil, fmt.Errorf("collection %s has no metadata field to filter on", m.collectionName)
+		}
+		expr += " && " + metadataExpr(filter)
+	}
 
 	// Generate embedding for query
 	embeddings, err := m.embedder.Embeddings(ctx, []string{query})
@@ -502,10 +1610,18 @@ func (m *MilvusMemory) GetRelevantMessages(ctx context.Context, conversationID s
 	// Convert query vector to entity.Vector
 	vectors := []entity.Vector{entity.FloatVector(queryVector)}
 
-	// Create default search parameters
-	searchParam, err := entity.NewIndexFlatSearchParam()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create search param: %w", err)
+	weighted := m.importWeight > 0 && m.hasImportance
+	outputFields := []string{"user_input", "llm_output"}
+	topK := limit
+	if weighted {
+		outputFields = append(outputFields, "importance")
+		topK = limit * 3
+	}
+	if m.reranker != nil {
+		topK = m.rerankCands
+		if topK <= 0 {
+			topK = limit * 3
+		}
 	}
 
 	// Search for similar Q&A pairs
@@ -513,73 +1629,325 @@ func (m *MilvusMemory) GetRelevantMessages(ctx context.Context, conversationID s
 		ctx,
 		m.collectionName,
 		[]string{},
-		"conversation_id == \""+convID+"\"",
-		[]string{"user_input", "llm_output"},
+		expr,
+		outputFields,
 		vectors,
 		"embedding",
-		entity.L2,
-		limit,
-		searchParam,
+		m.metricType,
+		topK,
+		m.searchParam,
+		client.WithSearchQueryConsistencyLevel(m.consistency),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search Milvus: %w", err)
 	}
 
-	// Convert Q&A pairs to messages
-	messages := make([]llms.ChatCompletionMessage, 0)
+	// Collect Q&A pairs in search order
+	var pairs []qaPair
+	seen := make(map[[2]string]bool)
 	for _, result := range searchResults {
 		// Extract fields from result columns
 		var userInputCol, llmOutputCol *entity.ColumnVarChar
+		var importanceCol *entity.ColumnFloat
 		for _, col := range result.Fields {
 			switch col.Name() {
 			case "user_input":
 				userInputCol = col.(*entity.ColumnVarChar)
 			case "llm_output":
 				llmOutputCol = col.(*entity.ColumnVarChar)
+			case "importance":
+				importanceCol, _ = col.(*entity.ColumnFloat)
 			}
 		}
 
-		if userInputCol != nil {
-			var llmOutputColActual *entity.ColumnVarChar
+		if userInputCol == nil {
+			continue
+		}
+		for i := 0; i < userInputCol.Len(); i++ {
+			userInputVal, _ := userInputCol.Get(i)
+			userInput, ok := userInputVal.(string)
+			if !ok || userInput == "" {
+				continue
+			}
+			pair := qaPair{userInput: userInput, importance: 1}
+			if importanceCol != nil {
+				if v, err := importanceCol.ValueByIdx(i); err == nil {
+					pair.importance = v
+				}
+			}
 			if llmOutputCol != nil {
-				llmOutputColActual = llmOutputCol
+				llmOutputVal, _ := llmOutputCol.Get(i)
+				pair.llmOutput, _ = llmOutputVal.(string)
 			}
+			// the same Q&A pair can be stored (or matched) more than once
+			key := [2]string{pair.userInput, pair.llmOutput}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if i < len(result.Scores) {
+				pair.score = result.Scores[i]
+			}
+			if !m.withinThreshold(pair.score) {
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+
+	if weighted {
+		m.weighPairs(pairs)
+		if m.reranker == nil && len(pairs) > limit {
+			pairs = pairs[:limit]
+		}
+	}
+
+	if m.reranker != nil && len(pairs) > 0 {
+		pairs, err = m.rerankPairs(ctx, query, pairs, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pairs, nil
+}
+
+// SearchAllConversations implements [CrossConversationSearcher]: it searches every
+// conversation of the collection (of the Namespace, when set), applying MetadataFilter and
+// ScoreThreshold. Score is as in ScoredMessages.
+func (m *MilvusMemory) SearchAllConversations(ctx context.Context, query string, limit int) ([]ScoredMessage, error) {
+	embeddings, err := m.embedder.Embeddings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+		return nil, fmt.Errorf("empty embedding generated")
+	}
+
+	var clauses []string
+	if m.namespace != "" {
+		clauses = append(clauses, "conversation_id like "+likePrefix(m.namespace+namespaceSeparator))
+	}
+	if m.hasRole {
+		clauses = append(clauses, answerExpr)
+	}
+	if len(m.metaFilter) > 0 && m.hasMetadata {
+		clauses = append(clauses, metadataExpr(m.metaFilter))
+	}
+
+	searchResults, err := m.milvusClient.Search(
+		ctx,
+		m.collectionName,
+		[]string{},
+		strings.Join(clauses, " && "),
+		[]string{"conversation_id", "user_input", "llm_output", "timestamp"},
+		[]entity.Vector{entity.FloatVector(embeddings[0])},
+		"embedding",
+		m.metricType,
+		limit,
+		m.searchParam,
+		client.WithSearchQueryConsistencyLevel(m.consistency),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search Milvus: %w", err)
+	}
 
-			// Assemble messages from Q&A pairs
-			for i := 0; i < userInputCol.Len(); i++ {
-				userInputVal, _ := userInputCol.Get(i)
-				userInput, ok := userInputVal.(string)
-				if !ok || userInput == "" {
+	var hits []ScoredMessage
+	for _, result := range searchResults {
+		var convCol, userInputCol, llmOutputCol *entity.ColumnVarChar
+		var timestampCol *entity.ColumnInt64
+		for _, col := range result.Fields {
+			switch c := col.(type) {
+			case *entity.ColumnVarChar:
+				switch c.Name() {
+				case "conversation_id":
+					convCol = c
+				case "user_input":
+					userInputCol = c
+				case "llm_output":
+					llmOutputCol = c
+				}
+			case *entity.ColumnInt64:
+				if c.Name() == "timestamp" {
+					timestampCol = c
+				}
+			}
+		}
+		if convCol == nil || userInputCol == nil {
+			continue
+		}
+		for i := 0; i < userInputCol.Len(); i++ {
+			pair := qaPair{}
+			pair.userInput, _ = userInputCol.ValueByIdx(i)
+			if pair.userInput == "" {
+				continue
+			}
+			if llmOutputCol != nil {
+				pair.llmOutput, _ = llmOutputCol.ValueByIdx(i)
+			}
+			if i < len(result.Scores) {
+				pair.score = result.Scores[i]
+			}
+			if !m.withinThreshold(pair.score) {
+				continue
+			}
+			convID, _ := convCol.ValueByIdx(i)
+			if m.namespace != "" {
+				// servers ignoring like escapes may return other namespaces
+				var ok bool
+				if convID, ok = strings.CutPrefix(convID, m.namespace+namespaceSeparator); !ok {
 					continue
 				}
+			}
+			hit := ScoredMessage{Messages: pair.messages(), Score: pair.score, ConversationID: convID}
+			if timestampCol != nil {
+				ts, _ := timestampCol.ValueByIdx(i)
+				hit.Timestamp = time.Unix(0, ts)
+			}
+			hits = append(hits, hit)
+		}
+	}
+	return hits, nil
+}
 
-				// Add user message
-				messages = append(messages, llms.ChatCompletionMessage{
-					Role:    llms.ChatMessageRoleUser,
-					Content: userInput,
-				})
+// ListConversations implements [ConversationLister] for the conversations of the collection
+// (of the Namespace, when set). Milvus has no DISTINCT or GROUP BY, so the conversation_id and
+// timestamp columns of every row are queried and aggregated here: use it for admin pages,
+// not on every request. In legacy collections each Q&A pair counts as two
+// messages.
+func (m *MilvusMemory) ListConversations(ctx context.Context, limit, offset int) ([]ConversationInfo, error) {
+	expr := `conversation_id != ""`
+	prefix := ""
+	if m.namespace != "" {
+		prefix = m.namespace + namespaceSeparator
+		expr = "conversation_id like " + likePrefix(prefix)
+	}
 
-				// Add assistant message if available
-				if llmOutputColActual != nil {
-					llmOutputVal, _ := llmOutputColActual.Get(i)
-					if llmOutput, ok := llmOutputVal.(string); ok && llmOutput != "" {
-						messages = append(messages, llms.ChatCompletionMessage{
-							Role:    llms.ChatMessageRoleAssistant,
-							Content: llmOutput,
-						})
-					}
-				}
+	results, err := m.milvusClient.Query(ctx, m.collectionName, []string{}, expr, []string{"conversation_id", "timestamp"},
+		client.WithSearchQueryConsistencyLevel(m.consistency))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Milvus: %w", err)
+	}
+	var convCol *entity.ColumnVarChar
+	var timestampCol *entity.ColumnInt64
+	for _, col := range results {
+		switch c := col.(type) {
+		case *entity.ColumnVarChar:
+			if c.Name() == "conversation_id" {
+				convCol = c
+			}
+		case *entity.ColumnInt64:
+			if c.Name() == "timestamp" {
+				timestampCol = c
 			}
 		}
 	}
+	if convCol == nil || timestampCol == nil {
+		return nil, nil
+	}
 
-	return messages, nil
+	perRow := int64(2)
+	if m.hasRole {
+		perRow = 1
+	}
+	byID := make(map[string]*ConversationInfo)
+	for i := 0; i < convCol.Len(); i++ {
+		id, _ := convCol.ValueByIdx(i)
+		if !strings.HasPrefix(id, prefix) {
+			// servers ignoring like escapes may return other namespaces
+			continue
+		}
+		ts, _ := timestampCol.ValueByIdx(i)
+		info, ok := byID[id]
+		if !ok {
+			info = &ConversationInfo{ID: strings.TrimPrefix(id, prefix)}
+			byID[id] = info
+		}
+		info.MessageCount += perRow
+		if t := time.Unix(0, ts); t.After(info.LastActivity) {
+			info.LastActivity = t
+		}
+	}
+	convs := make([]ConversationInfo, 0, len(byID))
+	for _, info := range byID {
+		convs = append(convs, *info)
+	}
+	return pageConversations(convs, limit, offset), nil
+}
+
+// withinThreshold reports whether a hit with score passes ScoreThreshold: a maximum distance
+// for L2, a minimum similarity for IP and COSINE.
+func (m *MilvusMemory) withinThreshold(score float32) bool {
+	if m.scoreThreshold == 0 {
+		return true
+	}
+	if m.metricType == entity.L2 {
+		return score <= m.scoreThreshold
+	}
+	return score >= m.scoreThreshold
+}
+
+// weighPairs orders pairs by their search score weighted by importance (see
+// MilvusConfig.ImportanceWeight), best first.
+func (m *MilvusMemory) weighPairs(pairs []qaPair) {
+	w := float32(m.importWeight)
+	rank := func(p qaPair) float32 {
+		if m.metricType == entity.L2 {
+			return -p.score * (1 + w*(1-p.importance))
+		}
+		return p.score * (1 - w + w*p.importance)
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return rank(pairs[i]) > rank(pairs[j])
+	})
+}
+
+// qaPair is one stored user input and the assistant output that answered it.
+type qaPair struct {
+	userInput  string
+	llmOutput  string
+	score      float32
+	importance float32
+}
+
+func (p qaPair) messages() []llms.ChatCompletionMessage {
+	messages := []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: p.userInput}}
+	if p.llmOutput != "" {
+		messages = append(messages, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: p.llmOutput})
+	}
+	return messages
 }
 
-// SummarizeMessages creates a summary of the conversation history.
-// This is a placeholder implementation - in a production system, you might want
-// to use an LLM to generate the summary.
+// rerankPairs orders pairs by reranker relevance to query and keeps at most limit.
+func (m *MilvusMemory) rerankPairs(ctx context.Context, query string, pairs []qaPair, limit int) ([]qaPair, error) {
+	docs := make([]string, len(pairs))
+	for i, p := range pairs {
+		docs[i] = "Q: " + p.userInput + "\nA: " + p.llmOutput
+	}
+	results, err := m.reranker.Rerank(ctx, query, docs, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank messages: %w", err)
+	}
+	out := make([]qaPair, 0, len(results))
+	for _, r := range results {
+		if r.Index >= 0 && r.Index < len(pairs) {
+			out = append(out, pairs[r.Index])
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// SummarizeMessages creates a summary of the conversation history. With
+// MilvusConfig.SummaryLLM it is generated by the LLM (see summarizeWithLLM); otherwise it is a
+// short description built from the first messages.
 func (m *MilvusMemory) SummarizeMessages(ctx context.Context, conversationID string) (string, error) {
+	if m.summaryLLM != nil {
+		return m.summarizeWithLLM(ctx, conversationID)
+	}
+
 	messages, err := m.LoadMessages(ctx, conversationID)
 	if err != nil {
 		return "", err
@@ -608,6 +1976,108 @@ func (m *MilvusMemory) SummarizeMessages(ctx context.Context, conversationID str
 	return summary, nil
 }
 
+// cachedSummary is an LLM summary and the latest timestamp of the history it covers.
+type cachedSummary struct {
+	latest  int64
+	summary string
+}
+
+const (
+	summaryChunkPrompt = "Summarize the following part of a conversation between a user and an assistant. Keep names, facts, decisions, preferences and open questions.\n\n"
+	summaryMergePrompt = "Merge the following summaries of consecutive parts of one conversation into a single concise summary, in chronological order.\n\n"
+)
+
+// summarizeWithLLM summarizes the full history with SummaryLLM. The transcript is split into
+// chunks of at most SummaryChunkTokens, each chunk is summarized, and the partial summaries are
+// merged. The result is cached by the conversation's latest timestamp.
+func (m *MilvusMemory) summarizeWithLLM(ctx context.Context, conversationID string) (string, error) {
+	convID := m.getConversationID(conversationID)
+
+	results, err := m.milvusClient.Query(
+		ctx,
+		m.collectionName,
+		[]string{},
+		m.conversationExpr(convID),
+		[]string{"user_input", "llm_output", "timestamp"},
+		client.WithSearchQueryConsistencyLevel(m.consistency),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to query Milvus: %w", err)
+	}
+	messages, err := m.assembleMessagesFromColumns(results)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return "", nil
+	}
+
+	var latest int64
+	for _, col := range results {
+		if ts, ok := col.(*entity.ColumnInt64); ok && col.Name() == "timestamp" {
+			for _, v := range ts.Data() {
+				latest = max(latest, v)
+			}
+		}
+	}
+
+	m.mutex.RLock()
+	cached, ok := m.summaryCache[convID]
+	m.mutex.RUnlock()
+	if ok && cached.latest == latest {
+		return cached.summary, nil
+	}
+
+	var chunks []string
+	var chunk strings.Builder
+	tokens := 0
+	for _, msg := range messages {
+		line := msg.Role + ": " + msg.Content + "\n"
+		n := countTokensCL100K(line)
+		if tokens > 0 && tokens+n > m.summaryChunk {
+			chunks = append(chunks, chunk.String())
+			chunk.Reset()
+			tokens = 0
+		}
+		chunk.WriteString(line)
+		tokens += n
+	}
+	chunks = append(chunks, chunk.String())
+
+	partials := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		s, err := m.chatSummary(ctx, summaryChunkPrompt+c)
+		if err != nil {
+			return "", err
+		}
+		partials = append(partials, s)
+	}
+
+	summary := partials[0]
+	if len(partials) > 1 {
+		summary, err = m.chatSummary(ctx, summaryMergePrompt+strings.Join(partials, "\n\n"))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	m.mutex.Lock()
+	m.summaryCache[convID] = cachedSummary{latest: latest, summary: summary}
+	m.mutex.Unlock()
+	return summary, nil
+}
+
+func (m *MilvusMemory) chatSummary(ctx context.Context, prompt string) (string, error) {
+	resp, err := m.summaryLLM.Chat(ctx, []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: prompt}})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate summary: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("failed to generate summary: empty response")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
 // Close closes the Milvus client connection.
 func (m *MilvusMemory) Close() error {
 	if m.milvusClient != nil {
@@ -625,6 +2095,12 @@ type EmbedderWrapper struct {
 	}
 
 	model string
+
+	// BatchSize caps how many inputs are sent per request. Defaults to 100 when <= 0.
+	BatchSize int
+	// MaxRetries is how many times a batch is retried on transient errors (network, 408, 429, 5xx).
+	// Defaults to 2 when 0; negative disables retries.
+	MaxRetries int
 }
 
 // NewEmbedderWrapper creates a wrapper for embedding models.
@@ -644,22 +2120,52 @@ func NewEmbedderWrapperFromEmbeddings(embedder interface {
 }
 
 // Embeddings implements EmbedderInterface.
-// It handles both single-input and batch processing.
+// Inputs are split into batches of BatchSize; results are concatenated in input order.
 func (w *EmbedderWrapper) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
 	if len(inputs) == 0 {
 		return nil, nil
 	}
+	if w.embedderSingle == nil {
+		return nil, fmt.Errorf("no embedder configured")
+	}
 
-	// Fallback to single-input method (less efficient, processes one by one)
-	if w.embedderSingle != nil {
+	batchSize := w.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
 
-		embedding, err := w.embedderSingle.Embeddings(ctx, inputs)
+	out := make([][]float32, 0, len(inputs))
+	for start := 0; start < len(inputs); start += batchSize {
+		end := min(start+batchSize, len(inputs))
+		embeddings, err := w.embedBatch(ctx, inputs[start:end])
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate embedding for input: %w", err)
+			return nil, fmt.Errorf("failed to generate embeddings for inputs [%d:%d]: %w", start, end, err)
 		}
-
-		return embedding, nil
+		if len(embeddings) != end-start {
+			return nil, fmt.Errorf("%w: embedder returned %d embeddings for inputs [%d:%d], expected %d", llms.ErrEmbeddingCountMismatch, len(embeddings), start, end, end-start)
+		}
+		out = append(out, embeddings...)
 	}
+	return out, nil
+}
 
-	return nil, fmt.Errorf("no embedder configured")
+// embedBatch calls the underlying embedder, retrying transient failures with exponential backoff.
+func (w *EmbedderWrapper) embedBatch(ctx context.Context, batch []string) ([][]float32, error) {
+	retries := w.MaxRetries
+	if retries == 0 {
+		retries = 2
+	}
+	backoff := 500 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		embeddings, err := w.embedderSingle.Embeddings(ctx, batch)
+		if err == nil || attempt >= retries || !llms.IsRetryable(err) {
+			return embeddings, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
 }
@@ -0,0 +1,515 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+// fakeMilvus is an in-memory client.Client implementing the calls MilvusMemory makes. It
+// evaluates the boolean expressions it receives, so escaping bugs show up as wrong rows.
+// Calls it doesn't implement panic through the nil embedded interface.
+type fakeMilvus struct {
+	client.Client
+
+	mu     sync.Mutex
+	schema *entity.Schema
+	rows   []map[string]any
+	nextID int64
+	// exprs records the expression of every Query, Search and Delete call
+	exprs []string
+}
+
+func (f *fakeMilvus) HasCollection(ctx context.Context, collName string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.schema != nil, nil
+}
+
+func (f *fakeMilvus) CreateCollection(ctx context.Context, schema *entity.Schema, shardsNum int32, opts ...client.CreateCollectionOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schema = schema
+	return nil
+}
+
+func (f *fakeMilvus) DescribeCollection(ctx context.Context, collName string) (*entity.Collection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &entity.Collection{Name: collName, Schema: f.schema}, nil
+}
+
+func (f *fakeMilvus) CreateIndex(ctx context.Context, collName string, fieldName string, idx entity.Index, async bool, opts ...client.IndexOption) error {
+	return nil
+}
+
+func (f *fakeMilvus) DescribeIndex(ctx context.Context, collName string, fieldName string, opts ...client.IndexOption) ([]entity.Index, error) {
+	return nil, nil
+}
+
+func (f *fakeMilvus) LoadCollection(ctx context.Context, collName string, async bool, opts ...client.LoadCollectionOption) error {
+	return nil
+}
+
+func (f *fakeMilvus) Flush(ctx context.Context, collName string, async bool, opts ...client.FlushOption) error {
+	return nil
+}
+
+func (f *fakeMilvus) Close() error { return nil }
+
+func (f *fakeMilvus) Insert(ctx context.Context, collName string, partitionName string, columns ...entity.Column) (entity.Column, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := columns[0].Len()
+	ids := make([]int64, n)
+	for i := range n {
+		f.nextID++
+		row := map[string]any{"id": f.nextID}
+		for _, col := range columns {
+			v, err := col.Get(i)
+			if err != nil {
+				return nil, err
+			}
+			row[col.Name()] = v
+		}
+		f.rows = append(f.rows, row)
+		ids[i] = f.nextID
+	}
+	return entity.NewColumnInt64("id", ids), nil
+}
+
+func (f *fakeMilvus) Delete(ctx context.Context, collName string, partitionName string, expr string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.exprs = append(f.exprs, expr)
+	kept := f.rows[:0]
+	for _, row := range f.rows {
+		match, err := evalMilvusExpr(expr, row)
+		if err != nil {
+			return err
+		}
+		if !match {
+			kept = append(kept, row)
+		}
+	}
+	f.rows = kept
+	return nil
+}
+
+func (f *fakeMilvus) Query(ctx context.Context, collectionName string, partitionNames []string, expr string, outputFields []string, opts ...client.SearchQueryOptionFunc) (client.ResultSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.exprs = append(f.exprs, expr)
+	rows, err := f.match(expr)
+	if err != nil {
+		return nil, err
+	}
+	return f.columns(rows, outputFields), nil
+}
+
+func (f *fakeMilvus) Search(ctx context.Context, collName string, partitions []string, expr string, outputFields []string, vectors []entity.Vector, vectorField string, metricType entity.MetricType, topK int, sp entity.SearchParam, opts ...client.SearchQueryOptionFunc) ([]client.SearchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.exprs = append(f.exprs, expr)
+	rows, err := f.match(expr)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]client.SearchResult, 0, len(vectors))
+	for _, vector := range vectors {
+		query := vector.(entity.FloatVector)
+		type hit struct {
+			row      map[string]any
+			distance float32
+		}
+		hits := make([]hit, 0, len(rows))
+		for _, row := range rows {
+			hits = append(hits, hit{row, vectorScore(metricType, row[vectorField].([]float32), query)})
+		}
+		sort.SliceStable(hits, func(i, j int) bool {
+			if metricType == entity.L2 {
+				return hits[i].distance < hits[j].distance
+			}
+			return hits[i].distance > hits[j].distance
+		})
+		if len(hits) > topK {
+			hits = hits[:topK]
+		}
+		matched := make([]map[string]any, len(hits))
+		scores := make([]float32, len(hits))
+		for i, h := range hits {
+			matched[i], scores[i] = h.row, h.distance
+		}
+		results = append(results, client.SearchResult{ResultCount: len(hits), Fields: f.columns(matched, outputFields), Scores: scores})
+	}
+	return results, nil
+}
+
+// vectorScore scores v against query as Milvus does for metric: the squared distance for L2,
+// the inner product for IP and the cosine similarity for COSINE.
+func vectorScore(metric entity.MetricType, v, query []float32) float32 {
+	var dist, dot, nv, nq float64
+	for i := range v {
+		a, b := float64(v[i]), float64(query[i])
+		dist += (a - b) * (a - b)
+		dot += a * b
+		nv += a * a
+		nq += b * b
+	}
+	switch metric {
+	case entity.IP:
+		return float32(dot)
+	case entity.COSINE:
+		if nv == 0 || nq == 0 {
+			return 0
+		}
+		return float32(dot / math.Sqrt(nv*nq))
+	default:
+		return float32(dist)
+	}
+}
+
+// count returns how many stored rows match expr.
+func (f *fakeMilvus) count(expr string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows, err := f.match(expr)
+	if err != nil {
+		panic(err)
+	}
+	return len(rows)
+}
+
+// match returns the rows matching expr. Callers hold mu.
+func (f *fakeMilvus) match(expr string) ([]map[string]any, error) {
+	var rows []map[string]any
+	for _, row := range f.rows {
+		ok, err := evalMilvusExpr(expr, row)
+		if err != nil {
+			return nil, fmt.Errorf("bad expression %q: %w", expr, err)
+		}
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// columns returns the fields of rows as columns typed by the collection schema.
+func (f *fakeMilvus) columns(rows []map[string]any, fields []string) client.ResultSet {
+	var cols client.ResultSet
+	for _, name := range fields {
+		var typ entity.FieldType
+		for _, field := range f.schema.Fields {
+			if field.Name == name {
+				typ = field.DataType
+			}
+		}
+		switch typ {
+		case entity.FieldTypeVarChar:
+			values := make([]string, len(rows))
+			for i, row := range rows {
+				values[i], _ = row[name].(string)
+			}
+			cols = append(cols, entity.NewColumnVarChar(name, values))
+		case entity.FieldTypeInt64:
+			values := make([]int64, len(rows))
+			for i, row := range rows {
+				values[i], _ = row[name].(int64)
+			}
+			cols = append(cols, entity.NewColumnInt64(name, values))
+		case entity.FieldTypeFloat:
+			values := make([]float32, len(rows))
+			for i, row := range rows {
+				values[i], _ = row[name].(float32)
+			}
+			cols = append(cols, entity.NewColumnFloat(name, values))
+		case entity.FieldTypeJSON:
+			values := make([][]byte, len(rows))
+			for i, row := range rows {
+				values[i], _ = row[name].([]byte)
+			}
+			cols = append(cols, entity.NewColumnJSONBytes(name, values))
+		}
+	}
+	return cols
+}
+
+// evalMilvusExpr evaluates the subset of the Milvus boolean expression language used by
+// MilvusMemory (comparisons, like, in, &&, ||, not, parentheses and metadata["key"]) on row.
+func evalMilvusExpr(expr string, row map[string]any) (bool, error) {
+	if strings.TrimSpace(expr) == "" {
+		return true, nil
+	}
+	tokens, err := lexMilvusExpr(expr)
+	if err != nil {
+		return false, err
+	}
+	p := &exprParser{tokens: tokens, row: row}
+	v, err := p.or()
+	if err != nil {
+		return false, err
+	}
+	if p.pos != len(p.tokens) {
+		return false, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return v, nil
+}
+
+type exprToken struct {
+	kind string // "ident", "string", "number", "op"
+	text string
+}
+
+func lexMilvusExpr(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ':
+			i++
+		case c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(expr) && expr[i] != '"'; i++ {
+				if expr[i] == '\\' {
+					i++
+					if i == len(expr) {
+						return nil, fmt.Errorf("unterminated string")
+					}
+				}
+				b.WriteByte(expr[i])
+			}
+			if i == len(expr) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+			tokens = append(tokens, exprToken{"string", b.String()})
+		case c >= '0' && c <= '9' || c == '-':
+			j := i + 1
+			for j < len(expr) && (expr[j] >= '0' && expr[j] <= '9' || expr[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, exprToken{"number", expr[i:j]})
+			i = j
+		case unicode.IsLetter(rune(c)) || c == '_':
+			j := i
+			for j < len(expr) && (unicode.IsLetter(rune(expr[j])) || unicode.IsDigit(rune(expr[j])) || expr[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, exprToken{"ident", expr[i:j]})
+			i = j
+		default:
+			for _, op := range []string{"&&", "||", "==", "!=", ">=", "<=", ">", "<", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, exprToken{"op", op})
+					i += len(op)
+					goto next
+				}
+			}
+			return nil, fmt.Errorf("unexpected character %q", c)
+		next:
+		}
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	row    map[string]any
+}
+
+func (p *exprParser) peek(text string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].text == text && p.tokens[p.pos].kind != "string"
+}
+
+func (p *exprParser) next() (exprToken, error) {
+	if p.pos == len(p.tokens) {
+		return exprToken{}, fmt.Errorf("unexpected end")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *exprParser) or() (bool, error) {
+	v, err := p.and()
+	for err == nil && p.peek("||") {
+		p.pos++
+		var w bool
+		w, err = p.and()
+		v = v || w
+	}
+	return v, err
+}
+
+func (p *exprParser) and() (bool, error) {
+	v, err := p.unary()
+	for err == nil && p.peek("&&") {
+		p.pos++
+		var w bool
+		w, err = p.unary()
+		v = v && w
+	}
+	return v, err
+}
+
+func (p *exprParser) unary() (bool, error) {
+	if p.peek("not") {
+		p.pos++
+		v, err := p.unary()
+		return !v, err
+	}
+	if p.peek("(") {
+		p.pos++
+		v, err := p.or()
+		if err != nil {
+			return false, err
+		}
+		if !p.peek(")") {
+			return false, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return v, nil
+	}
+	left, err := p.operand()
+	if err != nil {
+		return false, err
+	}
+	op, err := p.next()
+	if err != nil {
+		return false, err
+	}
+	right, err := p.operand()
+	if err != nil {
+		return false, err
+	}
+	switch op.text {
+	case "==":
+		return fmt.Sprint(left) == fmt.Sprint(right), nil
+	case "!=":
+		return fmt.Sprint(left) != fmt.Sprint(right), nil
+	case ">", ">=", "<", "<=":
+		l, _ := strconv.ParseFloat(fmt.Sprint(left), 64)
+		r, _ := strconv.ParseFloat(fmt.Sprint(right), 64)
+		return map[string]bool{">": l > r, ">=": l >= r, "<": l < r, "<=": l <= r}[op.text], nil
+	case "like":
+		return likeMatch(fmt.Sprint(right), fmt.Sprint(left)), nil
+	case "in":
+		for _, v := range right.([]any) {
+			if fmt.Sprint(v) == fmt.Sprint(left) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op.text)
+}
+
+func (p *exprParser) operand() (any, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tok.kind == "string" || tok.kind == "number":
+		return tok.text, nil
+	case tok.kind == "ident":
+		v := p.row[tok.text]
+		if p.peek("[") {
+			p.pos++
+			key, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			p.pos++ // ]
+			var m map[string]any
+			raw, _ := v.([]byte)
+			json.Unmarshal(raw, &m)
+			v = m[key.text]
+		}
+		if v == nil {
+			return "", nil
+		}
+		return v, nil
+	case tok.text == "[":
+		var list []any
+		for !p.peek("]") {
+			v, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			if p.peek(",") {
+				p.pos++
+			}
+		}
+		p.pos++
+		return list, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.text)
+}
+
+// likeMatch reports whether s matches the like pattern: % matches any run, _ one character
+// and a backslash makes the next character literal.
+func likeMatch(pattern, s string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '%':
+		for i := 0; i <= len(s); i++ {
+			if likeMatch(pattern[1:], s[i:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		return s != "" && likeMatch(pattern[1:], s[1:])
+	case '\\':
+		if len(pattern) > 1 {
+			return s != "" && s[0] == pattern[1] && likeMatch(pattern[2:], s[1:])
+		}
+	}
+	return s != "" && s[0] == pattern[0] && likeMatch(pattern[1:], s[1:])
+}
+
+// fakeEmbedder embeds text as the counts of its bytes modulo the dimension.
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	out := make([][]float32, len(inputs))
+	for i, in := range inputs {
+		v := make([]float32, 8)
+		for _, b := range []byte(strings.ToLower(in)) {
+			v[int(b)%8]++
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// newTestMilvus returns a MilvusMemory over a fresh fakeMilvus configured by cfg.
+func newTestMilvus(t *testing.T, cfg MilvusConfig) (*MilvusMemory, *fakeMilvus) {
+	t.Helper()
+	fake := &fakeMilvus{}
+	cfg.MilvusClient = fake
+	if cfg.Embedder == nil {
+		cfg.Embedder = fakeEmbedder{}
+	}
+	m, err := NewMilvusMemory(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, fake
+}
@@ -0,0 +1,498 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+// qa returns a user question and its answer.
+func qa(q, a string) []llms.ChatCompletionMessage {
+	return []llms.ChatCompletionMessage{
+		{Role: llms.ChatMessageRoleUser, Content: q},
+		{Role: llms.ChatMessageRoleAssistant, Content: a},
+	}
+}
+
+func TestLikePrefix(t *testing.T) {
+	tests := map[string]string{
+		"tenant:":  `"tenant:%"`,
+		"a_c:":     `"a\\_c:%"`,
+		"100%:":    `"100\\%:%"`,
+		`back\sl:`: `"back\\\\sl:%"`,
+		`quo"te:`:  `"quo\"te:%"`,
+	}
+	for prefix, want := range tests {
+		if got := likePrefix(prefix); got != want {
+			t.Errorf("likePrefix(%q) = %s, want %s", prefix, got, want)
+		}
+	}
+}
+
+func TestMilvusString(t *testing.T) {
+	tests := map[string]string{
+		"plain":                      `"plain"`,
+		`a" || conversation_id != "`: `"a\" || conversation_id != \""`,
+		`back\`:                      `"back\\"`,
+		`\" or 1 == 1 or "`:          `"\\\" or 1 == 1 or \""`,
+	}
+	for s, want := range tests {
+		if got := milvusString(s); got != want {
+			t.Errorf("milvusString(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+// Conversation IDs trying to break out of the string literal of a filter only ever select
+// their own rows.
+func TestMilvusMemoryHostileConversationID(t *testing.T) {
+	ctx := context.Background()
+	for _, hostile := range []string{
+		`a" || conversation_id != "`,
+		`a\" || conversation_id != "`,
+		`x\`,
+	} {
+		t.Run(hostile, func(t *testing.T) {
+			m, fake := newTestMilvus(t, MilvusConfig{})
+			if err := m.SaveMessages(ctx, "victim", qa("secret", "kept")); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.SaveMessages(ctx, hostile, qa("hello", "hi")); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := m.LoadMessages(ctx, hostile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 2 || got[0].Content != "hello" {
+				t.Errorf("LoadMessages(hostile) = %+v, want only its own exchange", got)
+			}
+			if err := m.ClearMessages(ctx, hostile); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := m.LoadMessages(ctx, hostile); len(got) != 0 {
+				t.Errorf("after ClearMessages LoadMessages(hostile) = %+v, want none", got)
+			}
+			victim, err := m.LoadMessages(ctx, "victim")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(victim) != 2 || victim[0].Content != "secret" {
+				t.Errorf("victim messages = %+v after clearing the hostile ID, want them kept", victim)
+			}
+			if len(fake.exprs) == 0 {
+				t.Error("no filter expression reached the client")
+			}
+		})
+	}
+}
+
+// storedPairs returns the number of Q&A pairs stored in fake: rows carrying a content hash,
+// or every row of a legacy collection.
+func storedPairs(fake *fakeMilvus) int {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	n := 0
+	for _, row := range fake.rows {
+		if h, ok := row["content_hash"]; !ok || h != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMilvusMemoryDedupWindow(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		window time.Duration
+		legacy bool
+		// saves are the messages of each SaveMessages call
+		saves [][]llms.ChatCompletionMessage
+		want  int
+	}{
+		{"repeated save", time.Hour, false, [][]llms.ChatCompletionMessage{qa("hi", "hello"), qa("hi", "hello")}, 1},
+		{"repeated within a save", time.Hour, false, [][]llms.ChatCompletionMessage{append(qa("hi", "hello"), qa("hi", "hello")...)}, 1},
+		{"other answer", time.Hour, false, [][]llms.ChatCompletionMessage{qa("hi", "hello"), qa("hi", "hey")}, 2},
+		{"disabled", 0, false, [][]llms.ChatCompletionMessage{qa("hi", "hello"), qa("hi", "hello")}, 2},
+		{"legacy repeated save", time.Hour, true, [][]llms.ChatCompletionMessage{qa("hi", "hello"), qa("hi", "hello")}, 2},
+		{"legacy repeated within a save", time.Hour, true, [][]llms.ChatCompletionMessage{append(qa("hi", "hello"), qa("hi", "hello")...)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, fake := newTestMilvus(t, MilvusConfig{})
+			if tt.legacy {
+				legacySchema(fake)
+			}
+			m, err := NewMilvusMemory(MilvusConfig{MilvusClient: fake, Embedder: fakeEmbedder{}, AllowLegacySchema: true, DedupWindow: tt.window})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, msgs := range tt.saves {
+				if err := m.SaveMessages(ctx, "c1", msgs); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if n := storedPairs(fake); n != tt.want {
+				t.Errorf("stored %d pairs, want %d", n, tt.want)
+			}
+		})
+	}
+}
+
+// A pair saved before the window is saved again, and the same pair in another conversation
+// is not a duplicate.
+func TestMilvusMemoryDedupWindowExpiry(t *testing.T) {
+	ctx := context.Background()
+	m, fake := newTestMilvus(t, MilvusConfig{DedupWindow: time.Minute})
+	if err := m.SaveMessages(ctx, "c1", qa("hi", "hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveMessages(ctx, "c2", qa("hi", "hello")); err != nil {
+		t.Fatal(err)
+	}
+	if n := storedPairs(fake); n != 2 {
+		t.Fatalf("stored %d pairs, want one per conversation", n)
+	}
+	fake.mu.Lock()
+	for _, row := range fake.rows {
+		row["timestamp"] = time.Now().Add(-time.Hour).UnixNano()
+	}
+	fake.mu.Unlock()
+	if err := m.SaveMessages(ctx, "c1", qa("hi", "hello")); err != nil {
+		t.Fatal(err)
+	}
+	if n := storedPairs(fake); n != 3 {
+		t.Errorf("stored %d pairs, want the expired pair saved again", n)
+	}
+	if !slices.ContainsFunc(fake.exprs, func(e string) bool { return strings.HasPrefix(e, "content_hash in [") }) {
+		t.Error("stored hashes were not looked up")
+	}
+
+	res, err := m.BulkImport(ctx, "c1", []QAPair{{UserInput: "hi", LLMOutput: "hello"}, {UserInput: "new", LLMOutput: "pair"}}, BulkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 1 || res.Skipped != 1 {
+		t.Errorf("BulkImport = %+v, want 1 inserted and 1 skipped", res)
+	}
+}
+
+// topicEmbedder embeds texts about kittens, cats and cars as fixed unit vectors: a cat is
+// close to a kitten and far from a car.
+type topicEmbedder struct{}
+
+func (topicEmbedder) Embeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	out := make([][]float32, len(inputs))
+	for i, in := range inputs {
+		v := make([]float32, 8)
+		switch {
+		case strings.Contains(in, "kitten"):
+			v[0], v[1] = 0.8, 0.6
+		case strings.Contains(in, "cat"):
+			v[0] = 1
+		case strings.Contains(in, "car"):
+			v[2] = 1
+		default:
+			v[3] = 1
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// multiVectorMilvus searches with every query vector twice, as a search with several query
+// vectors returning the same hits does.
+type multiVectorMilvus struct {
+	*fakeMilvus
+}
+
+func (f multiVectorMilvus) Search(ctx context.Context, collName string, partitions []string, expr string, outputFields []string, vectors []entity.Vector, vectorField string, metricType entity.MetricType, topK int, sp entity.SearchParam, opts ...client.SearchQueryOptionFunc) ([]client.SearchResult, error) {
+	return f.fakeMilvus.Search(ctx, collName, partitions, expr, outputFields, append(vectors, vectors...), vectorField, metricType, topK, sp, opts...)
+}
+
+// saveTopics saves an exchange about kittens, cats and cars to c1.
+func saveTopics(t *testing.T, m *MilvusMemory) {
+	t.Helper()
+	for _, ex := range [][]llms.ChatCompletionMessage{qa("kitten facts", "small"), qa("cat facts", "meow"), qa("car facts", "vroom")} {
+		if err := m.SaveMessages(context.Background(), "c1", ex); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMilvusMemoryScoreThreshold(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		metric    entity.MetricType
+		threshold float32
+		want      string
+	}{
+		{"no threshold", entity.L2, 0, "[cat facts kitten facts car facts]"},
+		{"L2 max distance", entity.L2, 0.5, "[cat facts kitten facts]"},
+		{"L2 exact only", entity.L2, 0.01, "[cat facts]"},
+		{"COSINE min similarity", entity.COSINE, 0.5, "[cat facts kitten facts]"},
+		{"COSINE exact only", entity.COSINE, 0.9, "[cat facts]"},
+		{"IP min similarity", entity.IP, 0.5, "[cat facts kitten facts]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestMilvus(t, MilvusConfig{Embedder: topicEmbedder{}, MetricType: tt.metric, ScoreThreshold: tt.threshold})
+			saveTopics(t, m)
+
+			scored, err := m.GetRelevantMessagesWithScores(ctx, "c1", "cat", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var questions []string
+			for _, s := range scored {
+				questions = append(questions, s.Messages[0].Content)
+				if len(s.Messages) != 2 || s.Messages[1].Role != llms.ChatMessageRoleAssistant {
+					t.Errorf("scored messages %+v, want the question and its answer", s.Messages)
+				}
+			}
+			if got := fmt.Sprint(questions); got != tt.want {
+				t.Errorf("GetRelevantMessagesWithScores = %v, want %v", got, tt.want)
+			}
+			if len(scored) > 1 && !m.withinThreshold(scored[0].Score) {
+				t.Errorf("best score %v fails the threshold", scored[0].Score)
+			}
+			msgs, err := m.GetRelevantMessages(ctx, "c1", "cat", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(msgs) != 2*len(scored) {
+				t.Errorf("GetRelevantMessages returned %d messages, want %d", len(msgs), 2*len(scored))
+			}
+		})
+	}
+}
+
+func TestMilvusMemoryRelevantMessagesScores(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestMilvus(t, MilvusConfig{Embedder: topicEmbedder{}})
+	saveTopics(t, m)
+	scored, err := m.GetRelevantMessagesWithScores(ctx, "c1", "cat", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scored) != 2 {
+		t.Fatalf("GetRelevantMessagesWithScores returned %d pairs, want limit 2", len(scored))
+	}
+	// L2 squared distances from the cat vector
+	if scored[0].Score != 0 || math.Abs(float64(scored[1].Score)-0.4) > 1e-6 {
+		t.Errorf("scores = %v, %v, want 0 and 0.4", scored[0].Score, scored[1].Score)
+	}
+}
+
+// A Q&A pair stored twice, or matched by several query vectors, is returned once.
+func TestMilvusMemoryRelevantMessagesDedup(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeMilvus{}
+	m, err := NewMilvusMemory(MilvusConfig{MilvusClient: multiVectorMilvus{fake}, Embedder: topicEmbedder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saveTopics(t, m)
+	if err := m.SaveMessages(ctx, "c1", qa("cat facts", "meow")); err != nil {
+		t.Fatal(err)
+	}
+
+	scored, err := m.GetRelevantMessagesWithScores(ctx, "c1", "cat", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var questions []string
+	for _, s := range scored {
+		questions = append(questions, s.Messages[0].Content)
+	}
+	if got := fmt.Sprint(questions); got != "[cat facts kitten facts car facts]" {
+		t.Errorf("GetRelevantMessagesWithScores = %v, want each pair once", got)
+	}
+	msgs, err := m.GetRelevantMessages(ctx, "c1", "cat", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 6 {
+		t.Errorf("GetRelevantMessages returned %d messages, want 6", len(msgs))
+	}
+}
+
+// legacySchema turns the collection of fake into one created before messages were stored
+// individually, which only has Q&A pair fields.
+func legacySchema(fake *fakeMilvus) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	var fields []*entity.Field
+	for _, f := range fake.schema.Fields {
+		switch f.Name {
+		case "metadata", "role", "message", "content_hash", "importance":
+		default:
+			fields = append(fields, f)
+		}
+	}
+	fake.schema = &entity.Schema{CollectionName: fake.schema.CollectionName, Fields: fields}
+}
+
+// Questions and answers saved in separate calls by concurrent agents, on several
+// conversations and memories sharing a collection, are paired within their conversation.
+// Run with -race.
+// A question is paired with the last answer before the next user message of the call, so
+// tool call notes saved ahead of the answer are not mistaken for it.
+func TestPairQA(t *testing.T) {
+	user := func(s string) llms.ChatCompletionMessage {
+		return llms.ChatCompletionMessage{Role: llms.ChatMessageRoleUser, Content: s}
+	}
+	assistant := func(s string) llms.ChatCompletionMessage {
+		return llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: s}
+	}
+	tests := []struct {
+		name  string
+		calls [][]llms.ChatCompletionMessage
+		want  []string
+	}{
+		{"plain", [][]llms.ChatCompletionMessage{{user("q1"), assistant("a1"), user("q2"), assistant("a2")}}, []string{"q1=a1", "q2=a2"}},
+		{"tool call notes", [][]llms.ChatCompletionMessage{{user("q1"), assistant("called tool x with args {}"), assistant("a1")}}, []string{"q1=a1"}},
+		{"native tool calls", [][]llms.ChatCompletionMessage{turn(1, 2)}, []string{"q1=a1"}},
+		{"answer in a later call", [][]llms.ChatCompletionMessage{{user("q1")}, {assistant("a1")}}, []string{"q1=a1"}},
+		{"answer without question", [][]llms.ChatCompletionMessage{{assistant("hello")}, {user("q1"), assistant("a1")}}, []string{"q1=a1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, groupPending := map[string]string{}, map[string]string{}
+			buffered := map[string][]llms.ChatCompletionMessage{}
+			var pairs, grouped []string
+			for _, msgs := range tt.calls {
+				for _, r := range pairQA(pending, "c1", msgs) {
+					pairs = append(pairs, r.userInput+"="+r.llmOutput)
+				}
+			}
+			for _, msgs := range tt.calls {
+				for _, g := range groupExchanges(groupPending, buffered, "c1", msgs) {
+					if g.record.userInput != "" {
+						grouped = append(grouped, g.record.userInput+"="+g.record.llmOutput)
+					}
+				}
+			}
+			if !slices.Equal(pairs, tt.want) {
+				t.Errorf("pairQA = %v, want %v", pairs, tt.want)
+			}
+			if !slices.Equal(grouped, tt.want) {
+				t.Errorf("groupExchanges = %v, want %v", grouped, tt.want)
+			}
+		})
+	}
+}
+
+func TestMilvusMemoryConcurrentPairing(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		t.Run(fmt.Sprintf("legacy=%v", legacy), func(t *testing.T) {
+			ctx := context.Background()
+			_, fake := newTestMilvus(t, MilvusConfig{})
+			if legacy {
+				legacySchema(fake)
+			}
+			newMem := func() *MilvusMemory {
+				m, err := NewMilvusMemory(MilvusConfig{MilvusClient: fake, Embedder: fakeEmbedder{}, AllowLegacySchema: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m.hasRole == legacy {
+					t.Fatalf("hasRole = %v with legacy = %v", m.hasRole, legacy)
+				}
+				return m
+			}
+			first, second := newMem(), newMem()
+
+			const turns = 20
+			convs := map[string]*MilvusMemory{"a": first, "b": first, "c": second, "d": second}
+			var wg sync.WaitGroup
+			for id, m := range convs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range turns {
+						q := []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: fmt.Sprintf("q%d-%s", i, id)}}
+						a := []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleAssistant, Content: fmt.Sprintf("a%d-%s", i, id)}}
+						if err := m.SaveMessages(ctx, id, q); err != nil {
+							t.Error(err)
+							return
+						}
+						if err := m.SaveMessages(ctx, id, a); err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			for id, m := range convs {
+				got, err := m.LoadMessages(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(got) != 2*turns {
+					t.Fatalf("conversation %s has %d messages, want %d", id, len(got), 2*turns)
+				}
+				for i := 0; i < len(got); i += 2 {
+					q, a := got[i].Content, got[i+1].Content
+					if !strings.HasSuffix(q, "-"+id) || "a"+strings.TrimPrefix(q, "q") != a {
+						t.Errorf("conversation %s paired %q with %q", id, q, a)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Wildcards in a namespace don't make its like filter match other namespaces.
+func TestMilvusMemoryNamespaceWildcards(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeMilvus{}
+	newMem := func(ns string) *MilvusMemory {
+		m, err := NewMilvusMemory(MilvusConfig{MilvusClient: fake, Embedder: fakeEmbedder{}, Namespace: ns})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	wild, other := newMem("a_c"), newMem("abc")
+	if err := wild.SaveMessages(ctx, "mine", qa("hello", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.SaveMessages(ctx, "theirs", qa("hello", "hi")); err != nil {
+		t.Fatal(err)
+	}
+
+	convs, err := wild.ListConversations(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(convs) != 1 || convs[0].ID != "mine" {
+		t.Errorf("ListConversations = %+v, want only mine", convs)
+	}
+	hits, err := wild.SearchAllConversations(ctx, "hello", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hit := range hits {
+		if hit.ConversationID != "mine" {
+			t.Errorf("SearchAllConversations returned %q of another namespace", hit.ConversationID)
+		}
+	}
+	if len(hits) != 1 {
+		t.Errorf("SearchAllConversations returned %d hits, want 1", len(hits))
+	}
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// namespaceSeparator separates the namespace from the conversation ID.
+const namespaceSeparator = ":"
+
+// checkNamespace rejects namespaces containing the separator, which would make the namespaced
+// IDs of different tenants ambiguous.
+func checkNamespace(ns string) error {
+	if strings.Contains(ns, namespaceSeparator) {
+		return fmt.Errorf("namespace %q must not contain %q", ns, namespaceSeparator)
+	}
+	return nil
+}
+
+// namespaced returns conversationID prefixed with ns, or unchanged when ns is empty.
+func namespaced(ns, conversationID string) string {
+	if ns == "" {
+		return conversationID
+	}
+	return ns + namespaceSeparator + conversationID
+}
+
+// NamespacedMemory prefixes every conversation ID with a namespace before passing it to the
+// wrapped memory, for backends without a native Namespace option. Create it with WithNamespace.
+type NamespacedMemory struct {
+	inner  Memory
+	prefix string
+}
+
+// namespacedConversationMemory is a NamespacedMemory over a query-aware ConversationMemory;
+// it keeps both capabilities so agents still load relevant history through it.
+type namespacedConversationMemory struct {
+	*NamespacedMemory
+	inner ConversationMemory
+}
+
+// WithNamespace wraps inner so conversations are stored as "ns:conversationID". Memories with
+// different namespaces over the same backend never see each other's conversations. When inner
+// is a query-aware ConversationMemory (such as PgVectorMemory), the result is one too.
+//
+// Example:
+//
+//	mem, err := memory.WithNamespace(memory.NewFileMemory("./memory.json"), "customer-a")
+func WithNamespace(inner Memory, ns string) (Memory, error) {
+	if ns == "" {
+		return nil, fmt.Errorf("namespace must not be empty")
+	}
+	if err := checkNamespace(ns); err != nil {
+		return nil, err
+	}
+	m := &NamespacedMemory{inner: inner, prefix: ns + namespaceSeparator}
+	if cm, ok := inner.(ConversationMemory); ok {
+		if _, ok := inner.(MilvusMemoryInterface); ok {
+			return &namespacedConversationMemory{NamespacedMemory: m, inner: cm}, nil
+		}
+	}
+	return m, nil
+}
+
+func (m *NamespacedMemory) id(conversationID string) string {
+	if conversationID == "" {
+		conversationID = "default"
+	}
+	return m.prefix + conversationID
+}
+
+// LoadMessages implements [Memory].
+func (m *NamespacedMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	return m.inner.LoadMessages(ctx, m.id(conversationID))
+}
+
+// SaveMessages implements [Memory].
+func (m *NamespacedMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	return m.inner.SaveMessages(ctx, m.id(conversationID), messages)
+}
+
+// ClearMessages implements [Memory].
+func (m *NamespacedMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	return m.inner.ClearMessages(ctx, m.id(conversationID))
+}
+
+// ListConversations implements [ConversationLister] for the conversations of this namespace,
+// without the prefix. The wrapped memory must be able to list conversations (a
+// [ConversationLister] such as SQLiteMemory, RedisMemory or JSONLMemory, or BufferMemory);
+// all of them are read and filtered here, so the page is cut after filtering.
+func (m *NamespacedMemory) ListConversations(ctx context.Context, limit, offset int) ([]ConversationInfo, error) {
+	var convs []ConversationInfo
+	switch lister := m.inner.(type) {
+	case ConversationLister:
+		all, err := lister.ListConversations(ctx, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range all {
+			if rest, ok := strings.CutPrefix(info.ID, m.prefix); ok {
+				info.ID = rest
+				convs = append(convs, info)
+			}
+		}
+	default:
+		ids, err := listConversations(ctx, m.inner)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if rest, ok := strings.CutPrefix(id, m.prefix); ok {
+				convs = append(convs, ConversationInfo{ID: rest})
+			}
+		}
+	}
+	return pageConversations(convs, limit, offset), nil
+}
+
+// listConversations returns the conversation IDs of m, through whichever listing method it has.
+func listConversations(ctx context.Context, m Memory) ([]string, error) {
+	switch lister := m.(type) {
+	case ConversationLister:
+		convs, err := lister.ListConversations(ctx, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, len(convs))
+		for i, info := range convs {
+			ids[i] = info.ID
+		}
+		return ids, nil
+	case interface{ GetConversations() []string }:
+		return lister.GetConversations(), nil
+	default:
+		return nil, fmt.Errorf("%T cannot list conversations", m)
+	}
+}
+
+// Close closes the wrapped memory when it implements [Closer].
+func (m *NamespacedMemory) Close() error {
+	return closeMemory(m.inner)
+}
+
+// SetQuery implements [MilvusMemoryInterface].
+func (m *namespacedConversationMemory) SetQuery(query string) {
+	m.inner.(MilvusMemoryInterface).SetQuery(query)
+}
+
+// GetRelevantMessages implements [ConversationMemory].
+func (m *namespacedConversationMemory) GetRelevantMessages(ctx context.Context, conversationID string, query string, limit int) ([]llms.ChatCompletionMessage, error) {
+	return m.inner.GetRelevantMessages(ctx, m.id(conversationID), query, limit)
+}
+
+// SummarizeMessages implements [ConversationMemory].
+func (m *namespacedConversationMemory) SummarizeMessages(ctx context.Context, conversationID string) (string, error) {
+	return m.inner.SummarizeMessages(ctx, m.id(conversationID))
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNamespacedMemoryListConversations(t *testing.T) {
+	ctx := context.Background()
+	inner := newTestSQLite(t, "chat.db")
+	mem, err := WithNamespace(inner, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a:1", "b:1", "a:2", "b:2", "a:3"} {
+		if err := inner.SaveMessages(ctx, id, turn(1, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lister := mem.(ConversationLister)
+	convs, err := lister.ListConversations(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conversationIDs(convs); got != "[3 2 1]" {
+		t.Errorf("ListConversations = %v, want [3 2 1]", got)
+	}
+	if convs[0].MessageCount != 2 {
+		t.Errorf("ListConversations = %+v, want the counts of the inner memory", convs)
+	}
+	// the page is cut after filtering out the other namespace
+	if page, _ := lister.ListConversations(ctx, 1, 1); conversationIDs(page) != "[2]" {
+		t.Errorf("ListConversations(1, 1) = %v, want [2]", conversationIDs(page))
+	}
+}
+
+func TestNamespacedMemoryListConversationsOverBuffer(t *testing.T) {
+	ctx := context.Background()
+	inner := NewBufferMemory()
+	mem, err := WithNamespace(inner, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a:2", "b:1", "a:1"} {
+		if err := inner.SaveMessages(ctx, id, turn(1, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	convs, err := mem.(ConversationLister).ListConversations(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conversationIDs(convs); got != "[1 2]" {
+		t.Errorf("ListConversations = %v, want [1 2]", got)
+	}
+}
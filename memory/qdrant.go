@@ -0,0 +1,490 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// QdrantMemory stores Q&A pairs as Qdrant points, with conversation_id, user_input, llm_output
+// and timestamp in the payload, and retrieves relevant history by cosine similarity. It talks
+// to the Qdrant REST API and implements [ConversationMemory] and [MilvusMemoryInterface]
+// like MilvusMemory, so agents set the query automatically.
+type QdrantMemory struct {
+	httpClient   *http.Client
+	baseURL      string
+	apiKey       string
+	collection   string
+	embedder     EmbedderInterface
+	embeddingDim int
+	strictEmbed  bool
+	// EnableQueryBasedLoading makes LoadMessages return the pairs most relevant to the query
+	// set by SetQuery instead of the full history.
+	EnableQueryBasedLoading bool
+	// MaxRelevantMessages limits the pairs retrieved by query-based loading.
+	MaxRelevantMessages int
+
+	mutex   sync.RWMutex
+	query   string
+	pending map[string]string
+}
+
+// QdrantConfig holds configuration for QdrantMemory.
+type QdrantConfig struct {
+	// URL is the Qdrant REST endpoint, e.g. "https://xyz.cloud.qdrant.io:6333".
+	// If empty, it is built from Host and Port over http.
+	URL string
+
+	// Host is the Qdrant server host. Default is "localhost".
+	Host string
+
+	// Port is the Qdrant REST port. Default is 6333.
+	Port int
+
+	// APIKey is sent in the api-key header when set.
+	APIKey string
+
+	// Collection is the collection name. Default is "langchain_memory".
+	Collection string
+
+	// EmbeddingDim is the vector size of the collection. If 0, it is inferred by embedding a
+	// probe string with Embedder.
+	EmbeddingDim int
+
+	// Embedder is the embedding model interface.
+	Embedder EmbedderInterface
+
+	// EnableQueryBasedLoading enables query-based loading in LoadMessages.
+	EnableQueryBasedLoading bool
+
+	// MaxRelevantMessages limits the number of relevant messages to retrieve
+	// when using query-based loading. Default is 10.
+	MaxRelevantMessages int
+
+	// StrictEmbedding makes SaveMessages store nothing when any Q&A pair fails to embed.
+	StrictEmbedding bool
+
+	// HTTPClient is used for requests. Default is a client with a 30s timeout.
+	HTTPClient *http.Client
+}
+
+// NewQdrantMemory creates a QdrantMemory, creating the collection (cosine distance) and a
+// keyword index on conversation_id if they don't exist.
+//
+// Example:
+//
+//	mem, err := memory.NewQdrantMemory(memory.QdrantConfig{
+//	    Host:         "localhost",
+//	    Port:         6333,
+//	    Collection:   "conversation_memory",
+//	    EmbeddingDim: 1536,
+//	    Embedder:     embedder,
+//	})
+func NewQdrantMemory(cfg QdrantConfig) (*QdrantMemory, error) {
+	if cfg.Embedder == nil {
+		return nil, fmt.Errorf("embedder must be provided")
+	}
+
+	baseURL := strings.TrimRight(cfg.URL, "/")
+	if baseURL == "" {
+		host := cfg.Host
+		if host == "" {
+			host = "localhost"
+		}
+		port := cfg.Port
+		if port == 0 {
+			port = 6333
+		}
+		baseURL = fmt.Sprintf("http://%s:%d", host, port)
+	}
+
+	collection := cfg.Collection
+	if collection == "" {
+		collection = "langchain_memory"
+	}
+
+	embeddingDim := cfg.EmbeddingDim
+	if embeddingDim == 0 {
+		probe, err := cfg.Embedder.Embeddings(context.Background(), []string{"dimension probe"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to infer embedding dimension: %w", err)
+		}
+		if len(probe) == 0 || len(probe[0]) == 0 {
+			return nil, fmt.Errorf("failed to infer embedding dimension: embedder returned no vector")
+		}
+		embeddingDim = len(probe[0])
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	maxRelevant := cfg.MaxRelevantMessages
+	if maxRelevant <= 0 {
+		maxRelevant = 10
+	}
+
+	mem := &QdrantMemory{
+		httpClient:              httpClient,
+		baseURL:                 baseURL,
+		apiKey:                  cfg.APIKey,
+		collection:              collection,
+		embedder:                cfg.Embedder,
+		embeddingDim:            embeddingDim,
+		strictEmbed:             cfg.StrictEmbedding,
+		EnableQueryBasedLoading: cfg.EnableQueryBasedLoading,
+		MaxRelevantMessages:     maxRelevant,
+		pending:                 make(map[string]string),
+	}
+
+	if err := mem.ensureCollection(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ensure collection: %w", err)
+	}
+
+	return mem, nil
+}
+
+// ensureCollection creates the collection if it doesn't exist and checks the vector size of
+// an existing one.
+func (m *QdrantMemory) ensureCollection(ctx context.Context) error {
+	var info struct {
+		Config struct {
+			Params struct {
+				Vectors struct {
+					Size int `json:"size"`
+				} `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	}
+	err := m.do(ctx, http.MethodGet, "", nil, &info)
+	if err == nil {
+		if size := info.Config.Params.Vectors.Size; size != 0 && size != m.embeddingDim {
+			return fmt.Errorf("collection %s has vector size %d, but EmbeddingDim is %d", m.collection, size, m.embeddingDim)
+		}
+		return nil
+	}
+	if !isQdrantNotFound(err) {
+		return err
+	}
+
+	create := map[string]any{
+		"vectors": map[string]any{"size": m.embeddingDim, "distance": "Cosine"},
+	}
+	if err := m.do(ctx, http.MethodPut, "", create, nil); err != nil {
+		return err
+	}
+	index := map[string]any{"field_name": "conversation_id", "field_schema": "keyword"}
+	return m.do(ctx, http.MethodPut, "/index?wait=true", index, nil)
+}
+
+// getConversationID returns the conversation ID, using default if empty.
+func (m *QdrantMemory) getConversationID(conversationID string) string {
+	if conversationID != "" {
+		return conversationID
+	}
+	return "default"
+}
+
+// SetQuery sets the query used by query-based loading in LoadMessages.
+func (m *QdrantMemory) SetQuery(query string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.query = query
+}
+
+// LoadMessages returns the pairs most relevant to the SetQuery query when
+// EnableQueryBasedLoading is set, and the full history in chronological order otherwise.
+func (m *QdrantMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	if m.EnableQueryBasedLoading {
+		m.mutex.RLock()
+		query := m.query
+		m.mutex.RUnlock()
+		if query != "" {
+			return m.GetRelevantMessages(ctx, conversationID, query, m.MaxRelevantMessages)
+		}
+	}
+
+	var points []qdrantPoint
+	req := map[string]any{
+		"filter":       m.filter(conversationID),
+		"limit":        256,
+		"with_payload": true,
+		"with_vector":  false,
+	}
+	for {
+		var page struct {
+			Points         []qdrantPoint `json:"points"`
+			NextPageOffset any           `json:"next_page_offset"`
+		}
+		if err := m.do(ctx, http.MethodPost, "/points/scroll", req, &page); err != nil {
+			return nil, fmt.Errorf("failed to scroll Qdrant: %w", err)
+		}
+		points = append(points, page.Points...)
+		if page.NextPageOffset == nil {
+			break
+		}
+		req["offset"] = page.NextPageOffset
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Payload.Timestamp < points[j].Payload.Timestamp
+	})
+	return qdrantMessages(points), nil
+}
+
+// SaveMessages pairs user messages with the assistant answers that follow them and upserts
+// each pair as a point.
+func (m *QdrantMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	m.mutex.Lock()
+	records := pairQA(m.pending, m.getConversationID(conversationID), messages)
+	m.mutex.Unlock()
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	embeddings, failedErr, err := embedRecords(ctx, m.embedder, m.embeddingDim, m.strictEmbed, records)
+	if err != nil {
+		return err
+	}
+
+	points := make([]map[string]any, 0, len(records))
+	for i, r := range records {
+		if embeddings[i] == nil {
+			continue
+		}
+		id, err := newUUID()
+		if err != nil {
+			return err
+		}
+		points = append(points, map[string]any{
+			"id":     id,
+			"vector": embeddings[i],
+			"payload": qdrantPayload{
+				ConversationID: r.conversationID,
+				UserInput:      r.userInput,
+				LLMOutput:      r.llmOutput,
+				Timestamp:      r.timestamp,
+			},
+		})
+	}
+	if len(points) > 0 {
+		if err := m.do(ctx, http.MethodPut, "/points?wait=true", map[string]any{"points": points}, nil); err != nil {
+			return fmt.Errorf("failed to upsert into Qdrant: %w", err)
+		}
+	}
+
+	if failedErr != nil {
+		return failedErr
+	}
+	return nil
+}
+
+// ClearMessages deletes all points of the conversation.
+func (m *QdrantMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	if err := m.do(ctx, http.MethodPost, "/points/delete?wait=true", map[string]any{"filter": m.filter(conversationID)}, nil); err != nil {
+		return fmt.Errorf("failed to delete from Qdrant: %w", err)
+	}
+	return nil
+}
+
+// GetRelevantMessages returns up to limit Q&A pairs of the conversation most similar to
+// query, most relevant first.
+func (m *QdrantMemory) GetRelevantMessages(ctx context.Context, conversationID string, query string, limit int) ([]llms.ChatCompletionMessage, error) {
+	embeddings, err := m.embedder.Embeddings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+		return nil, fmt.Errorf("empty embedding generated")
+	}
+
+	var points []qdrantPoint
+	req := map[string]any{
+		"vector":       embeddings[0],
+		"filter":       m.filter(conversationID),
+		"limit":        limit,
+		"with_payload": true,
+	}
+	if err := m.do(ctx, http.MethodPost, "/points/search", req, &points); err != nil {
+		return nil, fmt.Errorf("failed to search Qdrant: %w", err)
+	}
+	return qdrantMessages(points), nil
+}
+
+// SearchAllConversations implements [CrossConversationSearcher]. Score is the cosine
+// similarity (higher is closer).
+func (m *QdrantMemory) SearchAllConversations(ctx context.Context, query string, limit int) ([]ScoredMessage, error) {
+	embeddings, err := m.embedder.Embeddings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+		return nil, fmt.Errorf("empty embedding generated")
+	}
+
+	var points []qdrantPoint
+	req := map[string]any{
+		"vector":       embeddings[0],
+		"limit":        limit,
+		"with_payload": true,
+	}
+	if err := m.do(ctx, http.MethodPost, "/points/search", req, &points); err != nil {
+		return nil, fmt.Errorf("failed to search Qdrant: %w", err)
+	}
+	hits := make([]ScoredMessage, 0, len(points))
+	for _, p := range points {
+		if p.Payload.UserInput == "" {
+			continue
+		}
+		hits = append(hits, ScoredMessage{
+			ConversationID: p.Payload.ConversationID,
+			Messages:       qaPair{userInput: p.Payload.UserInput, llmOutput: p.Payload.LLMOutput}.messages(),
+			Score:          p.Score,
+			Timestamp:      time.Unix(0, p.Payload.Timestamp),
+		})
+	}
+	return hits, nil
+}
+
+// SummarizeMessages returns a short plain-text description of the stored history.
+func (m *QdrantMemory) SummarizeMessages(ctx context.Context, conversationID string) (string, error) {
+	var count struct {
+		Count int `json:"count"`
+	}
+	req := map[string]any{"filter": m.filter(conversationID), "exact": true}
+	if err := m.do(ctx, http.MethodPost, "/points/count", req, &count); err != nil {
+		return "", fmt.Errorf("failed to count Qdrant points: %w", err)
+	}
+	if count.Count == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("Conversation with %d Q&A pairs.", count.Count), nil
+}
+
+// Close implements io.Closer; the REST client holds no connection to release.
+func (m *QdrantMemory) Close() error {
+	return nil
+}
+
+func (m *QdrantMemory) filter(conversationID string) map[string]any {
+	return map[string]any{
+		"must": []any{map[string]any{
+			"key":   "conversation_id",
+			"match": map[string]any{"value": m.getConversationID(conversationID)},
+		}},
+	}
+}
+
+// qdrantError is a non-2xx response from Qdrant.
+type qdrantError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *qdrantError) Error() string {
+	return fmt.Sprintf("qdrant: HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+func isQdrantNotFound(err error) bool {
+	var qe *qdrantError
+	return errors.As(err, &qe) && qe.StatusCode == http.StatusNotFound
+}
+
+// do sends a request to /collections/<collection><path> and decodes the "result" field into out.
+func (m *QdrantMemory) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.baseURL+"/collections/"+url.PathEscape(m.collection)+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("api-key", m.apiKey)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return &qdrantError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if out == nil {
+		return nil
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("qdrant: invalid response: %w", err)
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
+
+type qdrantPayload struct {
+	ConversationID string `json:"conversation_id"`
+	UserInput      string `json:"user_input"`
+	LLMOutput      string `json:"llm_output"`
+	Timestamp      int64  `json:"timestamp"`
+}
+
+type qdrantPoint struct {
+	Payload qdrantPayload `json:"payload"`
+	Score   float32       `json:"score"`
+}
+
+// qdrantMessages converts points to alternating user/assistant messages.
+func qdrantMessages(points []qdrantPoint) []llms.ChatCompletionMessage {
+	messages := make([]llms.ChatCompletionMessage, 0, len(points)*2)
+	for _, p := range points {
+		if p.Payload.UserInput == "" {
+			continue
+		}
+		messages = append(messages, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleUser, Content: p.Payload.UserInput})
+		if p.Payload.LLMOutput != "" {
+			messages = append(messages, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: p.Payload.LLMOutput})
+		}
+	}
+	return messages
+}
+
+// newUUID returns a random (version 4) UUID, the point ID format Qdrant accepts besides integers.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
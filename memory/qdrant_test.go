@@ -0,0 +1,293 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// fakeQdrant is an in-memory Qdrant REST server for one collection. Scroll pages hold at most
+// pageSize points, ordered by ID as Qdrant does.
+type fakeQdrant struct {
+	mu       sync.Mutex
+	size     int // vector size; 0 until the collection exists
+	distance string
+	indexes  []string
+	points   map[string]qdrantFakePoint
+	pageSize int
+	scrolls  int
+	requests []string
+	apiKeys  []string
+}
+
+type qdrantFakePoint struct {
+	ID      string         `json:"id"`
+	Vector  []float32      `json:"vector"`
+	Payload map[string]any `json:"payload"`
+}
+
+func newFakeQdrant(t *testing.T) (*fakeQdrant, *httptest.Server) {
+	t.Helper()
+	f := &fakeQdrant{points: make(map[string]qdrantFakePoint), pageSize: 2}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.RequestURI())
+	f.apiKeys = append(f.apiKeys, r.Header.Get("api-key"))
+
+	var body map[string]json.RawMessage
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/collections/test")
+	if f.size == 0 && !(r.Method == http.MethodPut && path == "") {
+		http.Error(w, `{"status":{"error":"Not found: Collection test doesn't exist!"}}`, http.StatusNotFound)
+		return
+	}
+	var result any = true
+	switch r.Method + " " + path {
+	case "GET ":
+		result = map[string]any{"config": map[string]any{"params": map[string]any{"vectors": map[string]any{"size": f.size, "distance": f.distance}}}}
+	case "PUT ":
+		var vectors struct {
+			Size     int    `json:"size"`
+			Distance string `json:"distance"`
+		}
+		json.Unmarshal(body["vectors"], &vectors)
+		f.size, f.distance = vectors.Size, vectors.Distance
+	case "PUT /index":
+		var field, schema string
+		json.Unmarshal(body["field_name"], &field)
+		json.Unmarshal(body["field_schema"], &schema)
+		f.indexes = append(f.indexes, field+":"+schema)
+	case "PUT /points":
+		var points []qdrantFakePoint
+		dec := json.NewDecoder(bytes.NewReader(body["points"]))
+		dec.UseNumber() // keep nanosecond timestamps exact
+		dec.Decode(&points)
+		for _, p := range points {
+			f.points[p.ID] = p
+		}
+	case "POST /points/scroll":
+		f.scrolls++
+		var limit int
+		var offset string
+		json.Unmarshal(body["limit"], &limit)
+		json.Unmarshal(body["offset"], &offset)
+		matched := f.match(body["filter"])
+		sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+		start := sort.Search(len(matched), func(i int) bool { return matched[i].ID >= offset })
+		end := min(start+min(limit, f.pageSize), len(matched))
+		page := map[string]any{"points": matched[start:end], "next_page_offset": nil}
+		if end < len(matched) {
+			page["next_page_offset"] = matched[end].ID
+		}
+		result = page
+	case "POST /points/search":
+		var vector []float32
+		var limit int
+		json.Unmarshal(body["vector"], &vector)
+		json.Unmarshal(body["limit"], &limit)
+		type hit struct {
+			Payload map[string]any `json:"payload"`
+			Score   float64        `json:"score"`
+		}
+		var hits []hit
+		for _, p := range f.match(body["filter"]) {
+			hits = append(hits, hit{p.Payload, cosine(p.Vector, vector)})
+		}
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+		result = hits[:min(limit, len(hits))]
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"result": result, "status": "ok"})
+}
+
+// match returns the points matching a conversation_id filter, or every point without one.
+func (f *fakeQdrant) match(filter json.RawMessage) []qdrantFakePoint {
+	var parsed struct {
+		Must []struct {
+			Key   string `json:"key"`
+			Match struct {
+				Value string `json:"value"`
+			} `json:"match"`
+		} `json:"must"`
+	}
+	json.Unmarshal(filter, &parsed)
+	var out []qdrantFakePoint
+	for _, p := range f.points {
+		ok := true
+		for _, cond := range parsed.Must {
+			ok = ok && p.Payload[cond.Key] == cond.Match.Value
+		}
+		if ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func newTestQdrant(t *testing.T, srv *httptest.Server) *QdrantMemory {
+	t.Helper()
+	m, err := NewQdrantMemory(QdrantConfig{URL: srv.URL + "/", APIKey: "secret", Collection: "test", Embedder: topicEmbedder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestQdrantMemoryEnsureCollection(t *testing.T) {
+	fake, srv := newFakeQdrant(t)
+	newTestQdrant(t, srv)
+	want := []string{"GET /collections/test", "PUT /collections/test", "PUT /collections/test/index?wait=true"}
+	if fmt.Sprint(fake.requests) != fmt.Sprint(want) {
+		t.Errorf("requests = %v, want %v", fake.requests, want)
+	}
+	if fake.size != 8 || fake.distance != "Cosine" || fmt.Sprint(fake.indexes) != "[conversation_id:keyword]" {
+		t.Errorf("collection size %d, distance %q, indexes %v", fake.size, fake.distance, fake.indexes)
+	}
+	for _, key := range fake.apiKeys {
+		if key != "secret" {
+			t.Errorf("api-key header = %q, want the APIKey", key)
+		}
+	}
+
+	// an existing collection is reused
+	fake.requests = nil
+	newTestQdrant(t, srv)
+	if fmt.Sprint(fake.requests) != "[GET /collections/test]" {
+		t.Errorf("requests = %v, want only the lookup", fake.requests)
+	}
+
+	_, err := NewQdrantMemory(QdrantConfig{URL: srv.URL, Collection: "test", Embedder: topicEmbedder{}, EmbeddingDim: 16})
+	if err == nil || !strings.Contains(err.Error(), "vector size 8") {
+		t.Errorf("NewQdrantMemory with another dimension = %v, want a vector size error", err)
+	}
+}
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestQdrantMemorySaveMessagesIDs(t *testing.T) {
+	ctx := context.Background()
+	fake, srv := newFakeQdrant(t)
+	m := newTestQdrant(t, srv)
+	for range 2 {
+		if err := m.SaveMessages(ctx, "c1", append(qa("cat facts", "meow"), qa("car facts", "vroom")...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(fake.points) != 4 {
+		t.Fatalf("stored %d points, want 4 with distinct IDs", len(fake.points))
+	}
+	for id, p := range fake.points {
+		if !uuidV4.MatchString(id) {
+			t.Errorf("point ID %q is not a version 4 UUID", id)
+		}
+		if p.Payload["conversation_id"] != "c1" || len(p.Vector) != 8 {
+			t.Errorf("point = %+v", p)
+		}
+	}
+	if last := fake.requests[len(fake.requests)-1]; last != "PUT /collections/test/points?wait=true" {
+		t.Errorf("upsert request = %q", last)
+	}
+}
+
+func TestQdrantMemoryLoadMessagesScrolls(t *testing.T) {
+	ctx := context.Background()
+	fake, srv := newFakeQdrant(t)
+	m := newTestQdrant(t, srv)
+	var messages []llms.ChatCompletionMessage
+	var want []string
+	for i := range 5 {
+		q, a := fmt.Sprintf("question %d", i), fmt.Sprintf("answer %d", i)
+		messages = append(messages, qa(q, a)...)
+		want = append(want, q, a)
+	}
+	if err := m.SaveMessages(ctx, "c1", messages); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveMessages(ctx, "other", qa("unrelated", "skip")); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := m.LoadMessages(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, msg := range loaded {
+		got = append(got, msg.Content)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("LoadMessages = %v, want %v in chronological order", got, want)
+	}
+	if fake.scrolls != 3 {
+		t.Errorf("scrolled %d pages, want 3 of at most 2 points", fake.scrolls)
+	}
+}
+
+func TestQdrantMemoryGetRelevantMessages(t *testing.T) {
+	ctx := context.Background()
+	_, srv := newFakeQdrant(t)
+	m := newTestQdrant(t, srv)
+	for _, ex := range [][]llms.ChatCompletionMessage{qa("car facts", "vroom"), qa("kitten facts", "small"), qa("cat facts", "meow")} {
+		if err := m.SaveMessages(ctx, "c1", ex); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.SaveMessages(ctx, "c2", qa("cat care", "brush")); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := m.GetRelevantMessages(ctx, "c1", "cat", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, msg := range msgs {
+		got = append(got, string(msg.Role)+":"+msg.Content)
+	}
+	want := "[user:cat facts assistant:meow user:kitten facts assistant:small]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("GetRelevantMessages = %v, want %v", got, want)
+	}
+
+	// query-based loading goes through the same search
+	m.EnableQueryBasedLoading = true
+	m.MaxRelevantMessages = 1
+	m.SetQuery("car")
+	if msgs, err := m.LoadMessages(ctx, "c1"); err != nil || len(msgs) != 2 || msgs[0].Content != "car facts" {
+		t.Errorf("LoadMessages with a query = %+v, %v, want the car pair", msgs, err)
+	}
+}
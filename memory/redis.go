@@ -3,7 +3,10 @@ package memory
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -24,6 +27,9 @@ type RedisMemory struct {
 	client *redis.Client
 	ttl    time.Duration
 	prefix string
+	// maxMessages caps each conversation list to its newest entries; zero keeps everything
+	maxMessages int
+	logger      *slog.Logger
 }
 
 // RedisConfig holds configuration for RedisMemory.
@@ -49,6 +55,20 @@ type RedisConfig struct {
 
 	// KeyPrefix is the prefix for all Redis keys. Default is "langchain:memory:".
 	KeyPrefix string
+
+	// Namespace isolates tenants sharing a Redis: keys become KeyPrefix + Namespace + ":" + ...,
+	// so ClearMessages and ListConversations only see this namespace. It must not contain ':'.
+	Namespace string
+
+	// MaxMessages keeps about the newest N messages of each conversation; older ones are
+	// trimmed (LTRIM) when messages are saved. The kept messages start at a user message, so
+	// tool results are never separated from their tool calls; a turn longer than N is kept
+	// whole. Zero means no limit.
+	MaxMessages int
+
+	// Logger receives a warning, with the conversation ID, when the TTL of a conversation
+	// can't be set after saving. Default is slog.Default().
+	Logger *slog.Logger
 }
 
 // NewRedisMemory creates a new RedisMemory instance with the given Redis client and TTL.
@@ -78,6 +98,10 @@ func NewRedisMemory(client *redis.Client, ttl time.Duration) *RedisMemory {
 //	    KeyPrefix: "myapp:memory:",
 //	})
 func NewRedisMemoryWithConfig(cfg RedisConfig) (*RedisMemory, error) {
+	if err := checkNamespace(cfg.Namespace); err != nil {
+		return nil, err
+	}
+
 	var client *redis.Client
 
 	if cfg.Client != nil {
@@ -111,14 +135,27 @@ func NewRedisMemoryWithConfig(cfg RedisConfig) (*RedisMemory, error) {
 	if prefix == "" {
 		prefix = "langchain:memory:"
 	}
+	if cfg.Namespace != "" {
+		prefix += cfg.Namespace + ":"
+	}
 
 	return &RedisMemory{
-		client: client,
-		ttl:    cfg.TTL,
-		prefix: prefix,
+		client:      client,
+		ttl:         cfg.TTL,
+		prefix:      prefix,
+		maxMessages: cfg.MaxMessages,
+		logger:      cfg.Logger,
 	}, nil
 }
 
+// log returns the configured logger, or slog.Default().
+func (m *RedisMemory) log() *slog.Logger {
+	if m.logger != nil {
+		return m.logger
+	}
+	return slog.Default()
+}
+
 // getConversationID returns the conversation ID, using default if empty.
 func (m *RedisMemory) getConversationID(conversationID string) string {
 	if conversationID == "" {
@@ -147,26 +184,66 @@ func (m *RedisMemory) LoadMessages(ctx context.Context, conversationID string) (
 		return []llms.ChatCompletionMessage{}, nil
 	}
 
-	// Unmarshal each message from the list
-	messages := make([]llms.ChatCompletionMessage, 0, len(data))
+	return decodeRedisMessages(data), nil
+}
+
+// SaveMessages saves messages to the conversation history.
+// Uses Redis List (RPUSH) for efficient incremental appending.
+// Each message is stored as a separate list element, avoiding the need to
+// load and rewrite the entire conversation history.
+func (m *RedisMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	return m.SaveMessagesWithMetadata(ctx, conversationID, messages, nil)
+}
+
+// SaveMessagesWithMetadata is like SaveMessages and stores metadata with each message.
+func (m *RedisMemory) SaveMessagesWithMetadata(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage, metadata map[string]string) error {
+	now := time.Now()
+	entries := make([]redisEntry, 0, len(messages))
+	for _, msg := range messages {
+		entries = append(entries, redisEntry{storedMessage: messageToStored(msg), CreatedAt: now, Metadata: metadata})
+	}
+	return m.pushEntries(ctx, conversationID, entries)
+}
+
+// LoadMessagesV2 implements [MemoryV2]. Entries saved before timestamps were recorded have a
+// zero CreatedAt.
+func (m *RedisMemory) LoadMessagesV2(ctx context.Context, conversationID string) ([]Message, error) {
+	data, err := m.client.LRange(ctx, m.getKey(conversationID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages from Redis: %w", err)
+	}
+
+	messages := make([]Message, 0, len(data))
 	for _, item := range data {
-		var msg llms.ChatCompletionMessage
-		if err := json.Unmarshal([]byte(item), &msg); err != nil {
-			// Skip invalid messages but continue processing
+		entry, ok := decodeRedisEntry(item)
+		if !ok {
 			continue
 		}
+		msg := MessageFromLLM(storedToLLM(entry.storedMessage))
+		msg.CreatedAt = entry.CreatedAt
+		msg.Metadata = entry.Metadata
 		messages = append(messages, msg)
 	}
-
 	return messages, nil
 }
 
-// SaveMessages saves messages to the conversation history.
-// Uses Redis List (RPUSH) for efficient incremental appending.
-// Each message is stored as a separate list element, avoiding the need to
-// load and rewrite the entire conversation history.
-func (m *RedisMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
-	if len(messages) == 0 {
+// SaveMessagesV2 implements [MemoryV2], storing the time and metadata of each message.
+func (m *RedisMemory) SaveMessagesV2(ctx context.Context, conversationID string, messages []Message) error {
+	now := time.Now()
+	entries := make([]redisEntry, 0, len(messages))
+	for _, msg := range messages {
+		entry := redisEntry{storedMessage: messageToStored(msg.LLM()), CreatedAt: msg.CreatedAt, Metadata: msg.Metadata}
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = now
+		}
+		entries = append(entries, entry)
+	}
+	return m.pushEntries(ctx, conversationID, entries)
+}
+
+// pushEntries appends entries to the conversation list, skipping system messages.
+func (m *RedisMemory) pushEntries(ctx context.Context, conversationID string, entries []redisEntry) error {
+	if len(entries) == 0 {
 		return nil
 	}
 
@@ -174,19 +251,22 @@ func (m *RedisMemory) SaveMessages(ctx context.Context, conversationID string, m
 
 	// Serialize each message and push to the list
 	pipe := m.client.Pipeline()
-	for _, msg := range messages {
+	for _, entry := range entries {
 
 		// if system message, skip
-		if msg.Role == llms.ChatMessageRoleSystem {
+		if entry.Role == llms.ChatMessageRoleSystem {
 			continue
 		}
 
-		data, err := json.Marshal(msg)
+		data, err := json.Marshal(entry)
 		if err != nil {
 			return fmt.Errorf("failed to marshal message: %w", err)
 		}
 		pipe.RPush(ctx, key, data)
 	}
+	if m.maxMessages > 0 {
+		trimScript.Eval(ctx, pipe, []string{key}, m.maxMessages)
+	}
 
 	// Execute all pushes in a pipeline for better performance
 	_, err := pipe.Exec(ctx)
@@ -197,14 +277,49 @@ func (m *RedisMemory) SaveMessages(ctx context.Context, conversationID string, m
 	// Set TTL on the list if configured
 	if m.ttl > 0 {
 		if err := m.client.Expire(ctx, key, m.ttl).Err(); err != nil {
-			// Log but don't fail - TTL setting is best effort
-			// In production, you might want to log this
+			// TTL setting is best effort: the messages are saved
+			m.log().WarnContext(ctx, "failed to set conversation TTL", "conversation_id", m.getConversationID(conversationID), "error", err)
 		}
 	}
 
 	return nil
 }
 
+// trimScript trims the list KEYS[1] to about its newest ARGV[1] entries, starting at a user
+// message: the first one among the newest ARGV[1], or else the last one before them. It
+// returns how many entries were removed.
+var trimScript = redis.NewScript(`
+local n = redis.call('LLEN', KEYS[1])
+local start = n - tonumber(ARGV[1])
+if start <= 0 then
+	return 0
+end
+local items = redis.call('LRANGE', KEYS[1], 0, -1)
+local function isUser(i)
+	local ok, msg = pcall(cjson.decode, items[i + 1])
+	return ok and type(msg) == 'table' and msg.role == 'user'
+end
+local cut = 0
+for i = start, n - 1 do
+	if isUser(i) then
+		cut = i
+		break
+	end
+end
+if cut == 0 then
+	for i = start - 1, 1, -1 do
+		if isUser(i) then
+			cut = i
+			break
+		end
+	end
+end
+if cut > 0 then
+	redis.call('LTRIM', KEYS[1], cut, -1)
+end
+return cut
+`)
+
 // ClearMessages clears all messages for the given conversation ID.
 func (m *RedisMemory) ClearMessages(ctx context.Context, conversationID string) error {
 	key := m.getKey(conversationID)
@@ -217,6 +332,128 @@ func (m *RedisMemory) ClearMessages(ctx context.Context, conversationID string)
 	return nil
 }
 
+// LoadMessagesSince returns the messages saved at or after t, in chronological order. Entries
+// written before timestamps were stored have no time and are not included.
+func (m *RedisMemory) LoadMessagesSince(ctx context.Context, conversationID string, t time.Time) ([]llms.ChatCompletionMessage, error) {
+	data, err := m.client.LRange(ctx, m.getKey(conversationID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages from Redis: %w", err)
+	}
+
+	messages := []llms.ChatCompletionMessage{}
+	for _, item := range data {
+		entry, ok := decodeRedisEntry(item)
+		if !ok || entry.CreatedAt.IsZero() || entry.CreatedAt.Before(t) {
+			continue
+		}
+		messages = append(messages, storedToLLM(entry.storedMessage))
+	}
+	return messages, nil
+}
+
+// GetLastActivity returns when the newest message of the conversation was saved, or the zero
+// time when the conversation is empty or its newest entry predates stored timestamps.
+func (m *RedisMemory) GetLastActivity(ctx context.Context, conversationID string) (time.Time, error) {
+	item, err := m.client.LIndex(ctx, m.getKey(conversationID), -1).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last message from Redis: %w", err)
+	}
+	entry, _ := decodeRedisEntry(item)
+	return entry.CreatedAt, nil
+}
+
+// DeleteConversation deletes all messages of the conversation. It is an alias for ClearMessages.
+func (m *RedisMemory) DeleteConversation(ctx context.Context, conversationID string) error {
+	return m.ClearMessages(ctx, conversationID)
+}
+
+// forkScript copies the list KEYS[1] to the missing key KEYS[2] atomically and applies the TTL
+// ARGV[1] (milliseconds, 0 for none). It returns -1 when KEYS[2] exists.
+var forkScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return -1
+end
+local items = redis.call('LRANGE', KEYS[1], 0, -1)
+for i = 1, #items, 1000 do
+	redis.call('RPUSH', KEYS[2], unpack(items, i, math.min(i + 999, #items)))
+end
+if #items > 0 and tonumber(ARGV[1]) > 0 then
+	redis.call('PEXPIRE', KEYS[2], ARGV[1])
+end
+return #items
+`)
+
+// ForkConversation copies the list of srcConversationID to dstConversationID in one atomic
+// script, keeping timestamps and metadata. See [Fork].
+func (m *RedisMemory) ForkConversation(ctx context.Context, srcConversationID, dstConversationID string) error {
+	n, err := forkScript.Run(ctx, m.client, []string{m.getKey(srcConversationID), m.getKey(dstConversationID)}, m.ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to fork conversation in Redis: %w", err)
+	}
+	if n < 0 {
+		return fmt.Errorf("%w: %s", ErrConversationExists, m.getConversationID(dstConversationID))
+	}
+	return nil
+}
+
+// ListConversations implements [ConversationLister] for the conversations stored under the
+// key prefix. Keys are walked with SCAN, so large databases are not blocked, and the length and
+// newest entry of each list are read in one pipeline; Redis can't sort keys by activity, so
+// the page is cut here. Conversations whose newest entry predates stored timestamps have a
+// zero LastActivity and come last.
+func (m *RedisMemory) ListConversations(ctx context.Context, limit, offset int) ([]ConversationInfo, error) {
+	head := m.prefix + "conversation:"
+	const tail = ":messages"
+	var keys []string
+	iter := m.client.Scan(ctx, 0, globEscape(head)+"*"+tail, 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasPrefix(key, head) && strings.HasSuffix(key, tail) && len(key) >= len(head)+len(tail) {
+			keys = append(keys, key)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan conversations: %w", err)
+	}
+
+	pipe := m.client.Pipeline()
+	lens := make([]*redis.IntCmd, len(keys))
+	lasts := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		lens[i] = pipe.LLen(ctx, key)
+		lasts[i] = pipe.LIndex(ctx, key, -1)
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("failed to read conversations: %w", err)
+		}
+	}
+
+	convs := make([]ConversationInfo, 0, len(keys))
+	for i, key := range keys {
+		n := lens[i].Val()
+		if n == 0 {
+			// deleted between SCAN and the pipeline
+			continue
+		}
+		entry, _ := decodeRedisEntry(lasts[i].Val())
+		convs = append(convs, ConversationInfo{
+			ID:           key[len(head) : len(key)-len(tail)],
+			MessageCount: n,
+			LastActivity: entry.CreatedAt,
+		})
+	}
+	return pageConversations(convs, limit, offset), nil
+}
+
+// globEscape escapes the glob metacharacters of s for a SCAN MATCH pattern.
+func globEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
 // Close closes the Redis client connection.
 // This is optional but recommended for proper resource cleanup.
 func (m *RedisMemory) Close() error {
@@ -278,18 +515,7 @@ func (m *RedisMemory) LoadMessagesWithLimit(ctx context.Context, conversationID
 		return []llms.ChatCompletionMessage{}, nil
 	}
 
-	// Unmarshal each message from the list
-	messages := make([]llms.ChatCompletionMessage, 0, len(data))
-	for _, item := range data {
-		var msg llms.ChatCompletionMessage
-		if err := json.Unmarshal([]byte(item), &msg); err != nil {
-			// Skip invalid messages but continue processing
-			continue
-		}
-		messages = append(messages, msg)
-	}
-
-	return messages, nil
+	return decodeRedisMessages(data), nil
 }
 
 // GetMessageCount returns the number of messages stored for the given conversation ID.
@@ -301,3 +527,58 @@ func (m *RedisMemory) GetMessageCount(ctx context.Context, conversationID string
 	}
 	return count, nil
 }
+
+// GetStats implements [StatsProvider]. Redis lists can't be aggregated server side, so the
+// entries are fetched and counted here; only the Stats are returned. Entries saved before
+// timestamps were recorded don't contribute to FirstMessageAt and LastMessageAt.
+func (m *RedisMemory) GetStats(ctx context.Context, conversationID string) (Stats, error) {
+	data, err := m.client.LRange(ctx, m.getKey(conversationID), 0, -1).Result()
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to load messages from Redis: %w", err)
+	}
+	var stats Stats
+	for _, item := range data {
+		if entry, ok := decodeRedisEntry(item); ok {
+			stats.add(storedToLLM(entry.storedMessage), entry.CreatedAt)
+		}
+	}
+	return stats, nil
+}
+
+// redisEntry is one stored list element: the message, when it was saved and its metadata.
+type redisEntry struct {
+	storedMessage
+	CreatedAt time.Time         `json:"created_at"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// decodeRedisEntry decodes a list element. Elements written by earlier versions are bare
+// llms.ChatCompletionMessage JSON without created_at; they decode with a zero CreatedAt.
+func decodeRedisEntry(item string) (redisEntry, bool) {
+	var entry redisEntry
+	if err := json.Unmarshal([]byte(item), &entry); err != nil {
+		return redisEntry{}, false
+	}
+	if !entry.CreatedAt.IsZero() {
+		return entry, true
+	}
+	var msg llms.ChatCompletionMessage
+	if err := json.Unmarshal([]byte(item), &msg); err != nil {
+		return redisEntry{}, false
+	}
+	return redisEntry{storedMessage: messageToStored(msg)}, true
+}
+
+// decodeRedisMessages decodes list elements, skipping invalid ones.
+func decodeRedisMessages(data []string) []llms.ChatCompletionMessage {
+	messages := make([]llms.ChatCompletionMessage, 0, len(data))
+	for _, item := range data {
+		entry, ok := decodeRedisEntry(item)
+		if !ok {
+			// Skip invalid messages but continue processing
+			continue
+		}
+		messages = append(messages, storedToLLM(entry.storedMessage))
+	}
+	return messages
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis returns a client of a fresh in-process Redis server.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	// RESP2 skips the maintenance notifications handshake miniredis doesn't know
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr(), Protocol: 2})
+	t.Cleanup(func() { client.Close() })
+	return srv, client
+}
+
+// failCommandHook fails every command named name.
+type failCommandHook struct{ name string }
+
+func (h failCommandHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h failCommandHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == h.name {
+			err := errors.New(h.name + " refused")
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h failCommandHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRedisMemoryExpireFailureLogged(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestRedis(t)
+	client.AddHook(failCommandHook{name: "expire"})
+	logs := &captureHandler{}
+	m, err := NewRedisMemoryWithConfig(RedisConfig{Client: client, TTL: time.Hour, Logger: slog.New(logs)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SaveMessages(ctx, "c1", []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: "hi"}}); err != nil {
+		t.Fatalf("SaveMessages: %v, want the messages saved despite the TTL failure", err)
+	}
+	if got, _ := m.LoadMessages(ctx, "c1"); len(got) != 1 {
+		t.Errorf("loaded %d messages, want 1", len(got))
+	}
+	if ttl := srv.TTL("langchain:memory:c1"); ttl != 0 {
+		t.Errorf("TTL = %v, want none", ttl)
+	}
+	const warning = "failed to set conversation TTL"
+	if n := logs.count(slog.LevelWarn, warning); n != 1 {
+		t.Fatalf("got %d warnings, want 1", n)
+	}
+	if id := logs.attr(slog.LevelWarn, warning, "conversation_id"); id != "c1" {
+		t.Errorf("warning conversation_id = %q, want c1", id)
+	}
+}
+
+func TestRedisMemoryMaxMessagesTrimsAtUserMessages(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestRedis(t)
+
+	tests := []struct {
+		name        string
+		maxMessages int
+		turns       [][]llms.ChatCompletionMessage
+		want        []string // user contents kept, in order
+		wantLen     int
+	}{
+		{
+			name:        "under the limit",
+			maxMessages: 10,
+			turns:       [][]llms.ChatCompletionMessage{turn(1, 0), turn(2, 1)},
+			want:        []string{"q1", "q2"},
+			wantLen:     6,
+		},
+		{
+			name:        "cut inside a turn moves to the next user message",
+			maxMessages: 5,
+			turns:       [][]llms.ChatCompletionMessage{turn(1, 1), turn(2, 1)},
+			want:        []string{"q2"},
+			wantLen:     4,
+		},
+		{
+			name:        "cut on a user message",
+			maxMessages: 4,
+			turns:       [][]llms.ChatCompletionMessage{turn(1, 0), turn(2, 0), turn(3, 1)},
+			want:        []string{"q3"},
+			wantLen:     4,
+		},
+		{
+			name:        "turn longer than the limit is kept whole",
+			maxMessages: 3,
+			turns:       [][]llms.ChatCompletionMessage{turn(1, 0), turn(2, 2)},
+			want:        []string{"q2"},
+			wantLen:     6,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewRedisMemoryWithConfig(RedisConfig{Client: client, MaxMessages: tt.maxMessages})
+			if err != nil {
+				t.Fatal(err)
+			}
+			id := fmt.Sprintf("trim-%d", i)
+			for _, msgs := range tt.turns {
+				if err := m.SaveMessages(ctx, id, msgs); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := m.LoadMessages(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("kept %d messages, want %d", len(got), tt.wantLen)
+			}
+			if len(got) == 0 || got[0].Role != llms.ChatMessageRoleUser {
+				t.Fatalf("kept messages %+v don't start with a user message", got)
+			}
+			var users []string
+			for _, msg := range got {
+				if msg.Role == llms.ChatMessageRoleUser {
+					users = append(users, msg.Content)
+				}
+			}
+			if fmt.Sprint(users) != fmt.Sprint(tt.want) {
+				t.Errorf("kept user messages %v, want %v", users, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisMemoryListAndDeleteConversations(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestRedis(t)
+	m, err := NewRedisMemoryWithConfig(RedisConfig{Client: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b*"} {
+		if err := m.SaveMessages(ctx, id, turn(1, 0)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	convs, err := m.ListConversations(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conversationIDs(convs); got != "[b* a]" {
+		t.Errorf("ListConversations = %v, want [b* a]", got)
+	}
+	if convs[0].MessageCount != 2 || convs[0].LastActivity.Before(convs[1].LastActivity) {
+		t.Errorf("ListConversations = %+v, want 2 messages each, newest first", convs)
+	}
+	if page, _ := m.ListConversations(ctx, 1, 1); conversationIDs(page) != "[a]" {
+		t.Errorf("ListConversations(1, 1) = %v, want [a]", conversationIDs(page))
+	}
+	if err := m.DeleteConversation(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if convs, _ := m.ListConversations(ctx, 0, 0); conversationIDs(convs) != "[b*]" {
+		t.Errorf("after delete ListConversations = %v, want [b*]", conversationIDs(convs))
+	}
+}
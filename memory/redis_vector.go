@@ -0,0 +1,341 @@
+package memory
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// RedisVectorMemory adds semantic retrieval on top of [RedisMemory] using RediSearch (Redis
+// Stack). Messages are still kept in the chronological list used by RedisMemory; in addition
+// each Q&A pair is stored as a hash with a FLOAT32 embedding and indexed with HNSW, and
+// GetRelevantMessages runs a KNN query filtered by the conversation_id tag. It implements
+// [ConversationMemory] and [MilvusMemoryInterface], so agents set the query automatically.
+//
+// The RediSearch commands need RESP2: a client passed in RedisVectorConfig.Client must set
+// Protocol: 2 (or UnstableResp3: true). Clients created from Address do so automatically.
+type RedisVectorMemory struct {
+	*RedisMemory
+	indexName    string
+	vecPrefix    string
+	embedder     EmbedderInterface
+	embeddingDim int
+	strictEmbed  bool
+	// EnableQueryBasedLoading makes LoadMessages return the pairs most relevant to the query
+	// set by SetQuery instead of the full list.
+	EnableQueryBasedLoading bool
+	// MaxRelevantMessages limits the pairs retrieved by query-based loading.
+	MaxRelevantMessages int
+
+	mutex   sync.RWMutex
+	query   string
+	pending map[string]string
+}
+
+// RedisVectorConfig holds configuration for RedisVectorMemory.
+type RedisVectorConfig struct {
+	// Client, Address, Port, Password, DB, TTL and KeyPrefix are as in RedisConfig.
+	// TTL applies to the message list and to the Q&A hashes.
+	Client    *redis.Client
+	Address   string
+	Port      int
+	Password  string
+	DB        int
+	TTL       time.Duration
+	KeyPrefix string
+
+	// IndexName is the RediSearch index name. Default is "langchain_memory_idx".
+	IndexName string
+
+	// EmbeddingDim is the dimension of the vector field. If 0, it is inferred by embedding a
+	// probe string with Embedder.
+	EmbeddingDim int
+
+	// Embedder is the embedding model interface.
+	Embedder EmbedderInterface
+
+	// EnableQueryBasedLoading enables query-based loading in LoadMessages.
+	EnableQueryBasedLoading bool
+
+	// MaxRelevantMessages limits the number of relevant messages to retrieve
+	// when using query-based loading. Default is 10.
+	MaxRelevantMessages int
+
+	// StrictEmbedding makes SaveMessages store no Q&A hash when any pair fails to embed.
+	StrictEmbedding bool
+}
+
+// NewRedisVectorMemory creates a RedisVectorMemory and the RediSearch index if it doesn't exist.
+//
+// Example:
+//
+//	mem, err := memory.NewRedisVectorMemory(memory.RedisVectorConfig{
+//	    Address:      "localhost",
+//	    Port:         6379,
+//	    EmbeddingDim: 1536,
+//	    Embedder:     embedder,
+//	    EnableQueryBasedLoading: true,
+//	})
+func NewRedisVectorMemory(cfg RedisVectorConfig) (*RedisVectorMemory, error) {
+	if cfg.Embedder == nil {
+		return nil, fmt.Errorf("embedder must be provided")
+	}
+
+	client := cfg.Client
+	if client == nil {
+		address := cfg.Address
+		if address == "" {
+			address = "localhost"
+		}
+		port := cfg.Port
+		if port == 0 {
+			port = 6379
+		}
+		client = redis.NewClient(&redis.Options{
+			Addr:     fmt.Sprintf("%s:%d", address, port),
+			Password: cfg.Password,
+			DB:       cfg.DB,
+			Protocol: 2,
+		})
+	}
+
+	base, err := NewRedisMemoryWithConfig(RedisConfig{Client: client, TTL: cfg.TTL, KeyPrefix: cfg.KeyPrefix})
+	if err != nil {
+		return nil, err
+	}
+
+	embeddingDim := cfg.EmbeddingDim
+	if embeddingDim == 0 {
+		probe, err := cfg.Embedder.Embeddings(context.Background(), []string{"dimension probe"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to infer embedding dimension: %w", err)
+		}
+		if len(probe) == 0 || len(probe[0]) == 0 {
+			return nil, fmt.Errorf("failed to infer embedding dimension: embedder returned no vector")
+		}
+		embeddingDim = len(probe[0])
+	}
+
+	indexName := cfg.IndexName
+	if indexName == "" {
+		indexName = "langchain_memory_idx"
+	}
+
+	maxRelevant := cfg.MaxRelevantMessages
+	if maxRelevant <= 0 {
+		maxRelevant = 10
+	}
+
+	mem := &RedisVectorMemory{
+		RedisMemory:             base,
+		indexName:               indexName,
+		vecPrefix:               base.prefix + "qa:",
+		embedder:                cfg.Embedder,
+		embeddingDim:            embeddingDim,
+		strictEmbed:             cfg.StrictEmbedding,
+		EnableQueryBasedLoading: cfg.EnableQueryBasedLoading,
+		MaxRelevantMessages:     maxRelevant,
+		pending:                 make(map[string]string),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := mem.ensureIndex(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure index: %w", err)
+	}
+
+	return mem, nil
+}
+
+// ensureIndex creates the HNSW index over the Q&A hashes if it doesn't exist.
+func (m *RedisVectorMemory) ensureIndex(ctx context.Context) error {
+	err := m.client.FTCreate(ctx, m.indexName,
+		&redis.FTCreateOptions{OnHash: true, Prefix: []interface{}{m.vecPrefix}},
+		&redis.FieldSchema{FieldName: "conversation_id", FieldType: redis.SearchFieldTypeTag},
+		&redis.FieldSchema{FieldName: "timestamp", FieldType: redis.SearchFieldTypeNumeric, Sortable: true},
+		&redis.FieldSchema{FieldName: "embedding", FieldType: redis.SearchFieldTypeVector, VectorArgs: &redis.FTVectorArgs{
+			HNSWOptions: &redis.FTHNSWOptions{Type: "FLOAT32", Dim: m.embeddingDim, DistanceMetric: "COSINE"},
+		}},
+	).Err()
+	if err != nil && !strings.Contains(strings.ToLower(err.Error()), "index already exists") {
+		return err
+	}
+	return nil
+}
+
+// SetQuery sets the query used by query-based loading in LoadMessages.
+func (m *RedisVectorMemory) SetQuery(query string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.query = query
+}
+
+// LoadMessages returns the pairs most relevant to the SetQuery query when
+// EnableQueryBasedLoading is set, and the chronological message list otherwise.
+func (m *RedisVectorMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	if m.EnableQueryBasedLoading {
+		m.mutex.RLock()
+		query := m.query
+		m.mutex.RUnlock()
+		if query != "" {
+			return m.GetRelevantMessages(ctx, conversationID, query, m.MaxRelevantMessages)
+		}
+	}
+	return m.RedisMemory.LoadMessages(ctx, conversationID)
+}
+
+// SaveMessages appends messages to the list and stores each completed Q&A pair as an
+// indexed hash.
+func (m *RedisVectorMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	if err := m.RedisMemory.SaveMessages(ctx, conversationID, messages); err != nil {
+		return err
+	}
+
+	convID := m.getConversationID(conversationID)
+	m.mutex.Lock()
+	records := pairQA(m.pending, convID, messages)
+	m.mutex.Unlock()
+	if len(records) == 0 {
+		return nil
+	}
+
+	embeddings, failedErr, err := embedRecords(ctx, m.embedder, m.embeddingDim, m.strictEmbed, records)
+	if err != nil {
+		return err
+	}
+
+	setKey := m.vecPrefix + "keys:" + convID
+	pipe := m.client.TxPipeline()
+	for i, r := range records {
+		if embeddings[i] == nil {
+			continue
+		}
+		id, err := newUUID()
+		if err != nil {
+			return err
+		}
+		key := m.vecPrefix + id
+		pipe.HSet(ctx, key,
+			"conversation_id", r.conversationID,
+			"user_input", r.userInput,
+			"llm_output", r.llmOutput,
+			"timestamp", r.timestamp,
+			"embedding", float32Bytes(embeddings[i]),
+		)
+		pipe.SAdd(ctx, setKey, key)
+		if m.ttl > 0 {
+			pipe.Expire(ctx, key, m.ttl)
+		}
+	}
+	if m.ttl > 0 {
+		pipe.Expire(ctx, setKey, m.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save Q&A pairs to Redis: %w", err)
+	}
+
+	if failedErr != nil {
+		return failedErr
+	}
+	return nil
+}
+
+// ClearMessages deletes the message list and the Q&A hashes of the conversation.
+func (m *RedisVectorMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	if err := m.RedisMemory.ClearMessages(ctx, conversationID); err != nil {
+		return err
+	}
+	convID := m.getConversationID(conversationID)
+	setKey := m.vecPrefix + "keys:" + convID
+	keys, err := m.client.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list Q&A pairs: %w", err)
+	}
+	if err := m.client.Del(ctx, append(keys, setKey)...).Err(); err != nil {
+		return fmt.Errorf("failed to delete Q&A pairs: %w", err)
+	}
+	m.mutex.Lock()
+	delete(m.pending, convID)
+	m.mutex.Unlock()
+	return nil
+}
+
+// GetRelevantMessages returns up to limit Q&A pairs of the conversation nearest to query
+// (FT.SEARCH KNN), most relevant first.
+func (m *RedisVectorMemory) GetRelevantMessages(ctx context.Context, conversationID string, query string, limit int) ([]llms.ChatCompletionMessage, error) {
+	embeddings, err := m.embedder.Embeddings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+		return nil, fmt.Errorf("empty embedding generated")
+	}
+	if limit <= 0 {
+		limit = m.MaxRelevantMessages
+	}
+
+	q := fmt.Sprintf("(@conversation_id:{%s})=>[KNN %d @embedding $vec AS score]", escapeTag(m.getConversationID(conversationID)), limit)
+	res, err := m.client.FTSearchWithArgs(ctx, m.indexName, q, &redis.FTSearchOptions{
+		Return:         []redis.FTSearchReturn{{FieldName: "user_input"}, {FieldName: "llm_output"}, {FieldName: "score"}},
+		SortBy:         []redis.FTSearchSortBy{{FieldName: "score", Asc: true}},
+		Limit:          limit,
+		Params:         map[string]interface{}{"vec": float32Bytes(embeddings[0])},
+		DialectVersion: 2,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search Redis: %w", err)
+	}
+
+	messages := make([]llms.ChatCompletionMessage, 0, len(res.Docs)*2)
+	for _, doc := range res.Docs {
+		if doc.Fields["user_input"] == "" {
+			continue
+		}
+		messages = append(messages, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleUser, Content: doc.Fields["user_input"]})
+		if out := doc.Fields["llm_output"]; out != "" {
+			messages = append(messages, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: out})
+		}
+	}
+	return messages, nil
+}
+
+// SummarizeMessages returns a short plain-text description of the stored history.
+func (m *RedisVectorMemory) SummarizeMessages(ctx context.Context, conversationID string) (string, error) {
+	count, err := m.GetMessageCount(ctx, conversationID)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("Conversation with %d messages.", count), nil
+}
+
+// float32Bytes encodes v as little-endian FLOAT32, the RediSearch vector blob format.
+func float32Bytes(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// escapeTag escapes RediSearch tag query syntax in s.
+func escapeTag(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// SerializedMemory wraps a [Memory] so that calls on the same conversation run one at a time,
+// e.g. when concurrent requests share a conversation ID and their saves would interleave.
+// Calls on different conversations still run concurrently.
+//
+// It serializes single calls only; a turn's load and saves can still interleave with another
+// turn's. To serialize whole agent turns (and keep the Q&A pairing of MilvusMemory intact),
+// use agents.WithSerializedTurns instead. The wrapper is not query-aware, so wrap vector
+// memories that way rather than with SerializedMemory.
+type SerializedMemory struct {
+	inner Memory
+	mu    sync.Mutex
+	locks map[string]*conversationLock
+}
+
+// conversationLock is the mutex of one conversation and the number of callers holding or
+// waiting for it, so unused locks can be dropped.
+type conversationLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewSerializedMemory wraps inner.
+func NewSerializedMemory(inner Memory) *SerializedMemory {
+	return &SerializedMemory{inner: inner, locks: make(map[string]*conversationLock)}
+}
+
+// lock locks the conversation and returns the function unlocking it.
+func (m *SerializedMemory) lock(conversationID string) func() {
+	id := normalizeConversationID(conversationID)
+	m.mu.Lock()
+	l, ok := m.locks[id]
+	if !ok {
+		l = &conversationLock{}
+		m.locks[id] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		m.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, id)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// LoadMessages implements [Memory].
+func (m *SerializedMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	defer m.lock(conversationID)()
+	return m.inner.LoadMessages(ctx, conversationID)
+}
+
+// SaveMessages implements [Memory].
+func (m *SerializedMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	defer m.lock(conversationID)()
+	return m.inner.SaveMessages(ctx, conversationID, messages)
+}
+
+// ClearMessages implements [Memory].
+func (m *SerializedMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	defer m.lock(conversationID)()
+	return m.inner.ClearMessages(ctx, conversationID)
+}
+
+// Close closes the wrapped memory when it implements [Closer].
+func (m *SerializedMemory) Close() error {
+	return closeMemory(m.inner)
+}
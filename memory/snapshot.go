@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// bufferPersistence is the snapshot file of a BufferMemory created by
+// NewPersistentBufferMemory.
+type bufferPersistence struct {
+	path string
+	// mu serializes flushes; flushed is the change count of the last written snapshot
+	mu        sync.Mutex
+	flushed   int64
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// SaveSnapshot writes every conversation to w as JSON, in the file format of [FileMemory].
+// The conversations are copied under the lock and encoded after it is released, so
+// concurrent SaveMessages calls only wait for the copy.
+func (m *BufferMemory) SaveSnapshot(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(m.snapshot()); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot replaces the conversations with those of a snapshot written by SaveSnapshot
+// (or a FileMemory file). BufferOptions limits are applied to the restored conversations.
+func (m *BufferMemory) LoadSnapshot(r io.Reader) error {
+	var store fileStore
+	if err := json.NewDecoder(r).Decode(&store); err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	m.restore(store)
+	return nil
+}
+
+// snapshot copies the conversations under the read lock and converts them after it.
+func (m *BufferMemory) snapshot() fileStore {
+	m.mu.RLock()
+	conversations := make(map[string][]llms.ChatCompletionMessage, len(m.conversations))
+	for id, messages := range m.conversations {
+		conversations[id] = append([]llms.ChatCompletionMessage(nil), messages...)
+	}
+	m.mu.RUnlock()
+
+	store := fileStore{Conversations: make(map[string][]storedMessage, len(conversations))}
+	for id, messages := range conversations {
+		stored := make([]storedMessage, len(messages))
+		for i, msg := range messages {
+			stored[i] = messageToStored(msg)
+		}
+		store.Conversations[id] = stored
+	}
+	return store
+}
+
+// restore replaces the conversations with those of store.
+func (m *BufferMemory) restore(store fileStore) {
+	conversations := make(map[string][]llms.ChatCompletionMessage, len(store.Conversations))
+	for id, stored := range store.Conversations {
+		stored = stored[trimStart(len(stored), m.opts.MaxMessagesPerConversation, func(i int) bool { return stored[i].Role == llms.ChatMessageRoleUser }):]
+		messages := make([]llms.ChatCompletionMessage, len(stored))
+		for i, sm := range stored {
+			messages[i] = storedToLLM(sm)
+		}
+		conversations[normalizeConversationID(id)] = messages
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conversations = conversations
+	m.lastUse = make(map[string]*atomic.Int64, len(conversations))
+	for id := range conversations {
+		m.lastUse[id] = new(atomic.Int64)
+	}
+	if limit := m.opts.MaxConversations; limit > 0 {
+		for len(m.conversations) > limit {
+			m.evictLRU("")
+		}
+	}
+	m.changes.Add(1)
+}
+
+// NewPersistentBufferMemory creates a BufferMemory that survives restarts without a database:
+// it loads the snapshot at path (if any), writes it back every flushInterval when something
+// changed (only on Close when flushInterval <= 0) and on Close. Writes are atomic, as in
+// FileMemory. Errors of periodic flushes are retried on the next one; Close returns the error
+// of the final flush.
+//
+// Example:
+//
+//	mem, err := memory.NewPersistentBufferMemory("./data/buffer.json", 30*time.Second)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer mem.Close()
+func NewPersistentBufferMemory(path string, flushInterval time.Duration) (*BufferMemory, error) {
+	store, err := readStoreFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := NewBufferMemory()
+	m.restore(store)
+	m.persist = &bufferPersistence{
+		path:    path,
+		flushed: m.changes.Load(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if flushInterval > 0 {
+		go m.flushLoop(flushInterval)
+	} else {
+		close(m.persist.done)
+	}
+	return m, nil
+}
+
+func (m *BufferMemory) flushLoop(interval time.Duration) {
+	defer close(m.persist.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// a failed flush is retried on the next tick and reported by Close
+			_ = m.FlushSnapshot()
+		case <-m.persist.stop:
+			return
+		}
+	}
+}
+
+// FlushSnapshot writes the snapshot of a memory created with NewPersistentBufferMemory now,
+// unless nothing changed since the last write.
+func (m *BufferMemory) FlushSnapshot() error {
+	p := m.persist
+	if p == nil {
+		return fmt.Errorf("memory has no snapshot file; create it with NewPersistentBufferMemory")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := m.changes.Load()
+	if changes == p.flushed {
+		return nil
+	}
+	if err := writeStoreFile(p.path, m.snapshot()); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	p.flushed = changes
+	return nil
+}
@@ -0,0 +1,349 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// SQLiteMemory persists conversation history in a local SQLite database, one row per message,
+// for CLI and desktop apps that need persistence without a server.
+//
+// The package uses database/sql and does not import a driver. Register the cgo-free
+// modernc.org/sqlite driver in your program:
+//
+//	import _ "modernc.org/sqlite"
+//
+// or another driver (e.g. github.com/mattn/go-sqlite3) selected with WithSQLiteDriverName.
+// The database is switched to WAL mode and every connection gets a busy timeout, and writes
+// are serialized internally so concurrent SaveMessages calls (e.g. from the agent's streaming
+// goroutine) don't fail with "database is locked".
+type SQLiteMemory struct {
+	db      *sql.DB
+	writeMu sync.Mutex
+}
+
+// SQLiteOption configures [NewSQLiteMemory].
+type SQLiteOption func(*sqliteOptions)
+
+type sqliteOptions struct {
+	driverName  string
+	busyTimeout time.Duration
+}
+
+// WithSQLiteDriverName sets the name of the registered database/sql driver, e.g. "sqlite3" for
+// github.com/mattn/go-sqlite3. Default is "sqlite" (modernc.org/sqlite).
+func WithSQLiteDriverName(name string) SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.driverName = name
+	}
+}
+
+// WithSQLiteBusyTimeout sets how long a connection waits for a lock held by another process.
+// Default is 5s.
+func WithSQLiteBusyTimeout(d time.Duration) SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.busyTimeout = d
+	}
+}
+
+// NewSQLiteMemory opens (or creates) the database at path and creates the messages table.
+//
+// Example:
+//
+//	mem, err := memory.NewSQLiteMemory("./data/chat.db")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer mem.Close()
+func NewSQLiteMemory(path string, opts ...SQLiteOption) (*SQLiteMemory, error) {
+	o := sqliteOptions{driverName: "sqlite", busyTimeout: 5 * time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if !slices.Contains(sql.Drivers(), o.driverName) {
+		return nil, fmt.Errorf("SQLite driver %q is not registered: import one, e.g. _ \"modernc.org/sqlite\", or see WithSQLiteDriverName", o.driverName)
+	}
+	// sql.Open doesn't connect; it only gives access to the driver
+	probe, err := sql.Open(o.driverName, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite: %w", err)
+	}
+	drv := probe.Driver()
+	probe.Close()
+
+	// PRAGMAs are run on each new connection instead of passed in the DSN, whose parameters
+	// differ between drivers; the path is a URI filename, so '?' and '#' are escaped.
+	db := sql.OpenDB(sqliteConnector{
+		driver: drv,
+		dsn:    "file:" + strings.NewReplacer("%", "%25", "?", "%3F", "#", "%23").Replace(path),
+		pragmas: []string{
+			fmt.Sprintf("PRAGMA busy_timeout = %d", o.busyTimeout.Milliseconds()),
+			"PRAGMA journal_mode = WAL",
+		},
+	})
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	conversation_id TEXT NOT NULL,
+	role TEXT NOT NULL,
+	content TEXT NOT NULL,
+	message TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages (conversation_id, id);`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create messages table: %w", err)
+	}
+
+	return &SQLiteMemory{db: db}, nil
+}
+
+// sqliteConnector opens connections with driver and runs pragmas on each of them.
+type sqliteConnector struct {
+	driver  driver.Driver
+	dsn     string
+	pragmas []string
+}
+
+func (c sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	for _, pragma := range c.pragmas {
+		if err := execConn(ctx, conn, pragma); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to run %q: %w", pragma, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c sqliteConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// execConn runs query on a driver connection.
+func execConn(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if !errors.Is(err, driver.ErrSkip) {
+			return err
+		}
+	}
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+// LoadMessages returns all messages of the conversation in chronological order.
+func (m *SQLiteMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	return m.LoadMessagesWithLimit(ctx, conversationID, 0)
+}
+
+// LoadMessagesWithLimit returns the last limit messages (all when limit <= 0), oldest first.
+func (m *SQLiteMemory) LoadMessagesWithLimit(ctx context.Context, conversationID string, limit int) ([]llms.ChatCompletionMessage, error) {
+	query := "SELECT message FROM messages WHERE conversation_id = ? ORDER BY id"
+	args := []any{normalizeConversationID(conversationID)}
+	if limit > 0 {
+		query = "SELECT message FROM (SELECT id, message FROM messages WHERE conversation_id = ? ORDER BY id DESC LIMIT ?) ORDER BY id"
+		args = append(args, limit)
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SQLite: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]llms.ChatCompletionMessage, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		var sm storedMessage
+		if err := json.Unmarshal([]byte(data), &sm); err != nil {
+			// Skip invalid messages but continue processing
+			continue
+		}
+		messages = append(messages, storedToLLM(sm))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+	return messages, nil
+}
+
+// SaveMessages appends messages (system messages are skipped) in one transaction.
+func (m *SQLiteMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	stored := llmToStored(messages)
+	if len(stored) == 0 {
+		return nil
+	}
+
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id := normalizeConversationID(conversationID)
+	now := time.Now().UnixNano()
+	for _, sm := range stored {
+		data, err := json.Marshal(sm)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO messages (conversation_id, role, content, message, created_at) VALUES (?, ?, ?, ?, ?)",
+			id, sm.Role, sm.Content, string(data), now); err != nil {
+			return fmt.Errorf("failed to insert message: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// ClearMessages deletes all messages of the conversation.
+func (m *SQLiteMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	if _, err := m.db.ExecContext(ctx, "DELETE FROM messages WHERE conversation_id = ?", normalizeConversationID(conversationID)); err != nil {
+		return fmt.Errorf("failed to delete messages: %w", err)
+	}
+	return nil
+}
+
+// GetMessageCount returns the number of messages stored for the given conversation ID.
+func (m *SQLiteMemory) GetMessageCount(ctx context.Context, conversationID string) (int64, error) {
+	var count int64
+	err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM messages WHERE conversation_id = ?", normalizeConversationID(conversationID)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get message count: %w", err)
+	}
+	return count, nil
+}
+
+// ListConversations implements [ConversationLister]. Conversations are grouped, sorted and
+// paged in SQL.
+func (m *SQLiteMemory) ListConversations(ctx context.Context, limit, offset int) ([]ConversationInfo, error) {
+	if limit <= 0 {
+		limit = -1 // no limit in SQLite
+	}
+	rows, err := m.db.QueryContext(ctx, `SELECT conversation_id, COUNT(*), MAX(created_at) FROM messages
+GROUP BY conversation_id ORDER BY MAX(created_at) DESC, MAX(id) DESC LIMIT ? OFFSET ?`, limit, max(offset, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	defer rows.Close()
+
+	convs := []ConversationInfo{}
+	for rows.Next() {
+		var info ConversationInfo
+		var last int64
+		if err := rows.Scan(&info.ID, &info.MessageCount, &last); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		info.LastActivity = time.Unix(0, last)
+		convs = append(convs, info)
+	}
+	return convs, rows.Err()
+}
+
+// GetStats implements [StatsProvider]. Counts and timestamps are aggregated in SQL; only the
+// content column is read to estimate tokens.
+func (m *SQLiteMemory) GetStats(ctx context.Context, conversationID string) (Stats, error) {
+	id := normalizeConversationID(conversationID)
+	var stats Stats
+	var first, last sql.NullInt64
+	err := m.db.QueryRowContext(ctx, `SELECT COUNT(*),
+	COALESCE(SUM(CASE WHEN role = ? THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN role = ? THEN 1 ELSE 0 END), 0),
+	MIN(created_at), MAX(created_at)
+FROM messages WHERE conversation_id = ?`,
+		llms.ChatMessageRoleUser, llms.ChatMessageRoleAssistant, id).
+		Scan(&stats.MessageCount, &stats.UserTurns, &stats.AssistantTurns, &first, &last)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	if first.Valid {
+		stats.FirstMessageAt = time.Unix(0, first.Int64)
+		stats.LastMessageAt = time.Unix(0, last.Int64)
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT content FROM messages WHERE conversation_id = ?", id)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to query SQLite: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return Stats{}, fmt.Errorf("failed to scan content: %w", err)
+		}
+		stats.ApproxTokens += int64(countTokensCL100K(content))
+	}
+	if err := rows.Err(); err != nil {
+		return Stats{}, fmt.Errorf("failed to read messages: %w", err)
+	}
+	return stats, nil
+}
+
+// ForkConversation copies the rows of srcConversationID to dstConversationID with
+// INSERT ... SELECT, keeping their timestamps. See [Fork].
+func (m *SQLiteMemory) ForkConversation(ctx context.Context, srcConversationID, dstConversationID string) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	dst := normalizeConversationID(dstConversationID)
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM messages WHERE conversation_id = ?)", dst).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to query SQLite: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrConversationExists, dst)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO messages (conversation_id, role, content, message, created_at)
+SELECT ?, role, content, message, created_at FROM messages WHERE conversation_id = ? ORDER BY id`,
+		dst, normalizeConversationID(srcConversationID)); err != nil {
+		return fmt.Errorf("failed to copy messages: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database.
+func (m *SQLiteMemory) Close() error {
+	return m.db.Close()
+}
@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestSQLite opens a SQLiteMemory on a file named name in a temporary directory, with the
+// cgo driver registered by the test.
+func newTestSQLite(t *testing.T, name string) *SQLiteMemory {
+	t.Helper()
+	m, err := NewSQLiteMemory(filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestSQLiteMemoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSQLite(t, "chat.db")
+	for n := 1; n <= 3; n++ {
+		if err := m.SaveMessages(ctx, "c1", turn(n, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.SaveMessages(ctx, "c2", turn(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := m.LoadMessages(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 12 || all[0].Content != "q1" || all[2].ToolCallID != all[1].ToolCalls[0].ID {
+		t.Errorf("loaded %+v, want the 3 turns in order", all)
+	}
+	last, err := m.LoadMessagesWithLimit(ctx, "c1", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last) != 4 || last[0].Content != "q3" {
+		t.Errorf("LoadMessagesWithLimit = %+v, want the last turn", last)
+	}
+	if n, _ := m.GetMessageCount(ctx, "c1"); n != 12 {
+		t.Errorf("GetMessageCount = %d, want 12", n)
+	}
+	convs, err := m.ListConversations(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conversationIDs(convs); got != "[c2 c1]" {
+		t.Errorf("ListConversations = %v, want [c2 c1]", got)
+	}
+	if convs[1].MessageCount != 12 || convs[0].LastActivity.IsZero() {
+		t.Errorf("ListConversations = %+v, want counts and activity", convs)
+	}
+	for _, tt := range []struct {
+		limit, offset int
+		want          string
+	}{
+		{1, 0, "[c2]"},
+		{1, 1, "[c1]"},
+		{0, 1, "[c1]"},
+		{5, 2, "[]"},
+	} {
+		page, err := m.ListConversations(ctx, tt.limit, tt.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := conversationIDs(page); got != tt.want {
+			t.Errorf("ListConversations(%d, %d) = %v, want %v", tt.limit, tt.offset, got, tt.want)
+		}
+	}
+}
+
+// The busy timeout and WAL mode are applied on every pooled connection, whatever the driver.
+func TestSQLiteMemoryPragmas(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewSQLiteMemory(filepath.Join(t.TempDir(), "chat.db"), WithSQLiteBusyTimeout(1234*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	m.db.SetMaxIdleConns(4)
+
+	conns := make([]interface{ Close() error }, 0, 3)
+	for range 3 {
+		conn, err := m.db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+		var timeout int
+		var mode string
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&timeout); err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil {
+			t.Fatal(err)
+		}
+		if timeout != 1234 || mode != "wal" {
+			t.Errorf("busy_timeout = %d, journal_mode = %q, want 1234 and wal", timeout, mode)
+		}
+	}
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+func TestSQLiteMemoryPathNeedsNoEscaping(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chat?mode=ro#x%41.db")
+	m, err := NewSQLiteMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.SaveMessages(ctx, "c1", turn(1, 0)); err != nil {
+		t.Fatalf("SaveMessages: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "chat*")); len(matches) == 0 || !strings.Contains(strings.Join(matches, " "), "chat?mode=ro#x%41.db") {
+		t.Errorf("files %v, want the database at %s", matches, path)
+	}
+}
+
+func TestSQLiteMemoryUnregisteredDriver(t *testing.T) {
+	_, err := NewSQLiteMemory(filepath.Join(t.TempDir(), "chat.db"), WithSQLiteDriverName("no-such-driver"))
+	if err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("err = %v, want a driver not registered error", err)
+	}
+}
+
+// Concurrent saves are serialized instead of failing with "database is locked".
+func TestSQLiteMemoryConcurrentSaves(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSQLite(t, "chat.db")
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- m.SaveMessages(ctx, fmt.Sprintf("c%d", i%4), turn(i, 1))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var total int64
+	for i := range 4 {
+		n, _ := m.GetMessageCount(ctx, fmt.Sprintf("c%d", i))
+		total += n
+	}
+	if total != 80 {
+		t.Errorf("saved %d messages, want 80", total)
+	}
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// Stats summarizes one conversation for analytics.
+type Stats struct {
+	MessageCount int64
+	// UserTurns and AssistantTurns count the user and assistant messages.
+	UserTurns      int64
+	AssistantTurns int64
+	// ApproxTokens estimates the tokens of the message contents with cl100k_base.
+	ApproxTokens int64
+	// FirstMessageAt and LastMessageAt are when the oldest and newest stored messages were
+	// saved; zero when the backend doesn't record it.
+	FirstMessageAt time.Time
+	LastMessageAt  time.Time
+}
+
+// Duration returns the time between the first and the last message.
+func (s Stats) Duration() time.Duration {
+	return s.LastMessageAt.Sub(s.FirstMessageAt)
+}
+
+// StatsProvider is implemented by memories that can compute [Stats] without returning the
+// whole history to the caller (BufferMemory, RedisMemory, SQLiteMemory).
+type StatsProvider interface {
+	GetStats(ctx context.Context, conversationID string) (Stats, error)
+}
+
+// add counts msg, saved at t (zero when unknown).
+func (s *Stats) add(msg llms.ChatCompletionMessage, t time.Time) {
+	s.MessageCount++
+	switch msg.Role {
+	case llms.ChatMessageRoleUser:
+		s.UserTurns++
+	case llms.ChatMessageRoleAssistant:
+		s.AssistantTurns++
+	}
+	s.ApproxTokens += int64(countTokensCL100K(msg.Content))
+	if t.IsZero() {
+		return
+	}
+	if s.FirstMessageAt.IsZero() || t.Before(s.FirstMessageAt) {
+		s.FirstMessageAt = t
+	}
+	if t.After(s.LastMessageAt) {
+		s.LastMessageAt = t
+	}
+}
@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// DefaultSummaryPrompt is the default SummaryConfig.Prompt. {summary} is replaced with the
+// current summary (or "(none)") and {conversation} with a transcript of the new messages.
+const DefaultSummaryPrompt = `Progressively summarize the conversation, adding onto the previous summary and returning a new summary.
+Keep names, facts, decisions, preferences and open questions; drop pleasantries.
+
+Current summary:
+{summary}
+
+New lines of conversation:
+{conversation}
+
+New summary:`
+
+// SummaryConfig holds configuration for SummaryMemory.
+type SummaryConfig struct {
+	// MaxMessages is how many raw messages may accumulate beyond the summary before the
+	// older ones are folded into it. Default is 20.
+	MaxMessages int
+
+	// KeepMessages is how many recent raw messages LoadMessages returns after the summary.
+	// The cut is moved forward to a user message so an exchange is never split. Default is 6.
+	KeepMessages int
+
+	// Prompt is the summarization prompt; see DefaultSummaryPrompt for the placeholders.
+	Prompt string
+
+	// MaxTokens caps the summary length. Default is 500.
+	MaxTokens int
+
+	// Logger receives a warning, with the conversation ID, when summarization fails and the
+	// previous summary is kept. Default is slog.Default().
+	Logger *slog.Logger
+}
+
+// SummaryMemory keeps a rolling LLM-generated summary per conversation on top of a [Memory].
+// Raw messages are always saved to the wrapped memory; once more than MaxMessages raw messages
+// are not yet covered, the older ones are summarized. LoadMessages then returns one system
+// message with the summary followed by the most recent raw messages.
+//
+// When summarization fails the previous summary is kept (the raw history is returned when
+// there is none), so a failing LLM only costs prompt size. Summaries are held in process
+// memory and rebuilt from the raw history after a restart.
+//
+// Example:
+//
+//	mem := memory.NewSummaryMemory(memory.NewRedisMemory(rdb, 0), llm, memory.SummaryConfig{})
+type SummaryMemory struct {
+	inner Memory
+	llm   llms.LLM
+	cfg   SummaryConfig
+
+	mu    sync.Mutex
+	state map[string]summaryState
+	// summarizing holds the conversations whose summary is being updated
+	summarizing map[string]bool
+	// clears counts ClearMessages calls, so a summary finished after a clear is dropped
+	clears int
+}
+
+type summaryState struct {
+	summary string
+	// covered is the number of raw messages folded into summary.
+	covered int
+}
+
+// NewSummaryMemory wraps inner, summarizing with llm.
+func NewSummaryMemory(inner Memory, llm llms.LLM, cfg SummaryConfig) *SummaryMemory {
+	if cfg.MaxMessages <= 0 {
+		cfg.MaxMessages = 20
+	}
+	if cfg.KeepMessages <= 0 {
+		cfg.KeepMessages = 6
+	}
+	if cfg.Prompt == "" {
+		cfg.Prompt = DefaultSummaryPrompt
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = 500
+	}
+	return &SummaryMemory{inner: inner, llm: llm, cfg: cfg, state: make(map[string]summaryState), summarizing: make(map[string]bool)}
+}
+
+// log returns the configured logger, or slog.Default().
+func (m *SummaryMemory) log() *slog.Logger {
+	if m.cfg.Logger != nil {
+		return m.cfg.Logger
+	}
+	return slog.Default()
+}
+
+// LoadMessages returns the summary as a system message followed by the raw messages it does
+// not cover, or the raw history when there is no summary yet.
+func (m *SummaryMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	raw, err := m.inner.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	st := m.state[normalizeConversationID(conversationID)]
+	m.mu.Unlock()
+	if st.summary == "" || st.covered > len(raw) {
+		return raw, nil
+	}
+
+	out := make([]llms.ChatCompletionMessage, 0, 1+len(raw)-st.covered)
+	out = append(out, llms.ChatCompletionMessage{
+		Role:    llms.ChatMessageRoleSystem,
+		Content: "Summary of the earlier conversation:\n" + st.summary,
+	})
+	return append(out, raw[st.covered:]...), nil
+}
+
+// SaveMessages saves messages to the wrapped memory and updates the summary when the
+// uncovered history exceeds MaxMessages.
+func (m *SummaryMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	if err := m.inner.SaveMessages(ctx, conversationID, messages); err != nil {
+		return err
+	}
+
+	raw, err := m.inner.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return nil // the messages are saved; summarizing can wait for the next save
+	}
+
+	id := normalizeConversationID(conversationID)
+	m.mu.Lock()
+	if m.summarizing[id] {
+		// a later save folds these messages in once the running update is done
+		m.mu.Unlock()
+		return nil
+	}
+	prev, clears := m.state[id], m.clears
+	st := prev
+	if st.covered > len(raw) {
+		st = summaryState{}
+	}
+	cut := len(raw) - m.cfg.KeepMessages
+	for cut < len(raw) && raw[cut].Role != llms.ChatMessageRoleUser {
+		cut++
+	}
+	if len(raw)-st.covered <= m.cfg.MaxMessages || cut <= st.covered {
+		m.mu.Unlock()
+		return nil
+	}
+	m.summarizing[id] = true
+	m.mu.Unlock()
+
+	// The LLM call runs unlocked so loads and other conversations don't wait for it.
+	summary, err := m.summarize(ctx, st.summary, raw[st.covered:cut])
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.summarizing, id)
+	if err != nil {
+		m.log().WarnContext(ctx, "failed to summarize conversation", "conversation_id", id, "error", err)
+		return nil
+	}
+	if m.clears != clears || m.state[id] != prev {
+		return nil // cleared while summarizing
+	}
+	m.state[id] = summaryState{summary: summary, covered: cut}
+	return nil
+}
+
+// ClearMessages clears the wrapped memory and the summary.
+func (m *SummaryMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	m.mu.Lock()
+	delete(m.state, normalizeConversationID(conversationID))
+	m.clears++
+	m.mu.Unlock()
+	return m.inner.ClearMessages(ctx, conversationID)
+}
+
+// Close closes the wrapped memory when it implements [Closer].
+func (m *SummaryMemory) Close() error {
+	return closeMemory(m.inner)
+}
+
+// Summary returns the current summary of the conversation, or "" when there is none.
+func (m *SummaryMemory) Summary(conversationID string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state[normalizeConversationID(conversationID)].summary
+}
+
+// summarize folds messages into summary with the configured prompt.
+func (m *SummaryMemory) summarize(ctx context.Context, summary string, messages []llms.ChatCompletionMessage) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		switch {
+		case msg.Role == llms.ChatMessageRoleUser:
+			fmt.Fprintf(&transcript, "User: %s\n", msg.Content)
+		case msg.Role == llms.ChatMessageRoleAssistant && msg.Content != "":
+			fmt.Fprintf(&transcript, "Assistant: %s\n", msg.Content)
+		case msg.Role == llms.ChatMessageRoleTool:
+			fmt.Fprintf(&transcript, "Tool result: %s\n", msg.Content)
+		}
+	}
+	if summary == "" {
+		summary = "(none)"
+	}
+	prompt := strings.NewReplacer("{summary}", summary, "{conversation}", transcript.String()).Replace(m.cfg.Prompt)
+
+	resp, err := m.llm.Chat(ctx, []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleUser, Content: prompt}}, llms.WithCallMaxTokens(m.cfg.MaxTokens))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
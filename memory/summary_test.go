@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// blockingLLM answers Chat with reply once release is closed, signalling started first.
+type blockingLLM struct {
+	started chan struct{}
+	release chan struct{}
+	reply   string
+}
+
+func (m *blockingLLM) Chat(ctx context.Context, messages []llms.ChatCompletionMessage, opts ...llms.ChatOption) (llms.ChatCompletionResponse, error) {
+	m.started <- struct{}{}
+	<-m.release
+	return llms.ChatCompletionResponse{Choices: []llms.ChatCompletionChoice{{
+		Message: llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: m.reply},
+	}}}, nil
+}
+
+func newBlockingLLM(reply string) *blockingLLM {
+	return &blockingLLM{started: make(chan struct{}, 1), release: make(chan struct{}), reply: reply}
+}
+
+// saveTurns saves n one-exchange turns and fails the test on error.
+func saveTurns(t *testing.T, m Memory, id string, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		if err := m.SaveMessages(context.Background(), id, turn(i, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSummaryMemorySummarizes(t *testing.T) {
+	ctx := context.Background()
+	m := NewSummaryMemory(NewBufferMemory(), llms.NewFakeModel([]string{"they talked"}), SummaryConfig{MaxMessages: 4, KeepMessages: 2})
+	saveTurns(t, m, "c1", 3)
+
+	if got := m.Summary("c1"); got != "they talked" {
+		t.Fatalf("Summary = %q, want %q", got, "they talked")
+	}
+	msgs, err := m.LoadMessages(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 3 || msgs[0].Role != llms.ChatMessageRoleSystem || msgs[1].Content != "q3" {
+		t.Errorf("loaded %+v, want the summary and the last turn", msgs)
+	}
+}
+
+func TestSummaryMemoryFailureKeepsRawHistory(t *testing.T) {
+	ctx := context.Background()
+	llm := llms.NewFakeModel(nil).FailOnCall(1, errors.New("boom"))
+	logs := &captureHandler{}
+	m := NewSummaryMemory(NewBufferMemory(), llm, SummaryConfig{MaxMessages: 4, KeepMessages: 2, Logger: slog.New(logs)})
+	saveTurns(t, m, "c1", 3)
+
+	if id := logs.attr(slog.LevelWarn, "failed to summarize conversation", "conversation_id"); id != "c1" {
+		t.Errorf("warning conversation_id = %q, want c1", id)
+	}
+
+	if got := m.Summary("c1"); got != "" {
+		t.Errorf("Summary = %q, want none", got)
+	}
+	if msgs, _ := m.LoadMessages(ctx, "c1"); len(msgs) != 6 {
+		t.Errorf("loaded %d messages, want the 6 raw ones", len(msgs))
+	}
+}
+
+// Loads and other conversations don't wait for a summary being generated.
+func TestSummaryMemoryDoesNotLockDuringSummarize(t *testing.T) {
+	ctx := context.Background()
+	llm := newBlockingLLM("slow summary")
+	m := NewSummaryMemory(NewBufferMemory(), llm, SummaryConfig{MaxMessages: 4, KeepMessages: 2})
+	saveTurns(t, m, "c1", 2)
+
+	saved := make(chan error, 1)
+	go func() { saved <- m.SaveMessages(ctx, "c1", turn(3, 0)) }()
+	<-llm.started
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.LoadMessages(ctx, "c1")
+		m.Summary("c1")
+		m.SaveMessages(ctx, "c2", turn(1, 0))
+		// a save of the same conversation doesn't start a second summary
+		m.SaveMessages(ctx, "c1", turn(4, 0))
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("memory blocked while summarizing")
+	}
+
+	close(llm.release)
+	if err := <-saved; err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Summary("c1"); got != "slow summary" {
+		t.Errorf("Summary = %q, want %q", got, "slow summary")
+	}
+}
+
+// A summary finished after ClearMessages is dropped.
+func TestSummaryMemoryClearDuringSummarize(t *testing.T) {
+	ctx := context.Background()
+	llm := newBlockingLLM("stale")
+	m := NewSummaryMemory(NewBufferMemory(), llm, SummaryConfig{MaxMessages: 4, KeepMessages: 2})
+	saveTurns(t, m, "c1", 2)
+
+	saved := make(chan error, 1)
+	go func() { saved <- m.SaveMessages(ctx, "c1", turn(3, 0)) }()
+	<-llm.started
+	if err := m.ClearMessages(ctx, "c1"); err != nil {
+		t.Fatal(err)
+	}
+	close(llm.release)
+	if err := <-saved; err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.Summary("c1"); got != "" {
+		t.Errorf("Summary = %q after clear, want none", got)
+	}
+	if msgs, _ := m.LoadMessages(ctx, "c1"); len(msgs) != 0 {
+		t.Errorf("loaded %d messages after clear, want 0", len(msgs))
+	}
+}
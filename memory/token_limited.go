@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// DefaultTruncationNote is a suggested value for TokenLimitedMemory.TruncationNote.
+const DefaultTruncationNote = "[earlier conversation truncated]"
+
+// TokenLimitedMemory wraps a [Memory] and drops the oldest loaded messages until the history
+// fits a token budget. Leading system messages and the most recent user message (with
+// everything after it) are never dropped. Saves and clears go to the wrapped memory unchanged.
+//
+// Example:
+//
+//	mem := memory.NewTokenLimitedMemory(memory.NewBufferMemory(), 4000, agents.CountTokens)
+//	mem.TruncationNote = memory.DefaultTruncationNote
+type TokenLimitedMemory struct {
+	inner     Memory
+	maxTokens int
+	counter   func(string) int
+
+	// TruncationNote, when set, is inserted after the leading system messages whenever history
+	// was dropped, so the model knows context was cut. It is an assistant message, like the
+	// agent's own compression summary, because agents skip system messages loaded from memory.
+	TruncationNote string
+}
+
+// NewTokenLimitedMemory wraps inner with a budget of maxTokens (<= 0 means unlimited).
+// counter counts the tokens of a text; nil uses tiktoken cl100k_base, the encoding of
+// agents.CountTokens.
+func NewTokenLimitedMemory(inner Memory, maxTokens int, counter func(string) int) *TokenLimitedMemory {
+	if counter == nil {
+		counter = countTokensCL100K
+	}
+	return &TokenLimitedMemory{inner: inner, maxTokens: maxTokens, counter: counter}
+}
+
+// LoadMessages loads from the wrapped memory and trims the oldest messages to fit the budget.
+func (m *TokenLimitedMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	messages, err := m.inner.LoadMessages(ctx, conversationID)
+	if err != nil || m.maxTokens <= 0 {
+		return messages, err
+	}
+
+	lead := 0
+	for lead < len(messages) && messages[lead].Role == llms.ChatMessageRoleSystem {
+		lead++
+	}
+	lastUser := len(messages)
+	for i := len(messages) - 1; i >= lead; i-- {
+		if messages[i].Role == llms.ChatMessageRoleUser {
+			lastUser = i
+			break
+		}
+	}
+
+	total := 0
+	for _, msg := range messages {
+		total += m.count(msg)
+	}
+	if m.TruncationNote != "" {
+		total += m.counter(m.TruncationNote)
+	}
+
+	start := lead
+	for start < lastUser && total > m.maxTokens {
+		total -= m.count(messages[start])
+		start++
+	}
+	// don't start with tool results whose call was dropped
+	for start < lastUser && messages[start].Role == llms.ChatMessageRoleTool {
+		start++
+	}
+	if start == lead {
+		return messages, nil
+	}
+
+	out := make([]llms.ChatCompletionMessage, 0, lead+1+len(messages)-start)
+	out = append(out, messages[:lead]...)
+	if m.TruncationNote != "" {
+		out = append(out, llms.ChatCompletionMessage{Role: llms.ChatMessageRoleAssistant, Content: m.TruncationNote})
+	}
+	return append(out, messages[start:]...), nil
+}
+
+// SaveMessages implements [Memory].
+func (m *TokenLimitedMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	return m.inner.SaveMessages(ctx, conversationID, messages)
+}
+
+// ClearMessages implements [Memory].
+func (m *TokenLimitedMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	return m.inner.ClearMessages(ctx, conversationID)
+}
+
+// Close closes the wrapped memory when it implements [Closer].
+func (m *TokenLimitedMemory) Close() error {
+	return closeMemory(m.inner)
+}
+
+func (m *TokenLimitedMemory) count(msg llms.ChatCompletionMessage) int {
+	n := m.counter(msg.Content)
+	for _, tc := range msg.ToolCalls {
+		n += m.counter(tc.Name) + m.counter(tc.Arguments)
+	}
+	return n
+}
+
+var cl100k = sync.OnceValue(func() *tiktoken.Tiktoken {
+	enc, _ := tiktoken.GetEncoding(tiktoken.MODEL_CL100K_BASE)
+	return enc
+})
+
+// countTokensCL100K counts with cl100k_base, estimating 4 bytes per token when the encoding
+// can't be loaded.
+func countTokensCL100K(text string) int {
+	if enc := cl100k(); enc != nil {
+		return len(enc.Encode(text, nil, nil))
+	}
+	return (len(text) + 3) / 4
+}
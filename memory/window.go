@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// WindowMemory wraps a [Memory] and returns only the last windowSize exchanges from
+// LoadMessages. An exchange starts at a user message and includes the tool calls, tool results
+// and answer that follow it, so a pair is never split. Leading system messages are always kept.
+// Saves and clears go to the wrapped memory unchanged, so the full history stays stored.
+//
+// Example:
+//
+//	mem := memory.NewWindowMemory(memory.NewBufferMemory(), 5) // last 5 exchanges
+type WindowMemory struct {
+	inner      Memory
+	windowSize int
+}
+
+// NewWindowMemory wraps inner. A windowSize <= 0 means unlimited.
+func NewWindowMemory(inner Memory, windowSize int) *WindowMemory {
+	return &WindowMemory{inner: inner, windowSize: windowSize}
+}
+
+// LoadMessages loads from the wrapped memory and keeps the last windowSize exchanges.
+func (m *WindowMemory) LoadMessages(ctx context.Context, conversationID string) ([]llms.ChatCompletionMessage, error) {
+	messages, err := m.inner.LoadMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return lastExchanges(messages, m.windowSize), nil
+}
+
+// SaveMessages implements [Memory].
+func (m *WindowMemory) SaveMessages(ctx context.Context, conversationID string, messages []llms.ChatCompletionMessage) error {
+	return m.inner.SaveMessages(ctx, conversationID, messages)
+}
+
+// ClearMessages implements [Memory].
+func (m *WindowMemory) ClearMessages(ctx context.Context, conversationID string) error {
+	return m.inner.ClearMessages(ctx, conversationID)
+}
+
+// Close closes the wrapped memory when it implements [Closer].
+func (m *WindowMemory) Close() error {
+	return closeMemory(m.inner)
+}
+
+// lastExchanges keeps the leading system messages and the last n exchanges of messages.
+func lastExchanges(messages []llms.ChatCompletionMessage, n int) []llms.ChatCompletionMessage {
+	if n <= 0 {
+		return messages
+	}
+
+	lead := 0
+	for lead < len(messages) && messages[lead].Role == llms.ChatMessageRoleSystem {
+		lead++
+	}
+
+	start, seen := len(messages), 0
+	for i := len(messages) - 1; i >= lead && seen < n; i-- {
+		if messages[i].Role == llms.ChatMessageRoleUser {
+			start = i
+			seen++
+		}
+	}
+	if seen < n {
+		// fewer than n exchanges: keep everything
+		return messages
+	}
+
+	out := make([]llms.ChatCompletionMessage, 0, lead+len(messages)-start)
+	out = append(out, messages[:lead]...)
+	return append(out, messages[start:]...)
+}
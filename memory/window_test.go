@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/MrLeeang/langchain-go/llms"
+)
+
+// historyMemory loads a fixed history, as stores keeping system messages do.
+type historyMemory []llms.ChatCompletionMessage
+
+func (m historyMemory) LoadMessages(context.Context, string) ([]llms.ChatCompletionMessage, error) {
+	return slices.Clone(m), nil
+}
+
+func (m historyMemory) SaveMessages(context.Context, string, []llms.ChatCompletionMessage) error {
+	return nil
+}
+
+func (m historyMemory) ClearMessages(context.Context, string) error { return nil }
+
+func TestWindowMemory(t *testing.T) {
+	system := []llms.ChatCompletionMessage{{Role: llms.ChatMessageRoleSystem, Content: "be brief"}}
+	history := func(turns ...[]llms.ChatCompletionMessage) []llms.ChatCompletionMessage {
+		return slices.Concat(turns...)
+	}
+	tests := []struct {
+		name    string
+		history []llms.ChatCompletionMessage
+		window  int
+		want    []llms.ChatCompletionMessage
+	}{
+		{"unlimited", history(turn(1, 0), turn(2, 1)), 0, history(turn(1, 0), turn(2, 1))},
+		{"fewer exchanges than the window", history(turn(1, 1), turn(2, 0)), 5, history(turn(1, 1), turn(2, 0))},
+		{"exactly the window", history(turn(1, 1), turn(2, 0)), 2, history(turn(1, 1), turn(2, 0))},
+		{"last exchanges", history(turn(1, 0), turn(2, 0), turn(3, 0)), 2, history(turn(2, 0), turn(3, 0))},
+		{"tool calls kept with their results", history(turn(1, 1), turn(2, 2), turn(3, 1)), 2, history(turn(2, 2), turn(3, 1))},
+		{"boundary right after tool results", history(turn(1, 3), turn(2, 3)), 1, turn(2, 3)},
+		{"system messages kept", history(system, turn(1, 1), turn(2, 1)), 1, history(system, turn(2, 1))},
+		{"unanswered exchange counts", history(turn(1, 1), turn(2, 0), turn(3, 1)[:3]), 1, turn(3, 1)[:3]},
+		{"history starting mid-exchange", history(turn(1, 1)[1:], turn(2, 1)), 1, turn(2, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewWindowMemory(historyMemory(tt.history), tt.window).LoadMessages(context.Background(), "c1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("LoadMessages =\n%v\nwant\n%v", got, tt.want)
+			}
+			calls := map[string]bool{}
+			for i, msg := range got {
+				for _, tc := range msg.ToolCalls {
+					calls[tc.ID] = true
+				}
+				if msg.Role == llms.ChatMessageRoleTool && !calls[msg.ToolCallID] {
+					t.Errorf("tool result %d (%s) kept without its tool call", i, msg.ToolCallID)
+				}
+			}
+		})
+	}
+}
+
+// The window wraps any store unchanged: saves and clears reach it, it keeps the full history,
+// and loads return whole exchanges with each tool call next to its result.
+func TestWindowMemoryOverStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Memory{
+		"buffer": func(*testing.T) Memory { return NewBufferMemory() },
+		"redis": func(t *testing.T) Memory {
+			_, client := newTestRedis(t)
+			return NewRedisMemory(client, 0)
+		},
+		"sqlite": func(t *testing.T) Memory { return newTestSQLite(t, "window.db") },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			inner := newStore(t)
+			for n := 1; n <= 3; n++ {
+				if err := NewWindowMemory(inner, 1).SaveMessages(ctx, "c1", turn(n, n-1)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, tt := range []struct {
+				window int
+				want   []llms.ChatCompletionMessage
+			}{
+				{1, turn(3, 2)},
+				{2, slices.Concat(turn(2, 1), turn(3, 2))},
+				{0, slices.Concat(turn(1, 0), turn(2, 1), turn(3, 2))},
+				{-1, slices.Concat(turn(1, 0), turn(2, 1), turn(3, 2))},
+			} {
+				got, err := NewWindowMemory(inner, tt.window).LoadMessages(ctx, "c1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("window %d: LoadMessages =\n%v\nwant\n%v", tt.window, got, tt.want)
+				}
+			}
+			if stored, _ := inner.LoadMessages(ctx, "c1"); len(stored) != 12 {
+				t.Errorf("inner memory holds %d messages, want all 12", len(stored))
+			}
+
+			if err := NewWindowMemory(inner, 1).ClearMessages(ctx, "c1"); err != nil {
+				t.Fatal(err)
+			}
+			if stored, _ := inner.LoadMessages(ctx, "c1"); len(stored) != 0 {
+				t.Errorf("inner memory holds %d messages after clear", len(stored))
+			}
+		})
+	}
+}